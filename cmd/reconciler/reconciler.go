@@ -0,0 +1,48 @@
+package reconciler
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/component"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/repository"
+	"github.com/stellar-payment/sp-payment/internal/service"
+)
+
+// Start runs a single balance reconciliation and exits, the scheduler runs the same job daily
+func Start(conf *config.Config, logger zerolog.Logger) {
+	db, err := component.InitPostgres(&component.InitPostgresParams{
+		Conf:   &conf.PostgresConfig,
+		Logger: logger,
+	})
+
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize db")
+	}
+
+	redis, err := component.InitRedis(&component.InitRedisParams{
+		Conf:   &conf.RedisConfig,
+		Logger: logger,
+	})
+
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initalize redis")
+	}
+
+	repo := repository.NewRepository(&repository.NewRepositoryParams{
+		DB:    db,
+		Redis: redis,
+	})
+
+	service := service.NewService(&service.NewServiceParams{
+		Repository: repo,
+		Redis:      redis,
+	})
+
+	logger.Info().Msg("starting balance reconciliation")
+
+	if err := service.HandleReconcileBalances(logger.WithContext(context.Background())); err != nil {
+		logger.Fatal().Err(err).Msg("balance reconciliation failed")
+	}
+}
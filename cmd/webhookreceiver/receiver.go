@@ -0,0 +1,57 @@
+package webhookreceiver
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/util/webhookutil"
+)
+
+// deliveries signed further than this from the receiver clock are refused
+const signatureTolerance = 5 * time.Minute
+
+type StartParams struct {
+	Addr   string
+	Secret string
+
+	// FailStatus makes every verified delivery get this status back, to exercise the retry path
+	FailStatus int
+}
+
+// Start listens on params.Addr and verifies every delivery against params.Secret the way a merchant receiver should,
+// it is meant to test webhooks on a local environment
+func Start(params *StartParams, logger zerolog.Logger) {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err = webhookutil.Verify([]byte(params.Secret), r.Header, body, signatureTolerance); err != nil {
+			logger.Warn().Err(err).Str("delivery-id", r.Header.Get(webhookutil.DeliveryHeader)).Msg("webhook rejected")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		logger.Info().
+			Str("delivery-id", r.Header.Get(webhookutil.DeliveryHeader)).
+			Str("event", r.Header.Get(webhookutil.EventHeader)).
+			RawJSON("body", body).
+			Msg("webhook received")
+
+		if params.FailStatus != 0 {
+			w.WriteHeader(params.FailStatus)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	logger.Info().Str("addr", params.Addr).Msg("webhook receiver listening")
+	if err := http.ListenAndServe(params.Addr, nil); err != nil {
+		logger.Fatal().Err(err).Msg("webhook receiver stopped")
+	}
+}
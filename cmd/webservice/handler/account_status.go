@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type UpdateAccountStatusHandler func(context.Context, *dto.AccountsQueryParams, *dto.AccountStatusPayload) error
+
+func HandleUpdateAccountStatus(handler UpdateAccountStatusHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.AccountsQueryParams{
+			AccountID: c.Param("accountID"),
+		}
+
+		payload := &dto.AccountStatusPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type GetAccountStatusHistoryHandler func(context.Context, *dto.AccountsQueryParams) (*dto.ListAccountStatusHistoryResponse, error)
+
+func HandleGetAccountStatusHistory(handler GetAccountStatusHistoryHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.AccountsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetAPIClientsHandler func(context.Context, *dto.APIClientsQueryParams) (*dto.ListAPIClientResponse, error)
+
+func HandleGetAPIClients(handler GetAPIClientsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.APIClientsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateAPIClientHandler func(context.Context, *dto.APIClientPayload) (*dto.APIClientResponse, error)
+
+func HandleCreateAPIClient(handler CreateAPIClientHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.APIClientPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type RevokeAPIClientHandler func(context.Context, *dto.APIClientsQueryParams) error
+
+func HandleRevokeAPIClient(handler RevokeAPIClientHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.APIClientsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
@@ -5,8 +5,10 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
 	"github.com/stellar-payment/sp-payment/pkg/dto"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 type GetBeneficiariesHandler func(context.Context, *dto.BeneficiariesQueryParams) (*dto.ListBeneficiaryResponse, error)
@@ -45,7 +47,7 @@ func HandleGetBeneficiaryByID(handler GetBeneficiaryByIDHandler) echo.HandlerFun
 	}
 }
 
-type GetBeneficiaryPreviewHandler func(context.Context, *dto.BeneficiariesQueryParams) (float64, error)
+type GetBeneficiaryPreviewHandler func(context.Context, *dto.BeneficiariesQueryParams) (money.Money, error)
 
 func HandleGetBeneficiaryPreview(handler GetBeneficiaryPreviewHandler) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -63,7 +65,7 @@ func HandleGetBeneficiaryPreview(handler GetBeneficiaryPreviewHandler) echo.Hand
 	}
 }
 
-type CreateBeneficiaryHandler func(context.Context, *dto.BeneficiariesQueryParams) error
+type CreateBeneficiaryHandler func(context.Context, *dto.BeneficiariesQueryParams, *dto.BeneficiaryPayload) error
 
 func HandleCreateBeneficiary(handler CreateBeneficiaryHandler) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -71,6 +73,28 @@ func HandleCreateBeneficiary(handler CreateBeneficiaryHandler) echo.HandlerFunc
 			MerchantID: c.QueryParam("merchantID"),
 		}
 
+		payload := &dto.BeneficiaryPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type ApproveBeneficiaryHandler func(context.Context, *dto.BeneficiariesQueryParams) error
+
+func HandleApproveBeneficiary(handler ApproveBeneficiaryHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.BeneficiariesQueryParams{
+			BeneficiaryID: structutil.StringToInt64(c.Param("beneficiaryID")),
+		}
+
 		err := handler(c.Request().Context(), params)
 		if err != nil {
 			return echttputil.WriteErrorResponse(c, err)
@@ -79,3 +103,25 @@ func HandleCreateBeneficiary(handler CreateBeneficiaryHandler) echo.HandlerFunc
 		return echttputil.WriteSuccessResponse(c, nil)
 	}
 }
+
+type RejectBeneficiaryHandler func(context.Context, *dto.BeneficiariesQueryParams, *dto.BeneficiaryRejectPayload) error
+
+func HandleRejectBeneficiary(handler RejectBeneficiaryHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.BeneficiariesQueryParams{
+			BeneficiaryID: structutil.StringToInt64(c.Param("beneficiaryID")),
+		}
+
+		payload := &dto.BeneficiaryRejectPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
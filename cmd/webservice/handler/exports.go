@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type ExportTransactionsHandler func(context.Context, *dto.TransactionExportParams) (*dto.ExportStream, error)
+
+func HandleExportTransactions(handler ExportTransactionsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionExportParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteStreamAttachment(c, res.ContentType, res.Filename, res.Write)
+	}
+}
+
+type CreateTransactionExportHandler func(context.Context, *dto.TransactionExportParams) (*dto.ExportJobResponse, error)
+
+func HandleCreateTransactionExport(handler CreateTransactionExportHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionExportParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetExportHandler func(context.Context, *dto.ExportQueryParams) (*dto.ExportJobResponse, error)
+
+func HandleGetExport(handler GetExportHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ExportQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type DownloadExportHandler func(context.Context, *dto.ExportQueryParams) (*dto.ExportFile, error)
+
+func HandleDownloadExport(handler DownloadExportHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ExportQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteFileAttachment(c, res.Path, res.Filename)
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetFeeSchedulesHandler func(context.Context, *dto.FeeSchedulesQueryParams) (*dto.ListFeeScheduleResponse, error)
+
+func HandleGetFeeSchedules(handler GetFeeSchedulesHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.FeeSchedulesQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetFeeScheduleByIDHandler func(context.Context, *dto.FeeSchedulesQueryParams) (*dto.FeeScheduleResponse, error)
+
+func HandleGetFeeScheduleByID(handler GetFeeScheduleByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.FeeSchedulesQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateFeeScheduleHandler func(context.Context, *dto.FeeSchedulePayload) error
+
+func HandleCreateFeeSchedule(handler CreateFeeScheduleHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.FeeSchedulePayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type DeleteFeeScheduleHandler func(context.Context, *dto.FeeSchedulesQueryParams) error
+
+func HandleDeleteFeeSchedule(handler DeleteFeeScheduleHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.FeeSchedulesQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
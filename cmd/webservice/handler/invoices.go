@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetInvoicesHandler func(context.Context, *dto.InvoicesQueryParams) (*dto.ListInvoiceResponse, error)
+
+func HandleGetInvoices(handler GetInvoicesHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetInvoiceByIDHandler func(context.Context, *dto.InvoicesQueryParams) (*dto.InvoiceResponse, error)
+
+func HandleGetInvoiceByID(handler GetInvoiceByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateInvoiceHandler func(context.Context, *dto.InvoicePayload) (*dto.InvoiceResponse, error)
+
+func HandleCreateInvoice(handler CreateInvoiceHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.InvoicePayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type UpdateInvoiceHandler func(context.Context, *dto.InvoicesQueryParams, *dto.InvoicePayload) error
+
+func HandleUpdateInvoice(handler UpdateInvoiceHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{
+			InvoiceID: structutil.StringToUint64(c.Param("invoiceID")),
+		}
+
+		payload := &dto.InvoicePayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type DeleteInvoiceHandler func(context.Context, *dto.InvoicesQueryParams) error
+
+func HandleDeleteInvoice(handler DeleteInvoiceHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type IssueInvoiceHandler func(context.Context, *dto.InvoicesQueryParams) error
+
+func HandleIssueInvoice(handler IssueInvoiceHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{
+			InvoiceID: structutil.StringToUint64(c.Param("invoiceID")),
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type VoidInvoiceHandler func(context.Context, *dto.InvoicesQueryParams) error
+
+func HandleVoidInvoice(handler VoidInvoiceHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{
+			InvoiceID: structutil.StringToUint64(c.Param("invoiceID")),
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type PayInvoiceHandler func(context.Context, *dto.InvoicesQueryParams, *dto.InvoicePayPayload) (*dto.InvoiceResponse, error)
+
+func HandlePayInvoice(handler PayInvoiceHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.InvoicesQueryParams{
+			InvoiceID: structutil.StringToUint64(c.Param("invoiceID")),
+		}
+
+		payload := &dto.InvoicePayPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
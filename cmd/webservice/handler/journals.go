@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetAccountLedgerHandler func(context.Context, *dto.LedgerQueryParams) (*dto.AccountLedgerResponse, error)
+
+func HandleGetAccountLedger(handler GetAccountLedgerHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.LedgerQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
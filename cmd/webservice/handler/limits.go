@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetTransactionLimitsHandler func(context.Context, *dto.TransactionLimitsQueryParams) (*dto.ListTransactionLimitResponse, error)
+
+func HandleGetTransactionLimits(handler GetTransactionLimitsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionLimitsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetTransactionLimitByIDHandler func(context.Context, *dto.TransactionLimitsQueryParams) (*dto.TransactionLimitResponse, error)
+
+func HandleGetTransactionLimitByID(handler GetTransactionLimitByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionLimitsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateTransactionLimitHandler func(context.Context, *dto.TransactionLimitPayload) error
+
+func HandleCreateTransactionLimit(handler CreateTransactionLimitHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.TransactionLimitPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type UpdateTransactionLimitHandler func(context.Context, *dto.TransactionLimitsQueryParams, *dto.TransactionLimitPayload) error
+
+func HandleUpdateTransactionLimit(handler UpdateTransactionLimitHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionLimitsQueryParams{
+			LimitID: structutil.StringToUint64(c.Param("limitID")),
+		}
+
+		payload := &dto.TransactionLimitPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type DeleteTransactionLimitHandler func(context.Context, *dto.TransactionLimitsQueryParams) error
+
+func HandleDeleteTransactionLimit(handler DeleteTransactionLimitHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionLimitsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type GetAccountLimitHandler func(context.Context, *dto.AccountLimitQueryParams) (*dto.AccountLimitResponse, error)
+
+func HandleGetAccountLimit(handler GetAccountLimitHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.AccountLimitQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
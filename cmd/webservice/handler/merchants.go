@@ -115,3 +115,42 @@ func HandleDeleteMerchant(handler DeleteMerchantHandler) echo.HandlerFunc {
 		return echttputil.WriteSuccessResponse(c, nil)
 	}
 }
+
+type GetMerchantBankAccountHandler func(context.Context, *dto.MerchantsQueryParams) (*dto.MerchantBankAccountResponse, error)
+
+func HandleGetMerchantBankAccount(handler GetMerchantBankAccountHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.MerchantsQueryParams{
+			MerchantID: c.Param("merchantID"),
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type UpdateMerchantBankAccountHandler func(context.Context, *dto.MerchantsQueryParams, *dto.MerchantBankAccountPayload) error
+
+func HandleUpdateMerchantBankAccount(handler UpdateMerchantBankAccountHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.MerchantsQueryParams{
+			MerchantID: c.Param("merchantID"),
+		}
+
+		payload := &dto.MerchantBankAccountPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetPaymentLinksHandler func(context.Context, *dto.PaymentLinksQueryParams) (*dto.ListPaymentLinkResponse, error)
+
+func HandleGetPaymentLinks(handler GetPaymentLinksHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.PaymentLinksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetPaymentLinkByIDHandler func(context.Context, *dto.PaymentLinksQueryParams) (*dto.PaymentLinkResponse, error)
+
+func HandleGetPaymentLinkByID(handler GetPaymentLinkByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.PaymentLinksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetPaymentLinkStatsHandler func(context.Context, *dto.PaymentLinksQueryParams) (*dto.PaymentLinkStatsResponse, error)
+
+func HandleGetPaymentLinkStats(handler GetPaymentLinkStatsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.PaymentLinksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreatePaymentLinkHandler func(context.Context, *dto.PaymentLinkPayload) (*dto.PaymentLinkResponse, error)
+
+func HandleCreatePaymentLink(handler CreatePaymentLinkHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.PaymentLinkPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type DeactivatePaymentLinkHandler func(context.Context, *dto.PaymentLinksQueryParams) error
+
+func HandleDeactivatePaymentLink(handler DeactivatePaymentLinkHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.PaymentLinksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type GetPaymentLinkPreviewHandler func(context.Context, *dto.PaymentLinkTokenParams) (*dto.PaymentLinkPreviewResponse, error)
+
+func HandleGetPaymentLinkPreview(handler GetPaymentLinkPreviewHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.PaymentLinkTokenParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type PayPaymentLinkHandler func(context.Context, *dto.PaymentLinkTokenParams, *dto.PaymentLinkPayPayload) (*dto.PaymentLinkPaymentResponse, error)
+
+func HandlePayPaymentLink(handler PayPaymentLinkHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.PaymentLinkTokenParams{
+			Token: c.Param("token"),
+		}
+
+		payload := &dto.PaymentLinkPayPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
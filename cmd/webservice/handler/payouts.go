@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type PayoutCallbackHandler func(context.Context, http.Header, []byte) error
+
+// HandlePayoutCallback passes the raw body on, connectors sign the exact bytes they send
+func HandlePayoutCallback(handler PayoutCallbackHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err = handler(c.Request().Context(), c.Request().Header, body)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetMerchantStaticQRHandler func(context.Context, *dto.QRQueryParams) (*dto.QRResponse, error)
+
+func HandleGetMerchantStaticQR(handler GetMerchantStaticQRHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.QRQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateMerchantDynamicQRHandler func(context.Context, *dto.QRQueryParams, *dto.DynamicQRPayload) (*dto.QRResponse, error)
+
+func HandleCreateMerchantDynamicQR(handler CreateMerchantDynamicQRHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.QRQueryParams{
+			MerchantID: c.QueryParam("merchantID"),
+		}
+
+		payload := &dto.DynamicQRPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type ParseMerchantQRHandler func(context.Context, *dto.QRParsePayload) (*dto.QRPreviewResponse, error)
+
+func HandleParseMerchantQR(handler ParseMerchantQRHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.QRParsePayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type PayMerchantQRHandler func(context.Context, *dto.QRPayPayload) (*dto.QRPaymentResponse, error)
+
+func HandlePayMerchantQR(handler PayMerchantQRHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.QRPayPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetReconciliationRunsHandler func(context.Context, *dto.ReconciliationQueryParams) (*dto.ListReconciliationRunResponse, error)
+
+func HandleGetReconciliationRuns(handler GetReconciliationRunsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ReconciliationQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetReconciliationDiscrepanciesHandler func(context.Context, *dto.ReconciliationQueryParams) (*dto.ListReconciliationDiscrepancyResponse, error)
+
+func HandleGetReconciliationDiscrepancies(handler GetReconciliationDiscrepanciesHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ReconciliationQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
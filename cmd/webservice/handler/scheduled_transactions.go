@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetScheduledTransactionsHandler func(context.Context, *dto.ScheduledTransactionsQueryParams) (*dto.ListScheduledTransactionResponse, error)
+
+func HandleGetScheduledTransactions(handler GetScheduledTransactionsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ScheduledTransactionsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetScheduledTransactionByIDHandler func(context.Context, *dto.ScheduledTransactionsQueryParams) (*dto.ScheduledTransactionResponse, error)
+
+func HandleGetScheduledTransactionByID(handler GetScheduledTransactionByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ScheduledTransactionsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateScheduledTransactionHandler func(context.Context, *dto.ScheduledTransactionPayload) (*dto.ScheduledTransactionResponse, error)
+
+func HandleCreateScheduledTransaction(handler CreateScheduledTransactionHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.ScheduledTransactionPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CancelScheduledTransactionHandler func(context.Context, *dto.ScheduledTransactionsQueryParams) error
+
+func HandleCancelScheduledTransaction(handler CancelScheduledTransactionHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.ScheduledTransactionsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetSettlementBatchesHandler func(context.Context, *dto.SettlementBatchQueryParams) (*dto.ListSettlementBatchResponse, error)
+
+func HandleGetSettlementBatches(handler GetSettlementBatchesHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SettlementBatchQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetSettlementBatchByIDHandler func(context.Context, *dto.SettlementBatchQueryParams) (*dto.SettlementBatchResponse, error)
+
+func HandleGetSettlementBatchByID(handler GetSettlementBatchByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SettlementBatchQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetSplitBillsHandler func(context.Context, *dto.SplitBillsQueryParams) (*dto.ListSplitBillResponse, error)
+
+func HandleGetSplitBills(handler GetSplitBillsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SplitBillsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetSplitBillByIDHandler func(context.Context, *dto.SplitBillsQueryParams) (*dto.SplitBillResponse, error)
+
+func HandleGetSplitBillByID(handler GetSplitBillByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SplitBillsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateSplitBillHandler func(context.Context, *dto.SplitBillPayload) (*dto.SplitBillResponse, error)
+
+func HandleCreateSplitBill(handler CreateSplitBillHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.SplitBillPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type PaySplitBillHandler func(context.Context, *dto.SplitBillsQueryParams, *dto.SplitBillPayPayload) (*dto.SplitBillResponse, error)
+
+func HandlePaySplitBill(handler PaySplitBillHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SplitBillsQueryParams{
+			SplitID: structutil.StringToUint64(c.Param("splitID")),
+		}
+
+		payload := &dto.SplitBillPayPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type RemindSplitBillHandler func(context.Context, *dto.SplitBillsQueryParams) (*dto.SplitBillRemindResponse, error)
+
+func HandleRemindSplitBill(handler RemindSplitBillHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SplitBillsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CancelSplitBillHandler func(context.Context, *dto.SplitBillsQueryParams) error
+
+func HandleCancelSplitBill(handler CancelSplitBillHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.SplitBillsQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
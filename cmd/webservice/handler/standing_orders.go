@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetStandingOrdersHandler func(context.Context, *dto.StandingOrdersQueryParams) (*dto.ListStandingOrderResponse, error)
+
+func HandleGetStandingOrders(handler GetStandingOrdersHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.StandingOrdersQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetStandingOrderByIDHandler func(context.Context, *dto.StandingOrdersQueryParams) (*dto.StandingOrderResponse, error)
+
+func HandleGetStandingOrderByID(handler GetStandingOrderByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.StandingOrdersQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetStandingOrderRunsHandler func(context.Context, *dto.StandingOrdersQueryParams) (*dto.ListStandingOrderRunResponse, error)
+
+func HandleGetStandingOrderRuns(handler GetStandingOrderRunsHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.StandingOrdersQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateStandingOrderHandler func(context.Context, *dto.StandingOrderPayload) (*dto.StandingOrderResponse, error)
+
+func HandleCreateStandingOrder(handler CreateStandingOrderHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.StandingOrderPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CancelStandingOrderHandler func(context.Context, *dto.StandingOrdersQueryParams) error
+
+func HandleCancelStandingOrder(handler CancelStandingOrderHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.StandingOrdersQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
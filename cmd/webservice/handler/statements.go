@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetAccountStatementMeHandler func(context.Context, *dto.AccountStatementQueryParams) (*dto.AccountStatementFile, error)
+
+func HandleGetAccountStatementMe(handler GetAccountStatementMeHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.AccountStatementQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteFileBufferAttachment(c, res.Content, res.ContentType, res.Filename)
+	}
+}
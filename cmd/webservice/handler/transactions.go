@@ -65,6 +65,85 @@ func HandleCreateTransaction(handler CreateTransactionHandler) echo.HandlerFunc
 	}
 }
 
+type RefundTransactionHandler func(context.Context, *dto.TransactionsQueryParams, *dto.TransactionRefundPayload) error
+
+func HandleRefundTransaction(handler RefundTransactionHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionsQueryParams{
+			TransactionID: structutil.StringToUint64(c.Param("trxID")),
+		}
+
+		payload := &dto.TransactionRefundPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type AuthorizeTransactionHandler func(context.Context, *dto.TransactionPayload) (*dto.TransactionAuthorizationResponse, error)
+
+func HandleAuthorizeTransaction(handler AuthorizeTransactionHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.TransactionPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CaptureTransactionHandler func(context.Context, *dto.TransactionsQueryParams, *dto.TransactionCapturePayload) error
+
+func HandleCaptureTransaction(handler CaptureTransactionHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionsQueryParams{
+			TransactionID: structutil.StringToUint64(c.Param("trxID")),
+		}
+
+		payload := &dto.TransactionCapturePayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type ReleaseTransactionHandler func(context.Context, *dto.TransactionsQueryParams) error
+
+func HandleReleaseTransaction(handler ReleaseTransactionHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.TransactionsQueryParams{
+			TransactionID: structutil.StringToUint64(c.Param("trxID")),
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
 type UpdateTransactionHandler func(context.Context, *dto.TransactionsQueryParams, *dto.TransactionPayload) error
 
 func HandleUpdateTransactions(handler UpdateTransactionHandler) echo.HandlerFunc {
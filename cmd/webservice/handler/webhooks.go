@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type GetWebhooksHandler func(context.Context, *dto.WebhooksQueryParams) (*dto.ListWebhookResponse, error)
+
+func HandleGetWebhooks(handler GetWebhooksHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type GetWebhookByIDHandler func(context.Context, *dto.WebhooksQueryParams) (*dto.WebhookResponse, error)
+
+func HandleGetWebhookByID(handler GetWebhookByIDHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type CreateWebhookHandler func(context.Context, *dto.WebhookPayload) (*dto.WebhookResponse, error)
+
+func HandleCreateWebhook(handler CreateWebhookHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		payload := &dto.WebhookPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type UpdateWebhookHandler func(context.Context, *dto.WebhooksQueryParams, *dto.WebhookPayload) error
+
+func HandleUpdateWebhook(handler UpdateWebhookHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{
+			WebhookID: structutil.StringToUint64(c.Param("webhookID")),
+		}
+
+		payload := &dto.WebhookPayload{}
+		if err := c.Bind(payload); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params, payload)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type DeleteWebhookHandler func(context.Context, *dto.WebhooksQueryParams) error
+
+func HandleDeleteWebhook(handler DeleteWebhookHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type PingWebhookHandler func(context.Context, *dto.WebhooksQueryParams) error
+
+func HandlePingWebhook(handler PingWebhookHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
+
+type GetWebhookDeliveriesHandler func(context.Context, *dto.WebhooksQueryParams) (*dto.ListWebhookDeliveryResponse, error)
+
+func HandleGetWebhookDeliveries(handler GetWebhookDeliveriesHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		res, err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, res)
+	}
+}
+
+type RedeliverWebhookHandler func(context.Context, *dto.WebhooksQueryParams) error
+
+func HandleRedeliverWebhook(handler RedeliverWebhookHandler) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		params := &dto.WebhooksQueryParams{}
+		if err := c.Bind(params); err != nil {
+			return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+		}
+
+		err := handler(c.Request().Context(), params)
+		if err != nil {
+			return echttputil.WriteErrorResponse(c, err)
+		}
+
+		return echttputil.WriteSuccessResponse(c, nil)
+	}
+}
@@ -9,17 +9,48 @@ const (
 	customerMePath   = customerBasepath + "/me"
 	customerIDPath   = customerBasepath + "/:customerID"
 
+	customerMeInvoicePath    = customerMePath + "/invoices"
+	customerMeInvoiceIDPath  = customerMeInvoicePath + "/:invoiceID"
+	customerMeInvoicePayPath = customerMeInvoiceIDPath + "/pay"
+
 	// ----- Merchants
 	merchantBasepath = basePath + "/merchants"
 	merchantMePath   = merchantBasepath + "/me"
 	merchantIDPath   = merchantBasepath + "/:merchantID"
 
+	merchantMeBankAccountPath = merchantMePath + "/bank-account"
+	merchantBankAccountPath   = merchantIDPath + "/bank-account"
+
+	merchantMeInvoicePath      = merchantMePath + "/invoices"
+	merchantMeInvoiceIDPath    = merchantMeInvoicePath + "/:invoiceID"
+	merchantMeInvoiceIssuePath = merchantMeInvoiceIDPath + "/issue"
+	merchantMeInvoiceVoidPath  = merchantMeInvoiceIDPath + "/void"
+
+	merchantMePaymentLinkPath           = merchantMePath + "/payment-links"
+	merchantMePaymentLinkIDPath         = merchantMePaymentLinkPath + "/:linkID"
+	merchantMePaymentLinkStatsPath      = merchantMePaymentLinkIDPath + "/stats"
+	merchantMePaymentLinkDeactivatePath = merchantMePaymentLinkIDPath + "/deactivate"
+
+	merchantMeWebhookPath          = merchantMePath + "/webhooks"
+	merchantMeWebhookIDPath        = merchantMeWebhookPath + "/:webhookID"
+	merchantMeWebhookPingPath      = merchantMeWebhookIDPath + "/ping"
+	merchantMeWebhookDeliveryPath  = merchantMeWebhookIDPath + "/deliveries"
+	merchantMeWebhookRedeliverPath = merchantMeWebhookDeliveryPath + "/:deliveryID/redeliver"
+
+	merchantMeAPIClientPath   = merchantMePath + "/api-clients"
+	merchantMeAPIClientIDPath = merchantMeAPIClientPath + "/:apiClientID"
+
 	// ----- Accounts
-	accountBasepath     = basePath + "/accounts"
-	accountMePath       = accountBasepath + "/me"
-	accountIDPath       = accountBasepath + "/:accountID"
-	accountNoPath       = accountBasepath + "/no/:accountNo"
-	accountAuthenticate = accountBasepath + "/authenticate"
+	accountBasepath          = basePath + "/accounts"
+	accountMePath            = accountBasepath + "/me"
+	accountStatementPath     = accountMePath + "/statements"
+	accountIDPath            = accountBasepath + "/:accountID"
+	accountNoPath            = accountBasepath + "/no/:accountNo"
+	accountAuthenticate      = accountBasepath + "/authenticate"
+	accountLedgerPath        = accountIDPath + "/ledger"
+	accountLimitPath         = accountIDPath + "/limits"
+	accountStatusPath        = accountIDPath + "/status"
+	accountStatusHistoryPath = accountStatusPath + "/histories"
 
 	// ----- Transactions
 	trxBasepath = basePath + "/transactions"
@@ -28,14 +59,71 @@ const (
 	trxP2BPath  = trxBasepath + "/p2b"
 	trxSYSPath  = trxBasepath + "/sys"
 
+	trxRefundPath = trxIDPath + "/refund"
+
+	trxP2BAuthorizePath = trxP2BPath + "/authorize"
+	trxP2BCapturePath   = trxP2BPath + "/:trxID/capture"
+	trxP2BReleasePath   = trxP2BPath + "/:trxID/release"
+
+	trxScheduleBasepath = trxBasepath + "/schedules"
+	trxScheduleIDPath   = trxScheduleBasepath + "/:scheduleID"
+
+	trxStandingOrderBasepath = trxBasepath + "/standing-orders"
+	trxStandingOrderIDPath   = trxStandingOrderBasepath + "/:orderID"
+	trxStandingOrderRunsPath = trxStandingOrderIDPath + "/runs"
+
+	trxSplitBasepath   = trxBasepath + "/splits"
+	trxSplitIDPath     = trxSplitBasepath + "/:splitID"
+	trxSplitPayPath    = trxSplitIDPath + "/pay"
+	trxSplitRemindPath = trxSplitIDPath + "/remind"
+
+	trxExportPath = trxBasepath + "/export"
+
+	// ----- Reconciliations
+	reconciliationBasepath          = basePath + "/reconciliations"
+	reconciliationDiscrepanciesPath = reconciliationBasepath + "/:runID/discrepancies"
+
+	// ----- Exports
+	exportBasepath     = basePath + "/exports"
+	exportIDPath       = exportBasepath + "/:exportID"
+	exportDownloadPath = exportIDPath + "/download"
+
+	// ----- Fees
+	feeBasepath = basePath + "/fees"
+	feeIDPath   = feeBasepath + "/:feeScheduleID"
+
+	// ----- Transaction Limits
+	limitBasepath = basePath + "/limits"
+	limitIDPath   = limitBasepath + "/:limitID"
+
 	// ----- Settlements
 	settlementBasepath = basePath + "/settlements"
 	settlementIDPath   = settlementBasepath + "/:settlementID"
 
+	settlementBatchBasepath = settlementBasepath + "/batches"
+	settlementBatchIDPath   = settlementBatchBasepath + "/:batchID"
+
 	// ----- Beneficiaries
 	beneficiaryBasepath    = basePath + "/beneficiaries"
 	beneficiaryIDPath      = beneficiaryBasepath + "/:beneficiaryID"
 	beneficiaryPreviewPath = beneficiaryBasepath + "/preview"
+	beneficiaryApprovePath = beneficiaryIDPath + "/approve"
+	beneficiaryRejectPath  = beneficiaryIDPath + "/reject"
+
+	// ----- Payouts
+	payoutCallbackPath = basePath + "/payouts/callback"
+
+	// ----- Payment Links
+	paymentLinkBasepath  = basePath + "/payment-links"
+	paymentLinkTokenPath = paymentLinkBasepath + "/:token"
+	paymentLinkPayPath   = paymentLinkTokenPath + "/pay"
+
+	// ----- QR Codes
+	qrBasepath    = basePath + "/qr"
+	qrStaticPath  = qrBasepath + "/static"
+	qrDynamicPath = qrBasepath + "/dynamic"
+	qrParsePath   = qrBasepath + "/parse"
+	qrPayPath     = qrBasepath + "/pay"
 
 	// ----- Dashboard
 	dashboardBasepath     = basePath + "/dashboard"
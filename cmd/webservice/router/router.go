@@ -29,6 +29,12 @@ func Init(params *InitRouterParams) {
 
 	plainRouter := params.Ec.Group("")
 	secureRouter := params.Ec.Group("", middleware.AuthorizationMiddleware(params.Service))
+	// merchantRouter also takes requests signed by a merchant api client, only routes over the merchant's own
+	// resources belong here. Capture, release and refund are deliberately reachable with an api client signature,
+	// they only settle or return payments already made to that merchant. Any other route moving customer money
+	// or managing the merchant stays on secureRouter
+	merchantRouter := params.Ec.Group("", middleware.APIClientMiddleware(params.Service), middleware.AuthorizationMiddleware(params.Service))
+	idempotency := middleware.IdempotencyMiddleware(params.Service)
 
 	// ----- Maintenance
 	plainRouter.GET(PingPath, handler.HandlePing(params.Service.Ping))
@@ -38,8 +44,8 @@ func Init(params *InitRouterParams) {
 	secureRouter.OPTIONS(dashboardAdminPath, handler.HandleGetAdminDashboard(params.Service.GetAdminDashboard))
 	secureRouter.GET(dashboardCustomerPath, handler.HandleGetCustomerDashboard(params.Service.GetCustomerDashboard))
 	secureRouter.OPTIONS(dashboardCustomerPath, handler.HandleGetCustomerDashboard(params.Service.GetCustomerDashboard))
-	secureRouter.GET(dashboardMerchantPath, handler.HandleGetMerchantDashboard(params.Service.GetMerchantDashboard))
-	secureRouter.OPTIONS(dashboardMerchantPath, handler.HandleGetMerchantDashboard(params.Service.GetMerchantDashboard))
+	merchantRouter.GET(dashboardMerchantPath, handler.HandleGetMerchantDashboard(params.Service.GetMerchantDashboard))
+	merchantRouter.OPTIONS(dashboardMerchantPath, handler.HandleGetMerchantDashboard(params.Service.GetMerchantDashboard))
 
 	// ----- Customers
 	secureRouter.GET(customerBasepath, handler.HandleGetCustomers(params.Service.GetAllCustomer))
@@ -53,18 +59,90 @@ func Init(params *InitRouterParams) {
 	secureRouter.DELETE(customerIDPath, handler.HandleDeleteCustomer(params.Service.DeleteCustomer))
 	secureRouter.OPTIONS(customerIDPath, handler.HandleDeleteCustomer(params.Service.DeleteCustomer))
 
+	// ----- Customers (Invoices)
+	secureRouter.GET(customerMeInvoicePath, handler.HandleGetInvoices(params.Service.GetAllInvoice))
+	secureRouter.OPTIONS(customerMeInvoicePath, handler.HandleGetInvoices(params.Service.GetAllInvoice))
+	secureRouter.GET(customerMeInvoiceIDPath, handler.HandleGetInvoiceByID(params.Service.GetInvoice))
+	secureRouter.OPTIONS(customerMeInvoiceIDPath, handler.HandleGetInvoiceByID(params.Service.GetInvoice))
+	secureRouter.POST(customerMeInvoicePayPath, handler.HandlePayInvoice(params.Service.PayInvoice), idempotency)
+	secureRouter.OPTIONS(customerMeInvoicePayPath, handler.HandlePayInvoice(params.Service.PayInvoice))
+
 	// ----- Merchants
 	secureRouter.GET(merchantBasepath, handler.HandleGetMerchants(params.Service.GetAllMerchant))
 	secureRouter.OPTIONS(merchantBasepath, handler.HandleGetMerchants(params.Service.GetAllMerchant))
 	secureRouter.GET(merchantIDPath, handler.HandleGetMerchantByID(params.Service.GetMerchant))
 	secureRouter.OPTIONS(merchantIDPath, handler.HandleGetMerchantByID(params.Service.GetMerchant))
-	secureRouter.GET(merchantMePath, handler.HandleGetMerchantMe(params.Service.GetMerchantMe))
-	secureRouter.OPTIONS(merchantMePath, handler.HandleGetMerchantMe(params.Service.GetMerchantMe))
+	merchantRouter.GET(merchantMePath, handler.HandleGetMerchantMe(params.Service.GetMerchantMe))
+	merchantRouter.OPTIONS(merchantMePath, handler.HandleGetMerchantMe(params.Service.GetMerchantMe))
 	secureRouter.PUT(merchantIDPath, handler.HandleUpdateMerchants(params.Service.UpdateMerchant))
 	secureRouter.OPTIONS(merchantIDPath, handler.HandleUpdateMerchants(params.Service.UpdateMerchant))
 	secureRouter.DELETE(merchantIDPath, handler.HandleDeleteMerchant(params.Service.DeleteMerchant))
 	secureRouter.OPTIONS(merchantIDPath, handler.HandleDeleteMerchant(params.Service.DeleteMerchant))
 
+	// ----- Merchants (Bank Account)
+	merchantRouter.GET(merchantMeBankAccountPath, handler.HandleGetMerchantBankAccount(params.Service.GetMerchantBankAccount))
+	merchantRouter.OPTIONS(merchantMeBankAccountPath, handler.HandleGetMerchantBankAccount(params.Service.GetMerchantBankAccount))
+	secureRouter.GET(merchantBankAccountPath, handler.HandleGetMerchantBankAccount(params.Service.GetMerchantBankAccount))
+	secureRouter.OPTIONS(merchantBankAccountPath, handler.HandleGetMerchantBankAccount(params.Service.GetMerchantBankAccount))
+	secureRouter.PUT(merchantMeBankAccountPath, handler.HandleUpdateMerchantBankAccount(params.Service.UpdateMerchantBankAccount))
+	secureRouter.OPTIONS(merchantMeBankAccountPath, handler.HandleUpdateMerchantBankAccount(params.Service.UpdateMerchantBankAccount))
+	secureRouter.PUT(merchantBankAccountPath, handler.HandleUpdateMerchantBankAccount(params.Service.UpdateMerchantBankAccount))
+	secureRouter.OPTIONS(merchantBankAccountPath, handler.HandleUpdateMerchantBankAccount(params.Service.UpdateMerchantBankAccount))
+
+	// ----- Merchants (Invoices)
+	merchantRouter.GET(merchantMeInvoicePath, handler.HandleGetInvoices(params.Service.GetAllInvoice))
+	merchantRouter.OPTIONS(merchantMeInvoicePath, handler.HandleGetInvoices(params.Service.GetAllInvoice))
+	merchantRouter.GET(merchantMeInvoiceIDPath, handler.HandleGetInvoiceByID(params.Service.GetInvoice))
+	merchantRouter.OPTIONS(merchantMeInvoiceIDPath, handler.HandleGetInvoiceByID(params.Service.GetInvoice))
+	merchantRouter.POST(merchantMeInvoicePath, handler.HandleCreateInvoice(params.Service.CreateInvoice), idempotency)
+	merchantRouter.OPTIONS(merchantMeInvoicePath, handler.HandleCreateInvoice(params.Service.CreateInvoice))
+	merchantRouter.PUT(merchantMeInvoiceIDPath, handler.HandleUpdateInvoice(params.Service.UpdateInvoice))
+	merchantRouter.OPTIONS(merchantMeInvoiceIDPath, handler.HandleUpdateInvoice(params.Service.UpdateInvoice))
+	merchantRouter.DELETE(merchantMeInvoiceIDPath, handler.HandleDeleteInvoice(params.Service.DeleteInvoice))
+	merchantRouter.OPTIONS(merchantMeInvoiceIDPath, handler.HandleDeleteInvoice(params.Service.DeleteInvoice))
+	merchantRouter.POST(merchantMeInvoiceIssuePath, handler.HandleIssueInvoice(params.Service.IssueInvoice))
+	merchantRouter.OPTIONS(merchantMeInvoiceIssuePath, handler.HandleIssueInvoice(params.Service.IssueInvoice))
+	merchantRouter.POST(merchantMeInvoiceVoidPath, handler.HandleVoidInvoice(params.Service.VoidInvoice))
+	merchantRouter.OPTIONS(merchantMeInvoiceVoidPath, handler.HandleVoidInvoice(params.Service.VoidInvoice))
+
+	// ----- Merchants (Payment Links)
+	merchantRouter.GET(merchantMePaymentLinkPath, handler.HandleGetPaymentLinks(params.Service.GetAllPaymentLink))
+	merchantRouter.OPTIONS(merchantMePaymentLinkPath, handler.HandleGetPaymentLinks(params.Service.GetAllPaymentLink))
+	merchantRouter.GET(merchantMePaymentLinkIDPath, handler.HandleGetPaymentLinkByID(params.Service.GetPaymentLink))
+	merchantRouter.OPTIONS(merchantMePaymentLinkIDPath, handler.HandleGetPaymentLinkByID(params.Service.GetPaymentLink))
+	merchantRouter.GET(merchantMePaymentLinkStatsPath, handler.HandleGetPaymentLinkStats(params.Service.GetPaymentLinkStats))
+	merchantRouter.OPTIONS(merchantMePaymentLinkStatsPath, handler.HandleGetPaymentLinkStats(params.Service.GetPaymentLinkStats))
+	merchantRouter.POST(merchantMePaymentLinkPath, handler.HandleCreatePaymentLink(params.Service.CreatePaymentLink), idempotency)
+	merchantRouter.OPTIONS(merchantMePaymentLinkPath, handler.HandleCreatePaymentLink(params.Service.CreatePaymentLink))
+	merchantRouter.POST(merchantMePaymentLinkDeactivatePath, handler.HandleDeactivatePaymentLink(params.Service.DeactivatePaymentLink))
+	merchantRouter.OPTIONS(merchantMePaymentLinkDeactivatePath, handler.HandleDeactivatePaymentLink(params.Service.DeactivatePaymentLink))
+
+	// ----- Merchants (Webhooks)
+	merchantRouter.GET(merchantMeWebhookPath, handler.HandleGetWebhooks(params.Service.GetAllWebhook))
+	merchantRouter.OPTIONS(merchantMeWebhookPath, handler.HandleGetWebhooks(params.Service.GetAllWebhook))
+	merchantRouter.GET(merchantMeWebhookIDPath, handler.HandleGetWebhookByID(params.Service.GetWebhook))
+	merchantRouter.OPTIONS(merchantMeWebhookIDPath, handler.HandleGetWebhookByID(params.Service.GetWebhook))
+	merchantRouter.POST(merchantMeWebhookPath, handler.HandleCreateWebhook(params.Service.CreateWebhook), idempotency)
+	merchantRouter.OPTIONS(merchantMeWebhookPath, handler.HandleCreateWebhook(params.Service.CreateWebhook))
+	merchantRouter.PUT(merchantMeWebhookIDPath, handler.HandleUpdateWebhook(params.Service.UpdateWebhook))
+	merchantRouter.OPTIONS(merchantMeWebhookIDPath, handler.HandleUpdateWebhook(params.Service.UpdateWebhook))
+	merchantRouter.DELETE(merchantMeWebhookIDPath, handler.HandleDeleteWebhook(params.Service.DeleteWebhook))
+	merchantRouter.OPTIONS(merchantMeWebhookIDPath, handler.HandleDeleteWebhook(params.Service.DeleteWebhook))
+	merchantRouter.POST(merchantMeWebhookPingPath, handler.HandlePingWebhook(params.Service.PingWebhook))
+	merchantRouter.OPTIONS(merchantMeWebhookPingPath, handler.HandlePingWebhook(params.Service.PingWebhook))
+	merchantRouter.GET(merchantMeWebhookDeliveryPath, handler.HandleGetWebhookDeliveries(params.Service.GetAllWebhookDelivery))
+	merchantRouter.OPTIONS(merchantMeWebhookDeliveryPath, handler.HandleGetWebhookDeliveries(params.Service.GetAllWebhookDelivery))
+	merchantRouter.POST(merchantMeWebhookRedeliverPath, handler.HandleRedeliverWebhook(params.Service.RedeliverWebhook))
+	merchantRouter.OPTIONS(merchantMeWebhookRedeliverPath, handler.HandleRedeliverWebhook(params.Service.RedeliverWebhook))
+
+	// ----- Merchants (API Clients)
+	secureRouter.GET(merchantMeAPIClientPath, handler.HandleGetAPIClients(params.Service.GetAllAPIClient))
+	secureRouter.OPTIONS(merchantMeAPIClientPath, handler.HandleGetAPIClients(params.Service.GetAllAPIClient))
+	secureRouter.POST(merchantMeAPIClientPath, handler.HandleCreateAPIClient(params.Service.CreateAPIClient), idempotency)
+	secureRouter.OPTIONS(merchantMeAPIClientPath, handler.HandleCreateAPIClient(params.Service.CreateAPIClient))
+	secureRouter.DELETE(merchantMeAPIClientIDPath, handler.HandleRevokeAPIClient(params.Service.RevokeAPIClient))
+	secureRouter.OPTIONS(merchantMeAPIClientIDPath, handler.HandleRevokeAPIClient(params.Service.RevokeAPIClient))
+
 	// ----- Accounts
 	secureRouter.GET(accountBasepath, handler.HandleGetAccounts(params.Service.GetAllAccount))
 	secureRouter.OPTIONS(accountBasepath, handler.HandleGetAccounts(params.Service.GetAllAccount))
@@ -85,27 +163,127 @@ func Init(params *InitRouterParams) {
 	secureRouter.POST(accountAuthenticate, handler.HandleAuthenticateAccountMe(params.Service.AuthenticateAccountMe))
 	secureRouter.OPTIONS(accountAuthenticate, handler.HandleAuthenticateAccountMe(params.Service.AuthenticateAccountMe))
 
+	// ----- Accounts (Statements)
+	secureRouter.GET(accountStatementPath, handler.HandleGetAccountStatementMe(params.Service.GetAccountStatementMe))
+	secureRouter.OPTIONS(accountStatementPath, handler.HandleGetAccountStatementMe(params.Service.GetAccountStatementMe))
+
+	// ----- Accounts (Ledger)
+	secureRouter.GET(accountLedgerPath, handler.HandleGetAccountLedger(params.Service.GetAccountLedger))
+	secureRouter.OPTIONS(accountLedgerPath, handler.HandleGetAccountLedger(params.Service.GetAccountLedger))
+
+	// ----- Accounts (Status)
+	secureRouter.PUT(accountStatusPath, handler.HandleUpdateAccountStatus(params.Service.UpdateAccountStatus))
+	secureRouter.OPTIONS(accountStatusPath, handler.HandleUpdateAccountStatus(params.Service.UpdateAccountStatus))
+	secureRouter.GET(accountStatusHistoryPath, handler.HandleGetAccountStatusHistory(params.Service.GetAccountStatusHistory))
+	secureRouter.OPTIONS(accountStatusHistoryPath, handler.HandleGetAccountStatusHistory(params.Service.GetAccountStatusHistory))
+
+	// ----- Accounts (Limits)
+	secureRouter.GET(accountLimitPath, handler.HandleGetAccountLimit(params.Service.GetAccountLimit))
+	secureRouter.OPTIONS(accountLimitPath, handler.HandleGetAccountLimit(params.Service.GetAccountLimit))
+
 	// ----- Transactions
-	secureRouter.GET(trxBasepath, handler.HandleGetTransactions(params.Service.GetAllTransaction))
-	secureRouter.OPTIONS(trxBasepath, handler.HandleGetTransactions(params.Service.GetAllTransaction))
-	secureRouter.GET(trxIDPath, handler.HandleGetTransactionByID(params.Service.GetTransaction))
-	secureRouter.OPTIONS(trxIDPath, handler.HandleGetTransactionByID(params.Service.GetTransaction))
-	secureRouter.POST(trxP2PPath, handler.HandleCreateTransaction(params.Service.CreateTransactionP2P))
+	merchantRouter.GET(trxBasepath, handler.HandleGetTransactions(params.Service.GetAllTransaction))
+	merchantRouter.OPTIONS(trxBasepath, handler.HandleGetTransactions(params.Service.GetAllTransaction))
+	merchantRouter.GET(trxIDPath, handler.HandleGetTransactionByID(params.Service.GetTransaction))
+	merchantRouter.OPTIONS(trxIDPath, handler.HandleGetTransactionByID(params.Service.GetTransaction))
+	secureRouter.POST(trxP2PPath, handler.HandleCreateTransaction(params.Service.CreateTransactionP2P), idempotency)
 	secureRouter.OPTIONS(trxP2PPath, handler.HandleCreateTransaction(params.Service.CreateTransactionP2P))
-	secureRouter.POST(trxP2BPath, handler.HandleCreateTransaction(params.Service.CreateTransactionP2B))
+	secureRouter.POST(trxP2BPath, handler.HandleCreateTransaction(params.Service.CreateTransactionP2B), idempotency)
 	secureRouter.OPTIONS(trxP2BPath, handler.HandleCreateTransaction(params.Service.CreateTransactionP2B))
-	secureRouter.POST(trxSYSPath, handler.HandleCreateTransaction(params.Service.CreateTransactionSystem))
+	secureRouter.POST(trxP2BAuthorizePath, handler.HandleAuthorizeTransaction(params.Service.AuthorizeTransactionP2B), idempotency)
+	secureRouter.OPTIONS(trxP2BAuthorizePath, handler.HandleAuthorizeTransaction(params.Service.AuthorizeTransactionP2B))
+	merchantRouter.POST(trxP2BCapturePath, handler.HandleCaptureTransaction(params.Service.CaptureTransactionP2B), idempotency)
+	merchantRouter.OPTIONS(trxP2BCapturePath, handler.HandleCaptureTransaction(params.Service.CaptureTransactionP2B))
+	merchantRouter.POST(trxP2BReleasePath, handler.HandleReleaseTransaction(params.Service.ReleaseTransactionP2B))
+	merchantRouter.OPTIONS(trxP2BReleasePath, handler.HandleReleaseTransaction(params.Service.ReleaseTransactionP2B))
+	secureRouter.POST(trxSYSPath, handler.HandleCreateTransaction(params.Service.CreateTransactionSystem), idempotency)
 	secureRouter.OPTIONS(trxSYSPath, handler.HandleCreateTransaction(params.Service.CreateTransactionSystem))
+	merchantRouter.POST(trxRefundPath, handler.HandleRefundTransaction(params.Service.RefundTransaction), idempotency)
+	merchantRouter.OPTIONS(trxRefundPath, handler.HandleRefundTransaction(params.Service.RefundTransaction))
+	secureRouter.GET(trxExportPath, handler.HandleExportTransactions(params.Service.ExportTransactions))
+	secureRouter.OPTIONS(trxExportPath, handler.HandleExportTransactions(params.Service.ExportTransactions))
+	secureRouter.POST(trxExportPath, handler.HandleCreateTransactionExport(params.Service.CreateTransactionExport))
+	secureRouter.OPTIONS(trxExportPath, handler.HandleCreateTransactionExport(params.Service.CreateTransactionExport))
+	secureRouter.GET(trxScheduleBasepath, handler.HandleGetScheduledTransactions(params.Service.GetAllScheduledTransaction))
+	secureRouter.OPTIONS(trxScheduleBasepath, handler.HandleGetScheduledTransactions(params.Service.GetAllScheduledTransaction))
+	secureRouter.GET(trxScheduleIDPath, handler.HandleGetScheduledTransactionByID(params.Service.GetScheduledTransaction))
+	secureRouter.OPTIONS(trxScheduleIDPath, handler.HandleGetScheduledTransactionByID(params.Service.GetScheduledTransaction))
+	secureRouter.POST(trxScheduleBasepath, handler.HandleCreateScheduledTransaction(params.Service.CreateScheduledTransaction), idempotency)
+	secureRouter.OPTIONS(trxScheduleBasepath, handler.HandleCreateScheduledTransaction(params.Service.CreateScheduledTransaction))
+	secureRouter.DELETE(trxScheduleIDPath, handler.HandleCancelScheduledTransaction(params.Service.CancelScheduledTransaction))
+	secureRouter.OPTIONS(trxScheduleIDPath, handler.HandleCancelScheduledTransaction(params.Service.CancelScheduledTransaction))
+	secureRouter.GET(trxStandingOrderBasepath, handler.HandleGetStandingOrders(params.Service.GetAllStandingOrder))
+	secureRouter.OPTIONS(trxStandingOrderBasepath, handler.HandleGetStandingOrders(params.Service.GetAllStandingOrder))
+	secureRouter.GET(trxStandingOrderIDPath, handler.HandleGetStandingOrderByID(params.Service.GetStandingOrder))
+	secureRouter.OPTIONS(trxStandingOrderIDPath, handler.HandleGetStandingOrderByID(params.Service.GetStandingOrder))
+	secureRouter.GET(trxStandingOrderRunsPath, handler.HandleGetStandingOrderRuns(params.Service.GetStandingOrderRuns))
+	secureRouter.OPTIONS(trxStandingOrderRunsPath, handler.HandleGetStandingOrderRuns(params.Service.GetStandingOrderRuns))
+	secureRouter.POST(trxStandingOrderBasepath, handler.HandleCreateStandingOrder(params.Service.CreateStandingOrder), idempotency)
+	secureRouter.OPTIONS(trxStandingOrderBasepath, handler.HandleCreateStandingOrder(params.Service.CreateStandingOrder))
+	secureRouter.DELETE(trxStandingOrderIDPath, handler.HandleCancelStandingOrder(params.Service.CancelStandingOrder))
+	secureRouter.OPTIONS(trxStandingOrderIDPath, handler.HandleCancelStandingOrder(params.Service.CancelStandingOrder))
+	secureRouter.GET(trxSplitBasepath, handler.HandleGetSplitBills(params.Service.GetAllSplitBill))
+	secureRouter.OPTIONS(trxSplitBasepath, handler.HandleGetSplitBills(params.Service.GetAllSplitBill))
+	secureRouter.GET(trxSplitIDPath, handler.HandleGetSplitBillByID(params.Service.GetSplitBill))
+	secureRouter.OPTIONS(trxSplitIDPath, handler.HandleGetSplitBillByID(params.Service.GetSplitBill))
+	secureRouter.POST(trxSplitBasepath, handler.HandleCreateSplitBill(params.Service.CreateSplitBill), idempotency)
+	secureRouter.OPTIONS(trxSplitBasepath, handler.HandleCreateSplitBill(params.Service.CreateSplitBill))
+	secureRouter.POST(trxSplitPayPath, handler.HandlePaySplitBill(params.Service.PaySplitBill), idempotency)
+	secureRouter.OPTIONS(trxSplitPayPath, handler.HandlePaySplitBill(params.Service.PaySplitBill))
+	secureRouter.POST(trxSplitRemindPath, handler.HandleRemindSplitBill(params.Service.RemindSplitBill))
+	secureRouter.OPTIONS(trxSplitRemindPath, handler.HandleRemindSplitBill(params.Service.RemindSplitBill))
+	secureRouter.DELETE(trxSplitIDPath, handler.HandleCancelSplitBill(params.Service.CancelSplitBill))
+	secureRouter.OPTIONS(trxSplitIDPath, handler.HandleCancelSplitBill(params.Service.CancelSplitBill))
 	secureRouter.PUT(trxIDPath, handler.HandleUpdateTransactions(params.Service.UpdateTransaction))
 	secureRouter.OPTIONS(trxIDPath, handler.HandleUpdateTransactions(params.Service.UpdateTransaction))
 	secureRouter.DELETE(trxIDPath, handler.HandleDeleteTransaction(params.Service.DeleteTransaction))
 	secureRouter.OPTIONS(trxIDPath, handler.HandleDeleteTransaction(params.Service.DeleteTransaction))
 
+	// ----- Fees
+	secureRouter.GET(feeBasepath, handler.HandleGetFeeSchedules(params.Service.GetAllFeeSchedule))
+	secureRouter.OPTIONS(feeBasepath, handler.HandleGetFeeSchedules(params.Service.GetAllFeeSchedule))
+	secureRouter.GET(feeIDPath, handler.HandleGetFeeScheduleByID(params.Service.GetFeeSchedule))
+	secureRouter.OPTIONS(feeIDPath, handler.HandleGetFeeScheduleByID(params.Service.GetFeeSchedule))
+	secureRouter.POST(feeBasepath, handler.HandleCreateFeeSchedule(params.Service.CreateFeeSchedule))
+	secureRouter.OPTIONS(feeBasepath, handler.HandleCreateFeeSchedule(params.Service.CreateFeeSchedule))
+	secureRouter.DELETE(feeIDPath, handler.HandleDeleteFeeSchedule(params.Service.DeleteFeeSchedule))
+	secureRouter.OPTIONS(feeIDPath, handler.HandleDeleteFeeSchedule(params.Service.DeleteFeeSchedule))
+
+	// ----- Reconciliations
+	secureRouter.GET(reconciliationBasepath, handler.HandleGetReconciliationRuns(params.Service.GetAllReconciliationRun))
+	secureRouter.OPTIONS(reconciliationBasepath, handler.HandleGetReconciliationRuns(params.Service.GetAllReconciliationRun))
+	secureRouter.GET(reconciliationDiscrepanciesPath, handler.HandleGetReconciliationDiscrepancies(params.Service.GetReconciliationDiscrepancies))
+	secureRouter.OPTIONS(reconciliationDiscrepanciesPath, handler.HandleGetReconciliationDiscrepancies(params.Service.GetReconciliationDiscrepancies))
+
+	// ----- Exports
+	secureRouter.GET(exportIDPath, handler.HandleGetExport(params.Service.GetExport))
+	secureRouter.OPTIONS(exportIDPath, handler.HandleGetExport(params.Service.GetExport))
+	secureRouter.GET(exportDownloadPath, handler.HandleDownloadExport(params.Service.DownloadExport))
+	secureRouter.OPTIONS(exportDownloadPath, handler.HandleDownloadExport(params.Service.DownloadExport))
+
+	// ----- Transaction Limits
+	secureRouter.GET(limitBasepath, handler.HandleGetTransactionLimits(params.Service.GetAllTransactionLimit))
+	secureRouter.OPTIONS(limitBasepath, handler.HandleGetTransactionLimits(params.Service.GetAllTransactionLimit))
+	secureRouter.GET(limitIDPath, handler.HandleGetTransactionLimitByID(params.Service.GetTransactionLimit))
+	secureRouter.OPTIONS(limitIDPath, handler.HandleGetTransactionLimitByID(params.Service.GetTransactionLimit))
+	secureRouter.POST(limitBasepath, handler.HandleCreateTransactionLimit(params.Service.CreateTransactionLimit))
+	secureRouter.OPTIONS(limitBasepath, handler.HandleCreateTransactionLimit(params.Service.CreateTransactionLimit))
+	secureRouter.PUT(limitIDPath, handler.HandleUpdateTransactionLimit(params.Service.UpdateTransactionLimit))
+	secureRouter.OPTIONS(limitIDPath, handler.HandleUpdateTransactionLimit(params.Service.UpdateTransactionLimit))
+	secureRouter.DELETE(limitIDPath, handler.HandleDeleteTransactionLimit(params.Service.DeleteTransactionLimit))
+	secureRouter.OPTIONS(limitIDPath, handler.HandleDeleteTransactionLimit(params.Service.DeleteTransactionLimit))
+
 	// ----- Settlements
-	secureRouter.GET(settlementBasepath, handler.HandleGetSettlements(params.Service.GetAllSettlement))
-	secureRouter.OPTIONS(settlementBasepath, handler.HandleGetSettlements(params.Service.GetAllSettlement))
-	secureRouter.GET(settlementIDPath, handler.HandleGetSettlementByID(params.Service.GetSettlement))
-	secureRouter.OPTIONS(settlementIDPath, handler.HandleGetSettlementByID(params.Service.GetSettlement))
+	merchantRouter.GET(settlementBasepath, handler.HandleGetSettlements(params.Service.GetAllSettlement))
+	merchantRouter.OPTIONS(settlementBasepath, handler.HandleGetSettlements(params.Service.GetAllSettlement))
+	merchantRouter.GET(settlementIDPath, handler.HandleGetSettlementByID(params.Service.GetSettlement))
+	merchantRouter.OPTIONS(settlementIDPath, handler.HandleGetSettlementByID(params.Service.GetSettlement))
+
+	// ----- Settlement Batches
+	merchantRouter.GET(settlementBatchBasepath, handler.HandleGetSettlementBatches(params.Service.GetAllSettlementBatch))
+	merchantRouter.OPTIONS(settlementBatchBasepath, handler.HandleGetSettlementBatches(params.Service.GetAllSettlementBatch))
+	merchantRouter.GET(settlementBatchIDPath, handler.HandleGetSettlementBatchByID(params.Service.GetSettlementBatch))
+	merchantRouter.OPTIONS(settlementBatchIDPath, handler.HandleGetSettlementBatchByID(params.Service.GetSettlementBatch))
 
 	// ----- Beneficiaries
 	secureRouter.GET(beneficiaryBasepath, handler.HandleGetBeneficiaries(params.Service.GetAllBeneficiary))
@@ -114,6 +292,30 @@ func Init(params *InitRouterParams) {
 	secureRouter.OPTIONS(beneficiaryIDPath, handler.HandleGetBeneficiaryByID(params.Service.GetBeneficiary))
 	secureRouter.GET(beneficiaryPreviewPath, handler.HandleGetBeneficiaryPreview(params.Service.GetBeneficiaryPreview))
 	secureRouter.OPTIONS(beneficiaryPreviewPath, handler.HandleGetBeneficiaryPreview(params.Service.GetBeneficiaryPreview))
-	secureRouter.POST(beneficiaryBasepath, handler.HandleCreateBeneficiary(params.Service.CreateBeneficiary))
+	secureRouter.POST(beneficiaryBasepath, handler.HandleCreateBeneficiary(params.Service.CreateBeneficiary), idempotency)
 	secureRouter.OPTIONS(beneficiaryBasepath, handler.HandleCreateBeneficiary(params.Service.CreateBeneficiary))
+	secureRouter.POST(beneficiaryApprovePath, handler.HandleApproveBeneficiary(params.Service.ApproveBeneficiary))
+	secureRouter.OPTIONS(beneficiaryApprovePath, handler.HandleApproveBeneficiary(params.Service.ApproveBeneficiary))
+	secureRouter.POST(beneficiaryRejectPath, handler.HandleRejectBeneficiary(params.Service.RejectBeneficiary))
+	secureRouter.OPTIONS(beneficiaryRejectPath, handler.HandleRejectBeneficiary(params.Service.RejectBeneficiary))
+
+	// ----- Payouts
+	// bank callbacks carry no user session, the payout connector authenticates them
+	plainRouter.POST(payoutCallbackPath, handler.HandlePayoutCallback(params.Service.HandlePayoutCallback))
+
+	// ----- Payment Links
+	secureRouter.GET(paymentLinkTokenPath, handler.HandleGetPaymentLinkPreview(params.Service.GetPaymentLinkPreview))
+	secureRouter.OPTIONS(paymentLinkTokenPath, handler.HandleGetPaymentLinkPreview(params.Service.GetPaymentLinkPreview))
+	secureRouter.POST(paymentLinkPayPath, handler.HandlePayPaymentLink(params.Service.PayPaymentLink), idempotency)
+	secureRouter.OPTIONS(paymentLinkPayPath, handler.HandlePayPaymentLink(params.Service.PayPaymentLink))
+
+	// ----- QR Codes
+	merchantRouter.GET(qrStaticPath, handler.HandleGetMerchantStaticQR(params.Service.GetMerchantStaticQR))
+	merchantRouter.OPTIONS(qrStaticPath, handler.HandleGetMerchantStaticQR(params.Service.GetMerchantStaticQR))
+	merchantRouter.POST(qrDynamicPath, handler.HandleCreateMerchantDynamicQR(params.Service.CreateMerchantDynamicQR), idempotency)
+	merchantRouter.OPTIONS(qrDynamicPath, handler.HandleCreateMerchantDynamicQR(params.Service.CreateMerchantDynamicQR))
+	secureRouter.POST(qrParsePath, handler.HandleParseMerchantQR(params.Service.ParseMerchantQR))
+	secureRouter.OPTIONS(qrParsePath, handler.HandleParseMerchantQR(params.Service.ParseMerchantQR))
+	secureRouter.POST(qrPayPath, handler.HandlePayMerchantQR(params.Service.PayMerchantQR), idempotency)
+	secureRouter.OPTIONS(qrPayPath, handler.HandlePayMerchantQR(params.Service.PayMerchantQR))
 }
@@ -1,6 +1,7 @@
 package webservice
 
 import (
+	"context"
 	"sync"
 
 	"github.com/labstack/echo/v4"
@@ -10,6 +11,7 @@ import (
 	"github.com/stellar-payment/sp-payment/internal/config"
 	"github.com/stellar-payment/sp-payment/internal/pubsub"
 	"github.com/stellar-payment/sp-payment/internal/repository"
+	"github.com/stellar-payment/sp-payment/internal/scheduler"
 	"github.com/stellar-payment/sp-payment/internal/service"
 )
 
@@ -46,6 +48,10 @@ func Start(conf *config.Config, logger zerolog.Logger) {
 		Redis:      redis,
 	})
 
+	if err := service.HandlePostOpeningBalances(logger.WithContext(context.Background())); err != nil {
+		logger.Fatal().Err(err).Msg("failed to post opening balances")
+	}
+
 	psWorker := pubsub.NewEventPubSub(&pubsub.NewEventPubSubParams{
 		Logger:       logger,
 		Redis:        redis,
@@ -53,6 +59,11 @@ func Start(conf *config.Config, logger zerolog.Logger) {
 		SecureRoutes: []string{"transactions"},
 	})
 
+	scheduler := scheduler.NewScheduler(&scheduler.NewSchedulerParams{
+		Logger:  logger,
+		Service: service,
+	})
+
 	router.Init(&router.InitRouterParams{
 		Logger:  logger,
 		Service: service,
@@ -78,6 +89,12 @@ func Start(conf *config.Config, logger zerolog.Logger) {
 		psWorker.Listen()
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scheduler.Start()
+	}()
+
 	wg.Wait()
 
 }
@@ -4,10 +4,14 @@ import (
 	"encoding/base64"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/godruoyi/go-snowflake"
 	"github.com/joho/godotenv"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 type Config struct {
@@ -16,18 +20,41 @@ type Config struct {
 	ServiceID      string          `json:"serviceID"`
 	RPCAddress     string          `json:"rpcAddress"`
 	TrustedService map[string]bool `json:"trustedService"`
-	Environment    Environment     `json:"environment"`
 
-	BuildVer  string
-	BuildTime string
-	FilePath  string
-	RunSince  time.Time
+	// keys other services sign their bus requests with, a service without one cannot request anything
+	TrustedServiceKeys map[string][]byte
+	// trx types each service may request over the bus, system credits are never granted by default
+	TrustedServiceTrxTypes map[string]map[int64]bool
+	Environment            Environment `json:"environment"`
+
+	BuildVer     string
+	BuildTime    string
+	FilePath     string
+	TemplatePath string
+	RunSince     time.Time
 
 	FFJsonLogger string
 
 	AuthServiceAddr string
+	PDFRendererAddr string
+
+	SystemAccountUUID     string
+	FeeAccountUUID        string
+	SettlementAccountUUID string
+	PayoutAccountUUID     string
+
+	// contra account of opening balances, accounts funded before the journal existed
+	EquityAccountUUID string
+
+	// pending settlement a merchant must leave behind on every withdrawal
+	SettlementReserve money.Money
 
-	SystemAccountUUID string
+	// bank connector withdrawals are paid out through, callbacks are authenticated with PayoutCallbackKey
+	PayoutConnector   string
+	PayoutCallbackKey []byte
+
+	// merchant city printed on qr codes, merchants do not carry their own yet
+	QRMerchantCity string
 
 	DBKey   []byte
 	HashKey []byte
@@ -63,12 +90,20 @@ func Init(buildTime, buildVer string) {
 			Password:   os.Getenv("REDIS_PASSWORD"),
 			DefaultExp: 48 * time.Hour,
 		},
-		BuildVer:          buildVer,
-		BuildTime:         buildTime,
-		FilePath:          os.Getenv("FILE_PATH"),
-		FFJsonLogger:      os.Getenv("FF_OVERRIDE_JSON_LOGGER"),
-		AuthServiceAddr:   os.Getenv("AUTH_SERVICE_ADDR"),
-		SystemAccountUUID: os.Getenv("SYSTEM_ACCOUNT"),
+		BuildVer:              buildVer,
+		BuildTime:             buildTime,
+		FilePath:              os.Getenv("FILE_PATH"),
+		TemplatePath:          os.Getenv("TEMPLATE_PATH"),
+		FFJsonLogger:          os.Getenv("FF_OVERRIDE_JSON_LOGGER"),
+		AuthServiceAddr:       os.Getenv("AUTH_SERVICE_ADDR"),
+		PDFRendererAddr:       os.Getenv("PDF_RENDERER_ADDR"),
+		SystemAccountUUID:     os.Getenv("SYSTEM_ACCOUNT"),
+		FeeAccountUUID:        os.Getenv("FEE_ACCOUNT"),
+		SettlementAccountUUID: os.Getenv("SETTLEMENT_ACCOUNT"),
+		PayoutAccountUUID:     os.Getenv("PAYOUT_ACCOUNT"),
+		EquityAccountUUID:     os.Getenv("EQUITY_ACCOUNT"),
+		PayoutConnector:       os.Getenv("PAYOUT_CONNECTOR"),
+		QRMerchantCity:        os.Getenv("QR_MERCHANT_CITY"),
 	}
 
 	if conf.ServiceName == "" {
@@ -83,6 +118,49 @@ func Init(buildTime, buildVer string) {
 		log.Fatalf("%s address and db name cannot be empty", logTagConfig)
 	}
 
+	// every ledger account is posted to on its own, sharing one would merge their balances in the journal
+	ledgerAccounts := [][2]string{
+		{"SYSTEM_ACCOUNT", conf.SystemAccountUUID},
+		{"FEE_ACCOUNT", conf.FeeAccountUUID},
+		{"SETTLEMENT_ACCOUNT", conf.SettlementAccountUUID},
+		{"PAYOUT_ACCOUNT", conf.PayoutAccountUUID},
+		{"EQUITY_ACCOUNT", conf.EquityAccountUUID},
+	}
+
+	seenAccounts := map[string]string{}
+	for _, v := range ledgerAccounts {
+		if v[1] == "" {
+			log.Fatalf("%s %s should not be empty", logTagConfig, v[0])
+		}
+
+		if other, ok := seenAccounts[v[1]]; ok {
+			log.Fatalf("%s %s and %s must be different accounts", logTagConfig, other, v[0])
+		}
+
+		seenAccounts[v[1]] = v[0]
+	}
+
+	if conf.PayoutConnector == "" {
+		conf.PayoutConnector = "file"
+	}
+
+	if conf.QRMerchantCity == "" {
+		conf.QRMerchantCity = "JAKARTA"
+	}
+
+	if conf.TemplatePath == "" {
+		conf.TemplatePath = "templates"
+	}
+
+	if val := os.Getenv("SETTLEMENT_RESERVE"); val != "" {
+		reserve, err := money.Parse(val)
+		if err != nil || reserve.IsNegative() {
+			log.Fatalf("%s settlement reserve must be a non-negative amount, found: %s", logTagConfig, val)
+		}
+
+		conf.SettlementReserve = reserve
+	}
+
 	envString := os.Getenv("ENVIRONMENT")
 	if envString != "dev" && envString != "prod" && envString != "local" {
 		log.Fatalf("%s environment must be either local, dev or prod, found: %s", logTagConfig, envString)
@@ -102,7 +180,52 @@ func Init(buildTime, buildVer string) {
 		conf.HashKey = val
 	}
 
+	if val, err := base64.StdEncoding.DecodeString(os.Getenv("PAYOUT_CALLBACK_KEY")); err != nil {
+		log.Fatalf("%s failed to decode payout callback key err: %+v", logTagConfig, err)
+	} else {
+		conf.PayoutCallbackKey = val
+	}
+
+	// TRUSTED_SERVICES lists <service id>:<base64 key>[:<trx type>|<trx type>...] separated by comma,
+	// a service listed without trx types may request p2p and p2b transfers only
 	conf.TrustedService = map[string]bool{conf.ServiceID: true}
+	conf.TrustedServiceKeys = map[string][]byte{}
+	conf.TrustedServiceTrxTypes = map[string]map[int64]bool{}
+	for _, v := range strings.Split(os.Getenv("TRUSTED_SERVICES"), ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+
+		parts := strings.Split(v, ":")
+		serviceID := parts[0]
+
+		if len(parts) < 2 || len(parts) > 3 {
+			log.Fatalf("%s trusted service %s must come with a base64 key", logTagConfig, serviceID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(key) == 0 {
+			log.Fatalf("%s trusted service %s must come with a base64 key", logTagConfig, serviceID)
+		}
+
+		trxTypes := map[int64]bool{inconst.TRX_TYPE_P2P: true, inconst.TRX_TYPE_P2B: true}
+		if len(parts) == 3 {
+			trxTypes = map[int64]bool{}
+
+			for _, t := range strings.Split(parts[2], "|") {
+				trxType, err := strconv.ParseInt(t, 10, 64)
+				if err != nil {
+					log.Fatalf("%s trusted service %s has an invalid trx type %s", logTagConfig, serviceID, t)
+				}
+
+				trxTypes[trxType] = true
+			}
+		}
+
+		conf.TrustedService[serviceID] = true
+		conf.TrustedServiceKeys[serviceID] = key
+		conf.TrustedServiceTrxTypes[serviceID] = trxTypes
+	}
 
 	snowflake.SetMachineID(snowflake.PrivateIPToMachineID())
 	snowflake.SetStartTime(time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC))
@@ -115,3 +238,8 @@ func Get() (conf *Config) {
 	conf = config
 	return
 }
+
+// Set replaces the loaded config, for tests running without conf/.env
+func Set(conf *Config) {
+	config = conf
+}
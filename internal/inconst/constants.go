@@ -5,12 +5,23 @@ type CtxKey string
 const (
 	REQID_HEADER     = "X-Request-Id"
 	CORRREQID_HEADER = "X-Correlation-Id"
+
+	IDEMPOTENCY_HEADER        = "Idempotency-Key"
+	IDEMPOTENCY_REPLAY_HEADER = "Idempotent-Replayed"
+
+	API_CLIENT_HEADER    = "X-Client-Id"
+	API_TIMESTAMP_HEADER = "X-Timestamp"
+	API_NONCE_HEADER     = "X-Nonce"
+	API_SIGNATURE_HEADER = "X-Signature"
 )
 
 const (
 	AUTH_CTX_KEY  CtxKey = "auth-ctx"
 	TOKEN_CTX_KEY CtxKey = "token-ctx"
 	MID_CTX_KEY   CtxKey = "mid-ctx"
+
+	// set instead of TOKEN_CTX_KEY when the request is signed by a merchant api client
+	API_CLIENT_CTX_KEY CtxKey = "api-client-ctx"
 )
 
 const (
@@ -24,26 +35,193 @@ const (
 	ACCOUNT_TYPE_MERCHANT = 2
 )
 
+const (
+	ACCOUNT_TIER_REGULAR = 1
+	ACCOUNT_TIER_PREMIUM = 2
+)
+
 const (
 	TRX_TYPE_P2P             = 1
 	TRX_TYPE_P2B             = 2
 	TRX_TYPE_BENEFICIARY     = 3
+	TRX_TYPE_REFUND          = 4 // trx fee of a refund is rebated to recipient instead of charged
+	TRX_TYPE_PAYOUT          = 5 // withdrawal transferred out to a bank account, moves no account balance
 	TRX_TYPE_MERCHANT_SYSTEM = 8
 	TRX_TYPE_CUST_SYSTEM     = 9
 )
 
 const (
-	TRX_STATUS_PENDING   = 0
-	TRX_STATUS_SUCCESS   = 1
-	TRX_STATUS_CANCELLED = 2
-	TRX_STATUS_VOID      = 9
+	TRX_STATUS_PENDING            = 0
+	TRX_STATUS_SUCCESS            = 1
+	TRX_STATUS_CANCELLED          = 2
+	TRX_STATUS_PARTIALLY_REFUNDED = 3
+	TRX_STATUS_REFUNDED           = 4
+	TRX_STATUS_VOID               = 9
+)
+
+const (
+	// opening entries share the trx type numbering space, other entries use the trx type
+	JOURNAL_TYPE_OPENING  = 0
+	JOURNAL_TYPE_WRITEOFF = 99
+)
+
+const (
+	FEE_TYPE_FLAT            = 1
+	FEE_TYPE_PERCENTAGE      = 2
+	FEE_TYPE_FLAT_PERCENTAGE = 3
+
+	// schedule applies to every account tier
+	FEE_TIER_ANY = 0
+
+	// percentage rates are expressed in basis points
+	FEE_RATE_BASE = 10000
+)
+
+const (
+	ACCOUNT_STATUS_ACTIVE         = 1
+	ACCOUNT_STATUS_DEBIT_BLOCKED  = 2
+	ACCOUNT_STATUS_CREDIT_BLOCKED = 3
+	ACCOUNT_STATUS_FROZEN         = 4
+	ACCOUNT_STATUS_CLOSED         = 5
+
+	ACCOUNT_STATUS_REASON_NONE              = 0
+	ACCOUNT_STATUS_REASON_COMPLIANCE_REVIEW = 1
+	ACCOUNT_STATUS_REASON_SUSPECTED_FRAUD   = 2
+	ACCOUNT_STATUS_REASON_CUSTOMER_REQUEST  = 3
+	ACCOUNT_STATUS_REASON_LEGAL_ORDER       = 4
+	ACCOUNT_STATUS_REASON_DORMANT           = 5
+	ACCOUNT_STATUS_REASON_OTHER             = 9
+)
+
+const (
+	// limit applies to every trx type, account type or account tier
+	LIMIT_SCOPE_ANY = 0
+)
+
+const (
+	BNF_STATUS_PENDING  = 0
+	BNF_STATUS_CONFIRM  = 1
+	BNF_STATUS_REJECTED = 2
+	BNF_STATUS_PAYING   = 3 // approved, bank transfer still in flight
+	BNF_STATUS_FAILED   = 4
+
+	BNF_PAYOUT_ACCOUNT = 1 // credited to the merchant account
+	BNF_PAYOUT_BANK    = 2 // transferred to the merchant's registered bank account
+)
+
+const (
+	PAYOUT_STATUS_PENDING = 1
+	PAYOUT_STATUS_SENT    = 2
+	PAYOUT_STATUS_SETTLED = 3
+	PAYOUT_STATUS_FAILED  = 4
+)
+
+const (
+	SETTLEMENT_STATUS_OPEN    = 1
+	SETTLEMENT_STATUS_BATCHED = 2
+	SETTLEMENT_STATUS_PAID    = 3
+	SETTLEMENT_STATUS_FAILED  = 4
+)
+
+const (
+	QR_TYPE_STATIC  = 1
+	QR_TYPE_DYNAMIC = 2
+
+	// only dynamic qr codes are stored, static ones are derived from the merchant account
+	QR_STATUS_ACTIVE     = 1
+	QR_STATUS_PROCESSING = 2
+	QR_STATUS_PAID       = 3
+)
+
+const (
+	INVOICE_STATUS_DRAFT   = 1
+	INVOICE_STATUS_OPEN    = 2
+	INVOICE_STATUS_PAID    = 3
+	INVOICE_STATUS_OVERDUE = 4
+	INVOICE_STATUS_VOID    = 5
+)
+
+const (
+	PAYMENT_LINK_STATUS_ACTIVE   = 1
+	PAYMENT_LINK_STATUS_INACTIVE = 2
+)
+
+const (
+	SPLIT_BILL_TYPE_EQUAL  = 1
+	SPLIT_BILL_TYPE_CUSTOM = 2
+
+	SPLIT_BILL_STATUS_OPEN      = 1
+	SPLIT_BILL_STATUS_SETTLED   = 2
+	SPLIT_BILL_STATUS_CANCELLED = 3
+
+	SPLIT_SHARE_STATUS_PENDING    = 1
+	SPLIT_SHARE_STATUS_PROCESSING = 2 // no longer set, a share left processing by an interrupted payment is reviewed by hand
+	SPLIT_SHARE_STATUS_PAID       = 3
+	SPLIT_SHARE_STATUS_CANCELLED  = 4
+)
+
+const (
+	WEBHOOK_STATUS_ACTIVE   = 1
+	WEBHOOK_STATUS_DISABLED = 2
+
+	WEBHOOK_DELIVERY_STATUS_PENDING   = 1
+	WEBHOOK_DELIVERY_STATUS_DELIVERED = 2
+	WEBHOOK_DELIVERY_STATUS_FAILED    = 3
+
+	WEBHOOK_EVENT_PAYMENT_RECEIVED   = "payment.received"
+	WEBHOOK_EVENT_SETTLEMENT_BATCHED = "settlement.batched"
+	WEBHOOK_EVENT_WITHDRAWAL_UPDATED = "withdrawal.updated"
+	WEBHOOK_EVENT_PING               = "webhook.ping"
+)
+
+const (
+	API_CLIENT_STATUS_ACTIVE  = 1
+	API_CLIENT_STATUS_REVOKED = 2
+)
+
+const (
+	SCHEDULE_TRX_STATUS_PENDING    = 1
+	SCHEDULE_TRX_STATUS_PROCESSING = 2
+	SCHEDULE_TRX_STATUS_SUCCESS    = 3
+	SCHEDULE_TRX_STATUS_FAILED     = 4
+	SCHEDULE_TRX_STATUS_CANCELLED  = 5
+)
+
+const (
+	STANDING_ORDER_STATUS_ACTIVE     = 1
+	STANDING_ORDER_STATUS_PROCESSING = 2
+	STANDING_ORDER_STATUS_COMPLETED  = 3
+	STANDING_ORDER_STATUS_CANCELLED  = 4
+
+	// what to do with an occurrence the sender cannot cover
+	STANDING_ORDER_POLICY_SKIP  = 1
+	STANDING_ORDER_POLICY_RETRY = 2
+
+	STANDING_ORDER_RUN_SUCCESS  = 1
+	STANDING_ORDER_RUN_SKIPPED  = 2
+	STANDING_ORDER_RUN_RETRYING = 3
+	STANDING_ORDER_RUN_FAILED   = 4
+)
+
+const (
+	EXPORT_TYPE_TRANSACTIONS = 1
+
+	EXPORT_STATUS_PENDING    = 1
+	EXPORT_STATUS_PROCESSING = 2
+	EXPORT_STATUS_COMPLETED  = 3
+	EXPORT_STATUS_FAILED     = 4
+	EXPORT_STATUS_EXPIRED    = 5
 )
 
 const (
-	BNF_STATUS_PENDING = 0
-	BNF_STATUS_CONFIRM = 1
+	RECONCILIATION_STATUS_RUNNING   = 1
+	RECONCILIATION_STATUS_COMPLETED = 2
+	RECONCILIATION_STATUS_FAILED    = 3
 )
 
 const (
-	CACHE_TRX_KEY = "%s-%s:%s:%d"
+	CACHE_TRX_KEY         = "%s-%s:%s:%d"
+	CACHE_IDEMPOTENCY_KEY = "%s-idempotency:%s:%s"
+	CACHE_API_NONCE_KEY   = "%s-api-nonce:%s:%s"
+	CACHE_EVENT_NONCE_KEY = "%s-event-nonce:%s:%s"
 )
@@ -16,6 +16,9 @@ const (
 
 	// sp-worker
 	TOPIC_CREATE_TRX          = "create-trx"
+	TOPIC_CREATE_TRX_RESULT   = "create-trx-result"
 	TOPIC_CREATE_SCHEDULE_TRX = "create-schedule-trx"
 	TOPIC_DELETE_SCHEDULE_TRX = "delete-schedule-trx"
+	TOPIC_FAILED_SCHEDULE_TRX = "failed-schedule-trx"
+	TOPIC_SPLIT_BILL_REMINDER = "split-bill-reminder"
 )
@@ -1,5 +1,11 @@
 package indto
 
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
 type AccountParams struct {
 	AccountID     string
 	UserID        string
@@ -11,13 +17,34 @@ type AccountParams struct {
 }
 
 type Account struct {
-	ID            string  `db:"id"`
-	OwnerID       string  `db:"owner_id"`
-	OwnerName     []byte  `db:"owner_name"`
-	AccountType   int64   `db:"account_type"`
-	Balance       float64 `db:"balance"`
-	AccountNo     []byte  `db:"account_no"`
-	AccountNoHash []byte  `db:"account_no_hash"`
-	PIN           string  `db:"pin"`
-	RowHash       []byte  `db:"row_hash"`
+	ID            string      `db:"id"`
+	OwnerID       string      `db:"owner_id"`
+	OwnerName     []byte      `db:"owner_name"`
+	AccountType   int64       `db:"account_type"`
+	AccountTier   int64       `db:"account_tier"`
+	Balance       money.Money `db:"balance"`
+	HoldBalance   money.Money `db:"hold_balance"`
+	Status        int64       `db:"status"`
+	StatusReason  int64       `db:"status_reason"`
+	AccountNo     []byte      `db:"account_no"`
+	AccountNoHash []byte      `db:"account_no_hash"`
+	PIN           string      `db:"pin"`
+	RowHash       []byte      `db:"row_hash"`
+}
+
+type AccountStatusHistoryParams struct {
+	AccountID string
+	Limit     uint64
+	Page      uint64
+}
+
+type AccountStatusHistory struct {
+	ID         uint64    `db:"id"`
+	AccountID  string    `db:"account_id"`
+	PrevStatus int64     `db:"prev_status"`
+	Status     int64     `db:"status"`
+	ReasonCode int64     `db:"reason_code"`
+	Note       string    `db:"note"`
+	ChangedBy  string    `db:"changed_by"`
+	CreatedAt  time.Time `db:"created_at"`
 }
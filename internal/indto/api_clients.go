@@ -0,0 +1,40 @@
+package indto
+
+import (
+	"database/sql"
+	"time"
+)
+
+type APIClientParams struct {
+	APIClientID uint64
+	ClientID    string
+	MerchantID  string
+	Status      int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type APIClient struct {
+	ID         uint64       `db:"id"`
+	MerchantID string       `db:"merchant_id"`
+	ClientID   string       `db:"client_id"`
+	Name       string       `db:"name"`
+	PublicKey  string       `db:"public_key"`
+	Status     int64        `db:"status"`
+	RowHash    []byte       `db:"row_hash"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+	CreatedAt  time.Time    `db:"created_at"`
+	RevokedAt  sql.NullTime `db:"revoked_at"`
+}
+
+// APIClientSignature is what a signed request carries, Body is the raw request body
+type APIClientSignature struct {
+	ClientID  string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+}
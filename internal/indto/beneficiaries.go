@@ -1,6 +1,11 @@
 package indto
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
 
 type BeneficiaryParams struct {
 	BeneficiaryID int64
@@ -14,7 +19,20 @@ type Beneficiary struct {
 	ID             uint64       `db:"id"`
 	MerchantID     string       `db:"merchant_id"`
 	MerchantName   string       `db:"merchant_name"`
-	Amount         string       `db:"amount"`
+	Amount         money.Money  `db:"amount"`
 	WithdrawalDate sql.NullTime `db:"withdrawal_date"`
 	Status         int64        `db:"status"`
+	PayoutMethod   int64        `db:"payout_method"`
+	RejectReason   string       `db:"reject_reason"`
+	ReviewedBy     string       `db:"reviewed_by"`
+	ReviewedAt     sql.NullTime `db:"reviewed_at"`
+}
+
+type BeneficiaryAllocation struct {
+	ID            uint64      `db:"id"`
+	BeneficiaryID uint64      `db:"beneficiary_id"`
+	SettlementID  uint64      `db:"settlement_id"`
+	TransactionID uint64      `db:"transaction_id"`
+	Amount        money.Money `db:"amount"`
+	CreatedAt     time.Time   `db:"created_at"`
 }
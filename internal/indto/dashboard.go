@@ -1,15 +1,17 @@
 package indto
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type GenericDashboardGraph struct {
-	Key   any     `db:"key"`
-	Value float64 `db:"value"`
+	Key   any         `db:"key"`
+	Value money.Money `db:"value"`
 }
 
 type TransactionMetaDashboard struct {
-	SenderName    string  `db:"sender_name"`
-	RecipientName string  `db:"recipient_name"`
-	Nominal       float64 `db:"nominal"`
-	TrxDate       string  `db:"trx_date"`
+	SenderName    string      `db:"sender_name"`
+	RecipientName string      `db:"recipient_name"`
+	Nominal       money.Money `db:"nominal"`
+	TrxDate       string      `db:"trx_date"`
 }
 
 type AdminDashboard struct {
@@ -28,9 +30,9 @@ type MerchantDashboardParams struct {
 
 type MerchantDashboard struct {
 	TrxCount           int64                   `db:"trx_count"`
-	TrxNominal         float64                 `db:"trx_nominal"`
-	SettlementNominal  float64                 `db:"settlement_nominal"`
-	BeneficiaryNominal float64                 `db:"beneficiary_nominal"`
+	TrxNominal         money.Money             `db:"trx_nominal"`
+	SettlementNominal  money.Money             `db:"settlement_nominal"`
+	BeneficiaryNominal money.Money             `db:"beneficiary_nominal"`
 	TrxTraffic         []GenericDashboardGraph `db:"-"`
 }
 
@@ -41,8 +43,8 @@ type CustomerDashboardParams struct {
 
 type CustomerDashboard struct {
 	PeerTrxCount       int64                   `db:"peer_trx_count"`
-	PeerTrxNominal     float64                 `db:"peer_trx_nominal"`
+	PeerTrxNominal     money.Money             `db:"peer_trx_nominal"`
 	MerchantTrxCount   int64                   `db:"merchant_trx_count"`
-	MerchantTrxNominal float64                 `db:"merchant_trx_nominal"`
+	MerchantTrxNominal money.Money             `db:"merchant_trx_nominal"`
 	TrxTraffic         []GenericDashboardGraph `db:"-"`
 }
@@ -0,0 +1,13 @@
+package indto
+
+import "encoding/json"
+
+// EventEnvelope carries a request another service publishes on the bus. Signature is the hex HMAC-SHA512 of
+// "<topic>.<service_id>.<timestamp>.<payload>" keyed by the key shared with that service, see eventutil.Sign.
+// Payload is kept raw so the signature is checked against the exact bytes that were signed
+type EventEnvelope struct {
+	ServiceID string          `json:"service_id"`
+	Timestamp int64           `json:"timestamp"`
+	Signature string          `json:"signature"`
+	Payload   json.RawMessage `json:"payload"`
+}
@@ -0,0 +1,46 @@
+package indto
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type FeeScheduleParams struct {
+	FeeScheduleID uint64
+	Code          string
+	TrxType       int64
+	MerchantID    string
+	AccountTier   int64
+	EffectiveAt   time.Time
+
+	Limit uint64
+	Page  uint64
+}
+
+type FeeSchedule struct {
+	ID             uint64     `db:"id"`
+	Code           string     `db:"code"`
+	Name           string     `db:"name"`
+	TrxType        int64      `db:"trx_type"`
+	MerchantID     string     `db:"merchant_id"`
+	AccountTier    int64      `db:"account_tier"`
+	Version        int64      `db:"version"`
+	EffectiveFrom  time.Time  `db:"effective_from"`
+	EffectiveUntil *time.Time `db:"effective_until"`
+}
+
+type FeeRuleParams struct {
+	ScheduleID uint64
+}
+
+type FeeRule struct {
+	ID         uint64      `db:"id"`
+	ScheduleID uint64      `db:"schedule_id"`
+	MinNominal money.Money `db:"min_nominal"`
+	FeeType    int64       `db:"fee_type"`
+	FlatFee    money.Money `db:"flat_fee"`
+	RateBps    int64       `db:"rate_bps"`
+	MinFee     money.Money `db:"min_fee"`
+	MaxFee     money.Money `db:"max_fee"`
+}
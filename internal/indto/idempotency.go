@@ -0,0 +1,16 @@
+package indto
+
+type IdempotencyParams struct {
+	UserID      string
+	Key         string
+	RequestHash string
+}
+
+// IdempotencyRecord is stored as-is in redis, response fields are filled once the request completes
+type IdempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
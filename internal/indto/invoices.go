@@ -0,0 +1,50 @@
+package indto
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type InvoiceParams struct {
+	InvoiceID         uint64
+	MerchantID        string
+	CustomerAccountID string
+	Statuses          []int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type Invoice struct {
+	ID                uint64       `db:"id"`
+	MerchantID        string       `db:"merchant_id"`
+	MerchantName      string       `db:"merchant_name"`
+	CustomerAccountID string       `db:"customer_account_id"`
+	CustomerReference string       `db:"customer_reference"`
+	Description       string       `db:"description"`
+	Subtotal          money.Money  `db:"subtotal"`
+	TaxRateBps        int64        `db:"tax_rate_bps"`
+	TaxAmount         money.Money  `db:"tax_amount"`
+	Total             money.Money  `db:"total"`
+	DueDate           time.Time    `db:"due_date"`
+	Status            int64        `db:"status"`
+	TransactionID     uint64       `db:"transaction_id"`
+	IssuedAt          sql.NullTime `db:"issued_at"`
+	PaidAt            sql.NullTime `db:"paid_at"`
+	CreatedAt         time.Time    `db:"created_at"`
+}
+
+type InvoiceItemParams struct {
+	InvoiceID uint64
+}
+
+type InvoiceItem struct {
+	ID          uint64      `db:"id"`
+	InvoiceID   uint64      `db:"invoice_id"`
+	Description string      `db:"description"`
+	Quantity    int64       `db:"quantity"`
+	UnitPrice   money.Money `db:"unit_price"`
+	Amount      money.Money `db:"amount"`
+}
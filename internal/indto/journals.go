@@ -0,0 +1,26 @@
+package indto
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type JournalParams struct {
+	JournalID     uint64
+	TransactionID uint64
+	AccountID     string
+	Limit         uint64
+	Page          uint64
+}
+
+type JournalPosting struct {
+	ID            uint64      `db:"id"`
+	JournalID     uint64      `db:"journal_id"`
+	TransactionID uint64      `db:"transaction_id"`
+	EntryType     int64       `db:"entry_type"`
+	Description   string      `db:"description"`
+	EntryDatetime time.Time   `db:"entry_datetime"`
+	AccountID     string      `db:"account_id"`
+	Amount        money.Money `db:"amount"`
+}
@@ -0,0 +1,48 @@
+package indto
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type TransactionLimitParams struct {
+	LimitID     uint64
+	TrxType     int64
+	AccountType int64
+	AccountTier int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type TransactionLimit struct {
+	ID                      uint64      `db:"id"`
+	Name                    string      `db:"name"`
+	TrxType                 int64       `db:"trx_type"`
+	AccountType             int64       `db:"account_type"`
+	AccountTier             int64       `db:"account_tier"`
+	MaxPerTrx               money.Money `db:"max_per_trx"`
+	DailyAmount             money.Money `db:"daily_amount"`
+	DailyCount              int64       `db:"daily_count"`
+	MonthlyAmount           money.Money `db:"monthly_amount"`
+	MonthlyCount            int64       `db:"monthly_count"`
+	CounterpartyDailyAmount money.Money `db:"counterparty_daily_amount"`
+}
+
+// TransactionLimitUsageParams selects outgoing transactions counted against a limit, zero TrxType counts every
+// transfer type
+type TransactionLimitUsageParams struct {
+	AccountID   string
+	RecipientID string
+	TrxType     int64
+	At          time.Time
+}
+
+type TransactionLimitUsage struct {
+	DailyAmount             money.Money
+	DailyCount              int64
+	MonthlyAmount           money.Money
+	MonthlyCount            int64
+	CounterpartyDailyAmount money.Money
+}
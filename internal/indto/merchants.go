@@ -1,5 +1,7 @@
 package indto
 
+import "time"
+
 type MerchantParams struct {
 	UserID     string
 	MerchantID string
@@ -22,6 +24,15 @@ type Merchant struct {
 	RowHash      []byte `db:"row_hash"`
 }
 
+type MerchantBankAccount struct {
+	MerchantID  string    `db:"merchant_id"`
+	BankCode    string    `db:"bank_code"`
+	AccountNo   []byte    `db:"account_no"`
+	AccountName []byte    `db:"account_name"`
+	RowHash     []byte    `db:"row_hash"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
 type EventMerchant struct {
 	ID           string `json:"id"`
 	UserID       string `json:"user_id"`
@@ -0,0 +1,41 @@
+package indto
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type PaymentLinkParams struct {
+	LinkID     uint64
+	MerchantID string
+	TokenHash  []byte
+	Status     int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type PaymentLink struct {
+	ID           uint64      `db:"id"`
+	MerchantID   string      `db:"merchant_id"`
+	MerchantName string      `db:"merchant_name"`
+	Token        []byte      `db:"token"`
+	Amount       money.Money `db:"amount"`
+	Description  string      `db:"description"`
+	MaxUses      int64       `db:"max_uses"`
+	UseCount     int64       `db:"use_count"`
+	Status       int64       `db:"status"`
+	ExpiresAt    time.Time   `db:"expires_at"`
+	RowHash      []byte      `db:"row_hash"`
+	CreatedAt    time.Time   `db:"created_at"`
+}
+
+// PaymentLinkStats only counts completed payments, UseCount of the link also holds payments in progress
+type PaymentLinkStats struct {
+	Payments   int64        `db:"payments"`
+	Collected  money.Money  `db:"collected"`
+	Payers     int64        `db:"payers"`
+	LastPaidAt sql.NullTime `db:"last_paid_at"`
+}
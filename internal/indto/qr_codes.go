@@ -0,0 +1,27 @@
+package indto
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type MerchantQRCodeParams struct {
+	QRCodeID   uint64
+	MerchantID string
+	Reference  string
+}
+
+type MerchantQRCode struct {
+	ID            uint64       `db:"id"`
+	MerchantID    string       `db:"merchant_id"`
+	AccountID     string       `db:"account_id"`
+	Reference     string       `db:"reference"`
+	Amount        money.Money  `db:"amount"`
+	Status        int64        `db:"status"`
+	TransactionID uint64       `db:"transaction_id"`
+	ExpiresAt     time.Time    `db:"expires_at"`
+	PaidAt        sql.NullTime `db:"paid_at"`
+	CreatedAt     time.Time    `db:"created_at"`
+}
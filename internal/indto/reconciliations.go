@@ -0,0 +1,46 @@
+package indto
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type ReconciliationParams struct {
+	RunID     uint64
+	AccountID string
+	Limit     uint64
+	Page      uint64
+}
+
+type ReconciliationRun struct {
+	ID              uint64     `db:"id"`
+	Status          int64      `db:"status"`
+	AccountsChecked int64      `db:"accounts_checked"`
+	Discrepancies   int64      `db:"discrepancies"`
+	LastError       string     `db:"last_error"`
+	StartedAt       time.Time  `db:"started_at"`
+	FinishedAt      *time.Time `db:"finished_at"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID              uint64      `db:"id"`
+	RunID           uint64      `db:"run_id"`
+	AccountID       string      `db:"account_id"`
+	StoredBalance   money.Money `db:"stored_balance"`
+	ExpectedBalance money.Money `db:"expected_balance"`
+	Difference      money.Money `db:"difference"`
+	CreatedAt       time.Time   `db:"created_at"`
+}
+
+// AccountBalanceParams pages through accounts by id, AfterAccountID is the last id of the previous page
+type AccountBalanceParams struct {
+	AfterAccountID string
+	Limit          uint64
+}
+
+type AccountBalance struct {
+	AccountID       string      `db:"account_id"`
+	StoredBalance   money.Money `db:"stored_balance"`
+	ExpectedBalance money.Money `db:"expected_balance"`
+}
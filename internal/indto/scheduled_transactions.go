@@ -0,0 +1,52 @@
+package indto
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/constant"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type ScheduledTransactionParams struct {
+	ScheduleID uint64
+	UserID     string
+	Status     int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type ScheduledTransaction struct {
+	ID            uint64      `db:"id"`
+	UserID        string      `db:"user_id"`
+	AccountID     string      `db:"account_id"`
+	RecipientID   string      `db:"recipient_id"`
+	TrxType       int64       `db:"trx_type"`
+	Nominal       money.Money `db:"nominal"`
+	Description   string      `db:"description"`
+	ScheduledAt   time.Time   `db:"scheduled_at"`
+	Status        int64       `db:"status"`
+	Attempts      int64       `db:"attempts"`
+	NextAttemptAt time.Time   `db:"next_attempt_at"`
+	LastError     string      `db:"last_error"`
+	TransactionID uint64      `db:"transaction_id"`
+}
+
+type EventScheduledTransaction struct {
+	ID          uint64      `json:"id"`
+	UserID      string      `json:"user_id"`
+	AccountID   string      `json:"account_id"`
+	RecipientID string      `json:"recipient_id"`
+	TrxType     int64       `json:"trx_type"`
+	Nominal     money.Money `json:"nominal"`
+	Description string      `json:"description"`
+	ScheduledAt time.Time   `json:"scheduled_at"`
+}
+
+type EventFailedScheduledTransaction struct {
+	ID        uint64           `json:"id"`
+	UserID    string           `json:"user_id"`
+	Attempts  int64            `json:"attempts"`
+	ErrorCode constant.ErrCode `json:"error_code"`
+	Message   string           `json:"message"`
+}
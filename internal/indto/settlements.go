@@ -2,25 +2,52 @@ package indto
 
 import (
 	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 type SettlementParams struct {
 	SettlementID  uint64
 	TransactionID uint64
 	BeneficiaryID uint64
+	BatchID       uint64
 	MerchantID    string
+	Status        int64
 	Keyword       string
 	Limit         uint64
 	Page          uint64
 }
 
 type Settlement struct {
-	ID             uint64    `db:"id"`
-	TransactionID  uint64    `db:"transaction_id"`
-	MerchantID     string    `db:"merchant_id"`
-	MerchantName   string    `db:"merchant_name"`
-	BeneficiaryID  uint64    `db:"beneficiary_id"`
-	Amount         float64   `db:"amount"`
-	Status         int64     `db:"status"`
-	SettlementDate time.Time `db:"settlement_date"`
+	ID              uint64      `db:"id"`
+	TransactionID   uint64      `db:"transaction_id"`
+	MerchantID      string      `db:"merchant_id"`
+	MerchantName    string      `db:"merchant_name"`
+	BatchID         uint64      `db:"batch_id"`
+	Amount          money.Money `db:"amount"`
+	AllocatedAmount money.Money `db:"allocated_amount"`
+	Status          int64       `db:"status"`
+	SettlementDate  time.Time   `db:"settlement_date"`
+}
+
+type SettlementBatchParams struct {
+	BatchID    uint64
+	MerchantID string
+	Status     int64
+	Limit      uint64
+	Page       uint64
+}
+
+type SettlementBatch struct {
+	ID              uint64      `db:"id"`
+	MerchantID      string      `db:"merchant_id"`
+	MerchantName    string      `db:"merchant_name"`
+	Status          int64       `db:"status"`
+	SettlementCount int64       `db:"settlement_count"`
+	TotalAmount     money.Money `db:"total_amount"`
+	FeeAmount       money.Money `db:"fee_amount"`
+	FailureReason   string      `db:"failure_reason"`
+	CutoffAt        time.Time   `db:"cutoff_at"`
+	PaidAt          *time.Time  `db:"paid_at"`
+	CreatedAt       time.Time   `db:"created_at"`
 }
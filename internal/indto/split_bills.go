@@ -0,0 +1,59 @@
+package indto
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// SplitBillParams.AccountID matches the initiator as well as any participant of the split
+type SplitBillParams struct {
+	SplitID            uint64
+	InitiatorAccountID string
+	AccountID          string
+	Status             int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type SplitBill struct {
+	ID                 uint64      `db:"id"`
+	InitiatorAccountID string      `db:"initiator_account_id"`
+	Total              money.Money `db:"total"`
+	Settled            money.Money `db:"settled"`
+	Description        string      `db:"description"`
+	SplitType          int64       `db:"split_type"`
+	Status             int64       `db:"status"`
+	PaidShares         int64       `db:"paid_shares"`
+	TotalShares        int64       `db:"total_shares"`
+	CreatedAt          time.Time   `db:"created_at"`
+}
+
+type SplitBillShareParams struct {
+	ShareIDs  []uint64
+	SplitID   uint64
+	AccountID string
+	Statuses  []int64
+}
+
+type SplitBillShare struct {
+	ID            uint64       `db:"id"`
+	SplitID       uint64       `db:"split_id"`
+	AccountID     string       `db:"account_id"`
+	Amount        money.Money  `db:"amount"`
+	Status        int64        `db:"status"`
+	TransactionID uint64       `db:"transaction_id"`
+	ReminderCount int64        `db:"reminder_count"`
+	RemindedAt    sql.NullTime `db:"reminded_at"`
+	PaidAt        sql.NullTime `db:"paid_at"`
+}
+
+type EventSplitBillReminder struct {
+	SplitID     uint64      `json:"split_id"`
+	ShareID     uint64      `json:"share_id"`
+	UserID      string      `json:"user_id"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+}
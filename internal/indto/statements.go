@@ -0,0 +1,26 @@
+package indto
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type StatementParams struct {
+	AccountID string
+	DateStart time.Time
+	DateEnd   time.Time
+}
+
+// StatementEntry is a posting on the statement account, counterparty is the other account of the underlying transaction.
+// CustomerName holds the encrypted legal name while MerchantName is stored as plain text
+type StatementEntry struct {
+	ID            uint64      `db:"id"`
+	TransactionID uint64      `db:"transaction_id"`
+	EntryType     int64       `db:"entry_type"`
+	Description   string      `db:"description"`
+	EntryDatetime time.Time   `db:"entry_datetime"`
+	Amount        money.Money `db:"amount"`
+	CustomerName  []byte      `db:"customer_name"`
+	MerchantName  *string     `db:"merchant_name"`
+}
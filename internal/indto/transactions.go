@@ -1,6 +1,11 @@
 package indto
 
-import "time"
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/constant"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
 
 type TransactionParams struct {
 	TransactionID uint64
@@ -18,15 +23,45 @@ type TransactionParams struct {
 }
 
 type Transaction struct {
-	ID            uint64    `db:"id" json:"id"`
-	AccountID     string    `db:"account_id" json:"account_id"`
-	AccountName   []byte    `db:"account_name" json:"account_name"`
-	RecipientID   string    `db:"recipient_id" json:"recipient_id"`
-	RecipientName []byte    `db:"recipient_name" json:"recipient_name"`
-	TrxType       int64     `db:"trx_type" json:"trx_type"`
-	TrxDatetime   time.Time `db:"trx_datetime" json:"trx_datetime"`
-	TrxStatus     int64     `db:"trx_status" json:"trx_status"`
-	TrxFee        float64   `db:"trx_fee" json:"trx_fee"`
-	Nominal       float64   `db:"nominal" json:"nominal"`
-	Description   string    `db:"description" json:"description"`
+	ID            uint64      `db:"id" json:"id"`
+	ParentID      uint64      `db:"parent_id" json:"parent_id"`
+	AccountID     string      `db:"account_id" json:"account_id"`
+	AccountName   []byte      `db:"account_name" json:"account_name"`
+	RecipientID   string      `db:"recipient_id" json:"recipient_id"`
+	RecipientName []byte      `db:"recipient_name" json:"recipient_name"`
+	TrxType       int64       `db:"trx_type" json:"trx_type"`
+	TrxDatetime   time.Time   `db:"trx_datetime" json:"trx_datetime"`
+	TrxStatus     int64       `db:"trx_status" json:"trx_status"`
+	TrxFee        money.Money `db:"trx_fee" json:"trx_fee"`
+	FeeRuleID     uint64      `db:"fee_rule_id" json:"fee_rule_id"`
+	Nominal       money.Money `db:"nominal" json:"nominal"`
+	Description   string      `db:"description" json:"description"`
+
+	RefundedAmount money.Money `db:"refunded_amount" json:"refunded_amount"`
+
+	AuthorizedAmount money.Money `db:"authorized_amount" json:"authorized_amount"`
+	ExpiresAt        *time.Time  `db:"expires_at" json:"expires_at"`
+}
+
+// EventTransaction is a transaction requested over the bus by another service, it arrives as the payload of an
+// EventEnvelope and ServiceID is taken from the authenticated envelope. The sender account must belong to UserID
+type EventTransaction struct {
+	CorrelationID string      `json:"correlation_id"`
+	ServiceID     string      `json:"service_id"`
+	UserID        string      `json:"user_id"`
+	TrxType       int64       `json:"trx_type"`
+	AccountID     string      `json:"account_id"`
+	RecipientID   string      `json:"recipient_id"`
+	Nominal       money.Money `json:"nominal"`
+	Description   string      `json:"description"`
+}
+
+type EventTransactionResult struct {
+	CorrelationID string           `json:"correlation_id"`
+	ServiceID     string           `json:"service_id"`
+	Success       bool             `json:"success"`
+	TransactionID uint64           `json:"transaction_id,omitempty"`
+	TrxStatus     int64            `json:"trx_status,omitempty"`
+	ErrorCode     constant.ErrCode `json:"error_code,omitempty"`
+	Message       string           `json:"message,omitempty"`
 }
@@ -0,0 +1,59 @@
+package indto
+
+import (
+	"database/sql"
+	"time"
+)
+
+type WebhookParams struct {
+	WebhookID  uint64
+	MerchantID string
+	EventType  string
+	Status     int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type WebhookSubscription struct {
+	ID         uint64    `db:"id"`
+	MerchantID string    `db:"merchant_id"`
+	URL        string    `db:"url"`
+	Secret     []byte    `db:"secret"`
+	EventTypes []byte    `db:"event_types"`
+	Status     int64     `db:"status"`
+	RowHash    []byte    `db:"row_hash"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type WebhookDeliveryParams struct {
+	DeliveryID uint64
+	WebhookID  uint64
+	Status     int64
+
+	Limit uint64
+	Page  uint64
+}
+
+type WebhookDelivery struct {
+	ID             uint64       `db:"id"`
+	SubscriptionID uint64       `db:"subscription_id"`
+	EventType      string       `db:"event_type"`
+	Payload        []byte       `db:"payload"`
+	Status         int64        `db:"status"`
+	Attempts       int64        `db:"attempts"`
+	NextRunAt      sql.NullTime `db:"next_run_at"`
+	ResponseStatus int64        `db:"response_status"`
+	ResponseBody   string       `db:"response_body"`
+	LastError      string       `db:"last_error"`
+	DeliveredAt    sql.NullTime `db:"delivered_at"`
+	CreatedAt      time.Time    `db:"created_at"`
+}
+
+// WebhookEvent is the body of every delivery, Data depends on Event
+type WebhookEvent struct {
+	ID        uint64      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt string      `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
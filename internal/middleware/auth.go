@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/stellar-payment/sp-payment/internal/service"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
 	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
 )
@@ -12,6 +13,11 @@ import (
 func AuthorizationMiddleware(svc service.Service) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			// already authenticated by APIClientMiddleware
+			if ctxutil.GetAPIClientCtx(c.Request().Context()) != "" {
+				return next(c)
+			}
+
 			header := c.Request().Header
 
 			var err error
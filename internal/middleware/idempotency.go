@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/service"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+type responseRecorder struct {
+	http.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware replays the stored response when a request is retried with the same
+// Idempotency-Key, must be placed after AuthorizationMiddleware since keys are scoped per user
+func IdempotencyMiddleware(svc service.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(inconst.IDEMPOTENCY_HEADER)
+			if key == "" {
+				return next(c)
+			}
+
+			if len(key) > 255 {
+				return echttputil.WriteErrorResponse(c, errs.ErrBadRequest)
+			}
+
+			usrmeta := ctxutil.GetUserCTX(c.Request().Context())
+			if usrmeta == nil {
+				return echttputil.WriteErrorResponse(c, errs.ErrNoAccess)
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := sha256.New()
+			hash.Write([]byte(c.Request().Method + " " + c.Request().URL.RequestURI() + "\n"))
+			hash.Write(body)
+
+			params := &indto.IdempotencyParams{
+				UserID:      usrmeta.UserID,
+				Key:         key,
+				RequestHash: hex.EncodeToString(hash.Sum(nil)),
+			}
+
+			record, err := svc.AcquireIdempotencyKey(c.Request().Context(), params)
+			if err != nil {
+				return echttputil.WriteErrorResponse(c, err)
+			}
+
+			if record != nil {
+				c.Response().Header().Set(inconst.IDEMPOTENCY_REPLAY_HEADER, "true")
+				return c.Blob(record.StatusCode, record.ContentType, record.Body)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer, body: &bytes.Buffer{}}
+			c.Response().Writer = recorder
+
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			// client may be gone by now, the outcome must be stored regardless
+			ctx := zerolog.Ctx(c.Request().Context()).WithContext(context.Background())
+
+			// server side failures are safe to retry, nothing to replay
+			if c.Response().Status >= http.StatusInternalServerError {
+				svc.ReleaseIdempotencyKey(ctx, params)
+				return nil
+			}
+
+			svc.CompleteIdempotencyKey(ctx, params, &indto.IdempotencyRecord{
+				StatusCode:  c.Response().Status,
+				ContentType: c.Response().Header().Get(echo.HeaderContentType),
+				Body:        recorder.body.Bytes(),
+			})
+
+			return nil
+		}
+	}
+}
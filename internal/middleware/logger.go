@@ -63,6 +63,11 @@ func RequestBodyLogger(logger *zerolog.Logger) echo.MiddlewareFunc {
 		Skipper: func(c echo.Context) bool {
 			conf := config.Get()
 
+			// exports are streamed, dumping them would buffer the whole file in memory
+			if strings.Contains(c.Request().URL.Path, "/export") {
+				return true
+			}
+
 			if conf.Environment == "prod" {
 				// for security description, exempt body logger from auth endpoints
 				return strings.Contains(c.Request().URL.Path, "/auth")
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/service"
+	"github.com/stellar-payment/sp-payment/internal/util/echttputil"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+// APIClientMiddleware authenticates requests signed by a merchant api client, requests without X-Client-Id are
+// left to AuthorizationMiddleware which must come after it. It is only mounted on routes a merchant backend may
+// call, everywhere else a signed request has no user session and is refused
+func APIClientMiddleware(svc service.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header
+
+			clientID := header.Get(inconst.API_CLIENT_HEADER)
+			if clientID == "" {
+				return next(c)
+			}
+
+			body, err := io.ReadAll(c.Request().Body)
+			if err != nil {
+				return echttputil.WriteErrorResponse(c, errs.ErrBrokenUserReq)
+			}
+			c.Request().Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx, err := svc.AuthorizedAPIClientCtx(c.Request().Context(), &indto.APIClientSignature{
+				ClientID:  clientID,
+				Timestamp: header.Get(inconst.API_TIMESTAMP_HEADER),
+				Nonce:     header.Get(inconst.API_NONCE_HEADER),
+				Signature: header.Get(inconst.API_SIGNATURE_HEADER),
+				Method:    c.Request().Method,
+				Path:      c.Request().URL.RequestURI(),
+				Body:      body,
+			})
+			if err != nil {
+				if err != errs.ErrInvalidSignature && err != errs.ErrSignatureReplayed {
+					err = errs.ErrNoAccess
+				}
+
+				return echttputil.WriteErrorResponse(c, err)
+			}
+
+			c.SetRequest(c.Request().Clone(ctx))
+			return next(c)
+		}
+	}
+}
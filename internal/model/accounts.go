@@ -1,12 +1,27 @@
 package model
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type Account struct {
-	ID            string  `db:"id"`
-	OwnerID       string  `db:"owner_id"`
-	AccountType   int64   `db:"account_type"`
-	Balance       float64 `db:"balance"`
-	AccountNo     []byte  `db:"account_no"`
-	AccountNoHash []byte  `db:"account_no_hash"`
-	PIN           string  `db:"pin"`
-	RowHash       []byte  `db:"row_hash"`
+	ID            string      `db:"id"`
+	OwnerID       string      `db:"owner_id"`
+	AccountType   int64       `db:"account_type"`
+	AccountTier   int64       `db:"account_tier"`
+	Balance       money.Money `db:"balance"`
+	HoldBalance   money.Money `db:"hold_balance"`
+	AccountNo     []byte      `db:"account_no"`
+	AccountNoHash []byte      `db:"account_no_hash"`
+	PIN           string      `db:"pin"`
+	RowHash       []byte      `db:"row_hash"`
+}
+
+// AccountStatusHistory records a status change, PrevStatus is filled in by the repository
+type AccountStatusHistory struct {
+	ID         uint64 `db:"id"`
+	AccountID  string `db:"account_id"`
+	PrevStatus int64  `db:"prev_status"`
+	Status     int64  `db:"status"`
+	ReasonCode int64  `db:"reason_code"`
+	Note       string `db:"note"`
+	ChangedBy  string `db:"changed_by"`
 }
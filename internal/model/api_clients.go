@@ -0,0 +1,11 @@
+package model
+
+type APIClient struct {
+	ID         uint64 `db:"id"`
+	MerchantID string `db:"merchant_id"`
+	ClientID   string `db:"client_id"`
+	Name       string `db:"name"`
+	PublicKey  string `db:"public_key"`
+	Status     int64  `db:"status"`
+	RowHash    []byte `db:"row_hash"`
+}
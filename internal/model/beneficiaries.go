@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
 
 type BeneficiaryParams struct {
 	UserID     string
@@ -11,10 +15,24 @@ type BeneficiaryParams struct {
 }
 
 type Beneficiary struct {
-	ID             uint64     `db:"id"`
-	AccountID      string     `db:"-"`
-	MerchantID     string     `db:"merchant_id"`
-	Amount         float64    `db:"amount"`
-	WithdrawalDate *time.Time `db:"withdrawal_date"`
-	Status         int64      `db:"status"`
+	ID             uint64      `db:"id"`
+	AccountID      string      `db:"-"`
+	MerchantID     string      `db:"merchant_id"`
+	Amount         money.Money `db:"amount"`
+	Reserve        money.Money `db:"-"`
+	WithdrawalDate *time.Time  `db:"withdrawal_date"`
+	Status         int64       `db:"status"`
+	PayoutMethod   int64       `db:"payout_method"`
+	RejectReason   string      `db:"reject_reason"`
+	ReviewedBy     string      `db:"reviewed_by"`
+	ReviewedAt     *time.Time  `db:"reviewed_at"`
+
+	// bank transfer created alongside a confirmed bank payout
+	Payout *Payout `db:"-"`
+}
+
+type BeneficiaryAllocation struct {
+	BeneficiaryID uint64      `db:"beneficiary_id"`
+	SettlementID  uint64      `db:"settlement_id"`
+	Amount        money.Money `db:"amount"`
 }
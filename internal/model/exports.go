@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+type ExportJob struct {
+	ID         uint64     `db:"id"`
+	UserID     string     `db:"user_id"`
+	ExportType int64      `db:"export_type"`
+	Format     string     `db:"format"`
+	Params     []byte     `db:"params"`
+	Status     int64      `db:"status"`
+	RowCount   int64      `db:"row_count"`
+	FileName   string     `db:"file_name"`
+	LastError  string     `db:"last_error"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+}
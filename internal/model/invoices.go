@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// Invoice is billed to a single customer account, Total is Subtotal plus TaxAmount
+type Invoice struct {
+	ID                uint64         `db:"id"`
+	MerchantID        string         `db:"merchant_id"`
+	CustomerAccountID string         `db:"customer_account_id"`
+	CustomerReference string         `db:"customer_reference"`
+	Description       string         `db:"description"`
+	Subtotal          money.Money    `db:"subtotal"`
+	TaxRateBps        int64          `db:"tax_rate_bps"`
+	TaxAmount         money.Money    `db:"tax_amount"`
+	Total             money.Money    `db:"total"`
+	DueDate           time.Time      `db:"due_date"`
+	Status            int64          `db:"status"`
+	TransactionID     uint64         `db:"transaction_id"`
+	Items             []*InvoiceItem `db:"-"`
+}
+
+type InvoiceItem struct {
+	ID          uint64      `db:"id"`
+	InvoiceID   uint64      `db:"invoice_id"`
+	Description string      `db:"description"`
+	Quantity    int64       `db:"quantity"`
+	UnitPrice   money.Money `db:"unit_price"`
+	Amount      money.Money `db:"amount"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type JournalEntry struct {
+	ID            uint64            `db:"id"`
+	TransactionID uint64            `db:"transaction_id"`
+	EntryType     int64             `db:"entry_type"`
+	Description   string            `db:"description"`
+	EntryDatetime time.Time         `db:"entry_datetime"`
+	Postings      []*JournalPosting `db:"-"`
+}
+
+// Positive amount credits the account, negative amount debits it
+type JournalPosting struct {
+	ID        uint64      `db:"id"`
+	JournalID uint64      `db:"journal_id"`
+	AccountID string      `db:"account_id"`
+	Amount    money.Money `db:"amount"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+// TransactionLimit caps outgoing transactions of an account, zero value of a cap means unlimited
+type TransactionLimit struct {
+	ID                      uint64      `db:"id"`
+	Name                    string      `db:"name"`
+	TrxType                 int64       `db:"trx_type"`
+	AccountType             int64       `db:"account_type"`
+	AccountTier             int64       `db:"account_tier"`
+	MaxPerTrx               money.Money `db:"max_per_trx"`
+	DailyAmount             money.Money `db:"daily_amount"`
+	DailyCount              int64       `db:"daily_count"`
+	MonthlyAmount           money.Money `db:"monthly_amount"`
+	MonthlyCount            int64       `db:"monthly_count"`
+	CounterpartyDailyAmount money.Money `db:"counterparty_daily_amount"`
+}
@@ -13,3 +13,11 @@ type Merchant struct {
 	PhotoProfile string `db:"photo_profile"`
 	RowHash      []byte `db:"row_hash"`
 }
+
+type MerchantBankAccount struct {
+	MerchantID  string `db:"merchant_id"`
+	BankCode    string `db:"bank_code"`
+	AccountNo   []byte `db:"account_no"`
+	AccountName []byte `db:"account_name"`
+	RowHash     []byte `db:"row_hash"`
+}
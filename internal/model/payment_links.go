@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// PaymentLink is paid by anyone holding its token, zero Amount lets the payer choose and zero MaxUses is unlimited
+type PaymentLink struct {
+	ID          uint64      `db:"id"`
+	MerchantID  string      `db:"merchant_id"`
+	Token       []byte      `db:"token"`
+	TokenHash   []byte      `db:"token_hash"`
+	Amount      money.Money `db:"amount"`
+	Description string      `db:"description"`
+	MaxUses     int64       `db:"max_uses"`
+	Status      int64       `db:"status"`
+	ExpiresAt   time.Time   `db:"expires_at"`
+	RowHash     []byte      `db:"row_hash"`
+}
+
+type PaymentLinkPayment struct {
+	ID            uint64      `db:"id"`
+	LinkID        uint64      `db:"link_id"`
+	TransactionID uint64      `db:"transaction_id"`
+	AccountID     string      `db:"account_id"`
+	Amount        money.Money `db:"amount"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type Payout struct {
+	ID            uint64      `db:"id"`
+	BeneficiaryID uint64      `db:"beneficiary_id"`
+	MerchantID    string      `db:"merchant_id"`
+	Connector     string      `db:"connector"`
+	BankCode      string      `db:"bank_code"`
+	AccountNo     []byte      `db:"account_no"`
+	AccountName   []byte      `db:"account_name"`
+	Amount        money.Money `db:"amount"`
+	Status        int64       `db:"status"`
+	ProviderRef   string      `db:"provider_ref"`
+	FailureReason string      `db:"failure_reason"`
+	Attempts      int64       `db:"attempts"`
+	NextRunAt     *time.Time  `db:"next_run_at"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type MerchantQRCode struct {
+	ID            uint64      `db:"id"`
+	MerchantID    string      `db:"merchant_id"`
+	AccountID     string      `db:"account_id"`
+	Reference     string      `db:"reference"`
+	Amount        money.Money `db:"amount"`
+	Status        int64       `db:"status"`
+	TransactionID uint64      `db:"transaction_id"`
+	ExpiresAt     time.Time   `db:"expires_at"`
+}
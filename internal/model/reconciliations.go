@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type ReconciliationRun struct {
+	ID              uint64     `db:"id"`
+	Status          int64      `db:"status"`
+	AccountsChecked int64      `db:"accounts_checked"`
+	Discrepancies   int64      `db:"discrepancies"`
+	LastError       string     `db:"last_error"`
+	FinishedAt      *time.Time `db:"finished_at"`
+}
+
+type ReconciliationDiscrepancy struct {
+	RunID           uint64      `db:"run_id"`
+	AccountID       string      `db:"account_id"`
+	StoredBalance   money.Money `db:"stored_balance"`
+	ExpectedBalance money.Money `db:"expected_balance"`
+	Difference      money.Money `db:"difference"`
+}
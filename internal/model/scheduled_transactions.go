@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type ScheduledTransaction struct {
+	ID            uint64      `db:"id"`
+	UserID        string      `db:"user_id"`
+	AccountID     string      `db:"account_id"`
+	RecipientID   string      `db:"recipient_id"`
+	TrxType       int64       `db:"trx_type"`
+	Nominal       money.Money `db:"nominal"`
+	Description   string      `db:"description"`
+	ScheduledAt   time.Time   `db:"scheduled_at"`
+	Status        int64       `db:"status"`
+	Attempts      int64       `db:"attempts"`
+	NextAttemptAt time.Time   `db:"next_attempt_at"`
+	LastError     string      `db:"last_error"`
+	TransactionID uint64      `db:"transaction_id"`
+}
@@ -1,12 +1,29 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
 
 type Settlement struct {
-	ID             uint64    `db:"id"`
-	TransactionID  uint64    `db:"transaction_id"`
-	MerchantID     string    `db:"merchant_id"`
-	BeneficiaryID  uint64    `db:"beneficiary_id"`
-	Amount         float64   `db:"amount"`
-	SettlementDate time.Time `db:"settlement_date"`
+	ID              uint64      `db:"id"`
+	TransactionID   uint64      `db:"transaction_id"`
+	MerchantID      string      `db:"merchant_id"`
+	BatchID         uint64      `db:"batch_id"`
+	Amount          money.Money `db:"amount"`
+	AllocatedAmount money.Money `db:"allocated_amount"`
+	Status          int64       `db:"status"`
+	SettlementDate  time.Time   `db:"settlement_date"`
+}
+
+type SettlementBatch struct {
+	ID              uint64      `db:"id"`
+	MerchantID      string      `db:"merchant_id"`
+	Status          int64       `db:"status"`
+	SettlementCount int64       `db:"settlement_count"`
+	TotalAmount     money.Money `db:"total_amount"`
+	FeeAmount       money.Money `db:"fee_amount"`
+	FailureReason   string      `db:"failure_reason"`
+	CutoffAt        time.Time   `db:"cutoff_at"`
 }
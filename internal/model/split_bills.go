@@ -0,0 +1,24 @@
+package model
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+// SplitBill is paid back to its initiator account share by share, each participant owes exactly one share
+type SplitBill struct {
+	ID                 uint64      `db:"id"`
+	InitiatorAccountID string      `db:"initiator_account_id"`
+	Total              money.Money `db:"total"`
+	Description        string      `db:"description"`
+	SplitType          int64       `db:"split_type"`
+	Status             int64       `db:"status"`
+
+	Shares []*SplitBillShare
+}
+
+type SplitBillShare struct {
+	ID            uint64      `db:"id"`
+	SplitID       uint64      `db:"split_id"`
+	AccountID     string      `db:"account_id"`
+	Amount        money.Money `db:"amount"`
+	Status        int64       `db:"status"`
+	TransactionID uint64      `db:"transaction_id"`
+}
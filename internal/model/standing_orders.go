@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type StandingOrder struct {
+	ID                        uint64      `db:"id"`
+	UserID                    string      `db:"user_id"`
+	AccountID                 string      `db:"account_id"`
+	RecipientID               string      `db:"recipient_id"`
+	TrxType                   int64       `db:"trx_type"`
+	Nominal                   money.Money `db:"nominal"`
+	Description               string      `db:"description"`
+	Recurrence                string      `db:"recurrence"`
+	StartAt                   time.Time   `db:"start_at"`
+	EndAt                     *time.Time  `db:"end_at"`
+	MaxOccurrences            int64       `db:"max_occurrences"`
+	Occurrences               int64       `db:"occurrences"`
+	InsufficientBalancePolicy int64       `db:"insufficient_balance_policy"`
+	Status                    int64       `db:"status"`
+	NextOccurrenceAt          time.Time   `db:"next_occurrence_at"`
+	NextRunAt                 time.Time   `db:"next_run_at"`
+	Attempts                  int64       `db:"attempts"`
+	TransactionID             uint64      `db:"transaction_id"`
+}
+
+type StandingOrderRun struct {
+	ID            uint64    `db:"id"`
+	OrderID       uint64    `db:"order_id"`
+	OccurrenceAt  time.Time `db:"occurrence_at"`
+	Attempt       int64     `db:"attempt"`
+	Status        int64     `db:"status"`
+	TransactionID uint64    `db:"transaction_id"`
+	ErrorMessage  string    `db:"error_message"`
+}
@@ -1,16 +1,32 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
 
 type Transaction struct {
-	ID          uint64    `db:"id"`
-	AccountID   string    `db:"account_id"`
-	RecipientID string    `db:"recipient_id"`
-	MerchantID  string    `db:"-"`
-	TrxType     int64     `db:"trx_type"`
-	TrxDatetime time.Time `db:"trx_datetime"`
-	TrxStatus   int64     `db:"trx_status"`
-	TrxFee      float64   `db:"trx_fee"`
-	Nominal     float64   `db:"nominal"`
-	Description string    `db:"description"`
+	ID          uint64      `db:"id"`
+	ParentID    uint64      `db:"parent_id"`
+	AccountID   string      `db:"account_id"`
+	RecipientID string      `db:"recipient_id"`
+	MerchantID  string      `db:"-"`
+	TrxType     int64       `db:"trx_type"`
+	TrxDatetime time.Time   `db:"trx_datetime"`
+	TrxStatus   int64       `db:"trx_status"`
+	TrxFee      money.Money `db:"trx_fee"`
+	FeeRuleID   uint64      `db:"fee_rule_id"`
+	Nominal     money.Money `db:"nominal"`
+	Description string      `db:"description"`
+
+	RefundedAmount money.Money `db:"refunded_amount"`
+	RefundedFee    money.Money `db:"refunded_fee"`
+
+	AuthorizedAmount money.Money `db:"authorized_amount"`
+	HoldAmount       money.Money `db:"hold_amount"`
+	ExpiresAt        *time.Time  `db:"expires_at"`
+
+	// limit enforced while the sender is locked, nil means unlimited
+	Limit *TransactionLimit `db:"-"`
 }
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// WebhookSubscription.EventTypes is a json array of WEBHOOK_EVENT_*
+type WebhookSubscription struct {
+	ID         uint64 `db:"id"`
+	MerchantID string `db:"merchant_id"`
+	URL        string `db:"url"`
+	Secret     []byte `db:"secret"`
+	EventTypes []byte `db:"event_types"`
+	Status     int64  `db:"status"`
+	RowHash    []byte `db:"row_hash"`
+}
+
+type WebhookDelivery struct {
+	ID             uint64     `db:"id"`
+	SubscriptionID uint64     `db:"subscription_id"`
+	EventType      string     `db:"event_type"`
+	Payload        []byte     `db:"payload"`
+	Status         int64      `db:"status"`
+	Attempts       int64      `db:"attempts"`
+	NextRunAt      *time.Time `db:"next_run_at"`
+	ResponseStatus int64      `db:"response_status"`
+	ResponseBody   string     `db:"response_body"`
+	LastError      string     `db:"last_error"`
+}
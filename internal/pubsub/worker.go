@@ -46,6 +46,8 @@ func (pb *EventPubSub) Listen() {
 		inconst.TOPIC_CREATE_MERCHANT,
 		inconst.TOPIC_DELETE_MERCHANT,
 		inconst.TOPIC_CREATE_TRX,
+		inconst.TOPIC_CREATE_SCHEDULE_TRX,
+		inconst.TOPIC_DELETE_SCHEDULE_TRX,
 	)
 
 	data := fmt.Sprintf("%s,%s", "payment", strings.Join(pb.secureRoutes, ","))
@@ -99,6 +101,38 @@ func (pb *EventPubSub) Listen() {
 				continue
 			}
 		case inconst.TOPIC_CREATE_TRX:
+			data := &indto.EventEnvelope{}
+			if err := json.Unmarshal([]byte(msg.Payload), data); err != nil {
+				pb.logger.Warn().Err(err).Str("channel", msg.Channel).Msg("failed to marshal payload")
+				continue
+			}
+
+			if err := pb.service.HandleCreateTransaction(pb.logger.WithContext(context.Background()), data); err != nil {
+				pb.logger.Warn().Err(err).Str("channel", msg.Channel).Send()
+				continue
+			}
+		case inconst.TOPIC_CREATE_SCHEDULE_TRX:
+			data := &indto.EventEnvelope{}
+			if err := json.Unmarshal([]byte(msg.Payload), data); err != nil {
+				pb.logger.Warn().Err(err).Str("channel", msg.Channel).Msg("failed to marshal payload")
+				continue
+			}
+
+			if err := pb.service.HandleCreateScheduledTransaction(pb.logger.WithContext(context.Background()), data); err != nil {
+				pb.logger.Warn().Err(err).Str("channel", msg.Channel).Send()
+				continue
+			}
+		case inconst.TOPIC_DELETE_SCHEDULE_TRX:
+			data := &indto.EventEnvelope{}
+			if err := json.Unmarshal([]byte(msg.Payload), data); err != nil {
+				pb.logger.Warn().Err(err).Str("channel", msg.Channel).Msg("failed to marshal payload")
+				continue
+			}
+
+			if err := pb.service.HandleDeleteScheduledTransaction(pb.logger.WithContext(context.Background()), data); err != nil {
+				pb.logger.Warn().Err(err).Str("channel", msg.Channel).Send()
+				continue
+			}
 		case inconst.TOPIC_REQUEST_SECURE_ROUTE:
 			data := fmt.Sprintf("%s,%s", "payment", strings.Join(pb.secureRoutes, ","))
 			pb.redis.Publish(context.Background(), inconst.TOPIC_BROADCAST_SECURE_ROUTE, data)
@@ -7,10 +7,33 @@ import (
 
 	"github.com/Masterminds/squirrel"
 	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
 )
 
+// accounts in these statuses can still be debited or credited
+var (
+	accountDebitStatus  = []int64{inconst.ACCOUNT_STATUS_ACTIVE, inconst.ACCOUNT_STATUS_CREDIT_BLOCKED}
+	accountCreditStatus = []int64{inconst.ACCOUNT_STATUS_ACTIVE, inconst.ACCOUNT_STATUS_DEBIT_BLOCKED}
+)
+
+func accountStatusAllows(status int64, debit bool) bool {
+	allowed := accountCreditStatus
+	if debit {
+		allowed = accountDebitStatus
+	}
+
+	for _, v := range allowed {
+		if v == status {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *repository) FindAccounts(ctx context.Context, params *indto.AccountParams) (res []*indto.Account, err error) {
 	logger := zerolog.Ctx(ctx)
 
@@ -26,7 +49,7 @@ func (r *repository) FindAccounts(ctx context.Context, params *indto.AccountPara
 		cond = append(cond, squirrel.Eq{"a.account_type": params.AccountType})
 	}
 
-	baseStmt := pgSquirrel.Select("a.id", "a.owner_id", "coalesce(m.name::bytea, c.legal_name) owner_name", "a.account_type", "a.balance", "a.account_no", "a.row_hash").
+	baseStmt := pgSquirrel.Select("a.id", "a.owner_id", "coalesce(m.name::bytea, c.legal_name) owner_name", "a.account_type", "a.account_tier", "a.balance", "a.hold_balance", "a.status", "a.status_reason", "a.account_no", "a.row_hash").
 		From("accounts a").
 		LeftJoin("merchants m on a.owner_id = m.user_id and a.account_type = 2").
 		LeftJoin("customers c on a.owner_id = c.user_id and a.account_type = 1").
@@ -110,7 +133,7 @@ func (r *repository) FindAccount(ctx context.Context, params *indto.AccountParam
 		cond = append(cond, squirrel.Eq{"owner_id": params.UserID})
 	}
 
-	stmt, args, err := pgSquirrel.Select("a.id", "a.owner_id", "coalesce(m.name::bytea, c.legal_name) owner_name", "a.account_type", "a.balance", "a.account_no", "a.pin", "a.row_hash").
+	stmt, args, err := pgSquirrel.Select("a.id", "a.owner_id", "coalesce(m.name::bytea, c.legal_name) owner_name", "a.account_type", "a.account_tier", "a.balance", "a.hold_balance", "a.status", "a.status_reason", "a.account_no", "a.pin", "a.row_hash").
 		From("accounts a").
 		LeftJoin("merchants m on a.owner_id = m.user_id and a.account_type = 2").
 		LeftJoin("customers c on a.owner_id = c.user_id and a.account_type = 1").
@@ -135,8 +158,8 @@ func (r *repository) FindAccount(ctx context.Context, params *indto.AccountParam
 func (r *repository) CreateAccount(ctx context.Context, payload *model.Account) (res *model.Account, err error) {
 	logger := zerolog.Ctx(ctx)
 
-	stmt, args, err := pgSquirrel.Insert("accounts").Columns("id", "owner_id", "account_type", "balance", "account_no", "account_no_hash", "pin", "row_hash").
-		Values(payload.ID, payload.OwnerID, payload.AccountType, payload.Balance, payload.AccountNo, payload.AccountNoHash, payload.PIN, payload.RowHash).ToSql()
+	stmt, args, err := pgSquirrel.Insert("accounts").Columns("id", "owner_id", "account_type", "account_tier", "balance", "account_no", "account_no_hash", "pin", "row_hash").
+		Values(payload.ID, payload.OwnerID, payload.AccountType, payload.AccountTier, payload.Balance, payload.AccountNo, payload.AccountNoHash, payload.PIN, payload.RowHash).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
@@ -156,6 +179,7 @@ func (r *repository) UpdateAccount(ctx context.Context, payload *model.Account)
 
 	stmt, args, err := pgSquirrel.Update("accounts").SetMap(map[string]interface{}{
 		"account_type":    payload.AccountType,
+		"account_tier":    squirrel.Expr("coalesce(nullif(?, 0), account_tier)", payload.AccountTier),
 		"balance":         payload.Balance,
 		"account_no":      payload.AccountNo,
 		"account_no_hash": payload.AccountNoHash,
@@ -180,22 +204,296 @@ func (r *repository) UpdateAccount(ctx context.Context, payload *model.Account)
 	return
 }
 
+// lockAccountsTx row-locks accounts until tx ends, rows are locked in id order
+// so concurrent transfers between the same accounts cannot deadlock each other
+func (r *repository) lockAccountsTx(ctx context.Context, tx *sql.Tx, accountIDs ...string) (res map[string]*indto.Account, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"a.id": accountIDs},
+		squirrel.Eq{"a.deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Select("a.id", "a.account_type", "a.balance", "a.hold_balance", "a.status").From("accounts a").
+		Where(cond).OrderBy("a.id").Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = map[string]*indto.Account{}
+	for rows.Next() {
+		temp := &indto.Account{}
+
+		if err = rows.Scan(&temp.ID, &temp.AccountType, &temp.Balance, &temp.HoldBalance, &temp.Status); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res[temp.ID] = temp
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// updateAccountBalanceTx substracts payload.Balance from account balance, negative value credits the account.
+// Debit only succeeds when the available balance (balance - hold_balance) covers it, otherwise ErrInsufficientBalance is returned.
+// Accounts whose status blocks the movement are left untouched with ErrAccountRestricted
 func (r *repository) updateAccountBalanceTx(ctx context.Context, tx *sql.Tx, payload *model.Account) (err error) {
 	logger := zerolog.Ctx(ctx)
 
+	cond := squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if payload.Balance.IsPositive() {
+		cond = append(cond, squirrel.Expr("(balance - hold_balance) >= ?", payload.Balance), squirrel.Eq{"status": accountDebitStatus})
+	} else {
+		cond = append(cond, squirrel.Eq{"status": accountCreditStatus})
+	}
+
 	stmt, args, err := pgSquirrel.Update("accounts").SetMap(map[string]interface{}{
 		"balance":    squirrel.Expr("(balance - ?)", payload.Balance),
 		"updated_at": time.Now(),
-	}).Where(squirrel.And{
+	}).Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		if isCheckViolation(err) {
+			err = errs.ErrInsufficientBalance
+		}
+
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = r.accountNotUpdatedErrTx(ctx, tx, payload.ID, payload.Balance.IsPositive())
+		logger.Error().Err(err).Str("account-id", payload.ID).Str("amount", payload.Balance.String()).Msg("account balance not updated")
+		return
+	}
+
+	return
+}
+
+// updateAccountHoldTx places payload.HoldBalance on hold, negative value releases it.
+// Hold only succeeds when the available balance covers it, held funds stay on the balance until captured
+func (r *repository) updateAccountHoldTx(ctx context.Context, tx *sql.Tx, payload *model.Account) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
 		squirrel.Eq{"id": payload.ID},
 		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if payload.HoldBalance.IsPositive() {
+		cond = append(cond, squirrel.Expr("(balance - hold_balance) >= ?", payload.HoldBalance), squirrel.Eq{"status": accountDebitStatus})
+	}
+
+	stmt, args, err := pgSquirrel.Update("accounts").SetMap(map[string]interface{}{
+		"hold_balance": squirrel.Expr("(hold_balance + ?)", payload.HoldBalance),
+		"updated_at":   time.Now(),
+	}).Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		if isCheckViolation(err) {
+			err = errs.ErrInsufficientBalance
+		}
+
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		if payload.HoldBalance.IsPositive() {
+			err = r.accountNotUpdatedErrTx(ctx, tx, payload.ID, true)
+		} else {
+			err = errs.ErrNotFound
+		}
+
+		logger.Error().Err(err).Str("account-id", payload.ID).Str("amount", payload.HoldBalance.String()).Msg("account hold not updated")
+		return
+	}
+
+	return
+}
+
+// accountNotUpdatedErrTx tells why a guarded balance update of accountID matched no row
+func (r *repository) accountNotUpdatedErrTx(ctx context.Context, tx *sql.Tx, accountID string, debit bool) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("status").From("accounts").Where(squirrel.And{
+		squirrel.Eq{"id": accountID},
+		squirrel.Eq{"deleted_at": nil},
 	}).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
 	}
 
-	_, err = r.db.ExecContext(ctx, stmt, args...)
+	var status int64
+	if err = tx.QueryRowContext(ctx, stmt, args...).Scan(&status); err == sql.ErrNoRows {
+		return errs.ErrNotFound
+	} else if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if !accountStatusAllows(status, debit) {
+		return errs.ErrAccountRestricted
+	}
+
+	if debit {
+		return errs.ErrInsufficientBalance
+	}
+
+	return errs.ErrNotFound
+}
+
+// UpdateAccountStatus moves the account to payload.Status and records the change, closed accounts cannot be reopened
+func (r *repository) UpdateAccountStatus(ctx context.Context, payload *model.AccountStatusHistory) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	accounts, err := r.lockAccountsTx(ctx, tx, payload.AccountID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	account, ok := accounts[payload.AccountID]
+	if !ok {
+		err = errs.ErrNotFound
+		logger.Error().Err(err).Str("account-id", payload.AccountID).Msg("account not found")
+		return
+	}
+
+	if account.Status == inconst.ACCOUNT_STATUS_CLOSED {
+		err = errs.ErrAccountRestricted
+		logger.Error().Err(err).Str("account-id", payload.AccountID).Msg("closed account cannot change status")
+		return
+	}
+
+	if payload.Status == inconst.ACCOUNT_STATUS_CLOSED && (!account.Balance.IsZero() || !account.HoldBalance.IsZero()) {
+		err = errs.ErrBadRequest
+		logger.Error().Err(err).Str("account-id", payload.AccountID).Msg("account with remaining funds cannot be closed")
+		return
+	}
+
+	payload.PrevStatus = account.Status
+
+	stmt, args, err := pgSquirrel.Update("accounts").SetMap(map[string]interface{}{
+		"status":        payload.Status,
+		"status_reason": payload.ReasonCode,
+		"updated_at":    time.Now(),
+	}).Where(squirrel.Eq{"id": payload.AccountID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Insert("account_status_histories").
+		Columns("id", "account_id", "prev_status", "status", "reason_code", "note", "changed_by").
+		Values(payload.ID, payload.AccountID, payload.PrevStatus, payload.Status, payload.ReasonCode, payload.Note, payload.ChangedBy).
+		ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindAccountStatusHistories(ctx context.Context, params *indto.AccountStatusHistoryParams) (res []*indto.AccountStatusHistory, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select("id", "account_id", "prev_status", "status", "reason_code", "note", "changed_by", "created_at").
+		From("account_status_histories").Where(squirrel.Eq{"account_id": params.AccountID}).OrderBy("created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.AccountStatusHistory{}
+	for rows.Next() {
+		temp := &indto.AccountStatusHistory{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountAccountStatusHistories(ctx context.Context, params *indto.AccountStatusHistoryParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("account_status_histories").Where(squirrel.Eq{"account_id": params.AccountID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
 	if err != nil {
 		logger.Error().Err(err).Msg("sql err")
 		return
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var apiClientColumns = []string{
+	"id", "merchant_id", "client_id", "name", "public_key", "status", "row_hash", "last_used_at", "created_at", "revoked_at",
+}
+
+func (r *repository) FindAPIClients(ctx context.Context, params *indto.APIClientParams) (res []*indto.APIClient, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select(apiClientColumns...).From("merchant_api_clients").Where(apiClientCond(params)).OrderBy("created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.APIClient{}
+	for rows.Next() {
+		temp := &indto.APIClient{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountAPIClients(ctx context.Context, params *indto.APIClientParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("merchant_api_clients").Where(apiClientCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindAPIClient(ctx context.Context, params *indto.APIClientParams) (res *indto.APIClient, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(apiClientColumns...).From("merchant_api_clients").Where(apiClientCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.APIClient{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) CreateAPIClient(ctx context.Context, payload *model.APIClient) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("merchant_api_clients").
+		Columns("id", "merchant_id", "client_id", "name", "public_key", "status", "row_hash").
+		Values(payload.ID, payload.MerchantID, payload.ClientID, payload.Name, payload.PublicKey, payload.Status, payload.RowHash).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// RevokeAPIClient stops an active client of params.MerchantID from signing requests for good,
+// an unknown or already revoked client returns ErrNotFound
+func (r *repository) RevokeAPIClient(ctx context.Context, params *indto.APIClientParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("merchant_api_clients").SetMap(map[string]interface{}{
+		"status":     inconst.API_CLIENT_STATUS_REVOKED,
+		"revoked_at": time.Now(),
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": params.APIClientID},
+		squirrel.Eq{"merchant_id": params.MerchantID},
+		squirrel.Eq{"status": inconst.API_CLIENT_STATUS_ACTIVE},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrNotFound
+		logger.Error().Err(err).Uint64("api-client-id", params.APIClientID).Msg("api client not revoked")
+		return
+	}
+
+	return
+}
+
+func (r *repository) TouchAPIClient(ctx context.Context, params *indto.APIClientParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("merchant_api_clients").SetMap(map[string]interface{}{
+		"last_used_at": time.Now(),
+	}).Where(squirrel.Eq{"id": params.APIClientID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// CreateAPIClientNonce records nonce as seen for clientID, ok is false when it was already seen within exp
+func (r *repository) CreateAPIClientNonce(ctx context.Context, clientID string, nonce string, exp time.Duration) (ok bool, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	key := fmt.Sprintf(inconst.CACHE_API_NONCE_KEY, config.Get().ServiceName, clientID, nonce)
+
+	ok, err = r.redis.SetNX(ctx, key, 1, exp).Result()
+	if err != nil {
+		logger.Error().Err(err).Msg("redis err")
+		return
+	}
+
+	return
+}
+
+func apiClientCond(params *indto.APIClientParams) squirrel.And {
+	cond := squirrel.And{}
+
+	if params.APIClientID != 0 {
+		cond = append(cond, squirrel.Eq{"id": params.APIClientID})
+	}
+
+	if params.ClientID != "" {
+		cond = append(cond, squirrel.Eq{"client_id": params.ClientID})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"merchant_id": params.MerchantID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	return cond
+}
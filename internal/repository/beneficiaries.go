@@ -14,6 +14,7 @@ import (
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/model"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 func (r *repository) FindBeneficiaries(ctx context.Context, params *indto.BeneficiaryParams) (res []*indto.Beneficiary, err error) {
@@ -27,7 +28,8 @@ func (r *repository) FindBeneficiaries(ctx context.Context, params *indto.Benefi
 		cond = append(cond, squirrel.Eq{"b.merchant_id": params.MerchantID})
 	}
 
-	baseStmt := pgSquirrel.Select("b.id", "b.merchant_id", "m.name merchant_name", "b.amount", "b.withdrawal_date", "b.status").
+	baseStmt := pgSquirrel.Select("b.id", "b.merchant_id", "m.name merchant_name", "b.amount", "b.withdrawal_date", "b.status",
+		"b.payout_method", "b.reject_reason", "b.reviewed_by", "b.reviewed_at").
 		From("beneficiaries b").
 		LeftJoin("merchants m on m.id = b.merchant_id").
 		Where(cond)
@@ -97,7 +99,8 @@ func (r *repository) FindBeneficiary(ctx context.Context, params *indto.Benefici
 		squirrel.Eq{"b.id": params.BeneficiaryID},
 	}
 
-	stmt, args, err := pgSquirrel.Select("b.id", "b.merchant_id", "m.name merchant_name", "b.amount", "b.withdrawal_date", "b.status").
+	stmt, args, err := pgSquirrel.Select("b.id", "b.merchant_id", "m.name merchant_name", "b.amount", "b.withdrawal_date", "b.status",
+		"b.payout_method", "b.reject_reason", "b.reviewed_by", "b.reviewed_at").
 		From("beneficiaries b").
 		LeftJoin("merchants m on m.id = b.merchant_id").
 		Where(cond).ToSql()
@@ -118,9 +121,10 @@ func (r *repository) FindBeneficiary(ctx context.Context, params *indto.Benefici
 	return
 }
 
+// CreateBeneficiary reserves the settlements for payload, money only moves right away when payload is created confirmed.
+// A bank payout is only queued here, it is settled once the bank confirms the transfer
 func (r *repository) CreateBeneficiary(ctx context.Context, payload *model.Beneficiary) (res *model.Beneficiary, err error) {
 	logger := zerolog.Ctx(ctx)
-	conf := config.Get()
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -129,32 +133,161 @@ func (r *repository) CreateBeneficiary(ctx context.Context, payload *model.Benef
 	}
 	defer tx.Rollback()
 
+	// settlements are allocated first so amount reflects exactly the rows consumed within this tx
+	if err = r.allocateSettlementsTx(ctx, tx, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
 	_, err = r.createBeneficiaryTx(ctx, tx, payload)
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
-	aff, err := r.updateSettlementBeneficiaryTx(ctx, tx, &model.Settlement{MerchantID: payload.MerchantID, BeneficiaryID: payload.ID})
+	if payload.Payout != nil {
+		if err = r.createPayoutTx(ctx, tx, payload); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	} else if payload.Status == inconst.BNF_STATUS_CONFIRM {
+		if err = r.payBeneficiaryTx(ctx, tx, payload); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return payload, nil
+}
+
+// ApproveBeneficiary moves a pending beneficiary to payload.Status and pays its reserved amount out to payload.AccountID,
+// or queues payload.Payout for a bank payout. A beneficiary no longer pending returns ErrBeneficiaryClosed
+func (r *repository) ApproveBeneficiary(ctx context.Context, payload *model.Beneficiary) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("beneficiaries").SetMap(map[string]interface{}{
+		"status":          payload.Status,
+		"withdrawal_date": payload.WithdrawalDate,
+		"reviewed_by":     payload.ReviewedBy,
+		"reviewed_at":     time.Now(),
+		"updated_at":      time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": inconst.BNF_STATUS_PENDING},
+		squirrel.Eq{"deleted_at": nil},
+	}).Suffix("returning merchant_id, amount").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&payload.MerchantID, &payload.Amount)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		err = errs.ErrBeneficiaryClosed
+		logger.Error().Err(err).Uint64("beneficiary-id", payload.ID).Msg("beneficiary not approved")
+		return
+	}
+
+	if payload.Payout != nil {
+		err = r.createPayoutTx(ctx, tx, payload)
+	} else {
+		err = r.payBeneficiaryTx(ctx, tx, payload)
+	}
+
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
-	if aff == 0 {
-		err = errs.ErrUnknown
-		logger.Error().Err(err).Msg("settlement not properly affected")
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// RejectBeneficiary closes a pending beneficiary and hands its reserved amount back to the settlements,
+// a beneficiary no longer pending returns ErrBeneficiaryClosed
+func (r *repository) RejectBeneficiary(ctx context.Context, payload *model.Beneficiary) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
 		return
 	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("beneficiaries").SetMap(map[string]interface{}{
+		"status":        inconst.BNF_STATUS_REJECTED,
+		"reject_reason": payload.RejectReason,
+		"reviewed_by":   payload.ReviewedBy,
+		"reviewed_at":   time.Now(),
+		"updated_at":    time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": inconst.BNF_STATUS_PENDING},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrBeneficiaryClosed
+		logger.Error().Err(err).Uint64("beneficiary-id", payload.ID).Msg("beneficiary not rejected")
+		return
+	}
+
+	if err = r.releaseSettlementsTx(ctx, tx, payload.ID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// payBeneficiaryTx moves the beneficiary amount from settlement account to the merchant account and settles its allocations
+func (r *repository) payBeneficiaryTx(ctx context.Context, tx *sql.Tx, payload *model.Beneficiary) (err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
 
 	// add sender's fund
-	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.AccountID, Balance: -payload.Amount})
+	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.AccountID, Balance: payload.Amount.Neg()})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
-	_, err = r.CreateTransactionTx(ctx, tx, &model.Transaction{
+	trxModel := &model.Transaction{
 		ID:          snowflake.ID(),
 		AccountID:   conf.SystemAccountUUID,
 		RecipientID: payload.AccountID,
@@ -162,28 +295,76 @@ func (r *repository) CreateBeneficiary(ctx context.Context, payload *model.Benef
 		TrxType:     inconst.TRX_TYPE_BENEFICIARY,
 		TrxDatetime: *payload.WithdrawalDate,
 		TrxStatus:   inconst.TRX_STATUS_SUCCESS,
-		TrxFee:      0,
+		TrxFee:      money.Zero(),
 		Nominal:     payload.Amount,
 		Description: fmt.Sprintf("beneficiary %d withdrawal", payload.ID),
-	})
+	}
+
+	_, err = r.CreateTransactionTx(ctx, tx, trxModel)
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
-	if err = tx.Commit(); err != nil {
-		logger.Error().Err(err).Msg("tx err")
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(trxModel,
+		&model.JournalPosting{AccountID: conf.SettlementAccountUUID, Amount: payload.Amount.Neg()},
+		&model.JournalPosting{AccountID: payload.AccountID, Amount: payload.Amount},
+	))
+	if err != nil {
+		logger.Error().Err(err).Send()
 		return
 	}
 
-	return payload, nil
+	// nested builder keeps default placeholders, the outer statement numbers them
+	allocatedStmt := squirrel.Select("settlement_id").From("beneficiary_allocations").Where(squirrel.Eq{"beneficiary_id": payload.ID})
+
+	if err = r.paySettlementsTx(ctx, tx, payload.MerchantID, squirrel.Expr("s.id in (?)", allocatedStmt)); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindBeneficiaryAllocations(ctx context.Context, params *indto.BeneficiaryParams) (res []*indto.BeneficiaryAllocation, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("ba.id", "ba.beneficiary_id", "ba.settlement_id", "s.transaction_id", "ba.amount", "ba.created_at").
+		From("beneficiary_allocations ba").
+		Join("settlements s on s.id = ba.settlement_id").
+		Where(squirrel.Eq{"ba.beneficiary_id": params.BeneficiaryID}).
+		OrderBy("ba.id").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.BeneficiaryAllocation{}
+	for rows.Next() {
+		temp := &indto.BeneficiaryAllocation{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
 }
 
 func (r *repository) createBeneficiaryTx(ctx context.Context, tx *sql.Tx, payload *model.Beneficiary) (res *model.Beneficiary, err error) {
 	logger := zerolog.Ctx(ctx)
 
-	stmt, args, err := pgSquirrel.Insert("beneficiaries").Columns("id", "merchant_id", "amount", "withdrawal_date", "status").
-		Values(payload.ID, payload.MerchantID, payload.Amount, payload.WithdrawalDate, payload.Status).ToSql()
+	stmt, args, err := pgSquirrel.Insert("beneficiaries").Columns("id", "merchant_id", "amount", "withdrawal_date", "status", "payout_method", "reviewed_by", "reviewed_at").
+		Values(payload.ID, payload.MerchantID, payload.Amount, payload.WithdrawalDate, payload.Status, payload.PayoutMethod, payload.ReviewedBy, payload.ReviewedAt).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
@@ -2,12 +2,16 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jmoiron/sqlx"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -27,6 +31,8 @@ type Repository interface {
 	CreateMerchant(ctx context.Context, payload *model.Merchant) (res *model.Merchant, err error)
 	UpdateMerchant(ctx context.Context, payload *model.Merchant) (err error)
 	DeleteMerchant(ctx context.Context, params *indto.MerchantParams) (err error)
+	FindMerchantBankAccount(ctx context.Context, params *indto.MerchantParams) (res *indto.MerchantBankAccount, err error)
+	UpsertMerchantBankAccount(ctx context.Context, payload *model.MerchantBankAccount) (err error)
 
 	// ----- Accounts
 	FindAccounts(ctx context.Context, params *indto.AccountParams) (res []*indto.Account, err error)
@@ -35,31 +41,197 @@ type Repository interface {
 	CreateAccount(ctx context.Context, payload *model.Account) (res *model.Account, err error)
 	UpdateAccount(ctx context.Context, payload *model.Account) (err error)
 	DeleteAccount(ctx context.Context, params *indto.AccountParams) (err error)
+	UpdateAccountStatus(ctx context.Context, payload *model.AccountStatusHistory) (err error)
+	FindAccountStatusHistories(ctx context.Context, params *indto.AccountStatusHistoryParams) (res []*indto.AccountStatusHistory, err error)
+	CountAccountStatusHistories(ctx context.Context, params *indto.AccountStatusHistoryParams) (res int64, err error)
 
 	// ----- Transactions
 	FindTransactions(ctx context.Context, params *indto.TransactionParams) (res []*indto.Transaction, err error)
 	CountTransactions(ctx context.Context, params *indto.TransactionParams) (res int64, err error)
+	StreamTransactions(ctx context.Context, params *indto.TransactionParams, fn func(*indto.Transaction) error) (err error)
 	FindTransaction(ctx context.Context, params *indto.TransactionParams) (res *indto.Transaction, err error)
 	CreateTransactionP2P(ctx context.Context, payload *model.Transaction) (err error)
 	CreateTransactionP2B(ctx context.Context, payload *model.Transaction) (err error)
 	CreateTransactionSystem(ctx context.Context, payload *model.Transaction) (err error)
+	CreateTransactionRefund(ctx context.Context, payload *model.Transaction) (err error)
+	CreateTransactionAuthorization(ctx context.Context, payload *model.Transaction) (err error)
+	CaptureTransactionAuthorization(ctx context.Context, payload *model.Transaction) (err error)
+	ReleaseTransactionAuthorization(ctx context.Context, payload *model.Transaction) (err error)
+	FindExpiredTransactionAuthorizations(ctx context.Context, limit uint64) (res []uint64, err error)
 	UpdateTransaction(ctx context.Context, payload *model.Transaction) (err error)
 	DeleteTransaction(ctx context.Context, params *indto.TransactionParams) (err error)
 
+	// ----- Scheduled Transactions
+	FindScheduledTransactions(ctx context.Context, params *indto.ScheduledTransactionParams) (res []*indto.ScheduledTransaction, err error)
+	CountScheduledTransactions(ctx context.Context, params *indto.ScheduledTransactionParams) (res int64, err error)
+	FindScheduledTransaction(ctx context.Context, params *indto.ScheduledTransactionParams) (res *indto.ScheduledTransaction, err error)
+	CreateScheduledTransaction(ctx context.Context, payload *model.ScheduledTransaction) (err error)
+	ClaimDueScheduledTransactions(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.ScheduledTransaction, err error)
+	UpdateScheduledTransaction(ctx context.Context, payload *model.ScheduledTransaction) (err error)
+	CancelScheduledTransaction(ctx context.Context, params *indto.ScheduledTransactionParams) (err error)
+
+	// ----- Standing Orders
+	FindStandingOrders(ctx context.Context, params *indto.StandingOrderParams) (res []*indto.StandingOrder, err error)
+	CountStandingOrders(ctx context.Context, params *indto.StandingOrderParams) (res int64, err error)
+	FindStandingOrder(ctx context.Context, params *indto.StandingOrderParams) (res *indto.StandingOrder, err error)
+	CreateStandingOrder(ctx context.Context, payload *model.StandingOrder) (err error)
+	ClaimDueStandingOrders(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.StandingOrder, err error)
+	ReserveStandingOrderTransaction(ctx context.Context, payload *model.StandingOrder) (err error)
+	UpdateStandingOrderRun(ctx context.Context, payload *model.StandingOrder, run *model.StandingOrderRun) (err error)
+	CancelStandingOrder(ctx context.Context, params *indto.StandingOrderParams) (err error)
+	FindStandingOrderRuns(ctx context.Context, params *indto.StandingOrderParams) (res []*indto.StandingOrderRun, err error)
+	CountStandingOrderRuns(ctx context.Context, params *indto.StandingOrderParams) (res int64, err error)
+
+	// ----- Split Bills
+	FindSplitBills(ctx context.Context, params *indto.SplitBillParams) (res []*indto.SplitBill, err error)
+	CountSplitBills(ctx context.Context, params *indto.SplitBillParams) (res int64, err error)
+	FindSplitBill(ctx context.Context, params *indto.SplitBillParams) (res *indto.SplitBill, err error)
+	FindSplitBillShares(ctx context.Context, params *indto.SplitBillShareParams) (res []*indto.SplitBillShare, err error)
+	CreateSplitBill(ctx context.Context, payload *model.SplitBill) (err error)
+	PaySplitBillShare(ctx context.Context, payload *model.SplitBillShare, trx *model.Transaction) (err error)
+	CancelSplitBill(ctx context.Context, params *indto.SplitBillParams) (err error)
+	RemindSplitBillShares(ctx context.Context, params *indto.SplitBillShareParams) (err error)
+
+	// ----- Fees
+	FindFeeSchedules(ctx context.Context, params *indto.FeeScheduleParams) (res []*indto.FeeSchedule, err error)
+	CountFeeSchedules(ctx context.Context, params *indto.FeeScheduleParams) (res int64, err error)
+	FindFeeSchedule(ctx context.Context, params *indto.FeeScheduleParams) (res *indto.FeeSchedule, err error)
+	FindEffectiveFeeSchedule(ctx context.Context, params *indto.FeeScheduleParams) (res *indto.FeeSchedule, err error)
+	FindFeeRules(ctx context.Context, params *indto.FeeRuleParams) (res []*indto.FeeRule, err error)
+	CreateFeeSchedule(ctx context.Context, payload *model.FeeSchedule) (res *model.FeeSchedule, err error)
+	DeleteFeeSchedule(ctx context.Context, params *indto.FeeScheduleParams) (err error)
+
+	// ----- Transaction Limits
+	FindTransactionLimits(ctx context.Context, params *indto.TransactionLimitParams) (res []*indto.TransactionLimit, err error)
+	CountTransactionLimits(ctx context.Context, params *indto.TransactionLimitParams) (res int64, err error)
+	FindTransactionLimit(ctx context.Context, params *indto.TransactionLimitParams) (res *indto.TransactionLimit, err error)
+	FindEffectiveTransactionLimit(ctx context.Context, params *indto.TransactionLimitParams) (res *indto.TransactionLimit, err error)
+	CreateTransactionLimit(ctx context.Context, payload *model.TransactionLimit) (err error)
+	UpdateTransactionLimit(ctx context.Context, payload *model.TransactionLimit) (err error)
+	DeleteTransactionLimit(ctx context.Context, params *indto.TransactionLimitParams) (err error)
+	FindTransactionLimitUsage(ctx context.Context, params *indto.TransactionLimitUsageParams) (res *indto.TransactionLimitUsage, err error)
+
+	// ----- Journals
+	FindJournalPostings(ctx context.Context, params *indto.JournalParams) (res []*indto.JournalPosting, err error)
+	CountJournalPostings(ctx context.Context, params *indto.JournalParams) (res int64, err error)
+	FindLedgerBalance(ctx context.Context, params *indto.JournalParams) (res money.Money, err error)
+	PostOpeningBalances(ctx context.Context, equityAccountID string) (res int64, err error)
+	PostBalanceWriteOffs(ctx context.Context, equityAccountID string) (res int64, err error)
+
+	// ----- Exports
+	FindExportJob(ctx context.Context, params *indto.ExportJobParams) (res *indto.ExportJob, err error)
+	CreateExportJob(ctx context.Context, payload *model.ExportJob) (err error)
+	ClaimExportJobs(ctx context.Context, limit uint64, staleAfter time.Duration) (res []*indto.ExportJob, err error)
+	UpdateExportJob(ctx context.Context, payload *model.ExportJob) (err error)
+	ExpireExportJobs(ctx context.Context) (res []*indto.ExportJob, err error)
+
+	// ----- Reconciliations
+	FindAccountBalances(ctx context.Context, params *indto.AccountBalanceParams) (res []*indto.AccountBalance, err error)
+	CreateReconciliationRun(ctx context.Context, payload *model.ReconciliationRun, staleAfter time.Duration) (err error)
+	UpdateReconciliationRun(ctx context.Context, payload *model.ReconciliationRun) (err error)
+	CreateReconciliationDiscrepancies(ctx context.Context, payload []*model.ReconciliationDiscrepancy) (err error)
+	FindReconciliationRuns(ctx context.Context, params *indto.ReconciliationParams) (res []*indto.ReconciliationRun, err error)
+	CountReconciliationRuns(ctx context.Context, params *indto.ReconciliationParams) (res int64, err error)
+	FindReconciliationRun(ctx context.Context, params *indto.ReconciliationParams) (res *indto.ReconciliationRun, err error)
+	FindReconciliationDiscrepancies(ctx context.Context, params *indto.ReconciliationParams) (res []*indto.ReconciliationDiscrepancy, err error)
+	CountReconciliationDiscrepancies(ctx context.Context, params *indto.ReconciliationParams) (res int64, err error)
+
+	// ----- Statements
+	FindStatementEntries(ctx context.Context, params *indto.StatementParams) (res []*indto.StatementEntry, err error)
+	FindStatementOpeningBalance(ctx context.Context, params *indto.StatementParams) (res money.Money, err error)
+
 	// ----- Settlements
 	FindSettlements(ctx context.Context, params *indto.SettlementParams) (res []*indto.Settlement, err error)
 	CountSettlements(ctx context.Context, params *indto.SettlementParams) (res int64, err error)
 	FindSettlement(ctx context.Context, params *indto.SettlementParams) (res *indto.Settlement, err error)
-	FindPendingSettlement(ctx context.Context, params *indto.SettlementParams) (res float64, err error)
+	FindPendingSettlement(ctx context.Context, params *indto.SettlementParams) (res money.Money, err error)
+
+	// ----- Settlement Batches
+	FindSettlementBatches(ctx context.Context, params *indto.SettlementBatchParams) (res []*indto.SettlementBatch, err error)
+	CountSettlementBatches(ctx context.Context, params *indto.SettlementBatchParams) (res int64, err error)
+	FindSettlementBatch(ctx context.Context, params *indto.SettlementBatchParams) (res *indto.SettlementBatch, err error)
+	FindUnbatchedSettlementMerchants(ctx context.Context, cutoff time.Time) (res []string, err error)
+	CreateSettlementBatch(ctx context.Context, payload *model.SettlementBatch) (err error)
 
 	// ----- Beneficiaries
 	FindBeneficiaries(ctx context.Context, params *indto.BeneficiaryParams) (res []*indto.Beneficiary, err error)
 	CountBeneficiaries(ctx context.Context, params *indto.BeneficiaryParams) (res int64, err error)
 	FindBeneficiary(ctx context.Context, params *indto.BeneficiaryParams) (res *indto.Beneficiary, err error)
+	FindBeneficiaryAllocations(ctx context.Context, params *indto.BeneficiaryParams) (res []*indto.BeneficiaryAllocation, err error)
 	CreateBeneficiary(ctx context.Context, payload *model.Beneficiary) (res *model.Beneficiary, err error)
+	ApproveBeneficiary(ctx context.Context, payload *model.Beneficiary) (err error)
+	RejectBeneficiary(ctx context.Context, payload *model.Beneficiary) (err error)
 	UpdateBeneficiary(ctx context.Context, payload *model.Beneficiary) (err error)
 	DeleteBeneficiary(ctx context.Context, params *indto.BeneficiaryParams) (err error)
 
+	// ----- Merchant QR Codes
+	FindMerchantQRCode(ctx context.Context, params *indto.MerchantQRCodeParams) (res *indto.MerchantQRCode, err error)
+	CreateMerchantQRCode(ctx context.Context, payload *model.MerchantQRCode) (err error)
+	ClaimMerchantQRCode(ctx context.Context, params *indto.MerchantQRCodeParams) (err error)
+	CloseMerchantQRCode(ctx context.Context, payload *model.MerchantQRCode) (err error)
+
+	// ----- Invoices
+	FindInvoices(ctx context.Context, params *indto.InvoiceParams) (res []*indto.Invoice, err error)
+	CountInvoices(ctx context.Context, params *indto.InvoiceParams) (res int64, err error)
+	FindInvoice(ctx context.Context, params *indto.InvoiceParams) (res *indto.Invoice, err error)
+	FindInvoiceItems(ctx context.Context, params *indto.InvoiceItemParams) (res []*indto.InvoiceItem, err error)
+	CreateInvoice(ctx context.Context, payload *model.Invoice) (err error)
+	UpdateInvoice(ctx context.Context, payload *model.Invoice) (err error)
+	IssueInvoice(ctx context.Context, params *indto.InvoiceParams) (err error)
+	VoidInvoice(ctx context.Context, params *indto.InvoiceParams) (err error)
+	DeleteInvoice(ctx context.Context, params *indto.InvoiceParams) (err error)
+	PayInvoice(ctx context.Context, payload *model.Invoice, trx *model.Transaction) (err error)
+	MarkOverdueInvoices(ctx context.Context, date time.Time) (res int64, err error)
+
+	// ----- Payment Links
+	FindPaymentLinks(ctx context.Context, params *indto.PaymentLinkParams) (res []*indto.PaymentLink, err error)
+	CountPaymentLinks(ctx context.Context, params *indto.PaymentLinkParams) (res int64, err error)
+	FindPaymentLink(ctx context.Context, params *indto.PaymentLinkParams) (res *indto.PaymentLink, err error)
+	FindPaymentLinkStats(ctx context.Context, params *indto.PaymentLinkParams) (res *indto.PaymentLinkStats, err error)
+	CreatePaymentLink(ctx context.Context, payload *model.PaymentLink) (err error)
+	DeactivatePaymentLink(ctx context.Context, params *indto.PaymentLinkParams) (err error)
+	PayPaymentLink(ctx context.Context, payload *model.PaymentLinkPayment, trx *model.Transaction) (err error)
+
+	// ----- Webhooks
+	FindWebhooks(ctx context.Context, params *indto.WebhookParams) (res []*indto.WebhookSubscription, err error)
+	CountWebhooks(ctx context.Context, params *indto.WebhookParams) (res int64, err error)
+	FindWebhook(ctx context.Context, params *indto.WebhookParams) (res *indto.WebhookSubscription, err error)
+	CreateWebhook(ctx context.Context, payload *model.WebhookSubscription) (err error)
+	UpdateWebhook(ctx context.Context, payload *model.WebhookSubscription) (err error)
+	DeleteWebhook(ctx context.Context, params *indto.WebhookParams) (err error)
+	FindWebhookDeliveries(ctx context.Context, params *indto.WebhookDeliveryParams) (res []*indto.WebhookDelivery, err error)
+	CountWebhookDeliveries(ctx context.Context, params *indto.WebhookDeliveryParams) (res int64, err error)
+	FindWebhookDelivery(ctx context.Context, params *indto.WebhookDeliveryParams) (res *indto.WebhookDelivery, err error)
+	CreateWebhookDeliveries(ctx context.Context, payload []*model.WebhookDelivery) (err error)
+	ClaimDueWebhookDeliveries(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.WebhookDelivery, err error)
+	UpdateWebhookDelivery(ctx context.Context, payload *model.WebhookDelivery) (err error)
+	RedeliverWebhookDelivery(ctx context.Context, params *indto.WebhookDeliveryParams) (err error)
+
+	// ----- API Clients
+	FindAPIClients(ctx context.Context, params *indto.APIClientParams) (res []*indto.APIClient, err error)
+	CountAPIClients(ctx context.Context, params *indto.APIClientParams) (res int64, err error)
+	FindAPIClient(ctx context.Context, params *indto.APIClientParams) (res *indto.APIClient, err error)
+	CreateAPIClient(ctx context.Context, payload *model.APIClient) (err error)
+	RevokeAPIClient(ctx context.Context, params *indto.APIClientParams) (err error)
+	TouchAPIClient(ctx context.Context, params *indto.APIClientParams) (err error)
+	CreateAPIClientNonce(ctx context.Context, clientID string, nonce string, exp time.Duration) (ok bool, err error)
+
+	// ----- Payouts
+	FindPayout(ctx context.Context, params *indto.PayoutParams) (res *indto.Payout, err error)
+	ClaimDuePayouts(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.Payout, err error)
+	UpdatePayout(ctx context.Context, payload *model.Payout) (err error)
+	SettlePayout(ctx context.Context, payload *model.Payout) (ok bool, err error)
+	FailPayout(ctx context.Context, payload *model.Payout) (ok bool, err error)
+
+	// ----- Idempotency
+	FindIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams) (res *indto.IdempotencyRecord, err error)
+	CreateIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams, payload *indto.IdempotencyRecord, exp time.Duration) (ok bool, err error)
+	UpdateIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams, payload *indto.IdempotencyRecord, exp time.Duration) (err error)
+	DeleteIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams) (err error)
+
+	// ----- Events
+	CreateEventNonce(ctx context.Context, serviceID string, nonce string, exp time.Duration) (ok bool, err error)
+
 	// ---- Dashboard
 	FindAdminDashboard(ctx context.Context) (res *indto.AdminDashboard, err error)
 	FindMerchantDashboard(ctx context.Context, param *indto.MerchantDashboardParams) (res *indto.MerchantDashboard, err error)
@@ -90,3 +262,15 @@ func NewRepository(params *NewRepositoryParams) Repository {
 }
 
 var pgSquirrel = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+// isUniqueViolation reports whether err is raised by a unique index (sqlstate 23505)
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isCheckViolation reports whether err is raised by a check constraint (sqlstate 23514)
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23514"
+}
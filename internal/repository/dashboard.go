@@ -8,6 +8,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 func (r *repository) FindAdminDashboard(ctx context.Context) (res *indto.AdminDashboard, err error) {
@@ -68,10 +69,10 @@ func (r *repository) FindAdminDashboard(ctx context.Context) (res *indto.AdminDa
 		return
 	}
 
-	temp := make(map[int64]float64)
+	temp := make(map[int64]money.Money)
 	for rows.Next() {
 		var mon int64
-		var nominal float64
+		var nominal money.Money
 
 		if err = rows.Scan(&mon, &nominal); err != nil {
 			logger.Error().Err(err).Str("query", "trx_traffic").Msg("sql map err")
@@ -101,9 +102,9 @@ func (r *repository) FindMerchantDashboard(ctx context.Context, param *indto.Mer
 
 	res = &indto.MerchantDashboard{
 		TrxCount:           0,
-		TrxNominal:         0,
-		SettlementNominal:  0,
-		BeneficiaryNominal: 0,
+		TrxNominal:         money.Zero(),
+		SettlementNominal:  money.Zero(),
+		BeneficiaryNominal: money.Zero(),
 		TrxTraffic:         []indto.GenericDashboardGraph{},
 	}
 
@@ -111,8 +112,8 @@ func (r *repository) FindMerchantDashboard(ctx context.Context, param *indto.Mer
 		select
 			(select coalesce(count(*), 0) from transactions where deleted_at is null and trx_type = 2 and recipient_id = :account_id and date(trx_datetime) >= date(:date_start) and date(trx_datetime) <= date(:date_end)) trx_count,
 			(select coalesce(sum(nominal), 0) from transactions where deleted_at is null and trx_type = 2 and recipient_id = :account_id and date(trx_datetime) >= date(:date_start) and date(trx_datetime) <= date(:date_end)) trx_nominal,    
-			(select coalesce(sum(amount), 0) from settlements where deleted_at is null and beneficiary_id = 0 and merchant_id = :merchant_id and date(settlement_date) >= date(:date_start) and date(settlement_date) <= date(:date_end)) settlement_nominal,
-			(select coalesce(sum(amount), 0) from beneficiaries where deleted_at is null and merchant_id = :merchant_id and date(withdrawal_date) >= date(:date_start) and date(withdrawal_date) <= date(:date_end)) beneficiary_nominal
+			(select coalesce(sum(amount - allocated_amount), 0) from settlements where deleted_at is null and allocated_amount < amount and merchant_id = :merchant_id and date(settlement_date) >= date(:date_start) and date(settlement_date) <= date(:date_end)) settlement_nominal,
+			(select coalesce(sum(amount), 0) from beneficiaries where deleted_at is null and status in (1, 3) and merchant_id = :merchant_id and date(withdrawal_date) >= date(:date_start) and date(withdrawal_date) <= date(:date_end)) beneficiary_nominal
 	`
 
 	namedArgs = map[string]any{
@@ -151,10 +152,10 @@ func (r *repository) FindMerchantDashboard(ctx context.Context, param *indto.Mer
 		return
 	}
 
-	temp := make(map[int64]float64)
+	temp := make(map[int64]money.Money)
 	for rows.Next() {
 		var mon int64
-		var nominal float64
+		var nominal money.Money
 
 		if err = rows.Scan(&mon, &nominal); err != nil {
 			logger.Error().Err(err).Str("query", "trx_traffic").Msg("sql map err")
@@ -185,9 +186,9 @@ func (r *repository) FindCustomerDashboard(ctx context.Context, param *indto.Cus
 
 	res = &indto.CustomerDashboard{
 		PeerTrxCount:       0,
-		PeerTrxNominal:     0,
+		PeerTrxNominal:     money.Zero(),
 		MerchantTrxCount:   0,
-		MerchantTrxNominal: 0,
+		MerchantTrxNominal: money.Zero(),
 		TrxTraffic:         []indto.GenericDashboardGraph{},
 	}
 
@@ -234,10 +235,10 @@ func (r *repository) FindCustomerDashboard(ctx context.Context, param *indto.Cus
 		return
 	}
 
-	temp := make(map[int64]float64)
+	temp := make(map[int64]money.Money)
 	for rows.Next() {
 		var mon int64
-		var nominal float64
+		var nominal money.Money
 
 		if err = rows.Scan(&mon, &nominal); err != nil {
 			logger.Error().Err(err).Str("query", "trx_traffic").Msg("sql map err")
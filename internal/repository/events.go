@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+)
+
+// CreateEventNonce records nonce as seen for serviceID, ok is false when it was already seen within exp
+func (r *repository) CreateEventNonce(ctx context.Context, serviceID string, nonce string, exp time.Duration) (ok bool, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	key := fmt.Sprintf(inconst.CACHE_EVENT_NONCE_KEY, config.Get().ServiceName, serviceID, nonce)
+
+	ok, err = r.redis.SetNX(ctx, key, 1, exp).Result()
+	if err != nil {
+		logger.Error().Err(err).Msg("redis err")
+		return
+	}
+
+	return
+}
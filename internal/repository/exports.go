@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+)
+
+var exportJobColumns = []string{
+	"id", "user_id", "export_type", "format", "params", "status", "row_count", "file_name", "last_error", "expires_at", "created_at",
+}
+
+func (r *repository) FindExportJob(ctx context.Context, params *indto.ExportJobParams) (res *indto.ExportJob, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.ExportID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	stmt, args, err := pgSquirrel.Select(exportJobColumns...).From("export_jobs").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.ExportJob{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) CreateExportJob(ctx context.Context, payload *model.ExportJob) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("export_jobs").
+		Columns("id", "user_id", "export_type", "format", "params", "status").
+		Values(payload.ID, payload.UserID, payload.ExportType, payload.Format, payload.Params, payload.Status).
+		ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// ClaimExportJobs marks up to limit pending jobs as processing, jobs left processing longer than staleAfter are
+// claimed again since their worker is assumed gone
+func (r *repository) ClaimExportJobs(ctx context.Context, limit uint64, staleAfter time.Duration) (res []*indto.ExportJob, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	pendingStmt := squirrel.Select("id").From("export_jobs").Where(squirrel.And{
+		squirrel.Or{
+			squirrel.Eq{"status": inconst.EXPORT_STATUS_PENDING},
+			squirrel.And{
+				squirrel.Eq{"status": inconst.EXPORT_STATUS_PROCESSING},
+				squirrel.Lt{"updated_at": time.Now().Add(-staleAfter)},
+			},
+		},
+		squirrel.Eq{"deleted_at": nil},
+	}).OrderBy("created_at").Limit(limit).Suffix("for update skip locked")
+
+	stmt, args, err := pgSquirrel.Update("export_jobs").SetMap(map[string]interface{}{
+		"status":     inconst.EXPORT_STATUS_PROCESSING,
+		"updated_at": time.Now(),
+	}).Where(squirrel.Expr("id in (?)", pendingStmt)).Suffix("returning " + strings.Join(exportJobColumns, ", ")).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.ExportJob{}
+	for rows.Next() {
+		temp := &indto.ExportJob{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) UpdateExportJob(ctx context.Context, payload *model.ExportJob) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("export_jobs").SetMap(map[string]interface{}{
+		"status":     payload.Status,
+		"row_count":  payload.RowCount,
+		"file_name":  payload.FileName,
+		"last_error": payload.LastError,
+		"expires_at": payload.ExpiresAt,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// ExpireExportJobs marks completed jobs past their expiry as expired and returns them so their files can be removed
+func (r *repository) ExpireExportJobs(ctx context.Context) (res []*indto.ExportJob, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("export_jobs").SetMap(map[string]interface{}{
+		"status":     inconst.EXPORT_STATUS_EXPIRED,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"status": inconst.EXPORT_STATUS_COMPLETED},
+		squirrel.Lt{"expires_at": time.Now()},
+		squirrel.Eq{"deleted_at": nil},
+	}).Suffix("returning " + strings.Join(exportJobColumns, ", ")).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.ExportJob{}
+	for rows.Next() {
+		temp := &indto.ExportJob{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+)
+
+var feeScheduleColumns = []string{
+	"f.id", "f.code", "f.name", "f.trx_type", "coalesce(f.merchant_id::text, '') merchant_id", "f.account_tier", "f.version", "f.effective_from", "f.effective_until",
+}
+
+func (r *repository) FindFeeSchedules(ctx context.Context, params *indto.FeeScheduleParams) (res []*indto.FeeSchedule, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"f.deleted_at": nil},
+	}
+
+	if params.Code != "" {
+		cond = append(cond, squirrel.Eq{"f.code": params.Code})
+	}
+
+	if params.TrxType != 0 {
+		cond = append(cond, squirrel.Eq{"f.trx_type": params.TrxType})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"f.merchant_id": params.MerchantID})
+	}
+
+	baseStmt := pgSquirrel.Select(feeScheduleColumns...).From("fee_schedules f").
+		Where(cond).OrderBy("f.code", "f.version desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.FeeSchedule{}
+	for rows.Next() {
+		temp := &indto.FeeSchedule{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountFeeSchedules(ctx context.Context, params *indto.FeeScheduleParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"f.deleted_at": nil},
+	}
+
+	if params.Code != "" {
+		cond = append(cond, squirrel.Eq{"f.code": params.Code})
+	}
+
+	if params.TrxType != 0 {
+		cond = append(cond, squirrel.Eq{"f.trx_type": params.TrxType})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"f.merchant_id": params.MerchantID})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("fee_schedules f").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindFeeSchedule(ctx context.Context, params *indto.FeeScheduleParams) (res *indto.FeeSchedule, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"f.id": params.FeeScheduleID},
+		squirrel.Eq{"f.deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Select(feeScheduleColumns...).From("fee_schedules f").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.FeeSchedule{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// FindEffectiveFeeSchedule picks the most specific schedule in effect,
+// merchant-specific over tier-specific over generic, newest version first
+func (r *repository) FindEffectiveFeeSchedule(ctx context.Context, params *indto.FeeScheduleParams) (res *indto.FeeSchedule, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"f.deleted_at": nil},
+		squirrel.Eq{"f.trx_type": params.TrxType},
+		squirrel.LtOrEq{"f.effective_from": params.EffectiveAt},
+		squirrel.Or{
+			squirrel.Eq{"f.effective_until": nil},
+			squirrel.Gt{"f.effective_until": params.EffectiveAt},
+		},
+		squirrel.Eq{"f.account_tier": []int64{0, params.AccountTier}},
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Or{
+			squirrel.Eq{"f.merchant_id": nil},
+			squirrel.Eq{"f.merchant_id": params.MerchantID},
+		})
+	} else {
+		cond = append(cond, squirrel.Eq{"f.merchant_id": nil})
+	}
+
+	stmt, args, err := pgSquirrel.Select(feeScheduleColumns...).From("fee_schedules f").
+		Where(cond).OrderBy("f.merchant_id is null", "f.account_tier = 0", "f.effective_from desc", "f.version desc").
+		Limit(1).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.FeeSchedule{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) FindFeeRules(ctx context.Context, params *indto.FeeRuleParams) (res []*indto.FeeRule, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("r.id", "r.schedule_id", "r.min_nominal", "r.fee_type", "r.flat_fee", "r.rate_bps", "r.min_fee", "r.max_fee").
+		From("fee_rules r").
+		Where(squirrel.Eq{"r.schedule_id": params.ScheduleID}).
+		OrderBy("r.min_nominal").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.FeeRule{}
+	for rows.Next() {
+		temp := &indto.FeeRule{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// CreateFeeSchedule stores payload as the next version of its code,
+// earlier versions stop being effective once payload takes effect
+func (r *repository) CreateFeeSchedule(ctx context.Context, payload *model.FeeSchedule) (res *model.FeeSchedule, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Select("coalesce(max(version), 0)").From("fee_schedules").
+		Where(squirrel.Eq{"code": payload.Code}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if err = tx.QueryRowContext(ctx, stmt, args...).Scan(&payload.Version); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	payload.Version++
+
+	stmt, args, err = pgSquirrel.Update("fee_schedules").SetMap(map[string]interface{}{
+		"effective_until": payload.EffectiveFrom,
+		"updated_at":      time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"code": payload.Code},
+		squirrel.Eq{"deleted_at": nil},
+		squirrel.Or{
+			squirrel.Eq{"effective_until": nil},
+			squirrel.Gt{"effective_until": payload.EffectiveFrom},
+		},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if payload.ID == 0 {
+		payload.ID = snowflake.ID()
+	}
+
+	stmt, args, err = pgSquirrel.Insert("fee_schedules").Columns("id", "code", "name", "trx_type", "merchant_id", "account_tier", "version", "effective_from", "effective_until").
+		Values(payload.ID, payload.Code, payload.Name, payload.TrxType, squirrel.Expr("nullif(?, '')::uuid", payload.MerchantID), payload.AccountTier, payload.Version, payload.EffectiveFrom, payload.EffectiveUntil).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if len(payload.Rules) != 0 {
+		baseStmt := pgSquirrel.Insert("fee_rules").Columns("id", "schedule_id", "min_nominal", "fee_type", "flat_fee", "rate_bps", "min_fee", "max_fee")
+		for _, v := range payload.Rules {
+			v.ID = snowflake.ID()
+			v.ScheduleID = payload.ID
+			baseStmt = baseStmt.Values(v.ID, v.ScheduleID, v.MinNominal, v.FeeType, v.FlatFee, v.RateBps, v.MinFee, v.MaxFee)
+		}
+
+		stmt, args, err = baseStmt.ToSql()
+		if err != nil {
+			logger.Error().Err(err).Msg("squirrel err")
+			return
+		}
+
+		if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+			logger.Error().Err(err).Msg("sql err")
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return payload, nil
+}
+
+func (r *repository) DeleteFeeSchedule(ctx context.Context, params *indto.FeeScheduleParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.FeeScheduleID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Update("fee_schedules").SetMap(map[string]interface{}{
+		"updated_at": time.Now(),
+		"deleted_at": time.Now(),
+	}).Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
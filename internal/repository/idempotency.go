@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+)
+
+func idempotencyCacheKey(params *indto.IdempotencyParams) string {
+	return fmt.Sprintf(inconst.CACHE_IDEMPOTENCY_KEY, config.Get().ServiceName, params.UserID, params.Key)
+}
+
+func (r *repository) FindIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams) (res *indto.IdempotencyRecord, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	data, err := r.redis.Get(ctx, idempotencyCacheKey(params)).Bytes()
+	if err != nil && err != redis.Nil {
+		logger.Error().Err(err).Msg("redis err")
+		return
+	} else if err == redis.Nil {
+		return nil, nil
+	}
+
+	res = &indto.IdempotencyRecord{}
+	if err = json.Unmarshal(data, res); err != nil {
+		logger.Error().Err(err).Msg("redis map err")
+		return
+	}
+
+	return
+}
+
+// CreateIdempotencyRecord stores payload only when key is not taken yet, ok reports whether it was stored
+func (r *repository) CreateIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams, payload *indto.IdempotencyRecord, exp time.Duration) (ok bool, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error().Err(err).Msg("redis map err")
+		return
+	}
+
+	ok, err = r.redis.SetNX(ctx, idempotencyCacheKey(params), data, exp).Result()
+	if err != nil {
+		logger.Error().Err(err).Msg("redis err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) UpdateIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams, payload *indto.IdempotencyRecord, exp time.Duration) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		logger.Error().Err(err).Msg("redis map err")
+		return
+	}
+
+	if err = r.redis.Set(ctx, idempotencyCacheKey(params), data, exp).Err(); err != nil {
+		logger.Error().Err(err).Msg("redis err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) DeleteIdempotencyRecord(ctx context.Context, params *indto.IdempotencyParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	if err = r.redis.Del(ctx, idempotencyCacheKey(params)).Err(); err != nil {
+		logger.Error().Err(err).Msg("redis err")
+		return
+	}
+
+	return
+}
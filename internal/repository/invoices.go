@@ -0,0 +1,429 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var invoiceColumns = []string{
+	"i.id", "i.merchant_id", "coalesce(m.name, '') merchant_name", "i.customer_account_id", "i.customer_reference", "i.description",
+	"i.subtotal", "i.tax_rate_bps", "i.tax_amount", "i.total", "i.due_date", "i.status", "i.transaction_id", "i.issued_at", "i.paid_at", "i.created_at",
+}
+
+// payable invoices can be paid by their customer or voided by their merchant
+var invoicePayableStatuses = []int64{inconst.INVOICE_STATUS_OPEN, inconst.INVOICE_STATUS_OVERDUE}
+
+func (r *repository) FindInvoices(ctx context.Context, params *indto.InvoiceParams) (res []*indto.Invoice, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select(invoiceColumns...).From("invoices i").
+		LeftJoin("merchants m on m.id = i.merchant_id").
+		Where(invoiceCond(params)).OrderBy("i.created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.Invoice{}
+	for rows.Next() {
+		temp := &indto.Invoice{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountInvoices(ctx context.Context, params *indto.InvoiceParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("invoices i").Where(invoiceCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindInvoice(ctx context.Context, params *indto.InvoiceParams) (res *indto.Invoice, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(invoiceColumns...).From("invoices i").
+		LeftJoin("merchants m on m.id = i.merchant_id").
+		Where(invoiceCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.Invoice{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) FindInvoiceItems(ctx context.Context, params *indto.InvoiceItemParams) (res []*indto.InvoiceItem, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("id", "invoice_id", "description", "quantity", "unit_price", "amount").
+		From("invoice_items").
+		Where(squirrel.Eq{"invoice_id": params.InvoiceID}).
+		OrderBy("id").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.InvoiceItem{}
+	for rows.Next() {
+		temp := &indto.InvoiceItem{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// CreateInvoice stores payload as a draft together with its line items
+func (r *repository) CreateInvoice(ctx context.Context, payload *model.Invoice) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Insert("invoices").
+		Columns("id", "merchant_id", "customer_account_id", "customer_reference", "description", "subtotal", "tax_rate_bps", "tax_amount", "total", "due_date", "status").
+		Values(payload.ID, payload.MerchantID, payload.CustomerAccountID, payload.CustomerReference, payload.Description, payload.Subtotal, payload.TaxRateBps,
+			payload.TaxAmount, payload.Total, payload.DueDate, payload.Status).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = r.createInvoiceItemsTx(ctx, tx, payload); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// UpdateInvoice replaces a draft invoice of payload.MerchantID and its line items,
+// an invoice that was already issued returns ErrInvoiceClosed
+func (r *repository) UpdateInvoice(ctx context.Context, payload *model.Invoice) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("invoices").SetMap(map[string]interface{}{
+		"customer_account_id": payload.CustomerAccountID,
+		"customer_reference":  payload.CustomerReference,
+		"description":         payload.Description,
+		"subtotal":            payload.Subtotal,
+		"tax_rate_bps":        payload.TaxRateBps,
+		"tax_amount":          payload.TaxAmount,
+		"total":               payload.Total,
+		"due_date":            payload.DueDate,
+		"updated_at":          time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"merchant_id": payload.MerchantID},
+		squirrel.Eq{"status": inconst.INVOICE_STATUS_DRAFT},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrInvoiceClosed
+		logger.Error().Err(err).Uint64("invoice-id", payload.ID).Msg("invoice is not a draft")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Delete("invoice_items").Where(squirrel.Eq{"invoice_id": payload.ID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = r.createInvoiceItemsTx(ctx, tx, payload); err != nil {
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// IssueInvoice opens a draft invoice for payment
+func (r *repository) IssueInvoice(ctx context.Context, params *indto.InvoiceParams) (err error) {
+	return r.transitionInvoice(ctx, params, []int64{inconst.INVOICE_STATUS_DRAFT}, map[string]interface{}{
+		"status":    inconst.INVOICE_STATUS_OPEN,
+		"issued_at": time.Now(),
+	})
+}
+
+// VoidInvoice cancels an unpaid invoice, an invoice being paid cannot be voided
+func (r *repository) VoidInvoice(ctx context.Context, params *indto.InvoiceParams) (err error) {
+	return r.transitionInvoice(ctx, params, invoicePayableStatuses, map[string]interface{}{
+		"status": inconst.INVOICE_STATUS_VOID,
+	})
+}
+
+// DeleteInvoice removes a draft, issued invoices are voided instead so the customer keeps seeing them
+func (r *repository) DeleteInvoice(ctx context.Context, params *indto.InvoiceParams) (err error) {
+	return r.transitionInvoice(ctx, params, []int64{inconst.INVOICE_STATUS_DRAFT}, map[string]interface{}{
+		"deleted_at": time.Now(),
+	})
+}
+
+// PayInvoice records trx as the payment of a payable invoice of payload.CustomerAccountID in the same database
+// transaction that moves the money, either both happen or neither does. An invoice paid, voided or paid
+// concurrently returns ErrInvoiceClosed
+func (r *repository) PayInvoice(ctx context.Context, payload *model.Invoice, trx *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	// the row lock taken here holds a concurrent payment of the same invoice until this one ends
+	stmt, args, err := pgSquirrel.Update("invoices").SetMap(map[string]interface{}{
+		"status":         inconst.INVOICE_STATUS_PAID,
+		"transaction_id": trx.ID,
+		"paid_at":        time.Now(),
+		"updated_at":     time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"customer_account_id": payload.CustomerAccountID},
+		squirrel.Eq{"status": invoicePayableStatuses},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrInvoiceClosed
+		logger.Error().Err(err).Uint64("invoice-id", payload.ID).Msg("invoice not paid")
+		return
+	}
+
+	if err = r.createTransactionP2BTx(ctx, tx, trx); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// MarkOverdueInvoices flags open invoices due before date, date is a local calendar day
+func (r *repository) MarkOverdueInvoices(ctx context.Context, date time.Time) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("invoices").SetMap(map[string]interface{}{
+		"status":     inconst.INVOICE_STATUS_OVERDUE,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"status": inconst.INVOICE_STATUS_OPEN},
+		squirrel.Lt{"due_date": date.Format("2006-01-02")},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	ret, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res, _ = ret.RowsAffected()
+	return
+}
+
+func (r *repository) createInvoiceItemsTx(ctx context.Context, tx *sql.Tx, payload *model.Invoice) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(payload.Items) == 0 {
+		return
+	}
+
+	baseStmt := pgSquirrel.Insert("invoice_items").Columns("id", "invoice_id", "description", "quantity", "unit_price", "amount")
+	for _, v := range payload.Items {
+		v.ID = snowflake.ID()
+		v.InvoiceID = payload.ID
+		baseStmt = baseStmt.Values(v.ID, v.InvoiceID, v.Description, v.Quantity, v.UnitPrice, v.Amount)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// transitionInvoice applies values to an invoice of params.MerchantID in one of from,
+// any other invoice returns ErrInvoiceClosed
+func (r *repository) transitionInvoice(ctx context.Context, params *indto.InvoiceParams, from []int64, values map[string]interface{}) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	values["updated_at"] = time.Now()
+
+	stmt, args, err := pgSquirrel.Update("invoices").SetMap(values).Where(squirrel.And{
+		squirrel.Eq{"id": params.InvoiceID},
+		squirrel.Eq{"merchant_id": params.MerchantID},
+		squirrel.Eq{"status": from},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrInvoiceClosed
+		logger.Error().Err(err).Uint64("invoice-id", params.InvoiceID).Msg("invoice status not changed")
+		return
+	}
+
+	return
+}
+
+func invoiceCond(params *indto.InvoiceParams) squirrel.And {
+	cond := squirrel.And{
+		squirrel.Eq{"i.deleted_at": nil},
+	}
+
+	if params.InvoiceID != 0 {
+		cond = append(cond, squirrel.Eq{"i.id": params.InvoiceID})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"i.merchant_id": params.MerchantID})
+	}
+
+	if params.CustomerAccountID != "" {
+		cond = append(cond, squirrel.Eq{"i.customer_account_id": params.CustomerAccountID})
+	}
+
+	if len(params.Statuses) != 0 {
+		cond = append(cond, squirrel.Eq{"i.status": params.Statuses})
+	}
+
+	return cond
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/google/uuid"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func testP2BTransaction(accountID string, merchantAccountID string, merchantID string, nominal money.Money) *model.Transaction {
+	return &model.Transaction{
+		ID:          snowflake.ID(),
+		AccountID:   accountID,
+		RecipientID: merchantAccountID,
+		MerchantID:  merchantID,
+		TrxType:     inconst.TRX_TYPE_P2B,
+		TrxDatetime: time.Now(),
+		TrxStatus:   inconst.TRX_STATUS_SUCCESS,
+		TrxFee:      money.Zero(),
+		Nominal:     nominal,
+		Description: "test payment",
+	}
+}
+
+func createTestInvoice(t *testing.T, r *repository, merchantID string, customerAccountID string, total money.Money) uint64 {
+	t.Helper()
+
+	id := snowflake.ID()
+	err := r.CreateInvoice(context.Background(), &model.Invoice{
+		ID:                id,
+		MerchantID:        merchantID,
+		CustomerAccountID: customerAccountID,
+		Subtotal:          total,
+		TaxAmount:         money.Zero(),
+		Total:             total,
+		DueDate:           time.Now().AddDate(0, 0, 7),
+		Status:            inconst.INVOICE_STATUS_OPEN,
+	})
+	if err != nil {
+		t.Fatalf("failed to create invoice: %v", err)
+	}
+
+	return id
+}
+
+func findTestInvoice(t *testing.T, r *repository, id uint64) (status int64, transactionID uint64) {
+	t.Helper()
+
+	if err := r.db.QueryRowx("select status, transaction_id from invoices where id = $1", id).Scan(&status, &transactionID); err != nil {
+		t.Fatalf("failed to read invoice %d: %v", id, err)
+	}
+
+	return
+}
+
+func TestPayInvoiceRejectedPaymentKeepsInvoiceOpen(t *testing.T) {
+	r := newTestRepository(t)
+
+	merchantID := uuid.NewString()
+	merchantAccountID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+	customerID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("10"))
+
+	invoiceID := createTestInvoice(t, r, merchantID, customerID, money.MustParse("25"))
+
+	trx := testP2BTransaction(customerID, merchantAccountID, merchantID, money.MustParse("25"))
+	err := r.PayInvoice(context.Background(), &model.Invoice{ID: invoiceID, CustomerAccountID: customerID}, trx)
+	if !errors.Is(err, errs.ErrInsufficientBalance) {
+		t.Fatalf("PayInvoice() err = %v, want %v", err, errs.ErrInsufficientBalance)
+	}
+
+	if status, transactionID := findTestInvoice(t, r, invoiceID); status != inconst.INVOICE_STATUS_OPEN || transactionID != 0 {
+		t.Errorf("invoice status = %d, transaction = %d after a rejected payment, want it open and unpaid", status, transactionID)
+	}
+
+	if balance := findTestBalance(t, r, customerID); balance.Cmp(money.MustParse("10")) != 0 {
+		t.Errorf("customer balance = %s, want 10.00", balance)
+	}
+}
+
+func TestPayInvoiceConcurrently(t *testing.T) {
+	r := newTestRepository(t)
+
+	merchantID := uuid.NewString()
+	merchantAccountID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+	customerID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("100"))
+
+	total := money.MustParse("25")
+	invoiceID := createTestInvoice(t, r, merchantID, customerID, total)
+
+	const workers = 20
+
+	var paidBy atomic.Uint64
+	var paid, closed int64
+	unexpected := make(chan error, workers)
+
+	start := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			trx := testP2BTransaction(customerID, merchantAccountID, merchantID, total)
+			err := r.PayInvoice(context.Background(), &model.Invoice{ID: invoiceID, CustomerAccountID: customerID}, trx)
+			switch {
+			case err == nil:
+				atomic.AddInt64(&paid, 1)
+				paidBy.Store(trx.ID)
+			case errors.Is(err, errs.ErrInvoiceClosed):
+				atomic.AddInt64(&closed, 1)
+			default:
+				unexpected <- err
+			}
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(unexpected)
+
+	for err := range unexpected {
+		t.Errorf("unexpected payment err: %v", err)
+	}
+
+	if paid != 1 || closed != workers-1 {
+		t.Fatalf("paid = %d, closed = %d, want the invoice paid once", paid, closed)
+	}
+
+	if status, transactionID := findTestInvoice(t, r, invoiceID); status != inconst.INVOICE_STATUS_PAID || transactionID != paidBy.Load() {
+		t.Errorf("invoice status = %d, transaction = %d, want paid by %d", status, transactionID, paidBy.Load())
+	}
+
+	if balance := findTestBalance(t, r, customerID); balance.Cmp(money.MustParse("75")) != 0 {
+		t.Errorf("customer balance = %s, want 75.00", balance)
+	}
+}
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func (r *repository) FindJournalPostings(ctx context.Context, params *indto.JournalParams) (res []*indto.JournalPosting, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"j.deleted_at": nil},
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Eq{"p.account_id": params.AccountID})
+	}
+
+	if params.TransactionID != 0 {
+		cond = append(cond, squirrel.Eq{"j.transaction_id": params.TransactionID})
+	}
+
+	baseStmt := pgSquirrel.Select("p.id", "p.journal_id", "j.transaction_id", "j.entry_type", "j.description", "j.entry_datetime", "p.account_id", "p.amount").
+		From("journal_postings p").
+		Join("journal_entries j on p.journal_id = j.id").
+		Where(cond).OrderBy("j.entry_datetime desc", "p.id desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.JournalPosting{}
+	for rows.Next() {
+		temp := &indto.JournalPosting{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountJournalPostings(ctx context.Context, params *indto.JournalParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"j.deleted_at": nil},
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Eq{"p.account_id": params.AccountID})
+	}
+
+	if params.TransactionID != 0 {
+		cond = append(cond, squirrel.Eq{"j.transaction_id": params.TransactionID})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("journal_postings p").
+		Join("journal_entries j on p.journal_id = j.id").
+		Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindLedgerBalance(ctx context.Context, params *indto.JournalParams) (res money.Money, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"j.deleted_at": nil},
+		squirrel.Eq{"p.account_id": params.AccountID},
+	}
+
+	stmt, args, err := pgSquirrel.Select("coalesce(sum(p.amount), 0)").From("journal_postings p").
+		Join("journal_entries j on p.journal_id = j.id").
+		Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// PostOpeningBalances journals the balance of every account that carries one but has never been posted to,
+// i.e. funded before the journal existed, against equityAccountID. Running it again posts nothing new
+func (r *repository) PostOpeningBalances(ctx context.Context, equityAccountID string) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	// replicas starting together would otherwise both find the same accounts unposted
+	if _, err = tx.ExecContext(ctx, "select pg_advisory_xact_lock(hashtext('journal-opening-balances'))"); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	stmt, args, err := pgSquirrel.Select("a.id", "a.balance").From("accounts a").Where(squirrel.And{
+		squirrel.Eq{"a.deleted_at": nil},
+		squirrel.NotEq{"a.balance": 0},
+		squirrel.Expr("not exists (select 1 from journal_postings p where p.account_id = a.id)"),
+	}).OrderBy("a.id").Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	accounts := []*model.Account{}
+	for rows.Next() {
+		temp := &model.Account{}
+
+		if err = rows.Scan(&temp.ID, &temp.Balance); err != nil {
+			rows.Close()
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		accounts = append(accounts, temp)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	now := time.Now()
+	for _, v := range accounts {
+		_, err = r.createJournalTx(ctx, tx, &model.JournalEntry{
+			EntryType:     inconst.JOURNAL_TYPE_OPENING,
+			Description:   fmt.Sprintf("opening balance %s", v.ID),
+			EntryDatetime: now,
+			Postings: []*model.JournalPosting{
+				{AccountID: v.ID, Amount: v.Balance},
+				{AccountID: equityAccountID, Amount: v.Balance.Neg()},
+			},
+		})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return int64(len(accounts)), nil
+}
+
+// PostBalanceWriteOffs journals the negative balances zeroed when the balance constraint was added. An account
+// without any posting yet gets the opening balance it was written off from first, so its ledger tells the whole story
+func (r *repository) PostBalanceWriteOffs(ctx context.Context, equityAccountID string) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	// shares the lock of opening balances, both decide on whether an account has postings yet
+	if _, err = tx.ExecContext(ctx, "select pg_advisory_xact_lock(hashtext('journal-opening-balances'))"); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	stmt, args, err := pgSquirrel.Select("w.id", "w.account_id", "w.prev_balance",
+		"exists (select 1 from journal_postings p where p.account_id = w.account_id)").
+		From("account_balance_writeoffs w").Where(squirrel.Eq{"w.journal_id": nil}).
+		OrderBy("w.id").Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	type writeOff struct {
+		id          int64
+		accountID   string
+		prevBalance money.Money
+		posted      bool
+	}
+
+	writeOffs := []*writeOff{}
+	for rows.Next() {
+		temp := &writeOff{}
+
+		if err = rows.Scan(&temp.id, &temp.accountID, &temp.prevBalance, &temp.posted); err != nil {
+			rows.Close()
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		writeOffs = append(writeOffs, temp)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	now := time.Now()
+	for _, v := range writeOffs {
+		if !v.posted {
+			_, err = r.createJournalTx(ctx, tx, &model.JournalEntry{
+				EntryType:     inconst.JOURNAL_TYPE_OPENING,
+				Description:   fmt.Sprintf("opening balance %s", v.accountID),
+				EntryDatetime: now,
+				Postings: []*model.JournalPosting{
+					{AccountID: v.accountID, Amount: v.prevBalance},
+					{AccountID: equityAccountID, Amount: v.prevBalance.Neg()},
+				},
+			})
+			if err != nil {
+				logger.Error().Err(err).Send()
+				return
+			}
+		}
+
+		journal, err := r.createJournalTx(ctx, tx, &model.JournalEntry{
+			EntryType:     inconst.JOURNAL_TYPE_WRITEOFF,
+			Description:   fmt.Sprintf("negative balance write-off %s", v.accountID),
+			EntryDatetime: now,
+			Postings: []*model.JournalPosting{
+				{AccountID: v.accountID, Amount: v.prevBalance.Neg()},
+				{AccountID: equityAccountID, Amount: v.prevBalance},
+			},
+		})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return 0, err
+		}
+
+		stmt, args, err := pgSquirrel.Update("account_balance_writeoffs").Set("journal_id", journal.ID).
+			Where(squirrel.Eq{"id": v.id}).ToSql()
+		if err != nil {
+			logger.Error().Err(err).Msg("squirrel err")
+			return 0, err
+		}
+
+		if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+			logger.Error().Err(err).Msg("sql err")
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return int64(len(writeOffs)), nil
+}
+
+func (r *repository) createJournalTx(ctx context.Context, tx *sql.Tx, payload *model.JournalEntry) (res *model.JournalEntry, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	sum := money.Zero()
+	for _, v := range payload.Postings {
+		sum = sum.Add(v.Amount)
+	}
+
+	// db trigger enforces the same on commit, fail early with a clearer error
+	if !sum.IsZero() {
+		err = errs.New(errs.ErrDataIntegrity, "journal")
+		logger.Error().Err(err).Uint64("transaction-id", payload.TransactionID).Str("sum", sum.String()).Msg("unbalanced journal")
+		return
+	}
+
+	if payload.ID == 0 {
+		payload.ID = snowflake.ID()
+	}
+
+	stmt, args, err := pgSquirrel.Insert("journal_entries").Columns("id", "transaction_id", "entry_type", "description", "entry_datetime").
+		Values(payload.ID, payload.TransactionID, payload.EntryType, payload.Description, payload.EntryDatetime).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	baseStmt := pgSquirrel.Insert("journal_postings").Columns("journal_id", "account_id", "amount")
+	postingCount := 0
+	for _, v := range payload.Postings {
+		// zero legs (e.g. fee-less transfer) carry no information
+		if v.Amount.IsZero() {
+			continue
+		}
+
+		v.JournalID = payload.ID
+		baseStmt = baseStmt.Values(v.JournalID, v.AccountID, v.Amount)
+		postingCount++
+	}
+
+	if postingCount == 0 {
+		return payload, nil
+	}
+
+	stmt, args, err = baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return payload, nil
+}
+
+// journalFromTransaction wraps postings into a journal entry describing trx
+func journalFromTransaction(trx *model.Transaction, postings ...*model.JournalPosting) *model.JournalEntry {
+	return &model.JournalEntry{
+		TransactionID: trx.ID,
+		EntryType:     trx.TrxType,
+		Description:   trx.Description,
+		EntryDatetime: trx.TrxDatetime,
+		Postings:      postings,
+	}
+}
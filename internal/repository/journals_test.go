@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func TestPostBalanceWriteOffs(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	// funded account overdrawn before the constraint and zeroed by it, its journal still holds the overdrawn balance
+	postedID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("10"))
+
+	journalID := snowflake.ID()
+	for _, v := range []struct {
+		query string
+		args  []any
+	}{
+		{query: "insert into journal_entries (id, entry_type, description, entry_datetime) values ($1, $2, 'overdraw', now())", args: []any{journalID, inconst.TRX_TYPE_P2P}},
+		{query: "insert into journal_postings (journal_id, account_id, amount) values ($1, $2, -25), ($1, $3, 25)", args: []any{journalID, postedID, config.Get().EquityAccountUUID}},
+		{query: "update accounts set balance = 0 where id = $1", args: []any{postedID}},
+	} {
+		if _, err := r.db.Exec(v.query, v.args...); err != nil {
+			t.Fatalf("failed to overdraw account: %v", err)
+		}
+	}
+
+	// account overdrawn before the journal existed, nothing of it is posted
+	unpostedID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.Zero())
+
+	for _, v := range []struct {
+		accountID string
+		balance   string
+	}{{postedID, "-15"}, {unpostedID, "-20"}} {
+		if _, err := r.db.Exec("insert into account_balance_writeoffs (account_id, prev_balance) values ($1, $2)", v.accountID, v.balance); err != nil {
+			t.Fatalf("failed to write off balance: %v", err)
+		}
+	}
+
+	if _, err := r.PostBalanceWriteOffs(ctx, config.Get().EquityAccountUUID); err != nil {
+		t.Fatalf("PostBalanceWriteOffs() unexpected err: %v", err)
+	}
+
+	// posting again finds nothing left to post
+	if count, err := r.PostBalanceWriteOffs(ctx, config.Get().EquityAccountUUID); err != nil || count != 0 {
+		t.Fatalf("PostBalanceWriteOffs() again = %d, %v, want 0", count, err)
+	}
+
+	for _, accountID := range []string{postedID, unpostedID} {
+		ledger, err := r.FindLedgerBalance(ctx, &indto.JournalParams{AccountID: accountID})
+		if err != nil {
+			t.Fatalf("failed to read ledger balance: %v", err)
+		}
+
+		if balance := findTestBalance(t, r, accountID); ledger.Cmp(balance) != 0 {
+			t.Errorf("ledger balance of %s = %s, stored balance = %s", accountID, ledger, balance)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var transactionLimitColumns = []string{
+	"id", "name", "trx_type", "account_type", "account_tier", "max_per_trx", "daily_amount", "daily_count",
+	"monthly_amount", "monthly_count", "counterparty_daily_amount",
+}
+
+// limitOutgoingTrxTypes are counted against limits set for every trx type
+var limitOutgoingTrxTypes = []int64{inconst.TRX_TYPE_P2P, inconst.TRX_TYPE_P2B}
+
+func (r *repository) FindTransactionLimits(ctx context.Context, params *indto.TransactionLimitParams) (res []*indto.TransactionLimit, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.TrxType != 0 {
+		cond = append(cond, squirrel.Eq{"trx_type": params.TrxType})
+	}
+
+	if params.AccountType != 0 {
+		cond = append(cond, squirrel.Eq{"account_type": params.AccountType})
+	}
+
+	if params.AccountTier != 0 {
+		cond = append(cond, squirrel.Eq{"account_tier": params.AccountTier})
+	}
+
+	baseStmt := pgSquirrel.Select(transactionLimitColumns...).From("transaction_limits").
+		Where(cond).OrderBy("trx_type", "account_type", "account_tier")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.TransactionLimit{}
+	for rows.Next() {
+		temp := &indto.TransactionLimit{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountTransactionLimits(ctx context.Context, params *indto.TransactionLimitParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.TrxType != 0 {
+		cond = append(cond, squirrel.Eq{"trx_type": params.TrxType})
+	}
+
+	if params.AccountType != 0 {
+		cond = append(cond, squirrel.Eq{"account_type": params.AccountType})
+	}
+
+	if params.AccountTier != 0 {
+		cond = append(cond, squirrel.Eq{"account_tier": params.AccountTier})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("transaction_limits").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindTransactionLimit(ctx context.Context, params *indto.TransactionLimitParams) (res *indto.TransactionLimit, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.LimitID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Select(transactionLimitColumns...).From("transaction_limits").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.TransactionLimit{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// FindEffectiveTransactionLimit picks the most specific limit of an account,
+// trx type specific over tier specific over account type specific over generic
+func (r *repository) FindEffectiveTransactionLimit(ctx context.Context, params *indto.TransactionLimitParams) (res *indto.TransactionLimit, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+		squirrel.Eq{"trx_type": []int64{inconst.LIMIT_SCOPE_ANY, params.TrxType}},
+		squirrel.Eq{"account_type": []int64{inconst.LIMIT_SCOPE_ANY, params.AccountType}},
+		squirrel.Eq{"account_tier": []int64{inconst.LIMIT_SCOPE_ANY, params.AccountTier}},
+	}
+
+	stmt, args, err := pgSquirrel.Select(transactionLimitColumns...).From("transaction_limits").
+		Where(cond).OrderBy("trx_type = 0", "account_tier = 0", "account_type = 0").
+		Limit(1).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.TransactionLimit{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) CreateTransactionLimit(ctx context.Context, payload *model.TransactionLimit) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("transaction_limits").Columns(transactionLimitColumns...).
+		Values(payload.ID, payload.Name, payload.TrxType, payload.AccountType, payload.AccountTier, payload.MaxPerTrx, payload.DailyAmount, payload.DailyCount,
+			payload.MonthlyAmount, payload.MonthlyCount, payload.CounterpartyDailyAmount).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if isUniqueViolation(err) {
+		err = errs.ErrDuplicatedResources
+		logger.Error().Err(err).Msg("limit of the same scope already exists")
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) UpdateTransactionLimit(ctx context.Context, payload *model.TransactionLimit) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("transaction_limits").SetMap(map[string]interface{}{
+		"name":                      payload.Name,
+		"trx_type":                  payload.TrxType,
+		"account_type":              payload.AccountType,
+		"account_tier":              payload.AccountTier,
+		"max_per_trx":               payload.MaxPerTrx,
+		"daily_amount":              payload.DailyAmount,
+		"daily_count":               payload.DailyCount,
+		"monthly_amount":            payload.MonthlyAmount,
+		"monthly_count":             payload.MonthlyCount,
+		"counterparty_daily_amount": payload.CounterpartyDailyAmount,
+		"updated_at":                time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if isUniqueViolation(err) {
+		err = errs.ErrDuplicatedResources
+		logger.Error().Err(err).Msg("limit of the same scope already exists")
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) DeleteTransactionLimit(ctx context.Context, params *indto.TransactionLimitParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.LimitID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Update("transaction_limits").SetMap(map[string]interface{}{
+		"updated_at": time.Now(),
+		"deleted_at": time.Now(),
+	}).Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindTransactionLimitUsage(ctx context.Context, params *indto.TransactionLimitUsageParams) (res *indto.TransactionLimitUsage, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := transactionLimitUsageStmt(params)
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.TransactionLimitUsage{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res.DailyAmount, &res.DailyCount, &res.MonthlyAmount, &res.MonthlyCount, &res.CounterpartyDailyAmount)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// checkTransactionLimitTx verifies payload against payload.Limit, sender must already be locked in tx so concurrent
+// transfers of the same sender are counted one after another
+func (r *repository) checkTransactionLimitTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	limit := payload.Limit
+	if limit == nil {
+		return
+	}
+
+	stmt, args, err := transactionLimitUsageStmt(&indto.TransactionLimitUsageParams{
+		AccountID:   payload.AccountID,
+		RecipientID: payload.RecipientID,
+		TrxType:     limit.TrxType,
+		At:          payload.TrxDatetime,
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	usage := &indto.TransactionLimitUsage{}
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&usage.DailyAmount, &usage.DailyCount, &usage.MonthlyAmount, &usage.MonthlyCount, &usage.CounterpartyDailyAmount)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	exceeded := ""
+	switch {
+	case limit.MaxPerTrx.IsPositive() && payload.Nominal.GreaterThan(limit.MaxPerTrx):
+		exceeded = "per transaction"
+	case limit.DailyAmount.IsPositive() && usage.DailyAmount.Add(payload.Nominal).GreaterThan(limit.DailyAmount):
+		exceeded = "daily amount"
+	case limit.DailyCount > 0 && usage.DailyCount >= limit.DailyCount:
+		exceeded = "daily count"
+	case limit.MonthlyAmount.IsPositive() && usage.MonthlyAmount.Add(payload.Nominal).GreaterThan(limit.MonthlyAmount):
+		exceeded = "monthly amount"
+	case limit.MonthlyCount > 0 && usage.MonthlyCount >= limit.MonthlyCount:
+		exceeded = "monthly count"
+	case limit.CounterpartyDailyAmount.IsPositive() && usage.CounterpartyDailyAmount.Add(payload.Nominal).GreaterThan(limit.CounterpartyDailyAmount):
+		exceeded = "counterparty daily amount"
+	}
+
+	if exceeded != "" {
+		err = errs.New(errs.ErrLimitExceeded, exceeded)
+		logger.Error().Err(err).Uint64("limit-id", limit.ID).Str("account-id", payload.AccountID).Str("nominal", payload.Nominal.String()).Send()
+		return
+	}
+
+	return
+}
+
+// transactionLimitUsageStmt sums outgoing transactions of the current day and month in local time,
+// cancelled and voided transactions are not counted
+func transactionLimitUsageStmt(params *indto.TransactionLimitUsageParams) (string, []interface{}, error) {
+	at := timeutil.ConvertLocalTime(params.At)
+	dayStart := timeutil.Truncate(at)
+	monthStart, _ := timeutil.GetStartEndMonth(at)
+
+	trxTypes := limitOutgoingTrxTypes
+	if params.TrxType != inconst.LIMIT_SCOPE_ANY {
+		trxTypes = []int64{params.TrxType}
+	}
+
+	return pgSquirrel.Select().
+		Column(squirrel.Expr("coalesce(sum(nominal) filter (where trx_datetime >= ?), 0)", dayStart)).
+		Column(squirrel.Expr("count(*) filter (where trx_datetime >= ?)", dayStart)).
+		Column("coalesce(sum(nominal), 0)").
+		Column("count(*)").
+		Column(squirrel.Expr("coalesce(sum(nominal) filter (where trx_datetime >= ? and recipient_id::text = ?), 0)", dayStart, params.RecipientID)).
+		From("transactions").
+		Where(squirrel.And{
+			squirrel.Eq{"account_id": params.AccountID},
+			squirrel.Eq{"trx_type": trxTypes},
+			squirrel.NotEq{"trx_status": []int64{inconst.TRX_STATUS_CANCELLED, inconst.TRX_STATUS_VOID}},
+			squirrel.GtOrEq{"trx_datetime": monthStart},
+			squirrel.Eq{"deleted_at": nil},
+		}).ToSql()
+}
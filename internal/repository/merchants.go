@@ -188,3 +188,47 @@ func (r *repository) DeleteMerchant(ctx context.Context, params *indto.MerchantP
 
 	return
 }
+
+func (r *repository) FindMerchantBankAccount(ctx context.Context, params *indto.MerchantParams) (res *indto.MerchantBankAccount, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("merchant_id", "bank_code", "account_no", "account_name", "row_hash", "updated_at").
+		From("merchant_bank_accounts").Where(squirrel.Eq{"merchant_id": params.MerchantID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.MerchantBankAccount{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// UpsertMerchantBankAccount registers payload as the merchant's only bank account, replacing the previous one
+func (r *repository) UpsertMerchantBankAccount(ctx context.Context, payload *model.MerchantBankAccount) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("merchant_bank_accounts").Columns("merchant_id", "bank_code", "account_no", "account_name", "row_hash").
+		Values(payload.MerchantID, payload.BankCode, payload.AccountNo, payload.AccountName, payload.RowHash).
+		Suffix("on conflict (merchant_id) do update set bank_code = excluded.bank_code, account_no = excluded.account_no, " +
+			"account_name = excluded.account_name, row_hash = excluded.row_hash, updated_at = now()").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var paymentLinkColumns = []string{
+	"l.id", "l.merchant_id", "coalesce(m.name, '') merchant_name", "l.token", "l.amount", "l.description", "l.max_uses", "l.use_count",
+	"l.status", "l.expires_at", "l.row_hash", "l.created_at",
+}
+
+func (r *repository) FindPaymentLinks(ctx context.Context, params *indto.PaymentLinkParams) (res []*indto.PaymentLink, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select(paymentLinkColumns...).From("payment_links l").
+		LeftJoin("merchants m on m.id = l.merchant_id").
+		Where(paymentLinkCond(params)).OrderBy("l.created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.PaymentLink{}
+	for rows.Next() {
+		temp := &indto.PaymentLink{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountPaymentLinks(ctx context.Context, params *indto.PaymentLinkParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("payment_links l").Where(paymentLinkCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindPaymentLink(ctx context.Context, params *indto.PaymentLinkParams) (res *indto.PaymentLink, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(paymentLinkColumns...).From("payment_links l").
+		LeftJoin("merchants m on m.id = l.merchant_id").
+		Where(paymentLinkCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.PaymentLink{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) FindPaymentLinkStats(ctx context.Context, params *indto.PaymentLinkParams) (res *indto.PaymentLinkStats, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*) payments", "coalesce(sum(amount), 0) collected", "count(distinct account_id) payers", "max(created_at) last_paid_at").
+		From("payment_link_payments").
+		Where(squirrel.Eq{"link_id": params.LinkID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.PaymentLinkStats{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) CreatePaymentLink(ctx context.Context, payload *model.PaymentLink) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("payment_links").
+		Columns("id", "merchant_id", "token", "token_hash", "amount", "description", "max_uses", "status", "expires_at", "row_hash").
+		Values(payload.ID, payload.MerchantID, payload.Token, payload.TokenHash, payload.Amount, payload.Description, payload.MaxUses,
+			payload.Status, payload.ExpiresAt, payload.RowHash).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if isUniqueViolation(err) {
+		err = errs.ErrDuplicatedResources
+		logger.Error().Err(err).Msg("payment link token already used")
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// DeactivatePaymentLink stops an active link of params.MerchantID from taking further payments,
+// payments already in progress still complete
+func (r *repository) DeactivatePaymentLink(ctx context.Context, params *indto.PaymentLinkParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("payment_links").SetMap(map[string]interface{}{
+		"status":     inconst.PAYMENT_LINK_STATUS_INACTIVE,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": params.LinkID},
+		squirrel.Eq{"merchant_id": params.MerchantID},
+		squirrel.Eq{"status": inconst.PAYMENT_LINK_STATUS_ACTIVE},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrPaymentLinkClosed
+		logger.Error().Err(err).Uint64("link-id", params.LinkID).Msg("payment link not deactivated")
+		return
+	}
+
+	return
+}
+
+// PayPaymentLink takes one use of an active, unexpired link and records trx as its payment in the same database
+// transaction that moves the money, either both happen or neither does. A link deactivated, expired or out of uses
+// returns ErrPaymentLinkClosed
+func (r *repository) PayPaymentLink(ctx context.Context, payload *model.PaymentLinkPayment, trx *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	// the row lock taken here holds concurrent payments of the link until this one ends, so uses are not overdrawn
+	stmt, args, err := pgSquirrel.Update("payment_links").
+		Set("use_count", squirrel.Expr("use_count + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.And{
+			squirrel.Eq{"id": payload.LinkID},
+			squirrel.Eq{"status": inconst.PAYMENT_LINK_STATUS_ACTIVE},
+			squirrel.Gt{"expires_at": time.Now()},
+			squirrel.Or{
+				squirrel.Eq{"max_uses": 0},
+				squirrel.Expr("use_count < max_uses"),
+			},
+		}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrPaymentLinkClosed
+		logger.Error().Err(err).Uint64("link-id", payload.LinkID).Msg("payment link not paid")
+		return
+	}
+
+	if err = r.createTransactionP2BTx(ctx, tx, trx); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if payload.ID == 0 {
+		payload.ID = snowflake.ID()
+	}
+
+	stmt, args, err = pgSquirrel.Insert("payment_link_payments").Columns("id", "link_id", "transaction_id", "account_id", "amount").
+		Values(payload.ID, payload.LinkID, trx.ID, trx.AccountID, trx.Nominal).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+func paymentLinkCond(params *indto.PaymentLinkParams) squirrel.And {
+	cond := squirrel.And{}
+
+	if params.LinkID != 0 {
+		cond = append(cond, squirrel.Eq{"l.id": params.LinkID})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"l.merchant_id": params.MerchantID})
+	}
+
+	if params.TokenHash != nil {
+		cond = append(cond, squirrel.Eq{"l.token_hash": params.TokenHash})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"l.status": params.Status})
+	}
+
+	return cond
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/google/uuid"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func createTestPaymentLink(t *testing.T, r *repository, merchantID string, maxUses int64) uint64 {
+	t.Helper()
+
+	id := snowflake.ID()
+	token := []byte(uuid.NewString())
+	err := r.CreatePaymentLink(context.Background(), &model.PaymentLink{
+		ID:          id,
+		MerchantID:  merchantID,
+		Token:       token,
+		TokenHash:   token,
+		Amount:      money.Zero(),
+		Description: "test link",
+		MaxUses:     maxUses,
+		Status:      inconst.PAYMENT_LINK_STATUS_ACTIVE,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to create payment link: %v", err)
+	}
+
+	return id
+}
+
+func findTestPaymentLinkUses(t *testing.T, r *repository, id uint64) (useCount int64, payments int64) {
+	t.Helper()
+
+	err := r.db.QueryRowx("select use_count, (select count(*) from payment_link_payments where link_id = $1) from payment_links where id = $1", id).
+		Scan(&useCount, &payments)
+	if err != nil {
+		t.Fatalf("failed to read payment link %d: %v", id, err)
+	}
+
+	return
+}
+
+func TestPayPaymentLinkRejectedPaymentKeepsUse(t *testing.T) {
+	r := newTestRepository(t)
+
+	merchantID := uuid.NewString()
+	merchantAccountID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+	customerID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("10"))
+
+	linkID := createTestPaymentLink(t, r, merchantID, 1)
+
+	trx := testP2BTransaction(customerID, merchantAccountID, merchantID, money.MustParse("25"))
+	if err := r.PayPaymentLink(context.Background(), &model.PaymentLinkPayment{LinkID: linkID}, trx); !errors.Is(err, errs.ErrInsufficientBalance) {
+		t.Fatalf("PayPaymentLink() err = %v, want %v", err, errs.ErrInsufficientBalance)
+	}
+
+	if useCount, payments := findTestPaymentLinkUses(t, r, linkID); useCount != 0 || payments != 0 {
+		t.Errorf("use count = %d, payments = %d after a rejected payment, want none", useCount, payments)
+	}
+}
+
+func TestPayPaymentLinkConcurrently(t *testing.T) {
+	r := newTestRepository(t)
+
+	merchantID := uuid.NewString()
+	merchantAccountID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+
+	const maxUses, workers = 3, 12
+
+	linkID := createTestPaymentLink(t, r, merchantID, maxUses)
+
+	customers := make([]string, workers)
+	for i := range customers {
+		customers[i] = createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("50"))
+	}
+
+	var paid, closed int64
+	unexpected := make(chan error, workers)
+
+	start := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for _, customerID := range customers {
+		wg.Add(1)
+		go func(customerID string) {
+			defer wg.Done()
+			<-start
+
+			trx := testP2BTransaction(customerID, merchantAccountID, merchantID, money.MustParse("20"))
+			err := r.PayPaymentLink(context.Background(), &model.PaymentLinkPayment{LinkID: linkID}, trx)
+			switch {
+			case err == nil:
+				atomic.AddInt64(&paid, 1)
+			case errors.Is(err, errs.ErrPaymentLinkClosed):
+				atomic.AddInt64(&closed, 1)
+			default:
+				unexpected <- err
+			}
+		}(customerID)
+	}
+
+	close(start)
+	wg.Wait()
+	close(unexpected)
+
+	for err := range unexpected {
+		t.Errorf("unexpected payment err: %v", err)
+	}
+
+	if paid != maxUses || closed != workers-maxUses {
+		t.Fatalf("paid = %d, closed = %d, want %d payments", paid, closed, maxUses)
+	}
+
+	if useCount, payments := findTestPaymentLinkUses(t, r, linkID); useCount != maxUses || payments != maxUses {
+		t.Errorf("use count = %d, payments = %d, want %d of each", useCount, payments, maxUses)
+	}
+}
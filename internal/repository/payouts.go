@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+var payoutColumns = []string{
+	"id", "beneficiary_id", "merchant_id", "connector", "bank_code", "account_no", "account_name", "amount", "status",
+	"provider_ref", "failure_reason", "attempts", "next_run_at", "sent_at", "settled_at",
+}
+
+func (r *repository) FindPayout(ctx context.Context, params *indto.PayoutParams) (res *indto.Payout, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{}
+
+	if params.PayoutID != 0 {
+		cond = append(cond, squirrel.Eq{"id": params.PayoutID})
+	}
+
+	if params.BeneficiaryID != 0 {
+		cond = append(cond, squirrel.Eq{"beneficiary_id": params.BeneficiaryID})
+	}
+
+	stmt, args, err := pgSquirrel.Select(payoutColumns...).From("payouts").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.Payout{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// ClaimDuePayouts leases up to limit pending or sent payouts that are due, a claimed payout is not due again until
+// lease passes so a worker that dies halfway only delays it. Rows claimed by another worker are skipped
+func (r *repository) ClaimDuePayouts(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.Payout, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	dueStmt := squirrel.Select("id").From("payouts").Where(squirrel.And{
+		squirrel.Eq{"status": []int64{inconst.PAYOUT_STATUS_PENDING, inconst.PAYOUT_STATUS_SENT}},
+		squirrel.LtOrEq{"next_run_at": time.Now()},
+	}).OrderBy("next_run_at").Limit(limit).Suffix("for update skip locked")
+
+	stmt, args, err := pgSquirrel.Update("payouts").SetMap(map[string]interface{}{
+		"next_run_at": time.Now().Add(lease),
+		"updated_at":  time.Now(),
+	}).Where(squirrel.Expr("id in (?)", dueStmt)).Suffix("returning " + strings.Join(payoutColumns, ", ")).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.Payout{}
+	for rows.Next() {
+		temp := &indto.Payout{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// UpdatePayout records a payout that is still in flight, payouts already settled or failed are left untouched
+func (r *repository) UpdatePayout(ctx context.Context, payload *model.Payout) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	values := map[string]interface{}{
+		"status":      payload.Status,
+		"attempts":    payload.Attempts,
+		"next_run_at": payload.NextRunAt,
+		"updated_at":  time.Now(),
+	}
+
+	if payload.Status == inconst.PAYOUT_STATUS_SENT {
+		values["provider_ref"] = payload.ProviderRef
+		values["sent_at"] = squirrel.Expr("coalesce(sent_at, ?)", time.Now())
+	}
+
+	stmt, args, err := pgSquirrel.Update("payouts").SetMap(values).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": []int64{inconst.PAYOUT_STATUS_PENDING, inconst.PAYOUT_STATUS_SENT}},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// SettlePayout completes a payout the bank transferred, its beneficiary is confirmed and the withdrawn amount leaves
+// the settlement account for the payout account. A payout already settled or failed is left untouched and ok is false,
+// so a callback racing the status poll or replayed is harmless
+func (r *repository) SettlePayout(ctx context.Context, payload *model.Payout) (ok bool, err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("payouts").SetMap(map[string]interface{}{
+		"status":       inconst.PAYOUT_STATUS_SETTLED,
+		"provider_ref": payload.ProviderRef,
+		"next_run_at":  nil,
+		"settled_at":   time.Now(),
+		"updated_at":   time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": []int64{inconst.PAYOUT_STATUS_PENDING, inconst.PAYOUT_STATUS_SENT}},
+	}).Suffix("returning beneficiary_id, merchant_id, amount").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&payload.BeneficiaryID, &payload.MerchantID, &payload.Amount)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		logger.Warn().Uint64("payout-id", payload.ID).Msg("payout already closed")
+		return false, nil
+	}
+
+	if err = r.closePayoutBeneficiaryTx(ctx, tx, payload.BeneficiaryID, inconst.BNF_STATUS_CONFIRM); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trxModel := &model.Transaction{
+		ID:          snowflake.ID(),
+		AccountID:   conf.SystemAccountUUID,
+		RecipientID: conf.PayoutAccountUUID,
+		MerchantID:  payload.MerchantID,
+		TrxType:     inconst.TRX_TYPE_PAYOUT,
+		TrxDatetime: time.Now(),
+		TrxStatus:   inconst.TRX_STATUS_SUCCESS,
+		TrxFee:      money.Zero(),
+		Nominal:     payload.Amount,
+		Description: fmt.Sprintf("beneficiary %d payout", payload.BeneficiaryID),
+	}
+
+	_, err = r.CreateTransactionTx(ctx, tx, trxModel)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(trxModel,
+		&model.JournalPosting{AccountID: conf.SettlementAccountUUID, Amount: payload.Amount.Neg()},
+		&model.JournalPosting{AccountID: conf.PayoutAccountUUID, Amount: payload.Amount},
+	))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	allocatedStmt := squirrel.Select("settlement_id").From("beneficiary_allocations").Where(squirrel.Eq{"beneficiary_id": payload.BeneficiaryID})
+
+	if err = r.paySettlementsTx(ctx, tx, payload.MerchantID, squirrel.Expr("s.id in (?)", allocatedStmt)); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return true, nil
+}
+
+// FailPayout closes a payout the bank rejected or never received, its beneficiary fails and the reserved amount is
+// handed back to the settlements. A payout already settled or failed is left untouched and ok is false
+func (r *repository) FailPayout(ctx context.Context, payload *model.Payout) (ok bool, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("payouts").SetMap(map[string]interface{}{
+		"status":         inconst.PAYOUT_STATUS_FAILED,
+		"failure_reason": payload.FailureReason,
+		"attempts":       payload.Attempts,
+		"next_run_at":    nil,
+		"updated_at":     time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": []int64{inconst.PAYOUT_STATUS_PENDING, inconst.PAYOUT_STATUS_SENT}},
+	}).Suffix("returning beneficiary_id").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&payload.BeneficiaryID)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		logger.Warn().Uint64("payout-id", payload.ID).Msg("payout already closed")
+		return false, nil
+	}
+
+	if err = r.closePayoutBeneficiaryTx(ctx, tx, payload.BeneficiaryID, inconst.BNF_STATUS_FAILED); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = r.releaseSettlementsTx(ctx, tx, payload.BeneficiaryID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return true, nil
+}
+
+// createPayoutTx queues the bank transfer of beneficiary, the first attempt is due right away
+func (r *repository) createPayoutTx(ctx context.Context, tx *sql.Tx, beneficiary *model.Beneficiary) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	payload := beneficiary.Payout
+	payload.BeneficiaryID = beneficiary.ID
+	payload.MerchantID = beneficiary.MerchantID
+	payload.Amount = beneficiary.Amount
+	payload.Status = inconst.PAYOUT_STATUS_PENDING
+
+	if payload.ID == 0 {
+		payload.ID = snowflake.ID()
+	}
+
+	stmt, args, err := pgSquirrel.Insert("payouts").
+		Columns("id", "beneficiary_id", "merchant_id", "connector", "bank_code", "account_no", "account_name", "amount", "status", "next_run_at").
+		Values(payload.ID, payload.BeneficiaryID, payload.MerchantID, payload.Connector, payload.BankCode, payload.AccountNo, payload.AccountName,
+			payload.Amount, payload.Status, time.Now()).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) closePayoutBeneficiaryTx(ctx context.Context, tx *sql.Tx, beneficiaryID uint64, status int64) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("beneficiaries").SetMap(map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": beneficiaryID},
+		squirrel.Eq{"status": inconst.BNF_STATUS_PAYING},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
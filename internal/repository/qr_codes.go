@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+func (r *repository) FindMerchantQRCode(ctx context.Context, params *indto.MerchantQRCodeParams) (res *indto.MerchantQRCode, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{}
+
+	if params.QRCodeID != 0 {
+		cond = append(cond, squirrel.Eq{"id": params.QRCodeID})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"merchant_id": params.MerchantID})
+	}
+
+	if params.Reference != "" {
+		cond = append(cond, squirrel.Eq{"reference": params.Reference})
+	}
+
+	stmt, args, err := pgSquirrel.Select("id", "merchant_id", "account_id", "reference", "amount", "status", "transaction_id", "expires_at", "paid_at", "created_at").
+		From("merchant_qr_codes").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.MerchantQRCode{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// CreateMerchantQRCode stores a dynamic qr code, a reference already used by the merchant returns ErrDuplicatedResources
+func (r *repository) CreateMerchantQRCode(ctx context.Context, payload *model.MerchantQRCode) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("merchant_qr_codes").Columns("id", "merchant_id", "account_id", "reference", "amount", "status", "expires_at").
+		Values(payload.ID, payload.MerchantID, payload.AccountID, payload.Reference, payload.Amount, payload.Status, payload.ExpiresAt).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if isUniqueViolation(err) {
+		err = errs.ErrDuplicatedResources
+		logger.Error().Err(err).Str("reference", payload.Reference).Msg("qr reference already used")
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// ClaimMerchantQRCode locks an active, unexpired qr code for one payment attempt,
+// a qr code paid, being paid or expired returns ErrQRClosed
+func (r *repository) ClaimMerchantQRCode(ctx context.Context, params *indto.MerchantQRCodeParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("merchant_qr_codes").SetMap(map[string]interface{}{
+		"status":     inconst.QR_STATUS_PROCESSING,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": params.QRCodeID},
+		squirrel.Eq{"status": inconst.QR_STATUS_ACTIVE},
+		squirrel.Gt{"expires_at": time.Now()},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrQRClosed
+		logger.Error().Err(err).Uint64("qr-id", params.QRCodeID).Msg("qr code not claimed")
+		return
+	}
+
+	return
+}
+
+// CloseMerchantQRCode ends the payment attempt of a claimed qr code, it is paid by payload.TransactionID
+// or made payable again when the attempt failed
+func (r *repository) CloseMerchantQRCode(ctx context.Context, payload *model.MerchantQRCode) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	values := map[string]interface{}{
+		"status":     inconst.QR_STATUS_ACTIVE,
+		"updated_at": time.Now(),
+	}
+
+	if payload.TransactionID != 0 {
+		values["status"] = inconst.QR_STATUS_PAID
+		values["transaction_id"] = payload.TransactionID
+		values["paid_at"] = time.Now()
+	}
+
+	stmt, args, err := pgSquirrel.Update("merchant_qr_codes").SetMap(values).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": inconst.QR_STATUS_PROCESSING},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+// accountExpectedBalanceExpr replays the balance effect of the whole settled transaction history of account a, along
+// with the negative balances written off when the balance constraint was introduced. Nothing else may move a balance,
+// so the stored balance drifting from it is a discrepancy however old the cause. A P2B refund only claws back from the
+// merchant when its settlement was already withdrawn, which is recorded solely by the merchant posting on the refund
+// journal
+func accountExpectedBalanceExpr() squirrel.Sqlizer {
+	return squirrel.Alias(squirrel.Expr(`coalesce((
+		select sum(case
+			when t.trx_type in (?, ?) and t.account_id = a.id then -(t.nominal + t.trx_fee)
+			when t.trx_type in (?, ?, ?) and t.recipient_id = a.id then t.nominal
+			when t.trx_type = ? and t.recipient_id = a.id then t.nominal + t.trx_fee
+			when t.trx_type = ? and t.account_id = a.id and (coalesce(pt.trx_type, 0) <> ? or exists (
+				select 1 from journal_entries rj join journal_postings rp on rp.journal_id = rj.id
+				where rj.transaction_id = t.id and rj.deleted_at is null and rp.account_id = a.id
+			)) then -t.nominal
+			else 0 end)
+		from transactions t
+		left join transactions pt on t.trx_type = ? and t.parent_id = pt.id
+		where (t.account_id = a.id or t.recipient_id = a.id)
+			and t.trx_status in (?, ?, ?)
+			and t.deleted_at is null
+	), 0) - coalesce((
+		select sum(w.prev_balance) from account_balance_writeoffs w where w.account_id = a.id
+	), 0)`,
+		inconst.TRX_TYPE_P2P, inconst.TRX_TYPE_P2B,
+		inconst.TRX_TYPE_P2P, inconst.TRX_TYPE_BENEFICIARY, inconst.TRX_TYPE_CUST_SYSTEM,
+		inconst.TRX_TYPE_REFUND,
+		inconst.TRX_TYPE_REFUND, inconst.TRX_TYPE_P2B,
+		inconst.TRX_TYPE_REFUND,
+		inconst.TRX_STATUS_SUCCESS, inconst.TRX_STATUS_PARTIALLY_REFUNDED, inconst.TRX_STATUS_REFUNDED,
+	), "expected_balance")
+}
+
+// FindAccountBalances returns the stored and expected balance of the next page of accounts, both are read
+// within one statement so concurrent transfers cannot show up as a discrepancy
+func (r *repository) FindAccountBalances(ctx context.Context, params *indto.AccountBalanceParams) (res []*indto.AccountBalance, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"a.deleted_at": nil},
+	}
+
+	if params.AfterAccountID != "" {
+		cond = append(cond, squirrel.Gt{"a.id": params.AfterAccountID})
+	}
+
+	stmt, args, err := pgSquirrel.Select("a.id account_id", "a.balance stored_balance").Column(accountExpectedBalanceExpr()).
+		From("accounts a").
+		Where(cond).OrderBy("a.id").Limit(params.Limit).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.AccountBalance{}
+	for rows.Next() {
+		temp := &indto.AccountBalance{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// CreateReconciliationRun starts a run, runs left running longer than staleAfter are failed first.
+// ErrDuplicatedResources is returned while another run is still in progress
+func (r *repository) CreateReconciliationRun(ctx context.Context, payload *model.ReconciliationRun, staleAfter time.Duration) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("reconciliation_runs").SetMap(map[string]interface{}{
+		"status":      inconst.RECONCILIATION_STATUS_FAILED,
+		"last_error":  "run abandoned",
+		"finished_at": time.Now(),
+		"updated_at":  time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"status": inconst.RECONCILIATION_STATUS_RUNNING},
+		squirrel.Lt{"updated_at": time.Now().Add(-staleAfter)},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Insert("reconciliation_runs").Columns("id", "status").
+		Values(payload.ID, payload.Status).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		if isUniqueViolation(err) {
+			return errs.ErrDuplicatedResources
+		}
+
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) UpdateReconciliationRun(ctx context.Context, payload *model.ReconciliationRun) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("reconciliation_runs").SetMap(map[string]interface{}{
+		"status":           payload.Status,
+		"accounts_checked": payload.AccountsChecked,
+		"discrepancies":    payload.Discrepancies,
+		"last_error":       payload.LastError,
+		"finished_at":      payload.FinishedAt,
+		"updated_at":       time.Now(),
+	}).Where(squirrel.Eq{"id": payload.ID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) CreateReconciliationDiscrepancies(ctx context.Context, payload []*model.ReconciliationDiscrepancy) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(payload) == 0 {
+		return
+	}
+
+	baseStmt := pgSquirrel.Insert("reconciliation_discrepancies").Columns("run_id", "account_id", "stored_balance", "expected_balance", "difference")
+	for _, v := range payload {
+		baseStmt = baseStmt.Values(v.RunID, v.AccountID, v.StoredBalance, v.ExpectedBalance, v.Difference)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindReconciliationRuns(ctx context.Context, params *indto.ReconciliationParams) (res []*indto.ReconciliationRun, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select("id", "status", "accounts_checked", "discrepancies", "last_error", "started_at", "finished_at").
+		From("reconciliation_runs").OrderBy("started_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.ReconciliationRun{}
+	for rows.Next() {
+		temp := &indto.ReconciliationRun{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountReconciliationRuns(ctx context.Context, params *indto.ReconciliationParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("reconciliation_runs").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindReconciliationRun(ctx context.Context, params *indto.ReconciliationParams) (res *indto.ReconciliationRun, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("id", "status", "accounts_checked", "discrepancies", "last_error", "started_at", "finished_at").
+		From("reconciliation_runs").Where(squirrel.Eq{"id": params.RunID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.ReconciliationRun{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) FindReconciliationDiscrepancies(ctx context.Context, params *indto.ReconciliationParams) (res []*indto.ReconciliationDiscrepancy, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"run_id": params.RunID},
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Eq{"account_id": params.AccountID})
+	}
+
+	baseStmt := pgSquirrel.Select("id", "run_id", "account_id", "stored_balance", "expected_balance", "difference", "created_at").
+		From("reconciliation_discrepancies").Where(cond).OrderBy("id")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.ReconciliationDiscrepancy{}
+	for rows.Next() {
+		temp := &indto.ReconciliationDiscrepancy{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountReconciliationDiscrepancies(ctx context.Context, params *indto.ReconciliationParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"run_id": params.RunID},
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Eq{"account_id": params.AccountID})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("reconciliation_discrepancies").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
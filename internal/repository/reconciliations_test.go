@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// findTestAccountBalances pages through every account and keeps the ones asked for, other tests share the database
+func findTestAccountBalances(t *testing.T, r *repository, accountIDs ...string) map[string]*indto.AccountBalance {
+	t.Helper()
+
+	wanted := map[string]bool{}
+	for _, v := range accountIDs {
+		wanted[v] = true
+	}
+
+	res := map[string]*indto.AccountBalance{}
+	params := &indto.AccountBalanceParams{Limit: 500}
+	for {
+		data, err := r.FindAccountBalances(context.Background(), params)
+		if err != nil {
+			t.Fatalf("failed to read account balances: %v", err)
+		}
+
+		for _, v := range data {
+			if wanted[v.AccountID] {
+				res[v.AccountID] = v
+			}
+		}
+
+		if uint64(len(data)) < params.Limit {
+			return res
+		}
+
+		params.AfterAccountID = data[len(data)-1].AccountID
+	}
+}
+
+func TestFindAccountBalances(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	senderID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("100"))
+	recipientID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.Zero())
+	merchantAccountID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+
+	if err := debitP2P(recipientID)(ctx, r, senderID, money.MustParse("30"), money.MustParse("1")); err != nil {
+		t.Fatalf("failed to send p2p: %v", err)
+	}
+
+	if err := debitP2B(merchantAccountID, "")(ctx, r, senderID, money.MustParse("20"), money.MustParse("0.50")); err != nil {
+		t.Fatalf("failed to send p2b: %v", err)
+	}
+
+	// a written off balance is an adjustment on record, not a discrepancy
+	writtenOffID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.Zero())
+	if _, err := r.db.Exec("insert into account_balance_writeoffs (account_id, prev_balance) values ($1, -15)", writtenOffID); err != nil {
+		t.Fatalf("failed to write off balance: %v", err)
+	}
+
+	if _, err := r.db.Exec("update accounts set balance = 15 where id = $1", writtenOffID); err != nil {
+		t.Fatalf("failed to adjust balance: %v", err)
+	}
+
+	balances := findTestAccountBalances(t, r, senderID, recipientID, writtenOffID)
+
+	tests := []struct {
+		name      string
+		accountID string
+		want      money.Money
+	}{
+		{name: "sender", accountID: senderID, want: money.MustParse("48.50")},
+		{name: "recipient", accountID: recipientID, want: money.MustParse("30")},
+		{name: "written off", accountID: writtenOffID, want: money.MustParse("15")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, ok := balances[tt.accountID]
+			if !ok {
+				t.Fatalf("account %s not listed", tt.accountID)
+			}
+
+			if v.StoredBalance.Cmp(tt.want) != 0 || v.ExpectedBalance.Cmp(tt.want) != 0 {
+				t.Errorf("stored = %s, expected = %s, want both %s", v.StoredBalance, v.ExpectedBalance, tt.want)
+			}
+		})
+	}
+
+	// a balance moved outside any transaction is caught however long ago it happened
+	if _, err := r.db.Exec("update accounts set balance = balance + 7 where id = $1", recipientID); err != nil {
+		t.Fatalf("failed to tamper balance: %v", err)
+	}
+
+	v := findTestAccountBalances(t, r, recipientID)[recipientID]
+	if v == nil || v.ExpectedBalance.Cmp(money.MustParse("30")) != 0 || v.StoredBalance.Cmp(money.MustParse("37")) != 0 {
+		t.Errorf("tampered recipient = %+v, want stored 37.00 and expected 30.00", v)
+	}
+}
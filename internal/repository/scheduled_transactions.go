@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var scheduledTransactionColumns = []string{
+	"id", "user_id", "account_id", "recipient_id", "trx_type", "nominal", "description", "scheduled_at",
+	"status", "attempts", "next_attempt_at", "last_error", "transaction_id",
+}
+
+func (r *repository) FindScheduledTransactions(ctx context.Context, params *indto.ScheduledTransactionParams) (res []*indto.ScheduledTransaction, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	baseStmt := pgSquirrel.Select(scheduledTransactionColumns...).From("scheduled_transactions").
+		Where(cond).OrderBy("scheduled_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.ScheduledTransaction{}
+	for rows.Next() {
+		temp := &indto.ScheduledTransaction{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountScheduledTransactions(ctx context.Context, params *indto.ScheduledTransactionParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("scheduled_transactions").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindScheduledTransaction(ctx context.Context, params *indto.ScheduledTransactionParams) (res *indto.ScheduledTransaction, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.ScheduleID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	stmt, args, err := pgSquirrel.Select(scheduledTransactionColumns...).From("scheduled_transactions").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.ScheduledTransaction{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) CreateScheduledTransaction(ctx context.Context, payload *model.ScheduledTransaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("scheduled_transactions").
+		Columns("id", "user_id", "account_id", "recipient_id", "trx_type", "nominal", "description", "scheduled_at", "status", "next_attempt_at").
+		Values(payload.ID, payload.UserID, payload.AccountID, payload.RecipientID, payload.TrxType, payload.Nominal, payload.Description, payload.ScheduledAt, payload.Status, payload.NextAttemptAt).
+		ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// ClaimDueScheduledTransactions leases up to limit due schedules as processing and counts the attempt. A schedule
+// whose lease passed without an outcome recorded is claimed again, so a worker that dies halfway only delays it.
+// Rows claimed by another worker are skipped so each schedule is executed by one worker at a time
+func (r *repository) ClaimDueScheduledTransactions(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.ScheduledTransaction, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	dueStmt := squirrel.Select("id").From("scheduled_transactions").Where(squirrel.And{
+		squirrel.Or{
+			squirrel.And{
+				squirrel.Eq{"status": inconst.SCHEDULE_TRX_STATUS_PENDING},
+				squirrel.LtOrEq{"next_attempt_at": time.Now()},
+			},
+			squirrel.And{
+				squirrel.Eq{"status": inconst.SCHEDULE_TRX_STATUS_PROCESSING},
+				squirrel.LtOrEq{"locked_until": time.Now()},
+			},
+		},
+		squirrel.Eq{"deleted_at": nil},
+	}).OrderBy("next_attempt_at").Limit(limit).Suffix("for update skip locked")
+
+	stmt, args, err := pgSquirrel.Update("scheduled_transactions").SetMap(map[string]interface{}{
+		"status":       inconst.SCHEDULE_TRX_STATUS_PROCESSING,
+		"attempts":     squirrel.Expr("attempts + 1"),
+		"locked_until": time.Now().Add(lease),
+		"updated_at":   time.Now(),
+	}).Where(squirrel.Expr("id in (?)", dueStmt)).Suffix("returning " + strings.Join(scheduledTransactionColumns, ", ")).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.ScheduledTransaction{}
+	for rows.Next() {
+		temp := &indto.ScheduledTransaction{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) UpdateScheduledTransaction(ctx context.Context, payload *model.ScheduledTransaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("scheduled_transactions").SetMap(map[string]interface{}{
+		"status":          payload.Status,
+		"next_attempt_at": payload.NextAttemptAt,
+		"last_error":      payload.LastError,
+		"transaction_id":  payload.TransactionID,
+		"updated_at":      time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// CancelScheduledTransaction cancels a schedule that has not been picked up yet, otherwise ErrScheduleClosed is returned
+func (r *repository) CancelScheduledTransaction(ctx context.Context, params *indto.ScheduledTransactionParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.ScheduleID},
+		squirrel.Eq{"status": inconst.SCHEDULE_TRX_STATUS_PENDING},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	stmt, args, err := pgSquirrel.Update("scheduled_transactions").SetMap(map[string]interface{}{
+		"status":     inconst.SCHEDULE_TRX_STATUS_CANCELLED,
+		"updated_at": time.Now(),
+	}).Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrScheduleClosed
+		logger.Error().Err(err).Uint64("schedule-id", params.ScheduleID).Msg("scheduled transaction not cancelled")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/google/uuid"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func TestClaimDueScheduledTransactionsLease(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	id := snowflake.ID()
+	err := r.CreateScheduledTransaction(ctx, &model.ScheduledTransaction{
+		ID:            id,
+		UserID:        uuid.NewString(),
+		AccountID:     uuid.NewString(),
+		RecipientID:   uuid.NewString(),
+		TrxType:       inconst.TRX_TYPE_P2P,
+		Nominal:       money.MustParse("10"),
+		ScheduledAt:   time.Now().Add(-time.Minute),
+		Status:        inconst.SCHEDULE_TRX_STATUS_PENDING,
+		NextAttemptAt: time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+
+	// other tests share the database, only the schedule created here is looked at
+	claim := func() *indto.ScheduledTransaction {
+		t.Helper()
+
+		data, err := r.ClaimDueScheduledTransactions(ctx, 1000, time.Hour)
+		if err != nil {
+			t.Fatalf("failed to claim schedules: %v", err)
+		}
+
+		for _, v := range data {
+			if v.ID == id {
+				return v
+			}
+		}
+
+		return nil
+	}
+
+	first := claim()
+	if first == nil {
+		t.Fatal("due schedule was not claimed")
+	} else if first.Status != inconst.SCHEDULE_TRX_STATUS_PROCESSING || first.Attempts != 1 {
+		t.Fatalf("claimed schedule status = %d, attempts = %d, want %d and 1", first.Status, first.Attempts, inconst.SCHEDULE_TRX_STATUS_PROCESSING)
+	}
+
+	if claim() != nil {
+		t.Fatal("schedule was claimed again while its lease holds")
+	}
+
+	if _, err = r.db.Exec("update scheduled_transactions set locked_until = now() - interval '1 second' where id = $1", id); err != nil {
+		t.Fatalf("failed to expire lease: %v", err)
+	}
+
+	reclaimed := claim()
+	if reclaimed == nil {
+		t.Fatal("schedule was not reclaimed after its lease passed")
+	} else if reclaimed.Attempts != 2 {
+		t.Fatalf("reclaimed schedule attempts = %d, want 2", reclaimed.Attempts)
+	}
+}
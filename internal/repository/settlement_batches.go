@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func (r *repository) FindSettlementBatches(ctx context.Context, params *indto.SettlementBatchParams) (res []*indto.SettlementBatch, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"b.merchant_id": params.MerchantID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"b.status": params.Status})
+	}
+
+	baseStmt := pgSquirrel.Select("b.id", "b.merchant_id", "m.name merchant_name", "b.status", "b.settlement_count", "b.total_amount", "b.fee_amount",
+		"b.failure_reason", "b.cutoff_at", "b.paid_at", "b.created_at").
+		From("settlement_batches b").
+		LeftJoin("merchants m on m.id = b.merchant_id").
+		Where(cond).OrderBy("b.cutoff_at desc", "b.id desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.SettlementBatch{}
+	for rows.Next() {
+		temp := &indto.SettlementBatch{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountSettlementBatches(ctx context.Context, params *indto.SettlementBatchParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"b.merchant_id": params.MerchantID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"b.status": params.Status})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("settlement_batches b").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindSettlementBatch(ctx context.Context, params *indto.SettlementBatchParams) (res *indto.SettlementBatch, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"b.id": params.BatchID},
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"b.merchant_id": params.MerchantID})
+	}
+
+	stmt, args, err := pgSquirrel.Select("b.id", "b.merchant_id", "m.name merchant_name", "b.status", "b.settlement_count", "b.total_amount", "b.fee_amount",
+		"b.failure_reason", "b.cutoff_at", "b.paid_at", "b.created_at").
+		From("settlement_batches b").
+		LeftJoin("merchants m on m.id = b.merchant_id").
+		Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.SettlementBatch{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// FindUnbatchedSettlementMerchants lists merchants with open settlements made before cutoff
+func (r *repository) FindUnbatchedSettlementMerchants(ctx context.Context, cutoff time.Time) (res []string, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("distinct merchant_id").From("settlements").Where(squirrel.And{
+		squirrel.Eq{"status": inconst.SETTLEMENT_STATUS_OPEN},
+		squirrel.Lt{"settlement_date": cutoff},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = []string{}
+	err = r.db.SelectContext(ctx, &res, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// CreateSettlementBatch moves the merchant's open settlements made before payload.CutoffAt into a new batch carrying
+// payload.Status. Totals are taken from the claimed rows, nothing is written when there is no row left to claim
+func (r *repository) CreateSettlementBatch(ctx context.Context, payload *model.SettlementBatch) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("settlements s").SetMap(map[string]interface{}{
+		"batch_id":   payload.ID,
+		"status":     payload.Status,
+		"updated_at": time.Now(),
+	}).From("transactions t").Where(squirrel.And{
+		squirrel.Expr("t.id = s.transaction_id"),
+		squirrel.Eq{"s.merchant_id": payload.MerchantID},
+		squirrel.Eq{"s.status": inconst.SETTLEMENT_STATUS_OPEN},
+		squirrel.Lt{"s.settlement_date": payload.CutoffAt},
+		squirrel.Eq{"s.deleted_at": nil},
+	}).Suffix("returning s.amount, t.trx_fee - t.refunded_fee").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	payload.SettlementCount = 0
+	payload.TotalAmount = money.Zero()
+	payload.FeeAmount = money.Zero()
+	for rows.Next() {
+		var amount, fee money.Money
+		if err = rows.Scan(&amount, &fee); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		payload.SettlementCount++
+		payload.TotalAmount = payload.TotalAmount.Add(amount)
+		payload.FeeAmount = payload.FeeAmount.Add(fee)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if payload.SettlementCount == 0 {
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Insert("settlement_batches").
+		Columns("id", "merchant_id", "status", "settlement_count", "total_amount", "fee_amount", "failure_reason", "cutoff_at").
+		Values(payload.ID, payload.MerchantID, payload.Status, payload.SettlementCount, payload.TotalAmount, payload.FeeAmount, payload.FailureReason, payload.CutoffAt).
+		ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
@@ -3,13 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 func (r *repository) FindSettlements(ctx context.Context, params *indto.SettlementParams) (res []*indto.Settlement, err error) {
@@ -24,10 +26,18 @@ func (r *repository) FindSettlements(ctx context.Context, params *indto.Settleme
 	}
 
 	if params.BeneficiaryID != 0 {
-		cond = append(cond, squirrel.Eq{"s.beneficiary_id": params.BeneficiaryID})
+		cond = append(cond, squirrel.Expr("exists (select 1 from beneficiary_allocations ba where ba.settlement_id = s.id and ba.beneficiary_id = ?)", params.BeneficiaryID))
 	}
 
-	baseStmt := pgSquirrel.Select("s.id", "s.transaction_id", "s.merchant_id", "m.name merchant_name", "s.beneficiary_id", "s.amount", "s.settlement_date").
+	if params.BatchID != 0 {
+		cond = append(cond, squirrel.Eq{"s.batch_id": params.BatchID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"s.status": params.Status})
+	}
+
+	baseStmt := pgSquirrel.Select("s.id", "s.transaction_id", "s.merchant_id", "m.name merchant_name", "s.batch_id", "s.amount", "s.allocated_amount", "s.status", "s.settlement_date").
 		From("settlements s").
 		LeftJoin("merchants m on s.merchant_id = m.id").
 		Where(cond)
@@ -75,7 +85,15 @@ func (r *repository) CountSettlements(ctx context.Context, params *indto.Settlem
 	}
 
 	if params.BeneficiaryID != 0 {
-		cond = append(cond, squirrel.Eq{"s.beneficiary_id": params.BeneficiaryID})
+		cond = append(cond, squirrel.Expr("exists (select 1 from beneficiary_allocations ba where ba.settlement_id = s.id and ba.beneficiary_id = ?)", params.BeneficiaryID))
+	}
+
+	if params.BatchID != 0 {
+		cond = append(cond, squirrel.Eq{"s.batch_id": params.BatchID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"s.status": params.Status})
 	}
 
 	stmt, args, err := pgSquirrel.Select("count(*)").From("settlements s").Where(cond).ToSql()
@@ -97,6 +115,7 @@ func (r *repository) FindSettlement(ctx context.Context, params *indto.Settlemen
 	logger := zerolog.Ctx(ctx)
 
 	cond := squirrel.And{
+		squirrel.Eq{"s.id": params.SettlementID},
 		squirrel.Eq{"s.deleted_at": nil},
 	}
 
@@ -104,13 +123,9 @@ func (r *repository) FindSettlement(ctx context.Context, params *indto.Settlemen
 		cond = append(cond, squirrel.Eq{"s.merchant_id": params.MerchantID})
 	}
 
-	if params.BeneficiaryID != 0 {
-		cond = append(cond, squirrel.Eq{"s.beneficiary_id": params.BeneficiaryID})
-	}
-
-	stmt, args, err := pgSquirrel.Select("s.id", "s.transaction_id", "s.merchant_id", "m.name merchant_name", "s.beneficiary_id", "s.amount", "s.settlement_date").
+	stmt, args, err := pgSquirrel.Select("s.id", "s.transaction_id", "s.merchant_id", "m.name merchant_name", "s.batch_id", "s.amount", "s.allocated_amount", "s.status", "s.settlement_date").
 		From("settlements s").
-		LeftJoin("merchants m on m.id = s.merchant_name").
+		LeftJoin("merchants m on m.id = s.merchant_id").
 		Where(cond).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
@@ -132,8 +147,8 @@ func (r *repository) FindSettlement(ctx context.Context, params *indto.Settlemen
 func (r *repository) createSettlementTx(ctx context.Context, tx *sql.Tx, payload *model.Settlement) (res *model.Settlement, err error) {
 	logger := zerolog.Ctx(ctx)
 
-	stmt, args, err := pgSquirrel.Insert("settlements").Columns("id", "transaction_id", "merchant_id", "beneficiary_id", "amount", "settlement_date").
-		Values(payload.ID, payload.TransactionID, payload.MerchantID, payload.BeneficiaryID, payload.Amount, payload.SettlementDate).ToSql()
+	stmt, args, err := pgSquirrel.Insert("settlements").Columns("id", "transaction_id", "merchant_id", "amount", "status", "settlement_date").
+		Values(payload.ID, payload.TransactionID, payload.MerchantID, payload.Amount, payload.Status, payload.SettlementDate).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
@@ -148,32 +163,92 @@ func (r *repository) createSettlementTx(ctx context.Context, tx *sql.Tx, payload
 	return payload, nil
 }
 
-func (r *repository) updateSettlementTx(ctx context.Context, tx *sql.Tx, payload *model.Settlement) (err error) {
+// allocateSettlementsTx withdraws payload.Amount from the merchant's pending settlements for beneficiary payload.ID, oldest first.
+// A settlement straddling the remaining amount is allocated partially, a zero amount takes everything above payload.Reserve
+func (r *repository) allocateSettlementsTx(ctx context.Context, tx *sql.Tx, payload *model.Beneficiary) (err error) {
 	logger := zerolog.Ctx(ctx)
 
-	cond := squirrel.And{
+	stmt, args, err := pgSquirrel.Select("id", "amount - allocated_amount").From("settlements").Where(squirrel.And{
+		squirrel.Eq{"merchant_id": payload.MerchantID},
+		squirrel.Expr("allocated_amount < amount"),
 		squirrel.Eq{"deleted_at": nil},
+	}).OrderBy("settlement_date", "id").Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
 	}
 
-	if payload.ID != 0 {
-		cond = append(cond, squirrel.Eq{"id": payload.ID})
-	} else if payload.BeneficiaryID != 0 {
-		cond = append(cond, squirrel.Eq{"beneficiary_id": payload.BeneficiaryID})
-	} else if payload.TransactionID != 0 {
-		cond = append(cond, squirrel.Eq{"transaction_id": payload.TransactionID})
+	rows, err := tx.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
 	}
+	defer rows.Close()
 
-	stmt, args, err := pgSquirrel.Update("settlements").SetMap(map[string]interface{}{
-		"beneficiary_id": squirrel.Expr("coalesce(nullif(?, 0), beneficiary_id)", payload.BeneficiaryID),
-		"updated_at":     time.Now(),
-	}).Where(cond).ToSql()
+	pending := money.Zero()
+	settlements := []*model.Settlement{}
+	for rows.Next() {
+		temp := &model.Settlement{}
+		if err = rows.Scan(&temp.ID, &temp.Amount); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		pending = pending.Add(temp.Amount)
+		settlements = append(settlements, temp)
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	available := pending.Sub(payload.Reserve).Max(money.Zero())
+	if payload.Amount.IsZero() {
+		payload.Amount = available
+	}
+
+	if !payload.Amount.IsPositive() || payload.Amount.GreaterThan(available) {
+		err = errs.New(errs.ErrWithdrawalExceeded, available.String())
+		logger.Error().Err(err).Str("merchant-id", payload.MerchantID).Str("amount", payload.Amount.String()).Msg("settlement not allocated")
+		return
+	}
+
+	allocStmt := pgSquirrel.Insert("beneficiary_allocations").Columns("beneficiary_id", "settlement_id", "amount")
+
+	left := payload.Amount
+	for _, v := range settlements {
+		if !left.IsPositive() {
+			break
+		}
+
+		amount := v.Amount.Min(left)
+		left = left.Sub(amount)
+
+		stmt, args, err = pgSquirrel.Update("settlements").SetMap(map[string]interface{}{
+			"allocated_amount": squirrel.Expr("allocated_amount + ?", amount),
+			"updated_at":       time.Now(),
+		}).Where(squirrel.Eq{"id": v.ID}).ToSql()
+		if err != nil {
+			logger.Error().Err(err).Msg("squirrel err")
+			return
+		}
+
+		if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+			logger.Error().Err(err).Msg("sql err")
+			return
+		}
+
+		allocStmt = allocStmt.Values(payload.ID, v.ID, amount)
+	}
+
+	stmt, args, err = allocStmt.ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
 	}
 
-	_, err = r.db.ExecContext(ctx, stmt, args...)
-	if err != nil {
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
 		logger.Error().Err(err).Msg("sql err")
 		return
 	}
@@ -181,34 +256,112 @@ func (r *repository) updateSettlementTx(ctx context.Context, tx *sql.Tx, payload
 	return
 }
 
-func (r *repository) updateSettlementBeneficiaryTx(ctx context.Context, tx *sql.Tx, payload *model.Settlement) (aff int64, err error) {
+// releaseSettlementsTx hands every allocation of beneficiaryID back to its settlement
+func (r *repository) releaseSettlementsTx(ctx context.Context, tx *sql.Tx, beneficiaryID uint64) (err error) {
 	logger := zerolog.Ctx(ctx)
 
-	cond := squirrel.And{
-		squirrel.Eq{"deleted_at": nil},
-		squirrel.Eq{"beneficiary_id": 0},
-		squirrel.Eq{"merchant_id": payload.MerchantID},
+	stmt, args, err := pgSquirrel.Update("settlements s").SetMap(map[string]interface{}{
+		"allocated_amount": squirrel.Expr("s.allocated_amount - ba.amount"),
+		"updated_at":       time.Now(),
+	}).From("beneficiary_allocations ba").Where(squirrel.And{
+		squirrel.Expr("ba.settlement_id = s.id"),
+		squirrel.Eq{"ba.beneficiary_id": beneficiaryID},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
 	}
 
-	stmt, args, err := pgSquirrel.Update("settlements").SetMap(map[string]interface{}{
-		"beneficiary_id": payload.BeneficiaryID,
-		"updated_at":     time.Now(),
-	}).Where(cond).ToSql()
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Delete("beneficiary_allocations").Where(squirrel.Eq{"beneficiary_id": beneficiaryID}).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
 	}
 
-	fmt.Println(stmt)
-	fmt.Println(args...)
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
 
-	res, err := r.db.ExecContext(ctx, stmt, args...)
+// paySettlementsTx marks settlements matching cond paid once they are fully allocated to confirmed beneficiaries,
+// then settles the merchant's batches
+func (r *repository) paySettlementsTx(ctx context.Context, tx *sql.Tx, merchantID string, cond squirrel.Sqlizer) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	reservedStmt := squirrel.Select("1").From("beneficiary_allocations ba").
+		Join("beneficiaries b on b.id = ba.beneficiary_id").
+		Where(squirrel.And{
+			squirrel.Expr("ba.settlement_id = s.id"),
+			squirrel.Eq{"b.status": []int64{inconst.BNF_STATUS_PENDING, inconst.BNF_STATUS_PAYING}},
+		})
+
+	stmt, args, err := pgSquirrel.Update("settlements s").SetMap(map[string]interface{}{
+		"status":     inconst.SETTLEMENT_STATUS_PAID,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		cond,
+		squirrel.Expr("s.allocated_amount = s.amount"),
+		squirrel.NotEq{"s.status": inconst.SETTLEMENT_STATUS_PAID},
+		squirrel.Eq{"s.deleted_at": nil},
+		squirrel.Expr("not exists (?)", reservedStmt),
+	}).ToSql()
 	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
 		logger.Error().Err(err).Msg("sql err")
 		return
 	}
 
-	aff, _ = res.RowsAffected()
+	if err = r.paySettlementBatchesTx(ctx, tx, merchantID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// paySettlementBatchesTx marks the merchant's batches paid once all of their settlements are paid
+func (r *repository) paySettlementBatchesTx(ctx context.Context, tx *sql.Tx, merchantID string) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	unpaidStmt := squirrel.Select("1").From("settlements s").Where(squirrel.And{
+		squirrel.Expr("s.batch_id = settlement_batches.id"),
+		squirrel.NotEq{"s.status": inconst.SETTLEMENT_STATUS_PAID},
+		squirrel.Eq{"s.deleted_at": nil},
+	})
+
+	stmt, args, err := pgSquirrel.Update("settlement_batches").SetMap(map[string]interface{}{
+		"status":     inconst.SETTLEMENT_STATUS_PAID,
+		"paid_at":    time.Now(),
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"merchant_id": merchantID},
+		squirrel.Eq{"status": []int64{inconst.SETTLEMENT_STATUS_BATCHED, inconst.SETTLEMENT_STATUS_FAILED}},
+		squirrel.Expr("not exists (?)", unpaidStmt),
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
 
 	return
 }
@@ -222,8 +375,6 @@ func (r *repository) deleteSettlementTx(ctx context.Context, tx *sql.Tx, params
 
 	if params.SettlementID != 0 {
 		cond = append(cond, squirrel.Eq{"id": params.SettlementID})
-	} else if params.BeneficiaryID != 0 {
-		cond = append(cond, squirrel.Eq{"beneficiary_id": params.BeneficiaryID})
 	} else if params.TransactionID != 0 {
 		cond = append(cond, squirrel.Eq{"transaction_id": params.TransactionID})
 	}
@@ -237,7 +388,7 @@ func (r *repository) deleteSettlementTx(ctx context.Context, tx *sql.Tx, params
 		return
 	}
 
-	_, err = r.db.ExecContext(ctx, stmt, args...)
+	_, err = tx.ExecContext(ctx, stmt, args...)
 	if err != nil {
 		logger.Error().Err(err).Msg("sql err")
 		return
@@ -246,16 +397,102 @@ func (r *repository) deleteSettlementTx(ctx context.Context, tx *sql.Tx, params
 	return
 }
 
-func (r *repository) FindPendingSettlement(ctx context.Context, params *indto.SettlementParams) (res float64, err error) {
+// unwindSettlementTx takes amount back from the unallocated part of the settlement of transactionID and fee from its batch totals,
+// settled reports that too little is left unallocated so nothing could be unwound
+func (r *repository) unwindSettlementTx(ctx context.Context, tx *sql.Tx, transactionID uint64, amount, fee money.Money) (settled bool, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"transaction_id": transactionID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Select("id", "merchant_id", "batch_id", "amount", "allocated_amount").From("settlements").
+		Where(cond).Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	temp := &model.Settlement{}
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&temp.ID, &temp.MerchantID, &temp.BatchID, &temp.Amount, &temp.AllocatedAmount)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows || temp.Amount.Sub(temp.AllocatedAmount).LessThan(amount) {
+		return true, nil
+	}
+
+	temp.Amount = temp.Amount.Sub(amount)
+
+	values := map[string]interface{}{
+		"amount":     temp.Amount,
+		"updated_at": time.Now(),
+	}
+
+	if temp.Amount.IsZero() {
+		values["deleted_at"] = time.Now()
+	}
+
+	stmt, args, err = pgSquirrel.Update("settlements").SetMap(values).Where(squirrel.Eq{"id": temp.ID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	// what is left may already be allocated in full
+	if temp.AllocatedAmount.IsPositive() && temp.Amount.Cmp(temp.AllocatedAmount) == 0 {
+		if err = r.paySettlementsTx(ctx, tx, temp.MerchantID, squirrel.Eq{"s.id": temp.ID}); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	}
+
+	if temp.BatchID == 0 {
+		return false, nil
+	}
+
+	batchValues := map[string]interface{}{
+		"total_amount": squirrel.Expr("total_amount - ?", amount),
+		"fee_amount":   squirrel.Expr("fee_amount - ?", fee),
+		"updated_at":   time.Now(),
+	}
+
+	if temp.Amount.IsZero() {
+		batchValues["settlement_count"] = squirrel.Expr("settlement_count - 1")
+	}
+
+	stmt, args, err = pgSquirrel.Update("settlement_batches").SetMap(batchValues).Where(squirrel.Eq{"id": temp.BatchID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return false, nil
+}
+
+func (r *repository) FindPendingSettlement(ctx context.Context, params *indto.SettlementParams) (res money.Money, err error) {
 	logger := zerolog.Ctx(ctx)
 
 	cond := squirrel.And{
 		squirrel.Eq{"s.deleted_at": nil},
-		squirrel.Eq{"s.beneficiary_id": 0},
+		squirrel.Expr("s.allocated_amount < s.amount"),
 		squirrel.Eq{"s.merchant_id": params.MerchantID},
 	}
 
-	stmt, args, err := pgSquirrel.Select("coalesce(sum(amount), 0)").From("settlements s").Where(cond).ToSql()
+	stmt, args, err := pgSquirrel.Select("coalesce(sum(amount - allocated_amount), 0)").From("settlements s").Where(cond).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
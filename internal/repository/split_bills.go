@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var splitBillColumns = []string{
+	"b.id", "b.initiator_account_id", "b.total", "b.description", "b.split_type", "b.status", "b.created_at",
+	fmt.Sprintf("coalesce((select sum(s.amount) from split_bill_shares s where s.split_id = b.id and s.status = %d), 0) settled", inconst.SPLIT_SHARE_STATUS_PAID),
+	fmt.Sprintf("(select count(*) from split_bill_shares s where s.split_id = b.id and s.status = %d) paid_shares", inconst.SPLIT_SHARE_STATUS_PAID),
+	"(select count(*) from split_bill_shares s where s.split_id = b.id) total_shares",
+}
+
+var splitBillShareColumns = []string{
+	"id", "split_id", "account_id", "amount", "status", "transaction_id", "reminder_count", "reminded_at", "paid_at",
+}
+
+func (r *repository) FindSplitBills(ctx context.Context, params *indto.SplitBillParams) (res []*indto.SplitBill, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select(splitBillColumns...).From("split_bills b").Where(splitBillCond(params)).OrderBy("b.created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.SplitBill{}
+	for rows.Next() {
+		temp := &indto.SplitBill{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountSplitBills(ctx context.Context, params *indto.SplitBillParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("split_bills b").Where(splitBillCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindSplitBill(ctx context.Context, params *indto.SplitBillParams) (res *indto.SplitBill, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(splitBillColumns...).From("split_bills b").Where(splitBillCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.SplitBill{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) FindSplitBillShares(ctx context.Context, params *indto.SplitBillShareParams) (res []*indto.SplitBillShare, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(splitBillShareColumns...).From("split_bill_shares").
+		Where(splitBillShareCond(params)).OrderBy("created_at", "id").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.SplitBillShare{}
+	for rows.Next() {
+		temp := &indto.SplitBillShare{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// CreateSplitBill records the split with all of its shares, shares created as paid are stamped paid right away
+func (r *repository) CreateSplitBill(ctx context.Context, payload *model.SplitBill) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Insert("split_bills").
+		Columns("id", "initiator_account_id", "total", "description", "split_type", "status").
+		Values(payload.ID, payload.InitiatorAccountID, payload.Total, payload.Description, payload.SplitType, payload.Status).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	now := time.Now()
+	baseStmt := pgSquirrel.Insert("split_bill_shares").Columns("id", "split_id", "account_id", "amount", "status", "paid_at")
+	for _, v := range payload.Shares {
+		v.ID = snowflake.ID()
+		v.SplitID = payload.ID
+		paidAt := sql.NullTime{Time: now, Valid: v.Status == inconst.SPLIT_SHARE_STATUS_PAID}
+		baseStmt = baseStmt.Values(v.ID, v.SplitID, v.AccountID, v.Amount, v.Status, paidAt)
+	}
+
+	stmt, args, err = baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if isUniqueViolation(err) {
+		err = errs.ErrDuplicatedResources
+		logger.Error().Err(err).Msg("split bill participant listed twice")
+		return
+	} else if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// PaySplitBillShare records trx as the payment of the pending share of payload.AccountID in an open split, in the
+// same database transaction that moves the money so either both happen or neither does. The split is settled along
+// with its last outstanding share, any other share returns ErrSplitBillClosed
+func (r *repository) PaySplitBillShare(ctx context.Context, payload *model.SplitBillShare, trx *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	// the split is locked first so shares paid concurrently and a cancellation are applied one at a time,
+	// otherwise the last two shares paid together could each miss the other and leave the split open
+	stmt, args, err := pgSquirrel.Select("status").From("split_bills").Where(squirrel.Eq{"id": payload.SplitID}).Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	var status int64
+	if err = tx.QueryRowContext(ctx, stmt, args...).Scan(&status); err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows || status != inconst.SPLIT_BILL_STATUS_OPEN {
+		err = errs.ErrSplitBillClosed
+		logger.Error().Err(err).Uint64("split-id", payload.SplitID).Msg("split bill not open")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Update("split_bill_shares").SetMap(map[string]interface{}{
+		"status":         inconst.SPLIT_SHARE_STATUS_PAID,
+		"transaction_id": trx.ID,
+		"paid_at":        time.Now(),
+		"updated_at":     time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"split_id": payload.SplitID},
+		squirrel.Eq{"account_id": payload.AccountID},
+		squirrel.Eq{"status": inconst.SPLIT_SHARE_STATUS_PENDING},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrSplitBillClosed
+		logger.Error().Err(err).Uint64("split-id", payload.SplitID).Msg("split bill share not paid")
+		return
+	}
+
+	if err = r.createTransactionP2PTx(ctx, tx, trx); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Update("split_bills").SetMap(map[string]interface{}{
+		"status":     inconst.SPLIT_BILL_STATUS_SETTLED,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.SplitID},
+		squirrel.Expr("not exists (select 1 from split_bill_shares s where s.split_id = split_bills.id and s.status in (?, ?))",
+			inconst.SPLIT_SHARE_STATUS_PENDING, inconst.SPLIT_SHARE_STATUS_PROCESSING),
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// CancelSplitBill closes an open split of params.InitiatorAccountID and cancels its pending shares, paid shares are kept.
+// A split with a share being paid cannot be cancelled
+func (r *repository) CancelSplitBill(ctx context.Context, params *indto.SplitBillParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("split_bills").SetMap(map[string]interface{}{
+		"status":     inconst.SPLIT_BILL_STATUS_CANCELLED,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": params.SplitID},
+		squirrel.Eq{"initiator_account_id": params.InitiatorAccountID},
+		squirrel.Eq{"status": inconst.SPLIT_BILL_STATUS_OPEN},
+		squirrel.Expr("not exists (select 1 from split_bill_shares s where s.split_id = split_bills.id and s.status = ?)", inconst.SPLIT_SHARE_STATUS_PROCESSING),
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrSplitBillClosed
+		logger.Error().Err(err).Uint64("split-id", params.SplitID).Msg("split bill not cancelled")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Update("split_bill_shares").SetMap(map[string]interface{}{
+		"status":     inconst.SPLIT_SHARE_STATUS_CANCELLED,
+		"updated_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"split_id": params.SplitID},
+		squirrel.Eq{"status": inconst.SPLIT_SHARE_STATUS_PENDING},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// RemindSplitBillShares stamps params.ShareIDs as reminded now
+func (r *repository) RemindSplitBillShares(ctx context.Context, params *indto.SplitBillShareParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(params.ShareIDs) == 0 {
+		return
+	}
+
+	stmt, args, err := pgSquirrel.Update("split_bill_shares").
+		Set("reminder_count", squirrel.Expr("reminder_count + 1")).
+		Set("reminded_at", time.Now()).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": params.ShareIDs}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = r.db.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func splitBillCond(params *indto.SplitBillParams) squirrel.And {
+	cond := squirrel.And{}
+
+	if params.SplitID != 0 {
+		cond = append(cond, squirrel.Eq{"b.id": params.SplitID})
+	}
+
+	if params.InitiatorAccountID != "" {
+		cond = append(cond, squirrel.Eq{"b.initiator_account_id": params.InitiatorAccountID})
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Or{
+			squirrel.Eq{"b.initiator_account_id": params.AccountID},
+			squirrel.Expr("exists (select 1 from split_bill_shares s where s.split_id = b.id and s.account_id = ?)", params.AccountID),
+		})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"b.status": params.Status})
+	}
+
+	return cond
+}
+
+func splitBillShareCond(params *indto.SplitBillShareParams) squirrel.And {
+	cond := squirrel.And{}
+
+	if len(params.ShareIDs) != 0 {
+		cond = append(cond, squirrel.Eq{"id": params.ShareIDs})
+	}
+
+	if params.SplitID != 0 {
+		cond = append(cond, squirrel.Eq{"split_id": params.SplitID})
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Eq{"account_id": params.AccountID})
+	}
+
+	if len(params.Statuses) != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Statuses})
+	}
+
+	return cond
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func testP2PTransaction(accountID string, recipientID string, nominal money.Money) *model.Transaction {
+	return &model.Transaction{
+		ID:          snowflake.ID(),
+		AccountID:   accountID,
+		RecipientID: recipientID,
+		TrxType:     inconst.TRX_TYPE_P2P,
+		TrxDatetime: time.Now(),
+		TrxStatus:   inconst.TRX_STATUS_SUCCESS,
+		TrxFee:      money.Zero(),
+		Nominal:     nominal,
+		Description: "test split share",
+	}
+}
+
+func createTestSplitBill(t *testing.T, r *repository, initiatorID string, share money.Money, participants ...string) uint64 {
+	t.Helper()
+
+	splitModel := &model.SplitBill{
+		ID:                 snowflake.ID(),
+		InitiatorAccountID: initiatorID,
+		Total:              money.FromMinor(share.Minor() * int64(len(participants))),
+		Description:        "test split",
+		SplitType:          inconst.SPLIT_BILL_TYPE_CUSTOM,
+		Status:             inconst.SPLIT_BILL_STATUS_OPEN,
+	}
+
+	for _, v := range participants {
+		splitModel.Shares = append(splitModel.Shares, &model.SplitBillShare{
+			ID:        snowflake.ID(),
+			SplitID:   splitModel.ID,
+			AccountID: v,
+			Amount:    share,
+			Status:    inconst.SPLIT_SHARE_STATUS_PENDING,
+		})
+	}
+
+	if err := r.CreateSplitBill(context.Background(), splitModel); err != nil {
+		t.Fatalf("failed to create split bill: %v", err)
+	}
+
+	return splitModel.ID
+}
+
+func findTestSplitBillStatus(t *testing.T, r *repository, id uint64) (status int64, paid int64) {
+	t.Helper()
+
+	err := r.db.QueryRowx("select status, (select count(*) from split_bill_shares where split_id = $1 and status = $2) from split_bills where id = $1",
+		id, inconst.SPLIT_SHARE_STATUS_PAID).Scan(&status, &paid)
+	if err != nil {
+		t.Fatalf("failed to read split bill %d: %v", id, err)
+	}
+
+	return
+}
+
+func TestPaySplitBillShareRejectedPaymentKeepsSharePending(t *testing.T) {
+	r := newTestRepository(t)
+
+	initiatorID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.Zero())
+	participantID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("10"))
+
+	splitID := createTestSplitBill(t, r, initiatorID, money.MustParse("25"), participantID)
+
+	trx := testP2PTransaction(participantID, initiatorID, money.MustParse("25"))
+	err := r.PaySplitBillShare(context.Background(), &model.SplitBillShare{SplitID: splitID, AccountID: participantID}, trx)
+	if !errors.Is(err, errs.ErrInsufficientBalance) {
+		t.Fatalf("PaySplitBillShare() err = %v, want %v", err, errs.ErrInsufficientBalance)
+	}
+
+	if status, paid := findTestSplitBillStatus(t, r, splitID); status != inconst.SPLIT_BILL_STATUS_OPEN || paid != 0 {
+		t.Errorf("split status = %d, paid shares = %d after a rejected payment, want it open and unpaid", status, paid)
+	}
+}
+
+// every share paid at once must still settle the split, and a share paid twice at once must move money once
+func TestPaySplitBillSharesConcurrently(t *testing.T) {
+	r := newTestRepository(t)
+
+	const participants, attemptsPerShare = 8, 3
+
+	initiatorID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.Zero())
+
+	participantIDs := make([]string, participants)
+	for i := range participantIDs {
+		participantIDs[i] = createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.MustParse("100"))
+	}
+
+	share := money.MustParse("10")
+	splitID := createTestSplitBill(t, r, initiatorID, share, participantIDs...)
+
+	errCh := make(chan error, participants*attemptsPerShare)
+
+	start := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for _, participantID := range participantIDs {
+		for i := 0; i < attemptsPerShare; i++ {
+			wg.Add(1)
+			go func(participantID string) {
+				defer wg.Done()
+				<-start
+
+				trx := testP2PTransaction(participantID, initiatorID, share)
+				errCh <- r.PaySplitBillShare(context.Background(), &model.SplitBillShare{SplitID: splitID, AccountID: participantID}, trx)
+			}(participantID)
+		}
+	}
+
+	close(start)
+	wg.Wait()
+	close(errCh)
+
+	var paid int
+	for err := range errCh {
+		switch {
+		case err == nil:
+			paid++
+		case errors.Is(err, errs.ErrSplitBillClosed):
+		default:
+			t.Errorf("unexpected payment err: %v", err)
+		}
+	}
+
+	if paid != participants {
+		t.Fatalf("paid = %d, want each of the %d shares paid once", paid, participants)
+	}
+
+	if status, paidShares := findTestSplitBillStatus(t, r, splitID); status != inconst.SPLIT_BILL_STATUS_SETTLED || paidShares != participants {
+		t.Errorf("split status = %d, paid shares = %d, want settled with %d paid", status, paidShares, participants)
+	}
+
+	if balance := findTestBalance(t, r, initiatorID); balance.Cmp(money.FromMinor(share.Minor()*participants)) != 0 {
+		t.Errorf("initiator balance = %s, want %s", balance, money.FromMinor(share.Minor()*participants))
+	}
+}
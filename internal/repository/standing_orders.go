@@ -0,0 +1,392 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var standingOrderColumns = []string{
+	"id", "user_id", "account_id", "recipient_id", "trx_type", "nominal", "description", "recurrence", "start_at", "end_at",
+	"max_occurrences", "occurrences", "insufficient_balance_policy", "status", "next_occurrence_at", "next_run_at", "attempts",
+	"transaction_id",
+}
+
+func (r *repository) FindStandingOrders(ctx context.Context, params *indto.StandingOrderParams) (res []*indto.StandingOrder, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	baseStmt := pgSquirrel.Select(standingOrderColumns...).From("standing_orders").
+		Where(cond).OrderBy("created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.StandingOrder{}
+	for rows.Next() {
+		temp := &indto.StandingOrder{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountStandingOrders(ctx context.Context, params *indto.StandingOrderParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("standing_orders").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindStandingOrder(ctx context.Context, params *indto.StandingOrderParams) (res *indto.StandingOrder, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.OrderID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	stmt, args, err := pgSquirrel.Select(standingOrderColumns...).From("standing_orders").Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.StandingOrder{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) CreateStandingOrder(ctx context.Context, payload *model.StandingOrder) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("standing_orders").
+		Columns("id", "user_id", "account_id", "recipient_id", "trx_type", "nominal", "description", "recurrence", "start_at", "end_at",
+			"max_occurrences", "insufficient_balance_policy", "status", "next_occurrence_at", "next_run_at").
+		Values(payload.ID, payload.UserID, payload.AccountID, payload.RecipientID, payload.TrxType, payload.Nominal, payload.Description, payload.Recurrence, payload.StartAt, payload.EndAt,
+			payload.MaxOccurrences, payload.InsufficientBalancePolicy, payload.Status, payload.NextOccurrenceAt, payload.NextRunAt).
+		ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// ClaimDueStandingOrders leases up to limit due orders as processing and counts the attempt on their current
+// occurrence. An order whose lease passed without a run recorded is claimed again, so a worker that dies halfway only
+// delays it. Rows claimed by another worker are skipped so each occurrence is executed by one worker at a time
+func (r *repository) ClaimDueStandingOrders(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.StandingOrder, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	dueStmt := squirrel.Select("id").From("standing_orders").Where(squirrel.And{
+		squirrel.Or{
+			squirrel.And{
+				squirrel.Eq{"status": inconst.STANDING_ORDER_STATUS_ACTIVE},
+				squirrel.LtOrEq{"next_run_at": time.Now()},
+			},
+			squirrel.And{
+				squirrel.Eq{"status": inconst.STANDING_ORDER_STATUS_PROCESSING},
+				squirrel.LtOrEq{"locked_until": time.Now()},
+			},
+		},
+		squirrel.Eq{"deleted_at": nil},
+	}).OrderBy("next_run_at").Limit(limit).Suffix("for update skip locked")
+
+	stmt, args, err := pgSquirrel.Update("standing_orders").SetMap(map[string]interface{}{
+		"status":       inconst.STANDING_ORDER_STATUS_PROCESSING,
+		"attempts":     squirrel.Expr("attempts + 1"),
+		"locked_until": time.Now().Add(lease),
+		"updated_at":   time.Now(),
+	}).Where(squirrel.Expr("id in (?)", dueStmt)).Suffix("returning " + strings.Join(standingOrderColumns, ", ")).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.StandingOrder{}
+	for rows.Next() {
+		temp := &indto.StandingOrder{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// ReserveStandingOrderTransaction stores the id the transfer of the claimed occurrence will be made with, before it
+// is made. An order reclaimed after its worker died finds the id and checks whether that transfer went through
+func (r *repository) ReserveStandingOrderTransaction(ctx context.Context, payload *model.StandingOrder) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("standing_orders").SetMap(map[string]interface{}{
+		"transaction_id": payload.TransactionID,
+		"updated_at":     time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": inconst.STANDING_ORDER_STATUS_PROCESSING},
+		squirrel.Eq{"transaction_id": 0},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrStandingOrderClosed
+		logger.Error().Err(err).Uint64("order-id", payload.ID).Msg("standing order transaction not reserved")
+		return
+	}
+
+	return
+}
+
+// UpdateStandingOrderRun records run in the order history and moves the order to its next state in one tx,
+// releasing its lease and reserved transaction id
+func (r *repository) UpdateStandingOrderRun(ctx context.Context, payload *model.StandingOrder, run *model.StandingOrderRun) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	stmt, args, err := pgSquirrel.Update("standing_orders").SetMap(map[string]interface{}{
+		"occurrences":        payload.Occurrences,
+		"status":             payload.Status,
+		"next_occurrence_at": payload.NextOccurrenceAt,
+		"next_run_at":        payload.NextRunAt,
+		"attempts":           payload.Attempts,
+		"locked_until":       nil,
+		"transaction_id":     0,
+		"updated_at":         time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	stmt, args, err = pgSquirrel.Insert("standing_order_runs").
+		Columns("id", "order_id", "occurrence_at", "attempt", "status", "transaction_id", "error_message").
+		Values(snowflake.ID(), payload.ID, run.OccurrenceAt, run.Attempt, run.Status, run.TransactionID, run.ErrorMessage).
+		ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = tx.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// CancelStandingOrder stops an active order, an order being processed or already finished returns ErrStandingOrderClosed
+func (r *repository) CancelStandingOrder(ctx context.Context, params *indto.StandingOrderParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": params.OrderID},
+		squirrel.Eq{"status": inconst.STANDING_ORDER_STATUS_ACTIVE},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.UserID != "" {
+		cond = append(cond, squirrel.Eq{"user_id": params.UserID})
+	}
+
+	stmt, args, err := pgSquirrel.Update("standing_orders").SetMap(map[string]interface{}{
+		"status":     inconst.STANDING_ORDER_STATUS_CANCELLED,
+		"updated_at": time.Now(),
+	}).Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrStandingOrderClosed
+		logger.Error().Err(err).Uint64("order-id", params.OrderID).Msg("standing order not cancelled")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindStandingOrderRuns(ctx context.Context, params *indto.StandingOrderParams) (res []*indto.StandingOrderRun, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select("id", "order_id", "occurrence_at", "attempt", "status", "transaction_id", "error_message", "created_at").
+		From("standing_order_runs").Where(squirrel.Eq{"order_id": params.OrderID}).OrderBy("created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.StandingOrderRun{}
+	for rows.Next() {
+		temp := &indto.StandingOrderRun{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountStandingOrderRuns(ctx context.Context, params *indto.StandingOrderParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("standing_order_runs").Where(squirrel.Eq{"order_id": params.OrderID}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/google/uuid"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func TestClaimDueStandingOrdersLease(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	id := snowflake.ID()
+	err := r.CreateStandingOrder(ctx, &model.StandingOrder{
+		ID:                        id,
+		UserID:                    uuid.NewString(),
+		AccountID:                 uuid.NewString(),
+		RecipientID:               uuid.NewString(),
+		TrxType:                   inconst.TRX_TYPE_P2P,
+		Nominal:                   money.MustParse("10"),
+		Recurrence:                "FREQ=DAILY",
+		StartAt:                   time.Now().Add(-time.Minute),
+		InsufficientBalancePolicy: inconst.STANDING_ORDER_POLICY_SKIP,
+		Status:                    inconst.STANDING_ORDER_STATUS_ACTIVE,
+		NextOccurrenceAt:          time.Now().Add(-time.Minute),
+		NextRunAt:                 time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("failed to create standing order: %v", err)
+	}
+
+	// other tests share the database, only the order created here is looked at
+	claim := func() *indto.StandingOrder {
+		t.Helper()
+
+		data, err := r.ClaimDueStandingOrders(ctx, 1000, time.Hour)
+		if err != nil {
+			t.Fatalf("failed to claim standing orders: %v", err)
+		}
+
+		for _, v := range data {
+			if v.ID == id {
+				return v
+			}
+		}
+
+		return nil
+	}
+
+	first := claim()
+	if first == nil {
+		t.Fatal("due standing order was not claimed")
+	} else if first.Status != inconst.STANDING_ORDER_STATUS_PROCESSING || first.Attempts != 1 || first.TransactionID != 0 {
+		t.Fatalf("claimed order status = %d, attempts = %d, transaction id = %d, want %d, 1 and 0",
+			first.Status, first.Attempts, first.TransactionID, inconst.STANDING_ORDER_STATUS_PROCESSING)
+	}
+
+	trxID := snowflake.ID()
+	if err = r.ReserveStandingOrderTransaction(ctx, &model.StandingOrder{ID: id, TransactionID: trxID}); err != nil {
+		t.Fatalf("ReserveStandingOrderTransaction() unexpected err: %v", err)
+	}
+
+	// the reserved id is kept, another reservation must not replace it
+	if err = r.ReserveStandingOrderTransaction(ctx, &model.StandingOrder{ID: id, TransactionID: snowflake.ID()}); !errors.Is(err, errs.ErrStandingOrderClosed) {
+		t.Fatalf("second ReserveStandingOrderTransaction() err = %v, want %v", err, errs.ErrStandingOrderClosed)
+	}
+
+	if claim() != nil {
+		t.Fatal("standing order was claimed again while its lease holds")
+	}
+
+	if _, err = r.db.Exec("update standing_orders set locked_until = now() - interval '1 second' where id = $1", id); err != nil {
+		t.Fatalf("failed to expire lease: %v", err)
+	}
+
+	reclaimed := claim()
+	if reclaimed == nil {
+		t.Fatal("standing order was not reclaimed after its lease passed")
+	} else if reclaimed.Attempts != 2 || reclaimed.TransactionID != trxID {
+		t.Fatalf("reclaimed order attempts = %d, transaction id = %d, want 2 and %d", reclaimed.Attempts, reclaimed.TransactionID, trxID)
+	}
+
+	// recording the run releases the lease and the reserved id
+	err = r.UpdateStandingOrderRun(ctx, &model.StandingOrder{
+		ID:               id,
+		Occurrences:      1,
+		Status:           inconst.STANDING_ORDER_STATUS_ACTIVE,
+		NextOccurrenceAt: time.Now().Add(24 * time.Hour),
+		NextRunAt:        time.Now().Add(24 * time.Hour),
+	}, &model.StandingOrderRun{
+		OccurrenceAt:  reclaimed.NextOccurrenceAt,
+		Attempt:       reclaimed.Attempts,
+		Status:        inconst.STANDING_ORDER_RUN_SUCCESS,
+		TransactionID: trxID,
+	})
+	if err != nil {
+		t.Fatalf("UpdateStandingOrderRun() unexpected err: %v", err)
+	}
+
+	data, err := r.FindStandingOrder(ctx, &indto.StandingOrderParams{OrderID: id})
+	if err != nil || data == nil {
+		t.Fatalf("FindStandingOrder() = %v, %v", data, err)
+	}
+
+	if data.Status != inconst.STANDING_ORDER_STATUS_ACTIVE || data.TransactionID != 0 {
+		t.Errorf("order status = %d, transaction id = %d, want %d and 0", data.Status, data.TransactionID, inconst.STANDING_ORDER_STATUS_ACTIVE)
+	}
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// FindStatementEntries lists postings of the account within [DateStart, DateEnd) in booking order
+func (r *repository) FindStatementEntries(ctx context.Context, params *indto.StatementParams) (res []*indto.StatementEntry, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"j.deleted_at": nil},
+		squirrel.Eq{"p.account_id": params.AccountID},
+		squirrel.GtOrEq{"j.entry_datetime": params.DateStart},
+		squirrel.Lt{"j.entry_datetime": params.DateEnd},
+	}
+
+	stmt, args, err := pgSquirrel.Select(
+		"p.id", "j.transaction_id", "j.entry_type", "j.description", "j.entry_datetime", "p.amount",
+		"cc.legal_name customer_name", "cm.name merchant_name").
+		From("journal_postings p").
+		Join("journal_entries j on p.journal_id = j.id").
+		LeftJoin("transactions t on j.transaction_id = t.id").
+		LeftJoin("accounts ca on ca.id = case when t.account_id = p.account_id then t.recipient_id else t.account_id end").
+		LeftJoin("customers cc on ca.owner_id = cc.user_id").
+		LeftJoin("merchants cm on ca.owner_id = cm.user_id").
+		Where(cond).OrderBy("j.entry_datetime", "p.id").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.StatementEntry{}
+	for rows.Next() {
+		temp := &indto.StatementEntry{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// FindStatementOpeningBalance sums every posting of the account booked before DateStart
+func (r *repository) FindStatementOpeningBalance(ctx context.Context, params *indto.StatementParams) (res money.Money, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"j.deleted_at": nil},
+		squirrel.Eq{"p.account_id": params.AccountID},
+		squirrel.Lt{"j.entry_datetime": params.DateStart},
+	}
+
+	stmt, args, err := pgSquirrel.Select("coalesce(sum(p.amount), 0)").From("journal_postings p").
+		Join("journal_entries j on p.journal_id = j.id").
+		Where(cond).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
@@ -8,8 +8,12 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/godruoyi/go-snowflake"
 	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 func (r *repository) FindTransactions(ctx context.Context, params *indto.TransactionParams) (res []*indto.Transaction, err error) {
@@ -40,13 +44,14 @@ func (r *repository) FindTransactions(ctx context.Context, params *indto.Transac
 	}
 
 	baseStmt := pgSquirrel.Select(
-		"t.id", "t.account_id", "c1.legal_name account_name", "t.recipient_id", "coalesce(c2.legal_name, convert_to(m2.name, 'utf-8')) recipient_name",
-		"t.trx_type", "t.trx_datetime", "t.trx_status", "t.trx_fee", "t.nominal", "t.description").
+		"t.id", "t.parent_id", "t.account_id", "c1.legal_name account_name", "t.recipient_id", "coalesce(c2.legal_name, convert_to(m2.name, 'utf-8')) recipient_name",
+		"t.trx_type", "t.trx_datetime", "t.trx_status", "t.trx_fee", "t.fee_rule_id", "t.nominal", "t.description", "t.refunded_amount",
+		"t.authorized_amount", "t.expires_at").
 		From("transactions t").
 		LeftJoin("accounts a1 on t.account_id = a1.id and t.trx_type not in (3, 9)").
 		LeftJoin("customers c1 on a1.owner_id = c1.user_id").
 		LeftJoin("accounts a2 on t.recipient_id = a2.id").
-		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 9)").
+		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 4, 9)").
 		LeftJoin("merchants m2 on a2.owner_id = m2.user_id and t.trx_type in (2, 3, 8)").
 		Where(cond).OrderBy("t.trx_datetime desc")
 
@@ -112,7 +117,7 @@ func (r *repository) CountTransactions(ctx context.Context, params *indto.Transa
 		LeftJoin("accounts a1 on t.account_id = a1.id and t.trx_type not in (3, 9)").
 		LeftJoin("customers c1 on a1.owner_id = c1.user_id").
 		LeftJoin("accounts a2 on t.recipient_id = a2.id").
-		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 9)").
+		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 4, 9)").
 		LeftJoin("merchants m2 on a2.owner_id = m2.user_id and t.trx_type in (2, 3, 9)").
 		Where(cond).ToSql()
 	if err != nil {
@@ -129,6 +134,79 @@ func (r *repository) CountTransactions(ctx context.Context, params *indto.Transa
 	return
 }
 
+// StreamTransactions walks the filtered transactions oldest first and hands every row to fn as it is read from the cursor,
+// returning early with the error of fn
+func (r *repository) StreamTransactions(ctx context.Context, params *indto.TransactionParams, fn func(*indto.Transaction) error) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"t.deleted_at": nil},
+	}
+
+	if !params.DateStart.IsZero() && !params.DateEnd.IsZero() {
+		cond = append(cond,
+			squirrel.Expr("date(t.trx_datetime) >= date(?)", params.DateStart),
+			squirrel.Expr("date(t.trx_datetime) <= date(?)", params.DateEnd),
+		)
+	}
+
+	if params.AccountID != "" {
+		cond = append(cond, squirrel.Or{
+			squirrel.Eq{"t.account_id": params.AccountID},
+			squirrel.Eq{"t.recipient_id": params.AccountID},
+		})
+	}
+
+	if params.TrxType != 0 {
+		cond = append(cond, squirrel.Eq{"t.trx_type": params.TrxType})
+	} else if len(params.TrxTypes) != 0 {
+		cond = append(cond, squirrel.Eq{"t.trx_type": params.TrxTypes})
+	}
+
+	stmt, args, err := pgSquirrel.Select(
+		"t.id", "t.parent_id", "t.account_id", "c1.legal_name account_name", "t.recipient_id", "coalesce(c2.legal_name, convert_to(m2.name, 'utf-8')) recipient_name",
+		"t.trx_type", "t.trx_datetime", "t.trx_status", "t.trx_fee", "t.fee_rule_id", "t.nominal", "t.description", "t.refunded_amount",
+		"t.authorized_amount", "t.expires_at").
+		From("transactions t").
+		LeftJoin("accounts a1 on t.account_id = a1.id and t.trx_type not in (3, 9)").
+		LeftJoin("customers c1 on a1.owner_id = c1.user_id").
+		LeftJoin("accounts a2 on t.recipient_id = a2.id").
+		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 4, 9)").
+		LeftJoin("merchants m2 on a2.owner_id = m2.user_id and t.trx_type in (2, 3, 8)").
+		Where(cond).OrderBy("t.trx_datetime", "t.id").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		temp := &indto.Transaction{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		if err = fn(temp); err != nil {
+			return
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
 func (r *repository) FindTransaction(ctx context.Context, params *indto.TransactionParams) (res *indto.Transaction, err error) {
 	logger := zerolog.Ctx(ctx)
 
@@ -138,13 +216,14 @@ func (r *repository) FindTransaction(ctx context.Context, params *indto.Transact
 	}
 
 	stmt, args, err := pgSquirrel.Select(
-		"t.id", "t.account_id", "c1.legal_name account_name", "t.recipient_id", "coalesce(c2.legal_name, m2.name::bytea) recipient_name",
-		"t.trx_type", "t.trx_datetime", "t.trx_status", "t.trx_fee", "t.nominal", "t.description").
+		"t.id", "t.parent_id", "t.account_id", "c1.legal_name account_name", "t.recipient_id", "coalesce(c2.legal_name, m2.name::bytea) recipient_name",
+		"t.trx_type", "t.trx_datetime", "t.trx_status", "t.trx_fee", "t.fee_rule_id", "t.nominal", "t.description", "t.refunded_amount",
+		"t.authorized_amount", "t.expires_at").
 		From("transactions t").
 		LeftJoin("accounts a1 on t.account_id = a1.id and t.trx_type not in (3, 9)").
 		LeftJoin("customers c1 on a1.owner_id = c1.user_id").
 		LeftJoin("accounts a2 on t.recipient_id = a2.id").
-		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 9)").
+		LeftJoin("customers c2 on a2.owner_id = c2.user_id and t.trx_type in (1, 4, 9)").
 		LeftJoin("merchants m2 on a2.owner_id = m2.user_id and t.trx_type in (2, 3, 9)").
 		Where(cond).ToSql()
 	if err != nil {
@@ -174,8 +253,32 @@ func (r *repository) CreateTransactionP2P(ctx context.Context, payload *model.Tr
 	}
 	defer tx.Rollback()
 
+	if err = r.createTransactionP2PTx(ctx, tx, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// createTransactionP2PTx moves payload between two accounts within tx, so callers can record what the transfer
+// pays for in the same database transaction
+func (r *repository) createTransactionP2PTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
+
+	if err = r.checkSenderBalanceTx(ctx, tx, payload, payload.AccountID, payload.RecipientID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
 	// substract sender's fund
-	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.AccountID, Balance: payload.Nominal + payload.TrxFee})
+	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.AccountID, Balance: payload.Nominal.Add(payload.TrxFee)})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
@@ -183,7 +286,7 @@ func (r *repository) CreateTransactionP2P(ctx context.Context, payload *model.Tr
 
 	// add receiver's fund
 	// minus value used to denote addition
-	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.RecipientID, Balance: -payload.Nominal})
+	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.RecipientID, Balance: payload.Nominal.Neg()})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
@@ -196,8 +299,13 @@ func (r *repository) CreateTransactionP2P(ctx context.Context, payload *model.Tr
 		return
 	}
 
-	if err = tx.Commit(); err != nil {
-		logger.Error().Err(err).Msg("tx err")
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(payload,
+		&model.JournalPosting{AccountID: payload.AccountID, Amount: payload.Nominal.Add(payload.TrxFee).Neg()},
+		&model.JournalPosting{AccountID: payload.RecipientID, Amount: payload.Nominal},
+		&model.JournalPosting{AccountID: conf.FeeAccountUUID, Amount: payload.TrxFee},
+	))
+	if err != nil {
+		logger.Error().Err(err).Send()
 		return
 	}
 
@@ -214,8 +322,32 @@ func (r *repository) CreateTransactionP2B(ctx context.Context, payload *model.Tr
 	}
 	defer tx.Rollback()
 
+	if err = r.createTransactionP2BTx(ctx, tx, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// createTransactionP2BTx moves payload from a customer to the settlement of its merchant within tx, so callers can
+// record what the payment is for in the same database transaction
+func (r *repository) createTransactionP2BTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
+
+	if err = r.checkSenderBalanceTx(ctx, tx, payload, payload.AccountID, payload.RecipientID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
 	// substract sender's fund
-	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.AccountID, Balance: payload.Nominal + payload.TrxFee})
+	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.AccountID, Balance: payload.Nominal.Add(payload.TrxFee)})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
@@ -232,17 +364,31 @@ func (r *repository) CreateTransactionP2B(ctx context.Context, payload *model.Tr
 		TransactionID:  payload.ID,
 		MerchantID:     payload.MerchantID,
 		Amount:         payload.Nominal,
+		Status:         inconst.SETTLEMENT_STATUS_OPEN,
 		SettlementDate: time.Now(),
 	})
-	if err = tx.Commit(); err != nil {
-		logger.Error().Err(err).Msg("tx err")
+	if err != nil {
+		logger.Error().Err(err).Send()
 		return
 	}
+
+	// merchant's share is parked on settlement account until withdrawn as beneficiary
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(payload,
+		&model.JournalPosting{AccountID: payload.AccountID, Amount: payload.Nominal.Add(payload.TrxFee).Neg()},
+		&model.JournalPosting{AccountID: conf.SettlementAccountUUID, Amount: payload.Nominal},
+		&model.JournalPosting{AccountID: conf.FeeAccountUUID, Amount: payload.TrxFee},
+	))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
 	return
 }
 
 func (r *repository) CreateTransactionSystem(ctx context.Context, payload *model.Transaction) (err error) {
 	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -252,7 +398,7 @@ func (r *repository) CreateTransactionSystem(ctx context.Context, payload *model
 	defer tx.Rollback()
 
 	// add sender's fund
-	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.RecipientID, Balance: -payload.Nominal})
+	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: payload.RecipientID, Balance: payload.Nominal.Neg()})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
@@ -265,6 +411,15 @@ func (r *repository) CreateTransactionSystem(ctx context.Context, payload *model
 		return
 	}
 
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(payload,
+		&model.JournalPosting{AccountID: conf.SystemAccountUUID, Amount: payload.Nominal.Neg()},
+		&model.JournalPosting{AccountID: payload.RecipientID, Amount: payload.Nominal},
+	))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
 	if err = tx.Commit(); err != nil {
 		logger.Error().Err(err).Msg("tx err")
 		return
@@ -272,11 +427,54 @@ func (r *repository) CreateTransactionSystem(ctx context.Context, payload *model
 	return
 }
 
+// checkSenderBalanceTx locks accountIDs for the rest of tx and verifies sender's available balance covers nominal and fee,
+// that no locked account status blocks it and that payload stays within sender's limit. Balance read here cannot change until tx ends so the following debit is race-free
+func (r *repository) checkSenderBalanceTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction, accountIDs ...string) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	accounts, err := r.lockAccountsTx(ctx, tx, accountIDs...)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	sender, ok := accounts[payload.AccountID]
+	if !ok {
+		err = errs.ErrNotFound
+		logger.Error().Err(err).Str("account-id", payload.AccountID).Msg("sender account not found")
+		return
+	}
+
+	for _, v := range accounts {
+		if !accountStatusAllows(v.Status, v.ID == payload.AccountID) {
+			err = errs.ErrAccountRestricted
+			logger.Error().Err(err).Str("account-id", v.ID).Int64("status", v.Status).Msg("account status blocks transaction")
+			return
+		}
+	}
+
+	available := sender.Balance.Sub(sender.HoldBalance)
+	if need := payload.Nominal.Add(payload.TrxFee); available.LessThan(need) {
+		err = errs.ErrInsufficientBalance
+		logger.Error().Err(err).Msgf("accountID: %s does not have enough balance. (has=%s, need=%s)", payload.AccountID, available, need)
+		return
+	}
+
+	if err = r.checkTransactionLimitTx(ctx, tx, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
 func (r *repository) CreateTransactionTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction) (res *model.Transaction, err error) {
 	logger := zerolog.Ctx(ctx)
 
-	stmt, args, err := pgSquirrel.Insert("transactions").Columns("id", "account_id", "recipient_id", "trx_type", "trx_datetime", "trx_status", "trx_fee", "nominal", "description").
-		Values(payload.ID, payload.AccountID, payload.RecipientID, payload.TrxType, payload.TrxDatetime, payload.TrxStatus, payload.TrxFee, payload.Nominal, payload.Description).ToSql()
+	stmt, args, err := pgSquirrel.Insert("transactions").Columns("id", "parent_id", "account_id", "recipient_id", "trx_type", "trx_datetime", "trx_status", "trx_fee", "fee_rule_id", "nominal", "description",
+		"authorized_amount", "hold_amount", "expires_at").
+		Values(payload.ID, payload.ParentID, payload.AccountID, payload.RecipientID, payload.TrxType, payload.TrxDatetime, payload.TrxStatus, payload.TrxFee, payload.FeeRuleID, payload.Nominal, payload.Description,
+			payload.AuthorizedAmount, payload.HoldAmount, payload.ExpiresAt).ToSql()
 	if err != nil {
 		logger.Error().Err(err).Msg("squirrel err")
 		return
@@ -340,3 +538,458 @@ func (r *repository) DeleteTransaction(ctx context.Context, params *indto.Transa
 
 	return
 }
+
+// CreateTransactionRefund reverses payload.Nominal of the parent transaction, zero nominal refunds whatever
+// is left. Fee is rebated proportionally, the last refund takes the remaining fee so no rounding residue is left
+func (r *repository) CreateTransactionRefund(ctx context.Context, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	// parent row lock serializes concurrent refunds of the same transaction
+	parent, err := r.lockTransactionTx(ctx, tx, payload.ParentID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if parent == nil {
+		err = errs.ErrNotFound
+		logger.Error().Err(err).Uint64("transaction-id", payload.ParentID).Msg("refunded transaction not found")
+		return
+	}
+
+	if parent.TrxType != inconst.TRX_TYPE_P2P && parent.TrxType != inconst.TRX_TYPE_P2B && parent.TrxType != inconst.TRX_TYPE_CUST_SYSTEM {
+		err = errs.ErrBadRequest
+		logger.Error().Err(err).Int64("trx-type", parent.TrxType).Msg("transaction type is not refundable")
+		return
+	}
+
+	if parent.TrxStatus != inconst.TRX_STATUS_SUCCESS && parent.TrxStatus != inconst.TRX_STATUS_PARTIALLY_REFUNDED {
+		err = errs.ErrBadRequest
+		logger.Error().Err(err).Int64("trx-status", parent.TrxStatus).Msg("transaction status is not refundable")
+		return
+	}
+
+	remaining := parent.Nominal.Sub(parent.RefundedAmount)
+	if payload.Nominal.IsZero() {
+		payload.Nominal = remaining
+	}
+
+	if !remaining.IsPositive() || payload.Nominal.GreaterThan(remaining) {
+		err = errs.ErrRefundExceeded
+		logger.Error().Err(err).Msgf("transactionID: %d refund exceeds refundable amount. (remaining=%s, requested=%s)", parent.ID, remaining, payload.Nominal)
+		return
+	}
+
+	remainingFee := parent.TrxFee.Sub(parent.RefundedFee)
+	fee := parent.TrxFee.MulRatio(payload.Nominal.Minor(), parent.Nominal.Minor()).Min(remainingFee)
+	if payload.Nominal.Cmp(remaining) == 0 {
+		fee = remainingFee
+	}
+
+	payload.AccountID = parent.RecipientID
+	payload.RecipientID = parent.AccountID
+	payload.TrxType = inconst.TRX_TYPE_REFUND
+	payload.TrxFee = fee
+
+	postings := []*model.JournalPosting{
+		{AccountID: parent.AccountID, Amount: payload.Nominal.Add(fee)},
+		{AccountID: conf.FeeAccountUUID, Amount: fee.Neg()},
+	}
+
+	// claw back from recipient side first, a spent balance fails before anything is credited
+	clawbackAccountID := parent.RecipientID
+	if parent.TrxType == inconst.TRX_TYPE_P2B {
+		settled, err := r.unwindSettlementTx(ctx, tx, parent.ID, payload.Nominal, fee)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		// merchant's share is still parked on settlement account
+		if !settled {
+			clawbackAccountID = ""
+			postings = append(postings, &model.JournalPosting{AccountID: conf.SettlementAccountUUID, Amount: payload.Nominal.Neg()})
+		}
+	}
+
+	lockIDs := []string{}
+	if parent.TrxType != inconst.TRX_TYPE_CUST_SYSTEM {
+		lockIDs = append(lockIDs, parent.AccountID)
+	}
+
+	if clawbackAccountID != "" {
+		lockIDs = append(lockIDs, clawbackAccountID)
+		postings = append(postings, &model.JournalPosting{AccountID: clawbackAccountID, Amount: payload.Nominal.Neg()})
+	}
+
+	if _, err = r.lockAccountsTx(ctx, tx, lockIDs...); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if clawbackAccountID != "" {
+		err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: clawbackAccountID, Balance: payload.Nominal})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	}
+
+	// system account lives on the ledger only
+	if parent.TrxType != inconst.TRX_TYPE_CUST_SYSTEM {
+		err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: parent.AccountID, Balance: payload.Nominal.Add(fee).Neg()})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	}
+
+	parent.RefundedAmount = parent.RefundedAmount.Add(payload.Nominal)
+	parent.RefundedFee = parent.RefundedFee.Add(fee)
+	parent.TrxStatus = inconst.TRX_STATUS_PARTIALLY_REFUNDED
+	if parent.RefundedAmount.Cmp(parent.Nominal) == 0 {
+		parent.TrxStatus = inconst.TRX_STATUS_REFUNDED
+	}
+
+	if err = r.updateTransactionRefundTx(ctx, tx, parent); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	_, err = r.CreateTransactionTx(ctx, tx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(payload, postings...))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// CreateTransactionAuthorization records a pending P2B transaction and places payload.HoldAmount on sender's
+// available balance, nothing is moved on the ledger until the authorization is captured
+func (r *repository) CreateTransactionAuthorization(ctx context.Context, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	if err = r.checkSenderBalanceTx(ctx, tx, payload, payload.AccountID, payload.RecipientID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	err = r.updateAccountHoldTx(ctx, tx, &model.Account{ID: payload.AccountID, HoldBalance: payload.HoldAmount})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	_, err = r.CreateTransactionTx(ctx, tx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// CaptureTransactionAuthorization settles payload.Nominal of a pending authorization, whatever is left of the hold
+// is released back to sender's available balance
+func (r *repository) CaptureTransactionAuthorization(ctx context.Context, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	authz, err := r.lockAuthorizationTx(ctx, tx, payload.ID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if authz.ExpiresAt != nil && !authz.ExpiresAt.After(time.Now()) {
+		err = errs.ErrAuthorizationClosed
+		logger.Error().Err(err).Uint64("transaction-id", authz.ID).Time("expires-at", *authz.ExpiresAt).Msg("authorization has expired")
+		return
+	}
+
+	if payload.Nominal.GreaterThan(authz.AuthorizedAmount) {
+		err = errs.ErrBadRequest
+		logger.Error().Err(err).Msgf("transactionID: %d capture exceeds authorized amount. (authorized=%s, requested=%s)", authz.ID, authz.AuthorizedAmount, payload.Nominal)
+		return
+	}
+
+	accounts, err := r.lockAccountsTx(ctx, tx, authz.AccountID, authz.RecipientID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// merchant may have been restricted since the hold was placed
+	if recipient, ok := accounts[authz.RecipientID]; ok && !accountStatusAllows(recipient.Status, false) {
+		err = errs.ErrAccountRestricted
+		logger.Error().Err(err).Str("account-id", recipient.ID).Int64("status", recipient.Status).Msg("account status blocks transaction")
+		return
+	}
+
+	err = r.updateAccountHoldTx(ctx, tx, &model.Account{ID: authz.AccountID, HoldBalance: authz.HoldAmount.Neg()})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	err = r.updateAccountBalanceTx(ctx, tx, &model.Account{ID: authz.AccountID, Balance: payload.Nominal.Add(payload.TrxFee)})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	payload.AccountID = authz.AccountID
+	payload.RecipientID = authz.RecipientID
+	payload.TrxType = authz.TrxType
+	payload.TrxStatus = inconst.TRX_STATUS_SUCCESS
+	payload.HoldAmount = money.Zero()
+
+	if err = r.updateTransactionAuthorizationTx(ctx, tx, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	_, err = r.createSettlementTx(ctx, tx, &model.Settlement{
+		ID:             snowflake.ID(),
+		TransactionID:  payload.ID,
+		MerchantID:     payload.MerchantID,
+		Amount:         payload.Nominal,
+		Status:         inconst.SETTLEMENT_STATUS_OPEN,
+		SettlementDate: time.Now(),
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	_, err = r.createJournalTx(ctx, tx, journalFromTransaction(payload,
+		&model.JournalPosting{AccountID: payload.AccountID, Amount: payload.Nominal.Add(payload.TrxFee).Neg()},
+		&model.JournalPosting{AccountID: conf.SettlementAccountUUID, Amount: payload.Nominal},
+		&model.JournalPosting{AccountID: conf.FeeAccountUUID, Amount: payload.TrxFee},
+	))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+// ReleaseTransactionAuthorization closes a pending authorization with payload.TrxStatus and releases its hold
+func (r *repository) ReleaseTransactionAuthorization(ctx context.Context, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+	defer tx.Rollback()
+
+	authz, err := r.lockAuthorizationTx(ctx, tx, payload.ID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if _, err = r.lockAccountsTx(ctx, tx, authz.AccountID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	err = r.updateAccountHoldTx(ctx, tx, &model.Account{ID: authz.AccountID, HoldBalance: authz.HoldAmount.Neg()})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	authz.TrxDatetime = time.Now()
+	authz.TrxStatus = payload.TrxStatus
+	authz.HoldAmount = money.Zero()
+
+	if err = r.updateTransactionAuthorizationTx(ctx, tx, authz); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		logger.Error().Err(err).Msg("tx err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindExpiredTransactionAuthorizations(ctx context.Context, limit uint64) (res []uint64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"trx_status": inconst.TRX_STATUS_PENDING},
+		squirrel.Gt{"hold_amount": 0},
+		squirrel.Lt{"expires_at": time.Now()},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Select("id").From("transactions").Where(cond).OrderBy("expires_at").Limit(limit).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = []uint64{}
+	if err = r.db.SelectContext(ctx, &res, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// lockAuthorizationTx locks a transaction that is still holding funds, anything else is reported as closed
+func (r *repository) lockAuthorizationTx(ctx context.Context, tx *sql.Tx, transactionID uint64) (res *model.Transaction, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	res, err = r.lockTransactionTx(ctx, tx, transactionID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if res == nil {
+		err = errs.ErrNotFound
+		logger.Error().Err(err).Uint64("transaction-id", transactionID).Msg("authorization not found")
+		return
+	}
+
+	if res.TrxStatus != inconst.TRX_STATUS_PENDING || !res.HoldAmount.IsPositive() {
+		err = errs.ErrAuthorizationClosed
+		logger.Error().Err(err).Uint64("transaction-id", transactionID).Int64("trx-status", res.TrxStatus).Msg("transaction is not a pending authorization")
+		return nil, err
+	}
+
+	return
+}
+
+func (r *repository) updateTransactionAuthorizationTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("transactions").SetMap(map[string]interface{}{
+		"trx_datetime": payload.TrxDatetime,
+		"trx_status":   payload.TrxStatus,
+		"trx_fee":      payload.TrxFee,
+		"fee_rule_id":  payload.FeeRuleID,
+		"nominal":      payload.Nominal,
+		"hold_amount":  payload.HoldAmount,
+		"updated_at":   time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) lockTransactionTx(ctx context.Context, tx *sql.Tx, transactionID uint64) (res *model.Transaction, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	cond := squirrel.And{
+		squirrel.Eq{"id": transactionID},
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	stmt, args, err := pgSquirrel.Select("id", "account_id", "recipient_id", "trx_type", "trx_status", "trx_fee", "fee_rule_id", "nominal", "refunded_amount", "refunded_fee",
+		"authorized_amount", "hold_amount", "expires_at").
+		From("transactions").Where(cond).Suffix("for update").ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &model.Transaction{}
+	err = tx.QueryRowContext(ctx, stmt, args...).Scan(&res.ID, &res.AccountID, &res.RecipientID, &res.TrxType, &res.TrxStatus, &res.TrxFee, &res.FeeRuleID, &res.Nominal, &res.RefundedAmount, &res.RefundedFee,
+		&res.AuthorizedAmount, &res.HoldAmount, &res.ExpiresAt)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) updateTransactionRefundTx(ctx context.Context, tx *sql.Tx, payload *model.Transaction) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("transactions").SetMap(map[string]interface{}{
+		"trx_status":      payload.TrxStatus,
+		"refunded_amount": payload.RefundedAmount,
+		"refunded_fee":    payload.RefundedFee,
+		"updated_at":      time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = tx.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
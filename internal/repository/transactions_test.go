@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/google/uuid"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// debitFunc sends one transfer of nominal+fee out of accountID
+type debitFunc func(ctx context.Context, r *repository, accountID string, nominal, fee money.Money) error
+
+func debitP2P(recipientID string) debitFunc {
+	return func(ctx context.Context, r *repository, accountID string, nominal, fee money.Money) error {
+		return r.CreateTransactionP2P(ctx, &model.Transaction{
+			ID:          snowflake.ID(),
+			AccountID:   accountID,
+			RecipientID: recipientID,
+			TrxType:     inconst.TRX_TYPE_P2P,
+			TrxDatetime: time.Now(),
+			TrxStatus:   inconst.TRX_STATUS_SUCCESS,
+			TrxFee:      fee,
+			Nominal:     nominal,
+			Description: "concurrent p2p",
+		})
+	}
+}
+
+func debitP2B(merchantAccountID string, merchantID string) debitFunc {
+	return func(ctx context.Context, r *repository, accountID string, nominal, fee money.Money) error {
+		return r.CreateTransactionP2B(ctx, &model.Transaction{
+			ID:          snowflake.ID(),
+			AccountID:   accountID,
+			RecipientID: merchantAccountID,
+			MerchantID:  merchantID,
+			TrxType:     inconst.TRX_TYPE_P2B,
+			TrxDatetime: time.Now(),
+			TrxStatus:   inconst.TRX_STATUS_SUCCESS,
+			TrxFee:      fee,
+			Nominal:     nominal,
+			Description: "concurrent p2b",
+		})
+	}
+}
+
+func TestConcurrentDebits(t *testing.T) {
+	r := newTestRepository(t)
+
+	recipientID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, money.Zero())
+	merchantAccountID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+	merchantID := uuid.NewString()
+
+	tests := []struct {
+		name    string
+		debits  []debitFunc
+		workers int
+	}{
+		{name: "p2p", debits: []debitFunc{debitP2P(recipientID)}, workers: 40},
+		{name: "p2b", debits: []debitFunc{debitP2B(merchantAccountID, merchantID)}, workers: 40},
+		{name: "p2p and p2b", debits: []debitFunc{debitP2P(recipientID), debitP2B(merchantAccountID, merchantID)}, workers: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funding := money.MustParse("100")
+			nominal, fee := money.MustParse("5"), money.MustParse("0.50")
+
+			senderID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, funding)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// balance is sampled throughout the run, not only once every debit is done
+			var negative atomic.Value
+			watcherDone := make(chan struct{})
+			go func() {
+				defer close(watcherDone)
+				for ctx.Err() == nil {
+					var balance money.Money
+					if err := r.db.QueryRowx("select balance from accounts where id = $1", senderID).Scan(&balance); err == nil && balance.IsNegative() {
+						negative.Store(balance.String())
+					}
+				}
+			}()
+
+			var succeeded, rejected int64
+			unexpected := make(chan error, tt.workers)
+
+			start := make(chan struct{})
+			wg := &sync.WaitGroup{}
+			for i := 0; i < tt.workers; i++ {
+				wg.Add(1)
+				go func(debit debitFunc) {
+					defer wg.Done()
+					<-start
+
+					err := debit(context.Background(), r, senderID, nominal, fee)
+					switch {
+					case err == nil:
+						atomic.AddInt64(&succeeded, 1)
+					case errors.Is(err, errs.ErrInsufficientBalance):
+						atomic.AddInt64(&rejected, 1)
+					default:
+						unexpected <- err
+					}
+				}(tt.debits[i%len(tt.debits)])
+			}
+
+			close(start)
+			wg.Wait()
+			cancel()
+			<-watcherDone
+			close(unexpected)
+
+			for err := range unexpected {
+				t.Errorf("unexpected debit err: %v", err)
+			}
+
+			if v := negative.Load(); v != nil {
+				t.Errorf("sender balance went negative during the run: %s", v)
+			}
+
+			perDebit := nominal.Add(fee)
+			affordable := funding.Minor() / perDebit.Minor()
+
+			if succeeded != affordable || succeeded+rejected != int64(tt.workers) {
+				t.Errorf("succeeded = %d, rejected = %d, want %d succeeded out of %d", succeeded, rejected, affordable, tt.workers)
+			}
+
+			balance := findTestBalance(t, r, senderID)
+			if want := funding.Sub(money.FromMinor(perDebit.Minor() * succeeded)); balance.Cmp(want) != 0 {
+				t.Errorf("sender balance = %s, want %s", balance, want)
+			}
+
+			ledger, err := r.FindLedgerBalance(context.Background(), &indto.JournalParams{AccountID: senderID})
+			if err != nil {
+				t.Fatalf("failed to read ledger balance: %v", err)
+			}
+
+			if ledger.Cmp(balance) != 0 {
+				t.Errorf("sender ledger balance = %s, stored balance = %s", ledger, balance)
+			}
+
+			assertSenderJournalsBalanced(t, r, senderID, succeeded)
+		})
+	}
+}
+
+// assertSenderJournalsBalanced checks every journal moving money out of accountID nets to zero
+// and that one was written per successful debit, nothing for the rejected ones
+func assertSenderJournalsBalanced(t *testing.T, r *repository, accountID string, debits int64) {
+	t.Helper()
+
+	var journals, unbalanced int64
+	err := r.db.QueryRowx(`
+		select count(*), count(*) filter (where total <> 0)
+		from (
+			select p.journal_id, sum(p.amount) as total
+			from journal_postings p
+			where p.journal_id in (select journal_id from journal_postings where account_id = $1 and amount < 0)
+			group by p.journal_id
+		) j`, accountID).Scan(&journals, &unbalanced)
+	if err != nil {
+		t.Fatalf("failed to read journals: %v", err)
+	}
+
+	if unbalanced != 0 {
+		t.Errorf("%d journals of %s do not balance", unbalanced, accountID)
+	}
+
+	if journals != debits {
+		t.Errorf("found %d debit journals of %s, want %d", journals, accountID, debits)
+	}
+}
+
+func restrictTestAccount(t *testing.T, r *repository, accountID string, status int64) {
+	t.Helper()
+
+	err := r.UpdateAccountStatus(context.Background(), &model.AccountStatusHistory{
+		ID:         snowflake.ID(),
+		AccountID:  accountID,
+		Status:     status,
+		ReasonCode: inconst.ACCOUNT_STATUS_REASON_OTHER,
+		Note:       "test restriction",
+		ChangedBy:  uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatalf("failed to restrict account: %v", err)
+	}
+}
+
+func TestRestrictedRecipient(t *testing.T) {
+	r := newTestRepository(t)
+	merchantID := uuid.NewString()
+
+	tests := []struct {
+		name        string
+		accountType int64
+		status      int64
+		debit       func(recipientID string) debitFunc
+		wantErr     error
+	}{
+		{name: "p2p to frozen", accountType: inconst.ACCOUNT_TYPE_CUST, status: inconst.ACCOUNT_STATUS_FROZEN, debit: debitP2P, wantErr: errs.ErrAccountRestricted},
+		{name: "p2p to debit blocked", accountType: inconst.ACCOUNT_TYPE_CUST, status: inconst.ACCOUNT_STATUS_DEBIT_BLOCKED, debit: debitP2P},
+		{
+			name: "p2b to frozen", accountType: inconst.ACCOUNT_TYPE_MERCHANT, status: inconst.ACCOUNT_STATUS_FROZEN,
+			debit: func(id string) debitFunc { return debitP2B(id, merchantID) }, wantErr: errs.ErrAccountRestricted,
+		},
+		{
+			name: "p2b to closed", accountType: inconst.ACCOUNT_TYPE_MERCHANT, status: inconst.ACCOUNT_STATUS_CLOSED,
+			debit: func(id string) debitFunc { return debitP2B(id, merchantID) }, wantErr: errs.ErrAccountRestricted,
+		},
+		{
+			name: "p2b to credit blocked", accountType: inconst.ACCOUNT_TYPE_MERCHANT, status: inconst.ACCOUNT_STATUS_CREDIT_BLOCKED,
+			debit: func(id string) debitFunc { return debitP2B(id, merchantID) }, wantErr: errs.ErrAccountRestricted,
+		},
+		{
+			name: "p2b to debit blocked", accountType: inconst.ACCOUNT_TYPE_MERCHANT, status: inconst.ACCOUNT_STATUS_DEBIT_BLOCKED,
+			debit: func(id string) debitFunc { return debitP2B(id, merchantID) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			funding, nominal := money.MustParse("10"), money.MustParse("5")
+
+			senderID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, funding)
+			recipientID := createTestAccount(t, r, tt.accountType, money.Zero())
+			restrictTestAccount(t, r, recipientID, tt.status)
+
+			err := tt.debit(recipientID)(context.Background(), r, senderID, nominal, money.Zero())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("debit() err = %v, want %v", err, tt.wantErr)
+			}
+
+			want := funding
+			if tt.wantErr == nil {
+				want = funding.Sub(nominal)
+			}
+
+			if balance := findTestBalance(t, r, senderID); balance.Cmp(want) != 0 {
+				t.Errorf("sender balance = %s, want %s", balance, want)
+			}
+		})
+	}
+
+	t.Run("capture to frozen", func(t *testing.T) {
+		ctx := context.Background()
+		funding, nominal := money.MustParse("10"), money.MustParse("5")
+
+		senderID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_CUST, funding)
+		recipientID := createTestAccount(t, r, inconst.ACCOUNT_TYPE_MERCHANT, money.Zero())
+
+		expiresAt := time.Now().Add(time.Hour)
+		authz := &model.Transaction{
+			ID:               snowflake.ID(),
+			AccountID:        senderID,
+			RecipientID:      recipientID,
+			MerchantID:       merchantID,
+			TrxType:          inconst.TRX_TYPE_P2B,
+			TrxDatetime:      time.Now(),
+			TrxStatus:        inconst.TRX_STATUS_PENDING,
+			TrxFee:           money.Zero(),
+			Nominal:          nominal,
+			AuthorizedAmount: nominal,
+			HoldAmount:       nominal,
+			ExpiresAt:        &expiresAt,
+			Description:      "test authorization",
+		}
+
+		if err := r.CreateTransactionAuthorization(ctx, authz); err != nil {
+			t.Fatalf("failed to authorize: %v", err)
+		}
+
+		// merchant is frozen while the hold is still pending
+		restrictTestAccount(t, r, recipientID, inconst.ACCOUNT_STATUS_FROZEN)
+
+		err := r.CaptureTransactionAuthorization(ctx, &model.Transaction{
+			ID:          authz.ID,
+			MerchantID:  merchantID,
+			TrxDatetime: time.Now(),
+			TrxFee:      money.Zero(),
+			Nominal:     nominal,
+		})
+		if !errors.Is(err, errs.ErrAccountRestricted) {
+			t.Fatalf("CaptureTransactionAuthorization() err = %v, want %v", err, errs.ErrAccountRestricted)
+		}
+
+		if balance := findTestBalance(t, r, senderID); balance.Cmp(funding) != 0 {
+			t.Errorf("sender balance = %s, want %s", balance, funding)
+		}
+	})
+}
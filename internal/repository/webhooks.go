@@ -0,0 +1,431 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+var webhookColumns = []string{
+	"id", "merchant_id", "url", "secret", "event_types", "status", "row_hash", "created_at",
+}
+
+var webhookDeliveryColumns = []string{
+	"id", "subscription_id", "event_type", "payload", "status", "attempts", "next_run_at", "response_status", "response_body",
+	"last_error", "delivered_at", "created_at",
+}
+
+func (r *repository) FindWebhooks(ctx context.Context, params *indto.WebhookParams) (res []*indto.WebhookSubscription, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select(webhookColumns...).From("webhook_subscriptions").Where(webhookCond(params)).OrderBy("created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.WebhookSubscription{}
+	for rows.Next() {
+		temp := &indto.WebhookSubscription{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountWebhooks(ctx context.Context, params *indto.WebhookParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("webhook_subscriptions").Where(webhookCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindWebhook(ctx context.Context, params *indto.WebhookParams) (res *indto.WebhookSubscription, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(webhookColumns...).From("webhook_subscriptions").Where(webhookCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.WebhookSubscription{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+func (r *repository) CreateWebhook(ctx context.Context, payload *model.WebhookSubscription) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Insert("webhook_subscriptions").
+		Columns("id", "merchant_id", "url", "secret", "event_types", "status", "row_hash").
+		Values(payload.ID, payload.MerchantID, payload.URL, payload.Secret, payload.EventTypes, payload.Status, payload.RowHash).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// UpdateWebhook replaces the url, event types and status of a webhook of payload.MerchantID, its secret is kept
+func (r *repository) UpdateWebhook(ctx context.Context, payload *model.WebhookSubscription) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("webhook_subscriptions").SetMap(map[string]interface{}{
+		"url":         payload.URL,
+		"event_types": payload.EventTypes,
+		"status":      payload.Status,
+		"row_hash":    payload.RowHash,
+		"updated_at":  time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"merchant_id": payload.MerchantID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) DeleteWebhook(ctx context.Context, params *indto.WebhookParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("webhook_subscriptions").SetMap(map[string]interface{}{
+		"deleted_at": time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": params.WebhookID},
+		squirrel.Eq{"merchant_id": params.MerchantID},
+		squirrel.Eq{"deleted_at": nil},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindWebhookDeliveries(ctx context.Context, params *indto.WebhookDeliveryParams) (res []*indto.WebhookDelivery, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	baseStmt := pgSquirrel.Select(webhookDeliveryColumns...).From("webhook_deliveries").Where(webhookDeliveryCond(params)).OrderBy("created_at desc")
+
+	if params.Limit != 0 && params.Page >= 1 {
+		baseStmt = baseStmt.Limit(params.Limit).Offset((params.Page - 1) * params.Limit)
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	res = []*indto.WebhookDelivery{}
+	for rows.Next() {
+		temp := &indto.WebhookDelivery{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+func (r *repository) CountWebhookDeliveries(ctx context.Context, params *indto.WebhookDeliveryParams) (res int64, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select("count(*)").From("webhook_deliveries").Where(webhookDeliveryCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	err = r.db.QueryRowxContext(ctx, stmt, args...).Scan(&res)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+func (r *repository) FindWebhookDelivery(ctx context.Context, params *indto.WebhookDeliveryParams) (res *indto.WebhookDelivery, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Select(webhookDeliveryColumns...).From("webhook_deliveries").Where(webhookDeliveryCond(params)).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res = &indto.WebhookDelivery{}
+	err = r.db.QueryRowxContext(ctx, stmt, args...).StructScan(res)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	} else if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return
+}
+
+// CreateWebhookDeliveries queues payload for the dispatcher, every delivery is due right away
+func (r *repository) CreateWebhookDeliveries(ctx context.Context, payload []*model.WebhookDelivery) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	if len(payload) == 0 {
+		return
+	}
+
+	baseStmt := pgSquirrel.Insert("webhook_deliveries").Columns("id", "subscription_id", "event_type", "payload", "status", "next_run_at")
+	for _, v := range payload {
+		baseStmt = baseStmt.Values(v.ID, v.SubscriptionID, v.EventType, v.Payload, inconst.WEBHOOK_DELIVERY_STATUS_PENDING, time.Now())
+	}
+
+	stmt, args, err := baseStmt.ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	if _, err = r.db.ExecContext(ctx, stmt, args...); err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// ClaimDueWebhookDeliveries leases up to limit pending deliveries that are due, a lease is the next run pushed past now
+func (r *repository) ClaimDueWebhookDeliveries(ctx context.Context, limit uint64, lease time.Duration) (res []*indto.WebhookDelivery, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	// nested builder keeps default placeholders, the outer statement numbers them
+	dueStmt := squirrel.Select("id").From("webhook_deliveries").Where(squirrel.And{
+		squirrel.Eq{"status": inconst.WEBHOOK_DELIVERY_STATUS_PENDING},
+		squirrel.LtOrEq{"next_run_at": time.Now()},
+	}).OrderBy("next_run_at").Limit(limit).Suffix("for update skip locked")
+
+	stmt, args, err := pgSquirrel.Update("webhook_deliveries").SetMap(map[string]interface{}{
+		"next_run_at": time.Now().Add(lease),
+		"updated_at":  time.Now(),
+	}).Where(squirrel.Expr("id in (?)", dueStmt)).Suffix("returning " + strings.Join(webhookDeliveryColumns, ", ")).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	rows, err := r.db.QueryxContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+	defer rows.Close()
+
+	res = []*indto.WebhookDelivery{}
+	for rows.Next() {
+		temp := &indto.WebhookDelivery{}
+
+		if err = rows.StructScan(temp); err != nil {
+			logger.Error().Err(err).Msg("sql map err")
+			return
+		}
+
+		res = append(res, temp)
+	}
+
+	return
+}
+
+// UpdateWebhookDelivery records the outcome of an attempt on a pending delivery
+func (r *repository) UpdateWebhookDelivery(ctx context.Context, payload *model.WebhookDelivery) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	values := map[string]interface{}{
+		"status":          payload.Status,
+		"attempts":        payload.Attempts,
+		"next_run_at":     payload.NextRunAt,
+		"response_status": payload.ResponseStatus,
+		"response_body":   payload.ResponseBody,
+		"last_error":      payload.LastError,
+		"updated_at":      time.Now(),
+	}
+
+	if payload.Status == inconst.WEBHOOK_DELIVERY_STATUS_DELIVERED {
+		values["delivered_at"] = time.Now()
+	}
+
+	stmt, args, err := pgSquirrel.Update("webhook_deliveries").SetMap(values).Where(squirrel.And{
+		squirrel.Eq{"id": payload.ID},
+		squirrel.Eq{"status": inconst.WEBHOOK_DELIVERY_STATUS_PENDING},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	_, err = r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	return
+}
+
+// RedeliverWebhookDelivery queues a delivered or failed delivery again with a fresh attempt budget,
+// a delivery still pending returns ErrWebhookDeliveryBusy
+func (r *repository) RedeliverWebhookDelivery(ctx context.Context, params *indto.WebhookDeliveryParams) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	stmt, args, err := pgSquirrel.Update("webhook_deliveries").SetMap(map[string]interface{}{
+		"status":       inconst.WEBHOOK_DELIVERY_STATUS_PENDING,
+		"attempts":     0,
+		"next_run_at":  time.Now(),
+		"delivered_at": nil,
+		"updated_at":   time.Now(),
+	}).Where(squirrel.And{
+		squirrel.Eq{"id": params.DeliveryID},
+		squirrel.Eq{"subscription_id": params.WebhookID},
+		squirrel.NotEq{"status": inconst.WEBHOOK_DELIVERY_STATUS_PENDING},
+	}).ToSql()
+	if err != nil {
+		logger.Error().Err(err).Msg("squirrel err")
+		return
+	}
+
+	res, err := r.db.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("sql err")
+		return
+	}
+
+	if aff, _ := res.RowsAffected(); aff == 0 {
+		err = errs.ErrWebhookDeliveryBusy
+		logger.Error().Err(err).Uint64("delivery-id", params.DeliveryID).Msg("webhook delivery not requeued")
+		return
+	}
+
+	return
+}
+
+func webhookCond(params *indto.WebhookParams) squirrel.And {
+	cond := squirrel.And{
+		squirrel.Eq{"deleted_at": nil},
+	}
+
+	if params.WebhookID != 0 {
+		cond = append(cond, squirrel.Eq{"id": params.WebhookID})
+	}
+
+	if params.MerchantID != "" {
+		cond = append(cond, squirrel.Eq{"merchant_id": params.MerchantID})
+	}
+
+	// jsonb containment, the ? operator would clash with placeholders
+	if params.EventType != "" {
+		cond = append(cond, squirrel.Expr("event_types @> ?::jsonb", fmt.Sprintf("[%q]", params.EventType)))
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	return cond
+}
+
+func webhookDeliveryCond(params *indto.WebhookDeliveryParams) squirrel.And {
+	cond := squirrel.And{}
+
+	if params.DeliveryID != 0 {
+		cond = append(cond, squirrel.Eq{"id": params.DeliveryID})
+	}
+
+	if params.WebhookID != 0 {
+		cond = append(cond, squirrel.Eq{"subscription_id": params.WebhookID})
+	}
+
+	if params.Status != 0 {
+		cond = append(cond, squirrel.Eq{"status": params.Status})
+	}
+
+	return cond
+}
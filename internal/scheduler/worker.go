@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/service"
+)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	logger  zerolog.Logger
+	service service.Service
+}
+
+type NewSchedulerParams struct {
+	Logger  zerolog.Logger
+	Service service.Service
+}
+
+func NewScheduler(params *NewSchedulerParams) *Scheduler {
+	return &Scheduler{
+		logger:  params.Logger,
+		service: params.Service,
+	}
+}
+
+// Start runs every job on its own ticker, a job is never run concurrently with itself
+func (sc *Scheduler) Start() {
+	jobs := []*job{
+		{name: "expire-authorizations", interval: time.Minute, run: sc.service.HandleExpireAuthorizations},
+		{name: "execute-scheduled-transactions", interval: time.Minute, run: sc.service.HandleExecuteScheduledTransactions},
+		{name: "execute-standing-orders", interval: time.Minute, run: sc.service.HandleExecuteStandingOrders},
+		{name: "process-export-jobs", interval: 15 * time.Second, run: sc.service.HandleProcessExportJobs},
+		{name: "batch-settlements", interval: time.Hour, run: sc.service.HandleBatchSettlements},
+		{name: "process-payouts", interval: time.Minute, run: sc.service.HandleProcessPayouts},
+		{name: "dispatch-webhooks", interval: 15 * time.Second, run: sc.service.HandleDispatchWebhooks},
+		{name: "mark-overdue-invoices", interval: time.Hour, run: sc.service.HandleMarkOverdueInvoices},
+		{name: "reconcile-balances", interval: 24 * time.Hour, run: sc.service.HandleReconcileBalances},
+	}
+
+	wg := &sync.WaitGroup{}
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			sc.loop(j)
+		}(j)
+	}
+
+	wg.Wait()
+}
+
+func (sc *Scheduler) loop(j *job) {
+	logger := sc.logger.With().Str("job", j.name).Logger()
+	ctx := logger.WithContext(context.Background())
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := j.run(ctx); err != nil {
+			logger.Warn().Err(err).Msg("scheduled job failed")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+// UpdateAccountStatus restricts or restores an account, every status other than active requires a reason code
+func (s *service) UpdateAccountStatus(ctx context.Context, params *dto.AccountsQueryParams, payload *dto.AccountStatusPayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if !validAccountStatus(payload) {
+		logger.Error().Int64("status", payload.Status).Int64("reason-code", payload.ReasonCode).Msg("invalid account status")
+		return errs.ErrBadRequest
+	}
+
+	historyModel := &model.AccountStatusHistory{
+		ID:         snowflake.ID(),
+		AccountID:  params.AccountID,
+		Status:     payload.Status,
+		ReasonCode: payload.ReasonCode,
+		Note:       payload.Note,
+		ChangedBy:  ctxutil.GetUserCTX(ctx).UserID,
+	}
+
+	if err = s.repository.UpdateAccountStatus(ctx, historyModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) GetAccountStatusHistory(ctx context.Context, params *dto.AccountsQueryParams) (res *dto.ListAccountStatusHistoryResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.AccountStatusHistoryParams{
+		AccountID: params.AccountID,
+		Limit:     params.Limit,
+		Page:      params.Page,
+	}
+
+	res = &dto.ListAccountStatusHistoryResponse{
+		AccountID: params.AccountID,
+		Histories: []*dto.AccountStatusHistoryResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountAccountStatusHistories(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindAccountStatusHistories(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Histories = append(res.Histories, &dto.AccountStatusHistoryResponse{
+			ID:         v.ID,
+			PrevStatus: v.PrevStatus,
+			Status:     v.Status,
+			ReasonCode: v.ReasonCode,
+			Note:       v.Note,
+			ChangedBy:  v.ChangedBy,
+			CreatedAt:  timeutil.FormatVerboseTime(v.CreatedAt),
+		})
+	}
+
+	return
+}
+
+func validAccountStatus(v *dto.AccountStatusPayload) bool {
+	if v.Status < inconst.ACCOUNT_STATUS_ACTIVE || v.Status > inconst.ACCOUNT_STATUS_CLOSED {
+		return false
+	}
+
+	switch v.ReasonCode {
+	case inconst.ACCOUNT_STATUS_REASON_NONE:
+		return v.Status == inconst.ACCOUNT_STATUS_ACTIVE
+	case inconst.ACCOUNT_STATUS_REASON_COMPLIANCE_REVIEW, inconst.ACCOUNT_STATUS_REASON_SUSPECTED_FRAUD,
+		inconst.ACCOUNT_STATUS_REASON_CUSTOMER_REQUEST, inconst.ACCOUNT_STATUS_REASON_LEGAL_ORDER,
+		inconst.ACCOUNT_STATUS_REASON_DORMANT, inconst.ACCOUNT_STATUS_REASON_OTHER:
+		return true
+	}
+
+	return false
+}
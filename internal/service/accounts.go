@@ -19,6 +19,7 @@ import (
 	"github.com/stellar-payment/sp-payment/internal/util/structutil"
 	"github.com/stellar-payment/sp-payment/pkg/dto"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -74,10 +75,13 @@ func (s *service) GetAllAccount(ctx context.Context, params *dto.AccountsQueryPa
 
 	for _, v := range data {
 		temp := &dto.AccountResponse{
-			ID:          v.ID,
-			OwnerID:     v.OwnerID,
-			AccountType: v.AccountType,
-			AccountNo:   cryptoutil.DecryptField(v.AccountNo, conf.DBKey),
+			ID:           v.ID,
+			OwnerID:      v.OwnerID,
+			AccountType:  v.AccountType,
+			AccountTier:  v.AccountTier,
+			AccountNo:    cryptoutil.DecryptField(v.AccountNo, conf.DBKey),
+			Status:       v.Status,
+			StatusReason: v.StatusReason,
 		}
 
 		if v.OwnerName != nil {
@@ -129,11 +133,15 @@ func (s *service) GetAccount(ctx context.Context, params *dto.AccountsQueryParam
 	}
 
 	res = &dto.AccountResponse{
-		ID:          data.ID,
-		OwnerID:     data.OwnerID,
-		AccountType: data.AccountType,
-		Balance:     data.Balance,
-		AccountNo:   cryptoutil.DecryptField(data.AccountNo, conf.DBKey),
+		ID:           data.ID,
+		OwnerID:      data.OwnerID,
+		AccountType:  data.AccountType,
+		AccountTier:  data.AccountTier,
+		Balance:      &data.Balance,
+		HoldBalance:  &data.HoldBalance,
+		AccountNo:    cryptoutil.DecryptField(data.AccountNo, conf.DBKey),
+		Status:       data.Status,
+		StatusReason: data.StatusReason,
 	}
 
 	if data.OwnerName != nil {
@@ -237,11 +245,15 @@ func (s *service) GetAccountMe(ctx context.Context) (res *dto.AccountResponse, e
 	}
 
 	res = &dto.AccountResponse{
-		ID:          data.ID,
-		OwnerID:     data.OwnerID,
-		AccountType: data.AccountType,
-		Balance:     data.Balance,
-		AccountNo:   cryptoutil.DecryptField(data.AccountNo, conf.DBKey),
+		ID:           data.ID,
+		OwnerID:      data.OwnerID,
+		AccountType:  data.AccountType,
+		AccountTier:  data.AccountTier,
+		Balance:      &data.Balance,
+		HoldBalance:  &data.HoldBalance,
+		AccountNo:    cryptoutil.DecryptField(data.AccountNo, conf.DBKey),
+		Status:       data.Status,
+		StatusReason: data.StatusReason,
 	}
 
 	hash := data.AccountNo
@@ -320,7 +332,8 @@ func (s *service) CreateAccount(ctx context.Context, payload *dto.AccountPayload
 	accModel := &model.Account{
 		ID:            uuid.NewString(),
 		OwnerID:       payload.OwnerID,
-		Balance:       0,
+		AccountTier:   inconst.ACCOUNT_TIER_REGULAR,
+		Balance:       money.Zero(),
 		AccountNo:     cryptoutil.EncryptField([]byte(payload.AccountNo), conf.DBKey, &rowHash),
 		AccountNoHash: cryptoutil.HMACSHA512([]byte(payload.AccountNo), conf.HashKey),
 		RowHash:       rowHash,
@@ -379,6 +392,11 @@ func (s *service) UpdateAccount(ctx context.Context, params *dto.AccountsQueryPa
 		RowHash:       rowHash,
 	}
 
+	// tier drives fee schedule selection, only admin may change it
+	if usrmeta.RoleID <= inconst.ROLE_ADMIN {
+		accModel.AccountTier = payload.AccountTier
+	}
+
 	if payload.PIN != "" {
 		if enc, err := bcrypt.GenerateFromPassword([]byte(payload.PIN), bcrypt.DefaultCost); err != nil {
 			logger.Error().Err(err).Msgf("failed to hash PIN")
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strings"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/namegen"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	apiClientIDSize   = 18
+	apiClientIDPrefix = "spc_"
+
+	apiClientMinKeyBits = 2048
+)
+
+func (s *service) GetAllAPIClient(ctx context.Context, params *dto.APIClientsQueryParams) (res *dto.ListAPIClientResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.APIClientParams{
+		MerchantID: merchantMeta.ID,
+		Status:     params.Status,
+		Limit:      params.Limit,
+		Page:       params.Page,
+	}
+
+	res = &dto.ListAPIClientResponse{
+		APIClients: []*dto.APIClientResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountAPIClients(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindAPIClients(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		if !validAPIClientHash(v) {
+			logger.Warn().Uint64("api-client-id", v.ID).Msg("api client row hash mismatch")
+			continue
+		}
+
+		res.APIClients = append(res.APIClients, apiClientResponse(v))
+	}
+
+	return
+}
+
+// CreateAPIClient registers the public key of the calling merchant and returns the client id its backend signs
+// requests with. Clients are managed from a user session only, a signed request cannot mint more of them
+func (s *service) CreateAPIClient(ctx context.Context, payload *dto.APIClientPayload) (res *dto.APIClientResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok || ctxutil.GetAPIClientCtx(ctx) != "" {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	publicKey := strings.TrimSpace(payload.PublicKey) + "\n"
+
+	pk, err := cryptoutil.LoadPublicKey([]byte(publicKey))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return nil, errs.ErrInvalidPublicKey
+	} else if pk.N.BitLen() < apiClientMinKeyBits {
+		logger.Error().Int("key-bits", pk.N.BitLen()).Msg("public key is too short")
+		return nil, errs.ErrInvalidPublicKey
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	clientID, err := namegen.GenerateRandomToken(apiClientIDSize)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to generate api client id")
+		return
+	}
+
+	clientModel := &model.APIClient{
+		ID:         snowflake.ID(),
+		MerchantID: merchantMeta.ID,
+		ClientID:   apiClientIDPrefix + clientID,
+		Name:       payload.Name,
+		PublicKey:  publicKey,
+		Status:     inconst.API_CLIENT_STATUS_ACTIVE,
+	}
+	clientModel.RowHash = apiClientRowHash(clientModel.ClientID, clientModel.PublicKey)
+
+	if err = s.repository.CreateAPIClient(ctx, clientModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	data, err := s.repository.FindAPIClient(ctx, &indto.APIClientParams{APIClientID: clientModel.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return apiClientResponse(data), nil
+}
+
+// RevokeAPIClient is final, requests signed by the client are refused from then on
+func (s *service) RevokeAPIClient(ctx context.Context, params *dto.APIClientsQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok || ctxutil.GetAPIClientCtx(ctx) != "" {
+		return errs.ErrNoAccess
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.RevokeAPIClient(ctx, &indto.APIClientParams{APIClientID: params.APIClientID, MerchantID: merchantMeta.ID}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// apiClientStringToSign is what the merchant signs with SHA256withRSA, one field per line:
+// method, request uri with query, unix timestamp, nonce and the hex sha256 of the raw body
+func apiClientStringToSign(params *indto.APIClientSignature) []byte {
+	bodyHash := sha256.Sum256(params.Body)
+
+	return []byte(strings.Join([]string{
+		strings.ToUpper(params.Method),
+		params.Path,
+		params.Timestamp,
+		params.Nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n"))
+}
+
+// the public key is what a signature is trusted by, so it is covered by the row hash along with the client id
+func apiClientRowHash(clientID string, publicKey string) []byte {
+	conf := config.Get()
+
+	return cryptoutil.HMACSHA512([]byte(clientID+publicKey), conf.HashKey)
+}
+
+func validAPIClientHash(v *indto.APIClient) bool {
+	conf := config.Get()
+
+	return cryptoutil.VerifyHMACSHA512([]byte(v.ClientID+v.PublicKey), conf.HashKey, v.RowHash)
+}
+
+func apiClientResponse(v *indto.APIClient) *dto.APIClientResponse {
+	res := &dto.APIClientResponse{
+		ID:         v.ID,
+		MerchantID: v.MerchantID,
+		ClientID:   v.ClientID,
+		Name:       v.Name,
+		PublicKey:  v.PublicKey,
+		Status:     v.Status,
+		CreatedAt:  timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+
+	if v.LastUsedAt.Valid {
+		res.LastUsedAt = timeutil.FormatVerboseTime(v.LastUsedAt.Time)
+	}
+
+	if v.RevokedAt.Valid {
+		res.RevokedAt = timeutil.FormatVerboseTime(v.RevokedAt.Time)
+	}
+
+	return res
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	// uncaptured authorizations release their hold once expired
+	authorizationExp = 7 * 24 * time.Hour
+
+	authorizationExpireBatch = 100
+)
+
+// AuthorizeTransactionP2B places nominal and fee of a P2B payment on hold, the merchant later captures or releases it
+func (s *service) AuthorizeTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (res *dto.TransactionAuthorizationResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if !payload.Nominal.IsPositive() {
+		logger.Error().Str("nominal", payload.Nominal.String()).Msg("nominal must be positive")
+		return nil, errs.ErrBadRequest
+	}
+
+	if err = s.verifyAccountPIN(ctx, payload.AccountID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trxModel, err := s.prepareTransactionP2B(ctx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	expiresAt := trxModel.TrxDatetime.Add(authorizationExp)
+
+	trxModel.TrxStatus = inconst.TRX_STATUS_PENDING
+	trxModel.AuthorizedAmount = trxModel.Nominal
+	trxModel.HoldAmount = trxModel.Nominal.Add(trxModel.TrxFee)
+	trxModel.ExpiresAt = &expiresAt
+
+	if err = s.repository.CreateTransactionAuthorization(ctx, trxModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = &dto.TransactionAuthorizationResponse{
+		ID:         trxModel.ID,
+		HoldAmount: trxModel.HoldAmount,
+		ExpiresAt:  timeutil.FormatVerboseTime(expiresAt),
+	}
+
+	return
+}
+
+// CaptureTransactionP2B captures payload.Nominal of an authorization, zero nominal captures the authorized amount.
+// Fee is charged on the captured nominal
+func (s *service) CaptureTransactionP2B(ctx context.Context, params *dto.TransactionsQueryParams, payload *dto.TransactionCapturePayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if payload.Nominal.IsNegative() {
+		logger.Error().Str("nominal", payload.Nominal.String()).Msg("capture nominal must not be negative")
+		return errs.ErrBadRequest
+	}
+
+	data, err := s.findTransactionAuthorization(ctx, params.TransactionID, inconst.ROLE_MERCHANT)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	senderMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: data.AccountID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if senderMeta == nil {
+		logger.Error().Err(errs.ErrNotFound).Msgf("accountID: %s not found", data.AccountID)
+		return errs.ErrBadRequest
+	}
+
+	recipientMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: data.RecipientID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if recipientMeta == nil {
+		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s not found", data.RecipientID)
+		return errs.ErrBadRequest
+	}
+
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: recipientMeta.OwnerID})
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to fetch merchant meta")
+		return
+	} else if merchantMeta == nil {
+		err = errs.New(errs.ErrNotFound)
+		logger.Error().Err(err).Str("user-id", recipientMeta.OwnerID).Msg("failed to fetch merchant meta")
+		return
+	}
+
+	nominal := payload.Nominal
+	if nominal.IsZero() {
+		nominal = data.AuthorizedAmount
+	}
+
+	trxFee, feeRuleID, err := s.calculateTrxFee(ctx, &trxFeeParams{
+		TrxType:     inconst.TRX_TYPE_P2B,
+		MerchantID:  merchantMeta.ID,
+		AccountTier: senderMeta.AccountTier,
+		Nominal:     nominal,
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to calculate trx fee")
+		return
+	}
+
+	trxModel := &model.Transaction{
+		ID:          data.ID,
+		MerchantID:  merchantMeta.ID,
+		TrxDatetime: time.Now(),
+		TrxFee:      trxFee,
+		FeeRuleID:   feeRuleID,
+		Nominal:     nominal,
+		Description: data.Description,
+	}
+
+	if err = s.repository.CaptureTransactionAuthorization(ctx, trxModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trxModel.AccountID = data.AccountID
+	s.emitWebhook(ctx, merchantMeta.ID, inconst.WEBHOOK_EVENT_PAYMENT_RECEIVED, webhookPaymentData(trxModel))
+
+	return
+}
+
+// ReleaseTransactionP2B cancels an authorization and returns the held funds to sender's available balance
+func (s *service) ReleaseTransactionP2B(ctx context.Context, params *dto.TransactionsQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.findTransactionAuthorization(ctx, params.TransactionID, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	err = s.repository.ReleaseTransactionAuthorization(ctx, &model.Transaction{ID: data.ID, TrxStatus: inconst.TRX_STATUS_CANCELLED})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleExpireAuthorizations voids every authorization past its expiry, run periodically by the scheduler
+func (s *service) HandleExpireAuthorizations(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	for {
+		ids, err := s.repository.FindExpiredTransactionAuthorizations(ctx, authorizationExpireBatch)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		for _, id := range ids {
+			err = s.repository.ReleaseTransactionAuthorization(ctx, &model.Transaction{ID: id, TrxStatus: inconst.TRX_STATUS_VOID})
+			if err != nil && !errors.Is(err, errs.ErrAuthorizationClosed) {
+				logger.Error().Err(err).Uint64("transaction-id", id).Msg("failed to expire authorization")
+				return err
+			}
+		}
+
+		if len(ids) < authorizationExpireBatch {
+			return nil
+		}
+	}
+}
+
+// findTransactionAuthorization fetches a P2B transaction, callers holding one of ownerRoles
+// must be its sender (customer) or its recipient (merchant)
+func (s *service) findTransactionAuthorization(ctx context.Context, transactionID uint64, ownerRoles ...int64) (res *indto.Transaction, err error) {
+	logger := log.Ctx(ctx)
+
+	res, err = s.repository.FindTransaction(ctx, &indto.TransactionParams{TransactionID: transactionID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if res == nil || res.TrxType != inconst.TRX_TYPE_P2B {
+		return nil, errs.ErrNotFound
+	}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	for _, role := range ownerRoles {
+		if usrmeta.RoleID != role {
+			continue
+		}
+
+		accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: usrmeta.UserID})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return nil, err
+		}
+
+		if accountMeta == nil || (accountMeta.ID != res.AccountID && accountMeta.ID != res.RecipientID) {
+			return nil, errs.ErrNoAccess
+		}
+	}
+
+	return
+}
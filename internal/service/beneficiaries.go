@@ -7,14 +7,18 @@ import (
 
 	"github.com/godruoyi/go-snowflake"
 	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
 	"github.com/stellar-payment/sp-payment/internal/inconst"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/model"
 	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/payoututil"
 	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
 	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
 	"github.com/stellar-payment/sp-payment/pkg/dto"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 func (s *service) GetAllBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (res *dto.ListBeneficiaryResponse, err error) {
@@ -87,12 +91,19 @@ func (s *service) GetAllBeneficiary(ctx context.Context, params *dto.Beneficiari
 			MerchantName: v.MerchantName,
 			Amount:       v.Amount,
 			Status:       v.Status,
+			PayoutMethod: v.PayoutMethod,
+			RejectReason: v.RejectReason,
+			ReviewedBy:   v.ReviewedBy,
 		}
 
 		if v.WithdrawalDate.Valid {
 			temp.WithdrawalDate = timeutil.FormatVerboseTime(v.WithdrawalDate.Time)
 		}
 
+		if v.ReviewedAt.Valid {
+			temp.ReviewedAt = timeutil.FormatVerboseTime(v.ReviewedAt.Time)
+		}
+
 		res.Beneficiaries = append(res.Beneficiaries, temp)
 	}
 
@@ -140,20 +151,72 @@ func (s *service) GetBeneficiary(ctx context.Context, params *dto.BeneficiariesQ
 		Amount:         data.Amount,
 		WithdrawalDate: "",
 		Status:         data.Status,
+		PayoutMethod:   data.PayoutMethod,
+		RejectReason:   data.RejectReason,
+		ReviewedBy:     data.ReviewedBy,
 	}
 
 	if data.WithdrawalDate.Valid {
 		res.WithdrawalDate = timeutil.FormatVerboseTime(data.WithdrawalDate.Time)
 	}
 
+	if data.ReviewedAt.Valid {
+		res.ReviewedAt = timeutil.FormatVerboseTime(data.ReviewedAt.Time)
+	}
+
+	allocations, err := s.repository.FindBeneficiaryAllocations(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res.Allocations = []*dto.BeneficiaryAllocationResponse{}
+	for _, v := range allocations {
+		res.Allocations = append(res.Allocations, &dto.BeneficiaryAllocationResponse{
+			SettlementID:  v.SettlementID,
+			TransactionID: v.TransactionID,
+			Amount:        v.Amount,
+		})
+	}
+
+	if data.PayoutMethod != inconst.BNF_PAYOUT_BANK {
+		return
+	}
+
+	payout, err := s.repository.FindPayout(ctx, &indto.PayoutParams{BeneficiaryID: data.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if payout != nil {
+		res.Payout = &dto.BeneficiaryPayoutResponse{
+			ID:            payout.ID,
+			Connector:     payout.Connector,
+			BankCode:      payout.BankCode,
+			Status:        payout.Status,
+			ProviderRef:   payout.ProviderRef,
+			FailureReason: payout.FailureReason,
+			Attempts:      payout.Attempts,
+		}
+
+		if payout.SentAt.Valid {
+			res.Payout.SentAt = timeutil.FormatVerboseTime(payout.SentAt.Time)
+		}
+
+		if payout.SettledAt.Valid {
+			res.Payout.SettledAt = timeutil.FormatVerboseTime(payout.SettledAt.Time)
+		}
+	}
+
 	return
 }
 
-func (s *service) GetBeneficiaryPreview(ctx context.Context, params *dto.BeneficiariesQueryParams) (res float64, err error) {
+func (s *service) GetBeneficiaryPreview(ctx context.Context, params *dto.BeneficiariesQueryParams) (res money.Money, err error) {
 	logger := log.Ctx(ctx)
 
 	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
-		return 0, errs.ErrNoAccess
+		return res, errs.ErrNoAccess
 	}
 
 	repoParams := &indto.SettlementParams{MerchantID: params.MerchantID}
@@ -162,36 +225,51 @@ func (s *service) GetBeneficiaryPreview(ctx context.Context, params *dto.Benefic
 		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
 		if err != nil {
 			logger.Error().Err(err).Msg("failed to fetch merchant meta")
-			return 0, err
+			return res, err
 		} else if merchantMeta == nil {
 			err = errs.New(errs.ErrNotFound)
 			logger.Error().Err(err).Str("user-id", usrmeta.UserID).Msg("failed to fetch merchant meta")
-			return 0, err
+			return res, err
 		}
 
 		repoParams.MerchantID = merchantMeta.ID
 	}
 
-	res, err = s.repository.FindPendingSettlement(ctx, repoParams)
+	pending, err := s.repository.FindPendingSettlement(ctx, repoParams)
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
-	return
+	// preview is what a withdrawal without explicit amount would take
+	return pending.Sub(config.Get().SettlementReserve).Max(money.Zero()), nil
 }
 
-func (s *service) CreateBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (err error) {
+// CreateBeneficiary withdraws payload.Amount from the merchant's pending settlements, leaving at least the configured reserve.
+// An empty amount withdraws everything above the reserve. Merchant withdrawals only reserve the amount until an admin
+// approves them, withdrawals made by an admin are paid out right away. Bank payouts are transferred to the merchant's
+// registered bank account asynchronously and only count as withdrawn once the bank settles them
+func (s *service) CreateBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams, payload *dto.BeneficiaryPayload) (err error) {
 	logger := log.Ctx(ctx)
+	conf := config.Get()
 
 	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
 		return errs.ErrNoAccess
 	}
 
-	repoParams := &indto.SettlementParams{MerchantID: params.MerchantID}
+	if payload.Amount.IsNegative() {
+		return errs.ErrBadRequest
+	}
+
+	if payload.PayoutMethod == 0 {
+		payload.PayoutMethod = inconst.BNF_PAYOUT_ACCOUNT
+	} else if payload.PayoutMethod != inconst.BNF_PAYOUT_ACCOUNT && payload.PayoutMethod != inconst.BNF_PAYOUT_BANK {
+		return errs.ErrBadRequest
+	}
+
+	merchantID := params.MerchantID
 
 	usrmeta := ctxutil.GetUserCTX(ctx)
-	userID := usrmeta.UserID
 	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
 		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
 		if err != nil {
@@ -203,41 +281,199 @@ func (s *service) CreateBeneficiary(ctx context.Context, params *dto.Beneficiari
 			return err
 		}
 
-		userID = usrmeta.UserID
-		repoParams.MerchantID = merchantMeta.ID
+		merchantID = merchantMeta.ID
+	} else if merchantID == "" {
+		return errs.New(errs.ErrMissingRequiredAttribute, "MerchantID")
+	}
+
+	beneModel := &model.Beneficiary{
+		ID:           snowflake.ID(),
+		MerchantID:   merchantID,
+		Amount:       payload.Amount,
+		Reserve:      conf.SettlementReserve,
+		Status:       inconst.BNF_STATUS_PENDING,
+		PayoutMethod: payload.PayoutMethod,
+	}
+
+	// the destination is resolved upfront so a merchant cannot queue a withdrawal that can never be paid out
+	var payout *model.Payout
+	if beneModel.PayoutMethod == inconst.BNF_PAYOUT_BANK {
+		if payout, err = s.beneficiaryPayout(ctx, merchantID); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	} else {
+		accountMeta, err := s.beneficiaryAccount(ctx, merchantID)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		beneModel.AccountID = accountMeta.ID
+	}
+
+	if usrmeta.RoleID == inconst.ROLE_ADMIN {
+		now := time.Now()
+		beneModel.Status = inconst.BNF_STATUS_CONFIRM
+		beneModel.WithdrawalDate = &now
+		beneModel.ReviewedBy = usrmeta.UserID
+		beneModel.ReviewedAt = &now
+
+		if payout != nil {
+			beneModel.Status = inconst.BNF_STATUS_PAYING
+			beneModel.Payout = payout
+		}
 	}
 
-	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: userID})
+	_, err = s.repository.CreateBeneficiary(ctx, beneModel)
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
-	} else if accountMeta == nil {
-		err = errs.ErrNotFound
-		logger.Error().Err(err).Send()
-		return
 	}
 
-	nominal, err := s.repository.FindPendingSettlement(ctx, repoParams)
+	return
+}
+
+// ApproveBeneficiary pays a pending withdrawal out to the merchant account, or queues its bank transfer
+func (s *service) ApproveBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.repository.FindBeneficiary(ctx, &indto.BeneficiaryParams{BeneficiaryID: params.BeneficiaryID})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
+	if data == nil {
+		return errs.ErrNotFound
+	}
+
+	if data.Status != inconst.BNF_STATUS_PENDING {
+		return errs.ErrBeneficiaryClosed
+	}
+
+	now := time.Now()
 	beneModel := &model.Beneficiary{
-		ID:             snowflake.ID(),
-		AccountID:      accountMeta.ID,
-		MerchantID:     repoParams.MerchantID,
-		Amount:         nominal,
-		WithdrawalDate: &time.Time{},
+		ID:             data.ID,
 		Status:         inconst.BNF_STATUS_CONFIRM,
+		PayoutMethod:   data.PayoutMethod,
+		WithdrawalDate: &now,
+		ReviewedBy:     ctxutil.GetUserCTX(ctx).UserID,
 	}
-	*beneModel.WithdrawalDate = time.Now()
 
-	_, err = s.repository.CreateBeneficiary(ctx, beneModel)
-	if err != nil {
+	if data.PayoutMethod == inconst.BNF_PAYOUT_BANK {
+		if beneModel.Payout, err = s.beneficiaryPayout(ctx, data.MerchantID); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+
+		beneModel.Status = inconst.BNF_STATUS_PAYING
+	} else {
+		accountMeta, err := s.beneficiaryAccount(ctx, data.MerchantID)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		beneModel.AccountID = accountMeta.ID
+	}
+
+	if err = s.repository.ApproveBeneficiary(ctx, beneModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	s.emitWebhook(ctx, data.MerchantID, inconst.WEBHOOK_EVENT_WITHDRAWAL_UPDATED, &dto.WebhookWithdrawalData{
+		BeneficiaryID: data.ID,
+		Amount:        data.Amount,
+		Status:        beneModel.Status,
+	})
+
+	return
+}
+
+// RejectBeneficiary closes a pending withdrawal, its reserved amount is available for withdrawal again
+func (s *service) RejectBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams, payload *dto.BeneficiaryRejectPayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	beneModel := &model.Beneficiary{
+		ID:           uint64(params.BeneficiaryID),
+		RejectReason: payload.Reason,
+		ReviewedBy:   ctxutil.GetUserCTX(ctx).UserID,
+	}
+
+	if err = s.repository.RejectBeneficiary(ctx, beneModel); err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
+	if data, err := s.repository.FindBeneficiary(ctx, &indto.BeneficiaryParams{BeneficiaryID: params.BeneficiaryID}); err != nil {
+		logger.Error().Err(err).Msg("failed to fetch rejected beneficiary")
+	} else if data != nil {
+		s.emitWebhook(ctx, data.MerchantID, inconst.WEBHOOK_EVENT_WITHDRAWAL_UPDATED, &dto.WebhookWithdrawalData{
+			BeneficiaryID: data.ID,
+			Amount:        data.Amount,
+			Status:        data.Status,
+			Reason:        data.RejectReason,
+		})
+	}
+
+	return
+}
+
+// beneficiaryAccount resolves the account withdrawals of the merchant are paid out to
+func (s *service) beneficiaryAccount(ctx context.Context, merchantID string) (res *indto.Account, err error) {
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{MerchantID: merchantID})
+	if err != nil {
+		return
+	} else if merchantMeta == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	res, err = s.repository.FindAccount(ctx, &indto.AccountParams{UserID: merchantMeta.UserID})
+	if err != nil {
+		return
+	} else if res == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return
+}
+
+// beneficiaryPayout prepares the bank transfer of a withdrawal to the merchant's registered bank account,
+// the account details are copied so later changes do not redirect a transfer already queued
+func (s *service) beneficiaryPayout(ctx context.Context, merchantID string) (res *model.Payout, err error) {
+	connector, err := payoututil.GetConnector()
+	if err != nil {
+		return
+	}
+
+	bankMeta, err := s.merchantBankAccount(ctx, merchantID)
+	if err != nil {
+		return
+	} else if bankMeta == nil {
+		return nil, errs.ErrBankAccountMissing
+	}
+
+	res = &model.Payout{
+		Connector:   connector.Name(),
+		BankCode:    bankMeta.BankCode,
+		AccountNo:   bankMeta.AccountNo,
+		AccountName: bankMeta.AccountName,
+	}
+
 	return
 }
@@ -2,11 +2,13 @@ package service
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/repository"
 	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 )
 
 type Service interface {
@@ -14,6 +16,12 @@ type Service interface {
 
 	// ----- Session
 	AuthorizedAccessCtx(ctx context.Context, token string) (res context.Context, err error)
+	AuthorizedAPIClientCtx(ctx context.Context, params *indto.APIClientSignature) (res context.Context, err error)
+
+	// ----- Idempotency
+	AcquireIdempotencyKey(ctx context.Context, params *indto.IdempotencyParams) (res *indto.IdempotencyRecord, err error)
+	CompleteIdempotencyKey(ctx context.Context, params *indto.IdempotencyParams, payload *indto.IdempotencyRecord) (err error)
+	ReleaseIdempotencyKey(ctx context.Context, params *indto.IdempotencyParams) (err error)
 
 	// ----- Customers
 	GetAllCustomer(ctx context.Context, params *dto.CustomersQueryParams) (res *dto.ListCustomerResponse, err error)
@@ -32,6 +40,8 @@ type Service interface {
 	UpdateMerchant(ctx context.Context, params *dto.MerchantsQueryParams, payload *dto.MerchantPayload) (err error)
 	DeleteMerchant(ctx context.Context, params *dto.MerchantsQueryParams) (err error)
 	HandleDeleteMerchant(ctx context.Context, payload *indto.EventMerchant) (err error)
+	GetMerchantBankAccount(ctx context.Context, params *dto.MerchantsQueryParams) (res *dto.MerchantBankAccountResponse, err error)
+	UpdateMerchantBankAccount(ctx context.Context, params *dto.MerchantsQueryParams, payload *dto.MerchantBankAccountPayload) (err error)
 
 	// ----- Accounts
 	GetAllAccount(ctx context.Context, params *dto.AccountsQueryParams) (res *dto.ListAccountResponse, err error)
@@ -43,6 +53,27 @@ type Service interface {
 	DeleteAccount(ctx context.Context, params *dto.AccountsQueryParams) (err error)
 
 	AuthenticateAccountMe(ctx context.Context, payload *dto.AccountPayload) (err error)
+	UpdateAccountStatus(ctx context.Context, params *dto.AccountsQueryParams, payload *dto.AccountStatusPayload) (err error)
+	GetAccountStatusHistory(ctx context.Context, params *dto.AccountsQueryParams) (res *dto.ListAccountStatusHistoryResponse, err error)
+
+	// ----- Journals
+	GetAccountLedger(ctx context.Context, params *dto.LedgerQueryParams) (res *dto.AccountLedgerResponse, err error)
+	HandlePostOpeningBalances(ctx context.Context) (err error)
+
+	// ----- Exports
+	ExportTransactions(ctx context.Context, params *dto.TransactionExportParams) (res *dto.ExportStream, err error)
+	CreateTransactionExport(ctx context.Context, params *dto.TransactionExportParams) (res *dto.ExportJobResponse, err error)
+	GetExport(ctx context.Context, params *dto.ExportQueryParams) (res *dto.ExportJobResponse, err error)
+	DownloadExport(ctx context.Context, params *dto.ExportQueryParams) (res *dto.ExportFile, err error)
+	HandleProcessExportJobs(ctx context.Context) (err error)
+
+	// ----- Reconciliations
+	GetAllReconciliationRun(ctx context.Context, params *dto.ReconciliationQueryParams) (res *dto.ListReconciliationRunResponse, err error)
+	GetReconciliationDiscrepancies(ctx context.Context, params *dto.ReconciliationQueryParams) (res *dto.ListReconciliationDiscrepancyResponse, err error)
+	HandleReconcileBalances(ctx context.Context) (err error)
+
+	// ----- Statements
+	GetAccountStatementMe(ctx context.Context, params *dto.AccountStatementQueryParams) (res *dto.AccountStatementFile, err error)
 
 	// ----- Transactions
 	GetAllTransaction(ctx context.Context, params *dto.TransactionsQueryParams) (res *dto.ListTransactionResponse, err error)
@@ -50,18 +81,116 @@ type Service interface {
 	CreateTransactionP2P(ctx context.Context, payload *dto.TransactionPayload) (err error)
 	CreateTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (err error)
 	CreateTransactionSystem(ctx context.Context, payload *dto.TransactionPayload) (err error)
+	HandleCreateTransaction(ctx context.Context, envelope *indto.EventEnvelope) (err error)
+	RefundTransaction(ctx context.Context, params *dto.TransactionsQueryParams, payload *dto.TransactionRefundPayload) (err error)
+	AuthorizeTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (res *dto.TransactionAuthorizationResponse, err error)
+	CaptureTransactionP2B(ctx context.Context, params *dto.TransactionsQueryParams, payload *dto.TransactionCapturePayload) (err error)
+	ReleaseTransactionP2B(ctx context.Context, params *dto.TransactionsQueryParams) (err error)
+	HandleExpireAuthorizations(ctx context.Context) (err error)
 	UpdateTransaction(ctx context.Context, params *dto.TransactionsQueryParams, payload *dto.TransactionPayload) (err error)
 	DeleteTransaction(ctx context.Context, params *dto.TransactionsQueryParams) (err error)
 
+	// ----- Scheduled Transactions
+	GetAllScheduledTransaction(ctx context.Context, params *dto.ScheduledTransactionsQueryParams) (res *dto.ListScheduledTransactionResponse, err error)
+	GetScheduledTransaction(ctx context.Context, params *dto.ScheduledTransactionsQueryParams) (res *dto.ScheduledTransactionResponse, err error)
+	CreateScheduledTransaction(ctx context.Context, payload *dto.ScheduledTransactionPayload) (res *dto.ScheduledTransactionResponse, err error)
+	CancelScheduledTransaction(ctx context.Context, params *dto.ScheduledTransactionsQueryParams) (err error)
+	HandleCreateScheduledTransaction(ctx context.Context, envelope *indto.EventEnvelope) (err error)
+	HandleDeleteScheduledTransaction(ctx context.Context, envelope *indto.EventEnvelope) (err error)
+	HandleExecuteScheduledTransactions(ctx context.Context) (err error)
+
+	// ----- Standing Orders
+	GetAllStandingOrder(ctx context.Context, params *dto.StandingOrdersQueryParams) (res *dto.ListStandingOrderResponse, err error)
+	GetStandingOrder(ctx context.Context, params *dto.StandingOrdersQueryParams) (res *dto.StandingOrderResponse, err error)
+	GetStandingOrderRuns(ctx context.Context, params *dto.StandingOrdersQueryParams) (res *dto.ListStandingOrderRunResponse, err error)
+	CreateStandingOrder(ctx context.Context, payload *dto.StandingOrderPayload) (res *dto.StandingOrderResponse, err error)
+	CancelStandingOrder(ctx context.Context, params *dto.StandingOrdersQueryParams) (err error)
+	HandleExecuteStandingOrders(ctx context.Context) (err error)
+
+	// ----- Split Bills
+	GetAllSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (res *dto.ListSplitBillResponse, err error)
+	GetSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (res *dto.SplitBillResponse, err error)
+	CreateSplitBill(ctx context.Context, payload *dto.SplitBillPayload) (res *dto.SplitBillResponse, err error)
+	PaySplitBill(ctx context.Context, params *dto.SplitBillsQueryParams, payload *dto.SplitBillPayPayload) (res *dto.SplitBillResponse, err error)
+	RemindSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (res *dto.SplitBillRemindResponse, err error)
+	CancelSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (err error)
+
+	// ----- Fees
+	GetAllFeeSchedule(ctx context.Context, params *dto.FeeSchedulesQueryParams) (res *dto.ListFeeScheduleResponse, err error)
+	GetFeeSchedule(ctx context.Context, params *dto.FeeSchedulesQueryParams) (res *dto.FeeScheduleResponse, err error)
+	CreateFeeSchedule(ctx context.Context, payload *dto.FeeSchedulePayload) (err error)
+	DeleteFeeSchedule(ctx context.Context, params *dto.FeeSchedulesQueryParams) (err error)
+
+	// ----- Transaction Limits
+	GetAllTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams) (res *dto.ListTransactionLimitResponse, err error)
+	GetTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams) (res *dto.TransactionLimitResponse, err error)
+	CreateTransactionLimit(ctx context.Context, payload *dto.TransactionLimitPayload) (err error)
+	UpdateTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams, payload *dto.TransactionLimitPayload) (err error)
+	DeleteTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams) (err error)
+	GetAccountLimit(ctx context.Context, params *dto.AccountLimitQueryParams) (res *dto.AccountLimitResponse, err error)
+
 	// ----- Settlements
 	GetAllSettlement(ctx context.Context, params *dto.SettlementsQueryParams) (res *dto.ListSettlementResponse, err error)
 	GetSettlement(ctx context.Context, params *dto.SettlementsQueryParams) (res *dto.SettlementResponse, err error)
 
+	// ----- Settlement Batches
+	GetAllSettlementBatch(ctx context.Context, params *dto.SettlementBatchQueryParams) (res *dto.ListSettlementBatchResponse, err error)
+	GetSettlementBatch(ctx context.Context, params *dto.SettlementBatchQueryParams) (res *dto.SettlementBatchResponse, err error)
+	HandleBatchSettlements(ctx context.Context) (err error)
+
 	// ----- Beneficiaries
 	GetAllBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (res *dto.ListBeneficiaryResponse, err error)
 	GetBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (res *dto.BeneficiaryResponse, err error)
-	GetBeneficiaryPreview(ctx context.Context, params *dto.BeneficiariesQueryParams) (res float64, err error)
-	CreateBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (err error)
+	GetBeneficiaryPreview(ctx context.Context, params *dto.BeneficiariesQueryParams) (res money.Money, err error)
+	CreateBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams, payload *dto.BeneficiaryPayload) (err error)
+	ApproveBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams) (err error)
+	RejectBeneficiary(ctx context.Context, params *dto.BeneficiariesQueryParams, payload *dto.BeneficiaryRejectPayload) (err error)
+
+	// ----- Payouts
+	HandleProcessPayouts(ctx context.Context) (err error)
+	HandlePayoutCallback(ctx context.Context, header http.Header, body []byte) (err error)
+
+	// ----- QR Codes
+	GetMerchantStaticQR(ctx context.Context, params *dto.QRQueryParams) (res *dto.QRResponse, err error)
+	CreateMerchantDynamicQR(ctx context.Context, params *dto.QRQueryParams, payload *dto.DynamicQRPayload) (res *dto.QRResponse, err error)
+	ParseMerchantQR(ctx context.Context, payload *dto.QRParsePayload) (res *dto.QRPreviewResponse, err error)
+	PayMerchantQR(ctx context.Context, payload *dto.QRPayPayload) (res *dto.QRPaymentResponse, err error)
+
+	// ----- Invoices
+	GetAllInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (res *dto.ListInvoiceResponse, err error)
+	GetInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (res *dto.InvoiceResponse, err error)
+	CreateInvoice(ctx context.Context, payload *dto.InvoicePayload) (res *dto.InvoiceResponse, err error)
+	UpdateInvoice(ctx context.Context, params *dto.InvoicesQueryParams, payload *dto.InvoicePayload) (err error)
+	DeleteInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (err error)
+	IssueInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (err error)
+	VoidInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (err error)
+	PayInvoice(ctx context.Context, params *dto.InvoicesQueryParams, payload *dto.InvoicePayPayload) (res *dto.InvoiceResponse, err error)
+	HandleMarkOverdueInvoices(ctx context.Context) (err error)
+
+	// ----- Payment Links
+	GetAllPaymentLink(ctx context.Context, params *dto.PaymentLinksQueryParams) (res *dto.ListPaymentLinkResponse, err error)
+	GetPaymentLink(ctx context.Context, params *dto.PaymentLinksQueryParams) (res *dto.PaymentLinkResponse, err error)
+	GetPaymentLinkStats(ctx context.Context, params *dto.PaymentLinksQueryParams) (res *dto.PaymentLinkStatsResponse, err error)
+	CreatePaymentLink(ctx context.Context, payload *dto.PaymentLinkPayload) (res *dto.PaymentLinkResponse, err error)
+	DeactivatePaymentLink(ctx context.Context, params *dto.PaymentLinksQueryParams) (err error)
+	GetPaymentLinkPreview(ctx context.Context, params *dto.PaymentLinkTokenParams) (res *dto.PaymentLinkPreviewResponse, err error)
+	PayPaymentLink(ctx context.Context, params *dto.PaymentLinkTokenParams, payload *dto.PaymentLinkPayPayload) (res *dto.PaymentLinkPaymentResponse, err error)
+
+	// ----- Webhooks
+	GetAllWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (res *dto.ListWebhookResponse, err error)
+	GetWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (res *dto.WebhookResponse, err error)
+	CreateWebhook(ctx context.Context, payload *dto.WebhookPayload) (res *dto.WebhookResponse, err error)
+	UpdateWebhook(ctx context.Context, params *dto.WebhooksQueryParams, payload *dto.WebhookPayload) (err error)
+	DeleteWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (err error)
+	PingWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (err error)
+	GetAllWebhookDelivery(ctx context.Context, params *dto.WebhooksQueryParams) (res *dto.ListWebhookDeliveryResponse, err error)
+	RedeliverWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (err error)
+	HandleDispatchWebhooks(ctx context.Context) (err error)
+
+	// ----- API Clients
+	GetAllAPIClient(ctx context.Context, params *dto.APIClientsQueryParams) (res *dto.ListAPIClientResponse, err error)
+	CreateAPIClient(ctx context.Context, payload *dto.APIClientPayload) (res *dto.APIClientResponse, err error)
+	RevokeAPIClient(ctx context.Context, params *dto.APIClientsQueryParams) (err error)
 
 	// ----- Dashboard
 	GetAdminDashboard(ctx context.Context) (res *dto.AdminDashboard, err error)
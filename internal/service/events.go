@@ -3,10 +3,18 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/rs/zerolog"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/util/eventutil"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
 )
 
+// a signed event is refused once its timestamp drifts further than this from ours, either way
+const eventClockSkew = 5 * time.Minute
+
 func (s *service) publishEvent(ctx context.Context, channel string, payload interface{}) (err error) {
 	logger := zerolog.Ctx(ctx)
 
@@ -23,3 +31,38 @@ func (s *service) publishEvent(ctx context.Context, channel string, payload inte
 
 	return
 }
+
+// openEventEnvelope authenticates envelope as published on topic by a trusted service and decodes its payload into
+// dst. Each signature is accepted once, a request captured off the bus cannot be replayed
+func (s *service) openEventEnvelope(ctx context.Context, topic string, envelope *indto.EventEnvelope, dst interface{}) (err error) {
+	logger := zerolog.Ctx(ctx)
+	conf := config.Get()
+
+	key, ok := conf.TrustedServiceKeys[envelope.ServiceID]
+	if !ok || !conf.TrustedService[envelope.ServiceID] {
+		logger.Error().Str("service-id", envelope.ServiceID).Str("channel", topic).Msg("event published by untrusted service")
+		return errs.ErrNoAccess
+	}
+
+	err = eventutil.Verify(key, topic, envelope.ServiceID, envelope.Timestamp, envelope.Payload, envelope.Signature, eventClockSkew)
+	if err != nil {
+		logger.Error().Err(err).Str("service-id", envelope.ServiceID).Str("channel", topic).Send()
+		return errs.ErrNoAccess
+	}
+
+	ok, err = s.repository.CreateEventNonce(ctx, envelope.ServiceID, envelope.Signature, 2*eventClockSkew)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if !ok {
+		logger.Error().Str("service-id", envelope.ServiceID).Str("channel", topic).Msg("event replayed")
+		return errs.ErrNoAccess
+	}
+
+	if err = json.Unmarshal(envelope.Payload, dst); err != nil {
+		logger.Error().Err(err).Str("service-id", envelope.ServiceID).Str("channel", topic).Msg("failed to unmarshal payload")
+		return errs.ErrBadRequest
+	}
+
+	return
+}
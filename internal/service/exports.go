@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/exportutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	// larger exports have to go through a background export job
+	trxExportStreamMaxRows = 50000
+
+	exportJobBatch      = 5
+	exportJobStaleAfter = 30 * time.Minute
+	exportFileTTL       = 24 * time.Hour
+	exportDir           = "exports"
+
+	// mirrors exportDownloadPath of the router
+	exportDownloadURL = "/payment/api/v1/exports/%d/download"
+)
+
+var trxExportHeader = []string{
+	"id", "parent_id", "trx_datetime", "trx_type", "trx_status", "account_id", "account_name", "recipient_id", "recipient_name",
+	"nominal", "trx_fee", "refunded_amount", "description",
+}
+
+// ExportTransactions validates the export and returns a writer streaming every matching transaction
+func (s *service) ExportTransactions(ctx context.Context, params *dto.TransactionExportParams) (res *dto.ExportStream, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Format == "" {
+		params.Format = exportutil.FormatCSV
+	}
+
+	if !exportutil.IsValidFormat(params.Format) {
+		logger.Error().Str("format", params.Format).Msg("unsupported export format")
+		return nil, errs.ErrBadRequest
+	}
+
+	repoParams := transactionExportRepoParams(params)
+
+	count, err := s.repository.CountTransactions(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count > trxExportStreamMaxRows {
+		return nil, errs.New(errs.ErrExportTooLarge, trxExportStreamMaxRows)
+	}
+
+	res = &dto.ExportStream{
+		Filename:    fmt.Sprintf("transactions-%s.%s", timeutil.FormatVerboseLogTime(time.Now()), params.Format),
+		ContentType: exportutil.ContentType(params.Format),
+		Write: func(w io.Writer) (err error) {
+			_, err = s.writeTransactionExport(ctx, repoParams, params.Format, w)
+			return
+		},
+	}
+
+	return
+}
+
+// CreateTransactionExport queues the export for HandleProcessExportJobs, the file is kept for exportFileTTL
+func (s *service) CreateTransactionExport(ctx context.Context, params *dto.TransactionExportParams) (res *dto.ExportJobResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Format == "" {
+		params.Format = exportutil.FormatCSV
+	}
+
+	if !exportutil.IsValidFormat(params.Format) {
+		logger.Error().Str("format", params.Format).Msg("unsupported export format")
+		return nil, errs.ErrBadRequest
+	}
+
+	filters, err := json.Marshal(params)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to marshal export params")
+		return
+	}
+
+	jobModel := &model.ExportJob{
+		ID:         snowflake.ID(),
+		UserID:     ctxutil.GetUserCTX(ctx).UserID,
+		ExportType: inconst.EXPORT_TYPE_TRANSACTIONS,
+		Format:     params.Format,
+		Params:     filters,
+		Status:     inconst.EXPORT_STATUS_PENDING,
+	}
+
+	if err = s.repository.CreateExportJob(ctx, jobModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = &dto.ExportJobResponse{
+		ID:         jobModel.ID,
+		ExportType: jobModel.ExportType,
+		Format:     jobModel.Format,
+		Status:     jobModel.Status,
+		CreatedAt:  timeutil.FormatVerboseTime(time.Now()),
+	}
+
+	return
+}
+
+func (s *service) GetExport(ctx context.Context, params *dto.ExportQueryParams) (res *dto.ExportJobResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.repository.FindExportJob(ctx, &indto.ExportJobParams{
+		ExportID: params.ExportID,
+		UserID:   ctxutil.GetUserCTX(ctx).UserID,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	res = &dto.ExportJobResponse{
+		ID:         data.ID,
+		ExportType: data.ExportType,
+		Format:     data.Format,
+		Status:     data.Status,
+		RowCount:   data.RowCount,
+		LastError:  data.LastError,
+		CreatedAt:  timeutil.FormatVerboseTime(data.CreatedAt),
+	}
+
+	if data.Status == inconst.EXPORT_STATUS_COMPLETED {
+		res.DownloadURL = fmt.Sprintf(exportDownloadURL, data.ID)
+	}
+
+	if data.ExpiresAt != nil {
+		res.ExpiresAt = timeutil.FormatVerboseTime(*data.ExpiresAt)
+	}
+
+	return
+}
+
+func (s *service) DownloadExport(ctx context.Context, params *dto.ExportQueryParams) (res *dto.ExportFile, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.repository.FindExportJob(ctx, &indto.ExportJobParams{
+		ExportID: params.ExportID,
+		UserID:   ctxutil.GetUserCTX(ctx).UserID,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// only completed exports still have a file on disk
+	if data == nil || data.Status != inconst.EXPORT_STATUS_COMPLETED {
+		return nil, errs.ErrNotFound
+	}
+
+	res = &dto.ExportFile{
+		Path:     path.Join(conf.FilePath, exportDir, data.FileName),
+		Filename: fmt.Sprintf("transactions-%d.%s", data.ID, data.Format),
+	}
+
+	return
+}
+
+// HandleProcessExportJobs removes expired export files then writes every pending export to FilePath
+func (s *service) HandleProcessExportJobs(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	expired, err := s.repository.ExpireExportJobs(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range expired {
+		if err := os.Remove(path.Join(conf.FilePath, exportDir, v.FileName)); err != nil && !os.IsNotExist(err) {
+			logger.Error().Err(err).Uint64("export-id", v.ID).Msg("failed to remove expired export")
+		}
+	}
+
+	for {
+		data, err := s.repository.ClaimExportJobs(ctx, exportJobBatch, exportJobStaleAfter)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		for _, v := range data {
+			if err = s.processExportJob(ctx, v); err != nil {
+				logger.Error().Err(err).Uint64("export-id", v.ID).Msg("failed to update export job")
+			}
+		}
+
+		if len(data) < exportJobBatch {
+			return nil
+		}
+	}
+}
+
+func (s *service) processExportJob(ctx context.Context, data *indto.ExportJob) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	jobModel := &model.ExportJob{
+		ID:       data.ID,
+		Status:   inconst.EXPORT_STATUS_COMPLETED,
+		FileName: fmt.Sprintf("%d.%s", data.ID, data.Format),
+	}
+
+	filePath := path.Join(conf.FilePath, exportDir, jobModel.FileName)
+
+	jobModel.RowCount, err = s.writeExportFile(ctx, data, filePath)
+	if err != nil {
+		logger.Error().Err(err).Uint64("export-id", data.ID).Msg("export failed")
+
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			logger.Error().Err(err).Uint64("export-id", data.ID).Msg("failed to remove partial export")
+		}
+
+		jobModel.Status = inconst.EXPORT_STATUS_FAILED
+		jobModel.FileName = ""
+		jobModel.RowCount = 0
+		jobModel.LastError = err.Error()
+	} else {
+		expiresAt := time.Now().Add(exportFileTTL)
+		jobModel.ExpiresAt = &expiresAt
+	}
+
+	return s.repository.UpdateExportJob(ctx, jobModel)
+}
+
+func (s *service) writeExportFile(ctx context.Context, data *indto.ExportJob, filePath string) (count int64, err error) {
+	if data.ExportType != inconst.EXPORT_TYPE_TRANSACTIONS {
+		return 0, fmt.Errorf("unsupported export type %d", data.ExportType)
+	}
+
+	params := &dto.TransactionExportParams{}
+	if err = json.Unmarshal(data.Params, params); err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return
+	}
+
+	f, err := os.Create(filePath)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	if count, err = s.writeTransactionExport(ctx, transactionExportRepoParams(params), data.Format, f); err != nil {
+		return
+	}
+
+	return count, f.Close()
+}
+
+// writeTransactionExport writes rows as the cursor advances, names are decrypted per row
+func (s *service) writeTransactionExport(ctx context.Context, params *indto.TransactionParams, format string, w io.Writer) (count int64, err error) {
+	conf := config.Get()
+
+	rw, err := exportutil.NewRowWriter(format, w)
+	if err != nil {
+		return
+	}
+
+	if err = rw.WriteRow(trxExportHeader); err != nil {
+		return
+	}
+
+	err = s.repository.StreamTransactions(ctx, params, func(data *indto.Transaction) error {
+		accountName, recipientName := "", ""
+
+		if data.AccountName != nil {
+			accountName = cryptoutil.DecryptField(data.AccountName, conf.DBKey)
+		}
+
+		if data.RecipientName != nil {
+			if data.TrxType == 1 || data.TrxType == 4 || data.TrxType == 9 {
+				recipientName = cryptoutil.DecryptField(data.RecipientName, conf.DBKey)
+			} else {
+				recipientName = string(data.RecipientName)
+			}
+		}
+
+		count++
+		return rw.WriteRow([]string{
+			strconv.FormatUint(data.ID, 10),
+			strconv.FormatUint(data.ParentID, 10),
+			timeutil.FormatVerboseTime(data.TrxDatetime),
+			strconv.FormatInt(data.TrxType, 10),
+			strconv.FormatInt(data.TrxStatus, 10),
+			data.AccountID,
+			accountName,
+			data.RecipientID,
+			recipientName,
+			data.Nominal.String(),
+			data.TrxFee.String(),
+			data.RefundedAmount.String(),
+			data.Description,
+		})
+	})
+	if err != nil {
+		return
+	}
+
+	err = rw.Close()
+	return
+}
+
+func transactionExportRepoParams(params *dto.TransactionExportParams) *indto.TransactionParams {
+	return &indto.TransactionParams{
+		AccountID: params.AccountID,
+		TrxType:   params.TrxType,
+		DateStart: timeutil.ParseDate(params.DateStart),
+		DateEnd:   timeutil.ParseDate(params.DateEnd),
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func (s *service) GetAllFeeSchedule(ctx context.Context, params *dto.FeeSchedulesQueryParams) (res *dto.ListFeeScheduleResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.FeeScheduleParams{
+		Code:       params.Code,
+		TrxType:    params.TrxType,
+		MerchantID: params.MerchantID,
+		Limit:      params.Limit,
+		Page:       params.Page,
+	}
+
+	res = &dto.ListFeeScheduleResponse{
+		FeeSchedules: []*dto.FeeScheduleResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountFeeSchedules(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindFeeSchedules(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.FeeSchedules = append(res.FeeSchedules, feeScheduleResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetFeeSchedule(ctx context.Context, params *dto.FeeSchedulesQueryParams) (res *dto.FeeScheduleResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.repository.FindFeeSchedule(ctx, &indto.FeeScheduleParams{FeeScheduleID: params.FeeScheduleID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	rules, err := s.repository.FindFeeRules(ctx, &indto.FeeRuleParams{ScheduleID: data.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = feeScheduleResponse(data)
+	res.Rules = []*dto.FeeRuleResponse{}
+	for _, v := range rules {
+		res.Rules = append(res.Rules, &dto.FeeRuleResponse{
+			ID:         v.ID,
+			MinNominal: v.MinNominal,
+			FeeType:    v.FeeType,
+			FlatFee:    v.FlatFee,
+			RateBps:    v.RateBps,
+			MinFee:     v.MinFee,
+			MaxFee:     v.MaxFee,
+		})
+	}
+
+	return
+}
+
+func (s *service) CreateFeeSchedule(ctx context.Context, payload *dto.FeeSchedulePayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if len(payload.Rules) == 0 {
+		logger.Error().Msg("fee schedule requires at least one rule")
+		return errs.New(errs.ErrMissingRequiredAttribute, "Rules")
+	}
+
+	scheduleModel := &model.FeeSchedule{
+		Code:          payload.Code,
+		Name:          payload.Name,
+		TrxType:       payload.TrxType,
+		MerchantID:    payload.MerchantID,
+		AccountTier:   payload.AccountTier,
+		EffectiveFrom: time.Now(),
+	}
+
+	if payload.EffectiveFrom != "" {
+		if scheduleModel.EffectiveFrom = timeutil.ParseLocaltime(payload.EffectiveFrom); scheduleModel.EffectiveFrom.IsZero() {
+			logger.Error().Str("effective-from", payload.EffectiveFrom).Msg("invalid effective date")
+			return errs.ErrBadRequest
+		}
+	}
+
+	if payload.EffectiveUntil != "" {
+		until := timeutil.ParseLocaltime(payload.EffectiveUntil)
+		if until.IsZero() || !until.After(scheduleModel.EffectiveFrom) {
+			logger.Error().Str("effective-until", payload.EffectiveUntil).Msg("invalid effective date")
+			return errs.ErrBadRequest
+		}
+
+		scheduleModel.EffectiveUntil = &until
+	}
+
+	tiers := map[int64]bool{}
+	for _, v := range payload.Rules {
+		if !validFeeRule(v) || tiers[v.MinNominal.Minor()] {
+			logger.Error().Str("code", payload.Code).Str("min-nominal", v.MinNominal.String()).Msg("invalid fee rule")
+			return errs.ErrBadRequest
+		}
+		tiers[v.MinNominal.Minor()] = true
+
+		scheduleModel.Rules = append(scheduleModel.Rules, &model.FeeRule{
+			MinNominal: v.MinNominal,
+			FeeType:    v.FeeType,
+			FlatFee:    v.FlatFee,
+			RateBps:    v.RateBps,
+			MinFee:     v.MinFee,
+			MaxFee:     v.MaxFee,
+		})
+	}
+
+	if _, err = s.repository.CreateFeeSchedule(ctx, scheduleModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) DeleteFeeSchedule(ctx context.Context, params *dto.FeeSchedulesQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	err = s.repository.DeleteFeeSchedule(ctx, &indto.FeeScheduleParams{FeeScheduleID: params.FeeScheduleID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+type trxFeeParams struct {
+	TrxType     int64
+	MerchantID  string
+	AccountTier int64
+	Nominal     money.Money
+}
+
+// calculateTrxFee resolves fee of a transaction from the effective schedule,
+// no schedule in effect means the transaction is free of charge
+func (s *service) calculateTrxFee(ctx context.Context, params *trxFeeParams) (fee money.Money, ruleID uint64, err error) {
+	logger := log.Ctx(ctx)
+
+	fee = money.Zero()
+
+	schedule, err := s.repository.FindEffectiveFeeSchedule(ctx, &indto.FeeScheduleParams{
+		TrxType:     params.TrxType,
+		MerchantID:  params.MerchantID,
+		AccountTier: params.AccountTier,
+		EffectiveAt: time.Now(),
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if schedule == nil {
+		return
+	}
+
+	rules, err := s.repository.FindFeeRules(ctx, &indto.FeeRuleParams{ScheduleID: schedule.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// rules are ordered by min nominal, the highest tier reached applies
+	var rule *indto.FeeRule
+	for _, v := range rules {
+		if v.MinNominal.GreaterThan(params.Nominal) {
+			break
+		}
+		rule = v
+	}
+
+	if rule == nil {
+		return
+	}
+
+	switch rule.FeeType {
+	case inconst.FEE_TYPE_FLAT:
+		fee = rule.FlatFee
+	case inconst.FEE_TYPE_PERCENTAGE:
+		fee = params.Nominal.MulRatio(rule.RateBps, inconst.FEE_RATE_BASE)
+	case inconst.FEE_TYPE_FLAT_PERCENTAGE:
+		fee = rule.FlatFee.Add(params.Nominal.MulRatio(rule.RateBps, inconst.FEE_RATE_BASE))
+	}
+
+	fee = fee.Max(rule.MinFee)
+	if rule.MaxFee.IsPositive() {
+		fee = fee.Min(rule.MaxFee)
+	}
+
+	return fee, rule.ID, nil
+}
+
+func validFeeRule(v *dto.FeeRulePayload) bool {
+	if v.FeeType != inconst.FEE_TYPE_FLAT && v.FeeType != inconst.FEE_TYPE_PERCENTAGE && v.FeeType != inconst.FEE_TYPE_FLAT_PERCENTAGE {
+		return false
+	}
+
+	if v.RateBps < 0 || v.RateBps > inconst.FEE_RATE_BASE {
+		return false
+	}
+
+	if v.MinNominal.IsNegative() || v.FlatFee.IsNegative() || v.MinFee.IsNegative() || v.MaxFee.IsNegative() {
+		return false
+	}
+
+	// zero max fee means uncapped
+	return v.MaxFee.IsZero() || !v.MaxFee.LessThan(v.MinFee)
+}
+
+func feeScheduleResponse(v *indto.FeeSchedule) *dto.FeeScheduleResponse {
+	res := &dto.FeeScheduleResponse{
+		ID:            v.ID,
+		Code:          v.Code,
+		Name:          v.Name,
+		TrxType:       v.TrxType,
+		MerchantID:    v.MerchantID,
+		AccountTier:   v.AccountTier,
+		Version:       v.Version,
+		EffectiveFrom: timeutil.FormatVerboseTime(v.EffectiveFrom),
+	}
+
+	if v.EffectiveUntil != nil {
+		res.EffectiveUntil = timeutil.FormatVerboseTime(*v.EffectiveUntil)
+	}
+
+	return res
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+// in-flight keys expire quickly so a crashed request does not block retries for long
+const idempotencyLockExp = 5 * time.Minute
+
+// AcquireIdempotencyKey reserves key for the current request. A nil result means
+// the request should be processed, otherwise the stored response should be replayed
+func (s *service) AcquireIdempotencyKey(ctx context.Context, params *indto.IdempotencyParams) (res *indto.IdempotencyRecord, err error) {
+	logger := log.Ctx(ctx)
+
+	ok, err := s.repository.CreateIdempotencyRecord(ctx, params, &indto.IdempotencyRecord{RequestHash: params.RequestHash}, idempotencyLockExp)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if ok {
+		return nil, nil
+	}
+
+	res, err = s.repository.FindIdempotencyRecord(ctx, params)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if res == nil {
+		// expired between both calls, treat as in-flight and let client retry
+		return nil, errs.ErrRequestInProgress
+	}
+
+	if res.RequestHash != params.RequestHash {
+		logger.Warn().Str("user-id", params.UserID).Str("idempotency-key", params.Key).Msg("idempotency key reused with different payload")
+		return nil, errs.ErrIdempotencyConflict
+	}
+
+	if !res.Completed {
+		return nil, errs.ErrRequestInProgress
+	}
+
+	return
+}
+
+func (s *service) CompleteIdempotencyKey(ctx context.Context, params *indto.IdempotencyParams, payload *indto.IdempotencyRecord) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	payload.RequestHash = params.RequestHash
+	payload.Completed = true
+
+	if err = s.repository.UpdateIdempotencyRecord(ctx, params, payload, conf.RedisConfig.DefaultExp); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) ReleaseIdempotencyKey(ctx context.Context, params *indto.IdempotencyParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if err = s.repository.DeleteIdempotencyRecord(ctx, params); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
@@ -0,0 +1,482 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// customers never see drafts, they are not addressed to them until issued
+var invoiceCustomerStatuses = []int64{inconst.INVOICE_STATUS_OPEN, inconst.INVOICE_STATUS_PAID, inconst.INVOICE_STATUS_OVERDUE, inconst.INVOICE_STATUS_VOID}
+
+// GetAllInvoice lists invoices issued by the merchant or addressed to the customer account of the caller,
+// admins see every invoice
+func (s *service) GetAllInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (res *dto.ListInvoiceResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams, err := s.invoiceParams(ctx, params)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+	repoParams.Limit = params.Limit
+	repoParams.Page = params.Page
+
+	res = &dto.ListInvoiceResponse{
+		Invoices: []*dto.InvoiceResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountInvoices(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindInvoices(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Invoices = append(res.Invoices, invoiceResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (res *dto.InvoiceResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	repoParams, err := s.invoiceParams(ctx, params)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+	repoParams.InvoiceID = params.InvoiceID
+
+	if res, err = s.invoiceDetail(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// CreateInvoice drafts an invoice of the merchant, it is only visible to the customer once issued
+func (s *service) CreateInvoice(ctx context.Context, payload *dto.InvoicePayload) (res *dto.InvoiceResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	invoiceModel, err := s.invoiceModel(ctx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	invoiceModel.ID = snowflake.ID()
+	invoiceModel.MerchantID = merchantMeta.ID
+	invoiceModel.Status = inconst.INVOICE_STATUS_DRAFT
+
+	if err = s.repository.CreateInvoice(ctx, invoiceModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if res, err = s.invoiceDetail(ctx, &indto.InvoiceParams{InvoiceID: invoiceModel.ID}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// UpdateInvoice replaces a draft invoice including its line items, issued invoices can only be voided
+func (s *service) UpdateInvoice(ctx context.Context, params *dto.InvoicesQueryParams, payload *dto.InvoicePayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if exists, err := s.repository.FindInvoice(ctx, &indto.InvoiceParams{InvoiceID: params.InvoiceID, MerchantID: merchantMeta.ID}); err != nil {
+		logger.Error().Err(err).Send()
+		return err
+	} else if exists == nil {
+		return errs.ErrNotFound
+	}
+
+	invoiceModel, err := s.invoiceModel(ctx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	invoiceModel.ID = params.InvoiceID
+	invoiceModel.MerchantID = merchantMeta.ID
+
+	if err = s.repository.UpdateInvoice(ctx, invoiceModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) DeleteInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (err error) {
+	return s.transitionInvoice(ctx, params, s.repository.DeleteInvoice)
+}
+
+// IssueInvoice opens a draft invoice, from then on the customer sees it and can pay it
+func (s *service) IssueInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (err error) {
+	return s.transitionInvoice(ctx, params, s.repository.IssueInvoice)
+}
+
+func (s *service) VoidInvoice(ctx context.Context, params *dto.InvoicesQueryParams) (err error) {
+	return s.transitionInvoice(ctx, params, s.repository.VoidInvoice)
+}
+
+// PayInvoice pays the invoice total from the customer account it is addressed to through the regular P2B transfer,
+// the invoice is marked paid with the resulting transaction
+func (s *service) PayInvoice(ctx context.Context, params *dto.InvoicesQueryParams, payload *dto.InvoicePayPayload) (res *dto.InvoiceResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	repoParams, err := s.invoiceParams(ctx, params)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+	repoParams.InvoiceID = params.InvoiceID
+
+	data, err := s.repository.FindInvoice(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if data.Status != inconst.INVOICE_STATUS_OPEN && data.Status != inconst.INVOICE_STATUS_OVERDUE {
+		return nil, errs.ErrInvoiceClosed
+	}
+
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{MerchantID: data.MerchantID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if merchantMeta == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	merchantAccount, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: merchantMeta.UserID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if merchantAccount == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if err = s.verifyAccountPIN(ctx, data.CustomerAccountID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trx, err := s.prepareTransfer(ctx, 0, inconst.TRX_TYPE_P2B, &dto.TransactionPayload{
+		AccountID:   data.CustomerAccountID,
+		RecipientID: merchantAccount.ID,
+		TrxType:     inconst.TRX_TYPE_P2B,
+		Nominal:     data.Total,
+		Description: fmt.Sprintf("invoice %d", data.ID),
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// the invoice is closed in the database transaction that pays it
+	if err = s.repository.PayInvoice(ctx, &model.Invoice{ID: data.ID, CustomerAccountID: data.CustomerAccountID}, trx); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	s.notifyTransfer(ctx, trx)
+
+	if res, err = s.invoiceDetail(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleMarkOverdueInvoices flags open invoices whose due date has passed
+func (s *service) HandleMarkOverdueInvoices(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	count, err := s.repository.MarkOverdueInvoices(ctx, timeutil.ConvertLocalTime(time.Now()))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count > 0 {
+		logger.Info().Int64("count", count).Msg("invoices marked overdue")
+	}
+
+	return
+}
+
+// invoiceParams scopes invoice lookups to the caller, merchants to the invoices they issued and
+// customers to the issued invoices addressed to their account
+func (s *service) invoiceParams(ctx context.Context, params *dto.InvoicesQueryParams) (res *indto.InvoiceParams, err error) {
+	res = &indto.InvoiceParams{}
+	if params.Status != 0 {
+		res.Statuses = []int64{params.Status}
+	}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	switch usrmeta.RoleID {
+	case inconst.ROLE_MERCHANT:
+		merchantMeta, err := s.callerMerchant(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res.MerchantID = merchantMeta.ID
+	case inconst.ROLE_CUSTOMER:
+		accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: usrmeta.UserID})
+		if err != nil {
+			return nil, err
+		} else if accountMeta == nil {
+			return nil, errs.New(errs.ErrNotFound)
+		}
+
+		res.CustomerAccountID = accountMeta.ID
+		if params.Status == inconst.INVOICE_STATUS_DRAFT || params.Status == 0 {
+			res.Statuses = invoiceCustomerStatuses
+		}
+	}
+
+	return
+}
+
+func (s *service) invoiceDetail(ctx context.Context, params *indto.InvoiceParams) (res *dto.InvoiceResponse, err error) {
+	data, err := s.repository.FindInvoice(ctx, params)
+	if err != nil {
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	items, err := s.repository.FindInvoiceItems(ctx, &indto.InvoiceItemParams{InvoiceID: data.ID})
+	if err != nil {
+		return
+	}
+
+	res = invoiceResponse(data)
+	res.Items = []*dto.InvoiceItemResponse{}
+	for _, v := range items {
+		res.Items = append(res.Items, &dto.InvoiceItemResponse{
+			ID:          v.ID,
+			Description: v.Description,
+			Quantity:    v.Quantity,
+			UnitPrice:   v.UnitPrice,
+			Amount:      v.Amount,
+		})
+	}
+
+	return
+}
+
+// invoiceModel validates payload and totals its line items, the customer is addressed by account number
+func (s *service) invoiceModel(ctx context.Context, payload *dto.InvoicePayload) (res *model.Invoice, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if len(payload.Items) == 0 {
+		logger.Error().Msg("invoice requires at least one item")
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, "Items")
+	}
+
+	dueDate := timeutil.ParseLocalDate(payload.DueDate)
+	if dueDate.IsZero() || dueDate.Before(timeutil.Truncate(timeutil.ConvertLocalTime(time.Now()))) {
+		logger.Error().Str("due-date", payload.DueDate).Msg("invalid due date")
+		return nil, errs.ErrBadRequest
+	}
+
+	if payload.TaxRateBps < 0 || payload.TaxRateBps > inconst.FEE_RATE_BASE {
+		logger.Error().Int64("tax-rate-bps", payload.TaxRateBps).Msg("invalid tax rate")
+		return nil, errs.ErrBadRequest
+	}
+
+	res = &model.Invoice{
+		CustomerReference: payload.CustomerReference,
+		Description:       payload.Description,
+		Subtotal:          money.Zero(),
+		TaxRateBps:        payload.TaxRateBps,
+		DueDate:           dueDate,
+	}
+
+	for _, v := range payload.Items {
+		if v.Description == "" || v.Quantity <= 0 || !v.UnitPrice.IsPositive() {
+			logger.Error().Str("description", v.Description).Int64("quantity", v.Quantity).Str("unit-price", v.UnitPrice.String()).Msg("invalid invoice item")
+			return nil, errs.ErrBadRequest
+		}
+
+		item := &model.InvoiceItem{
+			Description: v.Description,
+			Quantity:    v.Quantity,
+			UnitPrice:   v.UnitPrice,
+			Amount:      v.UnitPrice.MulRatio(v.Quantity, 1),
+		}
+
+		res.Items = append(res.Items, item)
+		res.Subtotal = res.Subtotal.Add(item.Amount)
+	}
+
+	res.TaxAmount = res.Subtotal.MulRatio(res.TaxRateBps, inconst.FEE_RATE_BASE)
+	res.Total = res.Subtotal.Add(res.TaxAmount)
+
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountNoHash: cryptoutil.HMACSHA512([]byte(payload.CustomerAccountNo), conf.HashKey)})
+	if err != nil {
+		return
+	} else if accountMeta == nil || accountMeta.AccountType != inconst.ACCOUNT_TYPE_CUST {
+		logger.Error().Msg("invoice customer account not found")
+		return nil, errs.ErrNotFound
+	}
+
+	res.CustomerAccountID = accountMeta.ID
+
+	return
+}
+
+// transitionInvoice applies a status change to an invoice of the calling merchant
+func (s *service) transitionInvoice(ctx context.Context, params *dto.InvoicesQueryParams, apply func(context.Context, *indto.InvoiceParams) error) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.InvoiceParams{InvoiceID: params.InvoiceID, MerchantID: merchantMeta.ID}
+	if exists, err := s.repository.FindInvoice(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return err
+	} else if exists == nil {
+		return errs.ErrNotFound
+	}
+
+	if err = apply(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func invoiceResponse(v *indto.Invoice) *dto.InvoiceResponse {
+	res := &dto.InvoiceResponse{
+		ID:                v.ID,
+		MerchantID:        v.MerchantID,
+		MerchantName:      v.MerchantName,
+		CustomerAccountID: v.CustomerAccountID,
+		CustomerReference: v.CustomerReference,
+		Description:       v.Description,
+		Subtotal:          v.Subtotal,
+		TaxRateBps:        v.TaxRateBps,
+		TaxAmount:         v.TaxAmount,
+		Total:             v.Total,
+		DueDate:           timeutil.FormatDate(v.DueDate),
+		Status:            v.Status,
+		TransactionID:     v.TransactionID,
+		CreatedAt:         timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+
+	if v.IssuedAt.Valid {
+		res.IssuedAt = timeutil.FormatVerboseTime(v.IssuedAt.Time)
+	}
+
+	if v.PaidAt.Valid {
+		res.PaidAt = timeutil.FormatVerboseTime(v.PaidAt.Time)
+	}
+
+	return res
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+func (s *service) GetAccountLedger(ctx context.Context, params *dto.LedgerQueryParams) (res *dto.AccountLedgerResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	res = &dto.AccountLedgerResponse{
+		AccountID: params.AccountID,
+		Postings:  []*dto.JournalPostingResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	// system-side ledger accounts (fee, settlement) have no accounts row
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: params.AccountID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if accountMeta != nil {
+		res.StoredBalance = accountMeta.Balance
+	}
+
+	repoParams := &indto.JournalParams{
+		AccountID: params.AccountID,
+		Limit:     params.Limit,
+		Page:      params.Page,
+	}
+
+	res.LedgerBalance, err = s.repository.FindLedgerBalance(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	count, err := s.repository.CountJournalPostings(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindJournalPostings(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Postings = append(res.Postings, &dto.JournalPostingResponse{
+			ID:            v.ID,
+			JournalID:     v.JournalID,
+			TransactionID: v.TransactionID,
+			EntryType:     v.EntryType,
+			Description:   v.Description,
+			EntryDatetime: timeutil.FormatVerboseTime(v.EntryDatetime),
+			Amount:        v.Amount,
+		})
+	}
+
+	return
+}
+
+// HandlePostOpeningBalances brings accounts funded before the journal existed into it, along with the negative
+// balances written off by the balance constraint migration. Runs on every start
+func (s *service) HandlePostOpeningBalances(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	// write-offs go first, an account zeroed by one has nothing left for the opening balance to post
+	count, err := s.repository.PostBalanceWriteOffs(ctx, conf.EquityAccountUUID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count != 0 {
+		logger.Info().Int64("accounts", count).Msg("posted balance write-offs")
+	}
+
+	count, err = s.repository.PostOpeningBalances(ctx, conf.EquityAccountUUID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count != 0 {
+		logger.Info().Int64("accounts", count).Msg("posted opening balances")
+	}
+
+	return
+}
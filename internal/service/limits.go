@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func (s *service) GetAllTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams) (res *dto.ListTransactionLimitResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.TransactionLimitParams{
+		TrxType:     params.TrxType,
+		AccountType: params.AccountType,
+		AccountTier: params.AccountTier,
+		Limit:       params.Limit,
+		Page:        params.Page,
+	}
+
+	res = &dto.ListTransactionLimitResponse{
+		TransactionLimits: []*dto.TransactionLimitResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountTransactionLimits(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindTransactionLimits(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.TransactionLimits = append(res.TransactionLimits, transactionLimitResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams) (res *dto.TransactionLimitResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.repository.FindTransactionLimit(ctx, &indto.TransactionLimitParams{LimitID: params.LimitID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return transactionLimitResponse(data), nil
+}
+
+func (s *service) CreateTransactionLimit(ctx context.Context, payload *dto.TransactionLimitPayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if !validTransactionLimit(payload) {
+		logger.Error().Str("name", payload.Name).Msg("invalid transaction limit")
+		return errs.ErrBadRequest
+	}
+
+	limitModel := transactionLimitModel(payload)
+	limitModel.ID = snowflake.ID()
+
+	if err = s.repository.CreateTransactionLimit(ctx, limitModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) UpdateTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams, payload *dto.TransactionLimitPayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if !validTransactionLimit(payload) {
+		logger.Error().Str("name", payload.Name).Msg("invalid transaction limit")
+		return errs.ErrBadRequest
+	}
+
+	if exists, err := s.repository.FindTransactionLimit(ctx, &indto.TransactionLimitParams{LimitID: params.LimitID}); err != nil {
+		logger.Error().Err(err).Send()
+		return err
+	} else if exists == nil {
+		return errs.ErrNotFound
+	}
+
+	limitModel := transactionLimitModel(payload)
+	limitModel.ID = params.LimitID
+
+	if err = s.repository.UpdateTransactionLimit(ctx, limitModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) DeleteTransactionLimit(ctx context.Context, params *dto.TransactionLimitsQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return errs.ErrNoAccess
+	}
+
+	err = s.repository.DeleteTransactionLimit(ctx, &indto.TransactionLimitParams{LimitID: params.LimitID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// GetAccountLimit shows the remaining headroom of an account under its effective limit,
+// counterparty headroom is only computed when params.RecipientID is given
+func (s *service) GetAccountLimit(ctx context.Context, params *dto.AccountLimitQueryParams) (res *dto.AccountLimitResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: params.AccountID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if accountMeta == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER && accountMeta.OwnerID != usrmeta.UserID {
+		logger.Error().Err(errs.ErrNoAccess).Msgf("accountID: %s is not owned by userID: %s", params.AccountID, usrmeta.UserID)
+		return nil, errs.ErrNoAccess
+	}
+
+	res = &dto.AccountLimitResponse{
+		AccountID: params.AccountID,
+		TrxType:   params.TrxType,
+	}
+
+	limit, err := s.findTransactionLimit(ctx, accountMeta, params.TrxType)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if limit == nil {
+		return
+	}
+
+	usage, err := s.repository.FindTransactionLimitUsage(ctx, &indto.TransactionLimitUsageParams{
+		AccountID:   params.AccountID,
+		RecipientID: params.RecipientID,
+		TrxType:     limit.TrxType,
+		At:          time.Now(),
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res.LimitID = limit.ID
+	if limit.MaxPerTrx.IsPositive() {
+		res.MaxPerTrx = &limit.MaxPerTrx
+	}
+
+	res.DailyAmount = limitAmountHeadroom(limit.DailyAmount, usage.DailyAmount)
+	res.DailyCount = limitCountHeadroom(limit.DailyCount, usage.DailyCount)
+	res.MonthlyAmount = limitAmountHeadroom(limit.MonthlyAmount, usage.MonthlyAmount)
+	res.MonthlyCount = limitCountHeadroom(limit.MonthlyCount, usage.MonthlyCount)
+	if params.RecipientID != "" {
+		res.CounterpartyDailyAmount = limitAmountHeadroom(limit.CounterpartyDailyAmount, usage.CounterpartyDailyAmount)
+	}
+
+	return
+}
+
+// findTransactionLimit resolves the limit applied to trxType transfers out of account, nil means unlimited
+func (s *service) findTransactionLimit(ctx context.Context, account *indto.Account, trxType int64) (res *model.TransactionLimit, err error) {
+	logger := log.Ctx(ctx)
+
+	data, err := s.repository.FindEffectiveTransactionLimit(ctx, &indto.TransactionLimitParams{
+		TrxType:     trxType,
+		AccountType: account.AccountType,
+		AccountTier: account.AccountTier,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return
+	}
+
+	res = &model.TransactionLimit{
+		ID:                      data.ID,
+		Name:                    data.Name,
+		TrxType:                 data.TrxType,
+		AccountType:             data.AccountType,
+		AccountTier:             data.AccountTier,
+		MaxPerTrx:               data.MaxPerTrx,
+		DailyAmount:             data.DailyAmount,
+		DailyCount:              data.DailyCount,
+		MonthlyAmount:           data.MonthlyAmount,
+		MonthlyCount:            data.MonthlyCount,
+		CounterpartyDailyAmount: data.CounterpartyDailyAmount,
+	}
+
+	return
+}
+
+func validTransactionLimit(v *dto.TransactionLimitPayload) bool {
+	if v.TrxType != inconst.LIMIT_SCOPE_ANY && v.TrxType != inconst.TRX_TYPE_P2P && v.TrxType != inconst.TRX_TYPE_P2B {
+		return false
+	}
+
+	if v.AccountType != inconst.LIMIT_SCOPE_ANY && v.AccountType != inconst.ACCOUNT_TYPE_CUST && v.AccountType != inconst.ACCOUNT_TYPE_MERCHANT {
+		return false
+	}
+
+	if v.AccountTier != inconst.LIMIT_SCOPE_ANY && v.AccountTier != inconst.ACCOUNT_TIER_REGULAR && v.AccountTier != inconst.ACCOUNT_TIER_PREMIUM {
+		return false
+	}
+
+	if v.MaxPerTrx.IsNegative() || v.DailyAmount.IsNegative() || v.MonthlyAmount.IsNegative() || v.CounterpartyDailyAmount.IsNegative() {
+		return false
+	}
+
+	return v.DailyCount >= 0 && v.MonthlyCount >= 0
+}
+
+func transactionLimitModel(v *dto.TransactionLimitPayload) *model.TransactionLimit {
+	return &model.TransactionLimit{
+		Name:                    v.Name,
+		TrxType:                 v.TrxType,
+		AccountType:             v.AccountType,
+		AccountTier:             v.AccountTier,
+		MaxPerTrx:               v.MaxPerTrx,
+		DailyAmount:             v.DailyAmount,
+		DailyCount:              v.DailyCount,
+		MonthlyAmount:           v.MonthlyAmount,
+		MonthlyCount:            v.MonthlyCount,
+		CounterpartyDailyAmount: v.CounterpartyDailyAmount,
+	}
+}
+
+// limitAmountHeadroom returns nil for caps that are not set
+func limitAmountHeadroom(limit, used money.Money) *dto.LimitAmountHeadroom {
+	if !limit.IsPositive() {
+		return nil
+	}
+
+	return &dto.LimitAmountHeadroom{
+		Limit:     limit,
+		Used:      used,
+		Remaining: limit.Sub(used).Max(money.Zero()),
+	}
+}
+
+// limitCountHeadroom returns nil for caps that are not set
+func limitCountHeadroom(limit, used int64) *dto.LimitCountHeadroom {
+	if limit <= 0 {
+		return nil
+	}
+
+	res := &dto.LimitCountHeadroom{
+		Limit: limit,
+		Used:  used,
+	}
+
+	if used < limit {
+		res.Remaining = limit - used
+	}
+
+	return res
+}
+
+func transactionLimitResponse(v *indto.TransactionLimit) *dto.TransactionLimitResponse {
+	return &dto.TransactionLimitResponse{
+		ID:                      v.ID,
+		Name:                    v.Name,
+		TrxType:                 v.TrxType,
+		AccountType:             v.AccountType,
+		AccountTier:             v.AccountTier,
+		MaxPerTrx:               v.MaxPerTrx,
+		DailyAmount:             v.DailyAmount,
+		DailyCount:              v.DailyCount,
+		MonthlyAmount:           v.MonthlyAmount,
+		MonthlyCount:            v.MonthlyCount,
+		CounterpartyDailyAmount: v.CounterpartyDailyAmount,
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
 	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
 	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
 	"github.com/stellar-payment/sp-payment/pkg/dto"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
 )
@@ -291,3 +293,141 @@ func (s *service) HandleDeleteMerchant(ctx context.Context, params *indto.EventM
 
 	return
 }
+
+// GetMerchantBankAccount returns the bank account withdrawals are transferred to, merchants always get their own
+func (s *service) GetMerchantBankAccount(ctx context.Context, params *dto.MerchantsQueryParams) (res *dto.MerchantBankAccountResponse, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	merchantID := params.MerchantID
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to fetch merchant meta")
+			return nil, err
+		} else if merchantMeta == nil {
+			err = errs.New(errs.ErrNotFound)
+			logger.Error().Err(err).Str("user-id", usrmeta.UserID).Msg("failed to fetch merchant meta")
+			return nil, err
+		}
+
+		merchantID = merchantMeta.ID
+	}
+
+	data, err := s.merchantBankAccount(ctx, merchantID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	res = &dto.MerchantBankAccountResponse{
+		MerchantID:  data.MerchantID,
+		BankCode:    data.BankCode,
+		AccountNo:   cryptoutil.DecryptField(data.AccountNo, conf.DBKey),
+		AccountName: cryptoutil.DecryptField(data.AccountName, conf.DBKey),
+		UpdatedAt:   timeutil.FormatVerboseTime(data.UpdatedAt),
+	}
+
+	return
+}
+
+// UpdateMerchantBankAccount registers the bank account withdrawals are transferred to, replacing the previous one.
+// Payouts already queued keep the account they were created with
+func (s *service) UpdateMerchantBankAccount(ctx context.Context, params *dto.MerchantsQueryParams, payload *dto.MerchantBankAccountPayload) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	merchantID := params.MerchantID
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to fetch merchant meta")
+			return err
+		} else if merchantMeta == nil {
+			err = errs.New(errs.ErrNotFound)
+			logger.Error().Err(err).Str("user-id", usrmeta.UserID).Msg("failed to fetch merchant meta")
+			return err
+		}
+
+		merchantID = merchantMeta.ID
+	} else {
+		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{MerchantID: merchantID})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		} else if merchantMeta == nil {
+			return errs.ErrNotFound
+		}
+	}
+
+	rowHash := []byte{}
+	bankModel := &model.MerchantBankAccount{
+		MerchantID:  merchantID,
+		BankCode:    payload.BankCode,
+		AccountNo:   cryptoutil.EncryptField([]byte(payload.AccountNo), conf.DBKey, &rowHash),
+		AccountName: cryptoutil.EncryptField([]byte(payload.AccountName), conf.DBKey, &rowHash),
+	}
+
+	bankModel.RowHash = cryptoutil.HMACSHA512(rowHash, conf.HashKey)
+
+	if err = s.repository.UpsertMerchantBankAccount(ctx, bankModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// merchantBankAccount returns the verified bank account of merchantID, nil when none is registered
+func (s *service) merchantBankAccount(ctx context.Context, merchantID string) (res *indto.MerchantBankAccount, err error) {
+	conf := config.Get()
+
+	res, err = s.repository.FindMerchantBankAccount(ctx, &indto.MerchantParams{MerchantID: merchantID})
+	if err != nil || res == nil {
+		return
+	}
+
+	hash := res.AccountNo
+	hash = append(hash, res.AccountName...)
+
+	if !cryptoutil.VerifyHMACSHA512(hash, conf.HashKey, res.RowHash) {
+		return nil, errs.New(errs.ErrDataIntegrity, "merchant bank account")
+	}
+
+	return
+}
+
+// callerMerchant resolves the merchant of the calling merchant user
+func (s *service) callerMerchant(ctx context.Context) (res *indto.Merchant, err error) {
+	usrmeta := ctxutil.GetUserCTX(ctx)
+
+	res, err = s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
+	if err != nil {
+		return
+	} else if res == nil {
+		return nil, errs.New(errs.ErrNotFound)
+	}
+
+	return
+}
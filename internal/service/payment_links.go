@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/namegen"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	// 256 bits of randomness, the token is the only thing standing between the link and its payers
+	paymentLinkTokenSize = 32
+
+	paymentLinkMaxExpiry = 90 * 24 * time.Hour
+)
+
+func (s *service) GetAllPaymentLink(ctx context.Context, params *dto.PaymentLinksQueryParams) (res *dto.ListPaymentLinkResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.PaymentLinkParams{
+		Status: params.Status,
+		Limit:  params.Limit,
+		Page:   params.Page,
+	}
+
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		merchantMeta, err := s.callerMerchant(ctx)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return nil, err
+		}
+
+		repoParams.MerchantID = merchantMeta.ID
+	}
+
+	res = &dto.ListPaymentLinkResponse{
+		PaymentLinks: []*dto.PaymentLinkResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountPaymentLinks(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindPaymentLinks(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		if !validPaymentLinkHash(v) {
+			logger.Warn().Err(errs.New(errs.ErrDataIntegrity, "payment links")).Uint64("link-id", v.ID).Send()
+		}
+
+		res.PaymentLinks = append(res.PaymentLinks, paymentLinkResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetPaymentLink(ctx context.Context, params *dto.PaymentLinksQueryParams) (res *dto.PaymentLinkResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.merchantPaymentLink(ctx, params.LinkID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return paymentLinkResponse(data), nil
+}
+
+// GetPaymentLinkStats summarizes the completed payments of a link
+func (s *service) GetPaymentLinkStats(ctx context.Context, params *dto.PaymentLinksQueryParams) (res *dto.PaymentLinkStatsResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.merchantPaymentLink(ctx, params.LinkID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	stats, err := s.repository.FindPaymentLinkStats(ctx, &indto.PaymentLinkParams{LinkID: data.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = &dto.PaymentLinkStatsResponse{
+		LinkID:    data.ID,
+		Payments:  stats.Payments,
+		Payers:    stats.Payers,
+		Collected: stats.Collected,
+	}
+
+	if data.MaxUses > 0 {
+		remaining := data.MaxUses - data.UseCount
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		res.RemainingUses = &remaining
+	}
+
+	if stats.LastPaidAt.Valid {
+		res.LastPaidAt = timeutil.FormatVerboseTime(stats.LastPaidAt.Time)
+	}
+
+	return
+}
+
+// CreatePaymentLink issues a link the merchant can share, a zero amount lets the payer choose how much to pay
+func (s *service) CreatePaymentLink(ctx context.Context, payload *dto.PaymentLinkPayload) (res *dto.PaymentLinkResponse, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	expiresAt := timeutil.ParseLocaltime(payload.ExpiresAt)
+	if expiresAt.IsZero() || !expiresAt.After(time.Now()) || expiresAt.After(time.Now().Add(paymentLinkMaxExpiry)) {
+		logger.Error().Str("expires-at", payload.ExpiresAt).Msg("invalid payment link expiry")
+		return nil, errs.ErrBadRequest
+	}
+
+	if payload.Amount.IsNegative() || payload.MaxUses < 0 {
+		logger.Error().Str("amount", payload.Amount.String()).Int64("max-uses", payload.MaxUses).Msg("invalid payment link")
+		return nil, errs.ErrBadRequest
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	token, err := namegen.GenerateRandomToken(paymentLinkTokenSize)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to generate payment link token")
+		return
+	}
+
+	rowHash := []byte{}
+	linkModel := &model.PaymentLink{
+		ID:          snowflake.ID(),
+		MerchantID:  merchantMeta.ID,
+		Token:       cryptoutil.EncryptField([]byte(token), conf.DBKey, &rowHash),
+		TokenHash:   cryptoutil.HMACSHA512([]byte(token), conf.HashKey),
+		Amount:      payload.Amount,
+		Description: payload.Description,
+		MaxUses:     payload.MaxUses,
+		Status:      inconst.PAYMENT_LINK_STATUS_ACTIVE,
+		ExpiresAt:   expiresAt,
+	}
+	linkModel.RowHash = cryptoutil.HMACSHA512(rowHash, conf.HashKey)
+
+	if err = s.repository.CreatePaymentLink(ctx, linkModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	data, err := s.repository.FindPaymentLink(ctx, &indto.PaymentLinkParams{LinkID: linkModel.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return paymentLinkResponse(data), nil
+}
+
+// DeactivatePaymentLink stops a link from taking payments, it cannot be activated again
+func (s *service) DeactivatePaymentLink(ctx context.Context, params *dto.PaymentLinksQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.merchantPaymentLink(ctx, params.LinkID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.DeactivatePaymentLink(ctx, &indto.PaymentLinkParams{LinkID: data.ID, MerchantID: data.MerchantID}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// GetPaymentLinkPreview shows a payer what opening the link pays for
+func (s *service) GetPaymentLinkPreview(ctx context.Context, params *dto.PaymentLinkTokenParams) (res *dto.PaymentLinkPreviewResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.payablePaymentLink(ctx, params.Token)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = &dto.PaymentLinkPreviewResponse{
+		MerchantID:   data.MerchantID,
+		MerchantName: data.MerchantName,
+		Amount:       data.Amount,
+		Description:  data.Description,
+		ExpiresAt:    timeutil.FormatVerboseTime(data.ExpiresAt),
+	}
+
+	return
+}
+
+// PayPaymentLink pays a link through the regular P2B transfer, each payment takes one of the link's uses
+func (s *service) PayPaymentLink(ctx context.Context, params *dto.PaymentLinkTokenParams, payload *dto.PaymentLinkPayPayload) (res *dto.PaymentLinkPaymentResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	data, err := s.payablePaymentLink(ctx, params.Token)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	nominal := payload.Nominal
+	if data.Amount.IsPositive() {
+		if !nominal.IsZero() && nominal.Cmp(data.Amount) != 0 {
+			logger.Error().Str("nominal", nominal.String()).Str("link-amount", data.Amount.String()).Msg("nominal differs from link amount")
+			return nil, errs.ErrBadRequest
+		}
+
+		nominal = data.Amount
+	}
+
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{MerchantID: data.MerchantID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if merchantMeta == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	merchantAccount, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: merchantMeta.UserID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if merchantAccount == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if err = s.verifyAccountPIN(ctx, payload.AccountID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trx, err := s.prepareTransfer(ctx, 0, inconst.TRX_TYPE_P2B, &dto.TransactionPayload{
+		AccountID:   payload.AccountID,
+		RecipientID: merchantAccount.ID,
+		TrxType:     inconst.TRX_TYPE_P2B,
+		Nominal:     nominal,
+		Description: data.Description,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// the use of the link is taken in the database transaction that pays it
+	if err = s.repository.PayPaymentLink(ctx, &model.PaymentLinkPayment{LinkID: data.ID}, trx); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	s.notifyTransfer(ctx, trx)
+
+	res = &dto.PaymentLinkPaymentResponse{
+		TransactionID: trx.ID,
+		MerchantID:    data.MerchantID,
+		Nominal:       trx.Nominal,
+	}
+
+	return res, nil
+}
+
+// merchantPaymentLink finds linkID, merchants can only reach their own links
+func (s *service) merchantPaymentLink(ctx context.Context, linkID uint64) (res *indto.PaymentLink, err error) {
+	repoParams := &indto.PaymentLinkParams{LinkID: linkID}
+
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		merchantMeta, err := s.callerMerchant(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		repoParams.MerchantID = merchantMeta.ID
+	}
+
+	res, err = s.repository.FindPaymentLink(ctx, repoParams)
+	if err != nil {
+		return
+	} else if res == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if !validPaymentLinkHash(res) {
+		return nil, errs.New(errs.ErrDataIntegrity, "payment link")
+	}
+
+	return
+}
+
+// payablePaymentLink resolves token to its link, the token is only ever compared by its HMAC
+func (s *service) payablePaymentLink(ctx context.Context, token string) (res *indto.PaymentLink, err error) {
+	conf := config.Get()
+
+	if token == "" {
+		return nil, errs.ErrNotFound
+	}
+
+	res, err = s.repository.FindPaymentLink(ctx, &indto.PaymentLinkParams{TokenHash: cryptoutil.HMACSHA512([]byte(token), conf.HashKey)})
+	if err != nil {
+		return
+	} else if res == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if res.Status != inconst.PAYMENT_LINK_STATUS_ACTIVE || !res.ExpiresAt.After(time.Now()) || (res.MaxUses > 0 && res.UseCount >= res.MaxUses) {
+		return nil, errs.ErrPaymentLinkClosed
+	}
+
+	return
+}
+
+func validPaymentLinkHash(v *indto.PaymentLink) bool {
+	conf := config.Get()
+
+	return cryptoutil.VerifyHMACSHA512(v.Token, conf.HashKey, v.RowHash)
+}
+
+func paymentLinkResponse(v *indto.PaymentLink) *dto.PaymentLinkResponse {
+	conf := config.Get()
+
+	return &dto.PaymentLinkResponse{
+		ID:          v.ID,
+		MerchantID:  v.MerchantID,
+		Token:       cryptoutil.DecryptField(v.Token, conf.DBKey),
+		Amount:      v.Amount,
+		Description: v.Description,
+		MaxUses:     v.MaxUses,
+		UseCount:    v.UseCount,
+		Status:      v.Status,
+		ExpiresAt:   timeutil.FormatVerboseTime(v.ExpiresAt),
+		CreatedAt:   timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/payoututil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	// a bank that cannot be reached this many times in a row is asked whether it holds the transfer at all,
+	// the payout fails and releases the withdrawal only when it does not
+	payoutMaxAttempts = 5
+
+	// retries back off linearly, n-th retry waits n * payoutRetryDelay
+	payoutRetryDelay = 5 * time.Minute
+
+	// sent payouts are polled until the bank settles or rejects them
+	payoutPollInterval = 5 * time.Minute
+
+	// a claimed payout is not picked up again before the lease ends, even if its worker died
+	payoutLease = 10 * time.Minute
+
+	payoutProcessBatch = 50
+)
+
+// HandleProcessPayouts submits queued bank payouts and polls the ones already sent
+func (s *service) HandleProcessPayouts(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	connector, err := payoututil.GetConnector()
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for {
+		data, err := s.repository.ClaimDuePayouts(ctx, payoutProcessBatch, payoutLease)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		for _, v := range data {
+			if err = s.processPayout(ctx, connector, v); err != nil {
+				logger.Error().Err(err).Uint64("payout-id", v.ID).Msg("failed to process payout")
+			}
+		}
+
+		if len(data) < payoutProcessBatch {
+			return nil
+		}
+	}
+}
+
+// HandlePayoutCallback applies a status notification pushed by the bank, the connector authenticates it
+func (s *service) HandlePayoutCallback(ctx context.Context, header http.Header, body []byte) (err error) {
+	logger := log.Ctx(ctx)
+
+	connector, err := payoututil.GetConnector()
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res, err := connector.HandleCallback(ctx, header, body)
+	if errors.Is(err, payoututil.ErrInvalidSignature) {
+		logger.Error().Err(err).Send()
+		return errs.ErrNoAccess
+	} else if err != nil {
+		logger.Error().Err(err).Send()
+		return errs.ErrBrokenUserReq
+	}
+
+	payoutID, err := strconv.ParseUint(res.Reference, 10, 64)
+	if err != nil {
+		logger.Error().Err(err).Str("reference", res.Reference).Msg("invalid payout reference")
+		return errs.ErrNotFound
+	}
+
+	data, err := s.repository.FindPayout(ctx, &indto.PayoutParams{PayoutID: payoutID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// a callback of another connector cannot refer to this payout even if the reference matches
+	if data == nil || data.Connector != connector.Name() {
+		return errs.ErrNotFound
+	}
+
+	if err = s.applyPayoutStatus(ctx, data, res); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// processPayout moves one claimed payout forward, a bank that cannot be reached is retried with backoff
+// until payoutMaxAttempts and resolved by asking for the transfer after that. Polling a sent payout is retried
+// indefinitely as the money may already be on its way
+func (s *service) processPayout(ctx context.Context, connector payoututil.Connector, data *indto.Payout) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if data.Connector != connector.Name() {
+		return fmt.Errorf("payout was created on connector %s", data.Connector)
+	}
+
+	reference := strconv.FormatUint(data.ID, 10)
+
+	if data.Status == inconst.PAYOUT_STATUS_SENT {
+		res, err := connector.Status(ctx, reference)
+		if err != nil {
+			logger.Warn().Err(err).Uint64("payout-id", data.ID).Msg("failed to poll payout")
+
+			next := time.Now().Add(payoutPollInterval)
+			return s.repository.UpdatePayout(ctx, &model.Payout{
+				ID:        data.ID,
+				Status:    data.Status,
+				Attempts:  data.Attempts,
+				NextRunAt: &next,
+			})
+		}
+
+		return s.applyPayoutStatus(ctx, data, res)
+	}
+
+	data.Attempts++
+	res, err := connector.Initiate(ctx, &payoututil.Transfer{
+		Reference:   reference,
+		BankCode:    data.BankCode,
+		AccountNo:   cryptoutil.DecryptField(data.AccountNo, conf.DBKey),
+		AccountName: cryptoutil.DecryptField(data.AccountName, conf.DBKey),
+		Amount:      data.Amount,
+		Description: fmt.Sprintf("beneficiary %d withdrawal", data.BeneficiaryID),
+	})
+	if err != nil {
+		logger.Warn().Err(err).Uint64("payout-id", data.ID).Int64("attempt", data.Attempts).Msg("failed to initiate payout")
+
+		if data.Attempts >= payoutMaxAttempts {
+			return s.resolveUnreachablePayout(ctx, connector, data, err)
+		}
+
+		next := time.Now().Add(time.Duration(data.Attempts) * payoutRetryDelay)
+		return s.repository.UpdatePayout(ctx, &model.Payout{
+			ID:        data.ID,
+			Status:    data.Status,
+			Attempts:  data.Attempts,
+			NextRunAt: &next,
+		})
+	}
+
+	return s.applyPayoutStatus(ctx, data, res)
+}
+
+// resolveUnreachablePayout decides a payout the bank did not acknowledge payoutMaxAttempts times in a row. A request
+// may have reached the bank without its answer reaching us, so the payout fails only once the bank confirms it holds
+// no transfer under the reference. While that cannot be told it keeps being retried at the longest backoff
+func (s *service) resolveUnreachablePayout(ctx context.Context, connector payoututil.Connector, data *indto.Payout, initiateErr error) (err error) {
+	logger := log.Ctx(ctx)
+
+	res, err := connector.Status(ctx, strconv.FormatUint(data.ID, 10))
+	if err == nil {
+		return s.applyPayoutStatus(ctx, data, res)
+	}
+
+	if errors.Is(err, payoututil.ErrTransferNotFound) {
+		payoutModel := &model.Payout{
+			ID:            data.ID,
+			Attempts:      data.Attempts,
+			FailureReason: fmt.Sprintf("bank unreachable after %d attempts: %s", data.Attempts, initiateErr.Error()),
+		}
+
+		ok, err := s.repository.FailPayout(ctx, payoutModel)
+		if err != nil || !ok {
+			return err
+		}
+
+		s.emitPayoutWebhook(ctx, data, inconst.BNF_STATUS_FAILED, payoutModel.FailureReason)
+		return nil
+	}
+
+	logger.Error().Err(err).Uint64("payout-id", data.ID).Int64("attempt", data.Attempts).Msg("payout state unknown, retrying until the bank answers")
+
+	next := time.Now().Add(payoutMaxAttempts * payoutRetryDelay)
+	return s.repository.UpdatePayout(ctx, &model.Payout{
+		ID:        data.ID,
+		Status:    data.Status,
+		Attempts:  data.Attempts,
+		NextRunAt: &next,
+	})
+}
+
+// applyPayoutStatus records what the bank reported for data, final statuses settle or fail the withdrawal
+func (s *service) applyPayoutStatus(ctx context.Context, data *indto.Payout, res *payoututil.TransferStatus) (err error) {
+	payoutModel := &model.Payout{
+		ID:            data.ID,
+		Status:        res.Status,
+		ProviderRef:   res.ProviderRef,
+		FailureReason: res.FailureReason,
+		Attempts:      data.Attempts,
+	}
+
+	switch res.Status {
+	case inconst.PAYOUT_STATUS_SETTLED:
+		// a replayed callback or a poll racing it finds the payout closed already and tells nobody again
+		if ok, err := s.repository.SettlePayout(ctx, payoutModel); err != nil || !ok {
+			return err
+		}
+
+		s.emitPayoutWebhook(ctx, data, inconst.BNF_STATUS_CONFIRM, "")
+		return
+	case inconst.PAYOUT_STATUS_FAILED:
+		if payoutModel.FailureReason == "" {
+			payoutModel.FailureReason = "rejected by bank"
+		}
+
+		if ok, err := s.repository.FailPayout(ctx, payoutModel); err != nil || !ok {
+			return err
+		}
+
+		s.emitPayoutWebhook(ctx, data, inconst.BNF_STATUS_FAILED, payoutModel.FailureReason)
+		return
+	case inconst.PAYOUT_STATUS_SENT:
+		next := time.Now().Add(payoutPollInterval)
+		payoutModel.NextRunAt = &next
+
+		return s.repository.UpdatePayout(ctx, payoutModel)
+	}
+
+	return fmt.Errorf("unexpected payout status %d", res.Status)
+}
+
+// emitPayoutWebhook tells the merchant how the bank transfer of its withdrawal ended
+func (s *service) emitPayoutWebhook(ctx context.Context, data *indto.Payout, status int64, reason string) {
+	s.emitWebhook(ctx, data.MerchantID, inconst.WEBHOOK_EVENT_WITHDRAWAL_UPDATED, &dto.WebhookWithdrawalData{
+		BeneficiaryID: data.BeneficiaryID,
+		Amount:        data.Amount,
+		Status:        status,
+		Reason:        reason,
+	})
+}
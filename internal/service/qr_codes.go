@@ -0,0 +1,424 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/emvutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+const (
+	// identifies this service in the merchant account information template, qr codes of other issuers are rejected
+	qrMerchantGUID = "ID.STELLARPAYMENT"
+
+	// merchants carry no category yet, 5999 is miscellaneous retail
+	qrMerchantCategory = "5999"
+	qrCountryCode      = "ID"
+
+	// emv limits of the merchant name and reference label
+	qrMerchantNameLength = 25
+	qrReferenceLength    = 25
+
+	qrDefaultExpiry = 15 * time.Minute
+	qrMaxExpiry     = 24 * time.Hour
+)
+
+// ISO 4217 numeric codes of the supported currencies
+var qrCurrencyCodes = map[string]string{
+	"IDR": "360",
+}
+
+// merchantQR is a scanned qr code resolved to the merchant account it pays, code is only set for dynamic qr codes
+type merchantQR struct {
+	preview *dto.QRPreviewResponse
+	code    *indto.MerchantQRCode
+}
+
+// GetMerchantStaticQR returns the reusable qr code of the merchant account, payers enter the amount themselves
+func (s *service) GetMerchantStaticQR(ctx context.Context, params *dto.QRQueryParams) (res *dto.QRResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	merchantMeta, accountMeta, err := s.qrMerchant(ctx, params.MerchantID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	payload, err := encodeMerchantQR(merchantMeta, accountMeta, money.Zero(), "")
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = &dto.QRResponse{
+		MerchantID: merchantMeta.ID,
+		Type:       inconst.QR_TYPE_STATIC,
+		Payload:    payload,
+		Amount:     money.Zero(),
+	}
+
+	return
+}
+
+// CreateMerchantDynamicQR issues a single use qr code for payload.Amount, the reference must be unique per merchant
+func (s *service) CreateMerchantDynamicQR(ctx context.Context, params *dto.QRQueryParams, payload *dto.DynamicQRPayload) (res *dto.QRResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if !payload.Amount.IsPositive() || len(payload.Reference) > qrReferenceLength || !isQRText(payload.Reference) {
+		logger.Error().Str("amount", payload.Amount.String()).Str("reference", payload.Reference).Msg("invalid dynamic qr")
+		return nil, errs.ErrBadRequest
+	}
+
+	expiry := time.Duration(payload.ExpiresIn) * time.Second
+	if expiry <= 0 {
+		expiry = qrDefaultExpiry
+	} else if expiry > qrMaxExpiry {
+		return nil, errs.ErrBadRequest
+	}
+
+	merchantMeta, accountMeta, err := s.qrMerchant(ctx, params.MerchantID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	qrPayload, err := encodeMerchantQR(merchantMeta, accountMeta, payload.Amount, payload.Reference)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	qrModel := &model.MerchantQRCode{
+		ID:         snowflake.ID(),
+		MerchantID: merchantMeta.ID,
+		AccountID:  accountMeta.ID,
+		Reference:  payload.Reference,
+		Amount:     payload.Amount,
+		Status:     inconst.QR_STATUS_ACTIVE,
+		ExpiresAt:  time.Now().Add(expiry),
+	}
+
+	if err = s.repository.CreateMerchantQRCode(ctx, qrModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = &dto.QRResponse{
+		ID:         qrModel.ID,
+		MerchantID: qrModel.MerchantID,
+		Type:       inconst.QR_TYPE_DYNAMIC,
+		Payload:    qrPayload,
+		Amount:     qrModel.Amount,
+		Reference:  qrModel.Reference,
+		ExpiresAt:  timeutil.FormatVerboseTime(qrModel.ExpiresAt),
+	}
+
+	return
+}
+
+// ParseMerchantQR validates a scanned qr code and previews the payment it makes
+func (s *service) ParseMerchantQR(ctx context.Context, payload *dto.QRParsePayload) (res *dto.QRPreviewResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	data, err := s.resolveMerchantQR(ctx, payload.Payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return data.preview, nil
+}
+
+// PayMerchantQR pays a scanned qr code through the regular P2B transfer. The amount of a dynamic qr code is fixed
+// and it can only be paid once, a static qr code is paid payload.Nominal
+func (s *service) PayMerchantQR(ctx context.Context, payload *dto.QRPayPayload) (res *dto.QRPaymentResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	data, err := s.resolveMerchantQR(ctx, payload.Payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	nominal := payload.Nominal
+	if data.preview.Amount.IsPositive() {
+		if !nominal.IsZero() && nominal.Cmp(data.preview.Amount) != 0 {
+			logger.Error().Str("nominal", nominal.String()).Str("qr-amount", data.preview.Amount.String()).Msg("nominal differs from qr amount")
+			return nil, errs.ErrBadRequest
+		}
+
+		nominal = data.preview.Amount
+	}
+
+	description := payload.Description
+	if description == "" {
+		description = fmt.Sprintf("qr payment to %s", data.preview.MerchantName)
+		if data.preview.Reference != "" {
+			description = fmt.Sprintf("qr payment %s", data.preview.Reference)
+		}
+	}
+
+	if data.code != nil {
+		if err = s.repository.ClaimMerchantQRCode(ctx, &indto.MerchantQRCodeParams{QRCodeID: data.code.ID}); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+	}
+
+	trx, trxErr := s.createTransactionP2B(ctx, &dto.TransactionPayload{
+		AccountID:   payload.AccountID,
+		RecipientID: data.preview.RecipientID,
+		TrxType:     inconst.TRX_TYPE_P2B,
+		Nominal:     nominal,
+		Description: description,
+		PIN:         payload.PIN,
+	})
+
+	if data.code != nil {
+		// a failed payment hands the qr code back so it can be paid again
+		qrModel := &model.MerchantQRCode{ID: data.code.ID}
+		if trxErr == nil {
+			qrModel.TransactionID = trx.ID
+		}
+
+		if err = s.repository.CloseMerchantQRCode(ctx, qrModel); err != nil {
+			logger.Error().Err(err).Uint64("qr-id", data.code.ID).Msg("failed to close qr code")
+		}
+	}
+
+	if trxErr != nil {
+		logger.Error().Err(trxErr).Send()
+		return nil, trxErr
+	}
+
+	res = &dto.QRPaymentResponse{
+		TransactionID: trx.ID,
+		MerchantID:    data.preview.MerchantID,
+		Nominal:       trx.Nominal,
+		Reference:     data.preview.Reference,
+	}
+
+	return res, nil
+}
+
+// qrMerchant resolves the merchant qr codes are issued for, merchants always get their own
+func (s *service) qrMerchant(ctx context.Context, merchantID string) (merchantMeta *indto.Merchant, accountMeta *indto.Account, err error) {
+	repoParams := &indto.MerchantParams{MerchantID: merchantID}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		repoParams = &indto.MerchantParams{UserID: usrmeta.UserID}
+	} else if merchantID == "" {
+		return nil, nil, errs.New(errs.ErrMissingRequiredAttribute, "MerchantID")
+	}
+
+	merchantMeta, err = s.repository.FindMerchant(ctx, repoParams)
+	if err != nil {
+		return
+	} else if merchantMeta == nil {
+		return nil, nil, errs.ErrNotFound
+	}
+
+	accountMeta, err = s.repository.FindAccount(ctx, &indto.AccountParams{UserID: merchantMeta.UserID})
+	if err != nil {
+		return
+	} else if accountMeta == nil {
+		return nil, nil, errs.ErrNotFound
+	}
+
+	return
+}
+
+// resolveMerchantQR decodes raw and resolves the merchant account it pays. Malformed payloads, qr codes of other
+// issuers and dynamic qr codes whose amount does not match the issued one are ErrInvalidQR
+func (s *service) resolveMerchantQR(ctx context.Context, raw string) (res *merchantQR, err error) {
+	conf := config.Get()
+
+	fields, err := emvutil.Decode(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, errs.ErrInvalidQR
+	}
+
+	method, _ := fields.Get(emvutil.TagInitiationMethod)
+	if method != emvutil.InitiationStatic && method != emvutil.InitiationDynamic {
+		return nil, errs.ErrInvalidQR
+	}
+
+	if currency, _ := fields.Get(emvutil.TagCurrency); currency != qrCurrencyCodes[money.DefaultCurrency.Code] {
+		return nil, errs.ErrInvalidQR
+	}
+
+	accountTemplate, _ := fields.Get(emvutil.TagMerchantAccount)
+	accountFields, err := emvutil.DecodeTemplate(accountTemplate)
+	if err != nil {
+		return nil, errs.ErrInvalidQR
+	}
+
+	guid, _ := accountFields.Get(emvutil.SubTagGUID)
+	accountNo, _ := accountFields.Get(emvutil.SubTagAccountNo)
+	if guid != qrMerchantGUID || accountNo == "" {
+		return nil, errs.ErrInvalidQR
+	}
+
+	amount := money.Zero()
+	if val, ok := fields.Get(emvutil.TagAmount); ok {
+		if amount, err = money.Parse(val); err != nil || !amount.IsPositive() {
+			return nil, errs.ErrInvalidQR
+		}
+	}
+
+	reference := ""
+	if val, ok := fields.Get(emvutil.TagAdditionalData); ok {
+		additionalFields, err := emvutil.DecodeTemplate(val)
+		if err != nil {
+			return nil, errs.ErrInvalidQR
+		}
+
+		reference, _ = additionalFields.Get(emvutil.SubTagReference)
+	}
+
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountNoHash: cryptoutil.HMACSHA512([]byte(accountNo), conf.HashKey)})
+	if err != nil {
+		return
+	} else if accountMeta == nil || accountMeta.AccountType != inconst.ACCOUNT_TYPE_MERCHANT {
+		return nil, errs.ErrInvalidQR
+	}
+
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: accountMeta.OwnerID})
+	if err != nil {
+		return
+	} else if merchantMeta == nil {
+		return nil, errs.ErrInvalidQR
+	}
+
+	res = &merchantQR{
+		preview: &dto.QRPreviewResponse{
+			Type:         inconst.QR_TYPE_STATIC,
+			MerchantID:   merchantMeta.ID,
+			MerchantName: merchantMeta.Name,
+			RecipientID:  accountMeta.ID,
+			AccountNo:    accountNo,
+			Amount:       amount,
+			Reference:    reference,
+		},
+	}
+
+	if method == emvutil.InitiationStatic {
+		return
+	}
+
+	if reference == "" || !amount.IsPositive() {
+		return nil, errs.ErrInvalidQR
+	}
+
+	res.code, err = s.repository.FindMerchantQRCode(ctx, &indto.MerchantQRCodeParams{MerchantID: merchantMeta.ID, Reference: reference})
+	if err != nil {
+		return
+	} else if res.code == nil || res.code.Amount.Cmp(amount) != 0 || res.code.AccountID != accountMeta.ID {
+		return nil, errs.ErrInvalidQR
+	}
+
+	if res.code.Status != inconst.QR_STATUS_ACTIVE || !res.code.ExpiresAt.After(time.Now()) {
+		return nil, errs.ErrQRClosed
+	}
+
+	res.preview.Type = inconst.QR_TYPE_DYNAMIC
+	res.preview.ExpiresAt = timeutil.FormatVerboseTime(res.code.ExpiresAt)
+
+	return
+}
+
+// encodeMerchantQR builds the EMVCo payload paying accountMeta, a positive amount makes it a dynamic qr code
+func encodeMerchantQR(merchantMeta *indto.Merchant, accountMeta *indto.Account, amount money.Money, reference string) (res string, err error) {
+	conf := config.Get()
+
+	method, amountValue := emvutil.InitiationStatic, ""
+	if amount.IsPositive() {
+		method, amountValue = emvutil.InitiationDynamic, amount.String()
+	}
+
+	return emvutil.Encode(
+		emvutil.Field{Tag: emvutil.TagPayloadFormat, Value: emvutil.PayloadFormat},
+		emvutil.Field{Tag: emvutil.TagInitiationMethod, Value: method},
+		emvutil.Template(emvutil.TagMerchantAccount,
+			emvutil.Field{Tag: emvutil.SubTagGUID, Value: qrMerchantGUID},
+			emvutil.Field{Tag: emvutil.SubTagAccountNo, Value: cryptoutil.DecryptField(accountMeta.AccountNo, conf.DBKey)},
+		),
+		emvutil.Field{Tag: emvutil.TagMerchantCategory, Value: qrMerchantCategory},
+		emvutil.Field{Tag: emvutil.TagCurrency, Value: qrCurrencyCodes[amount.Currency().Code]},
+		emvutil.Field{Tag: emvutil.TagAmount, Value: amountValue},
+		emvutil.Field{Tag: emvutil.TagCountry, Value: qrCountryCode},
+		emvutil.Field{Tag: emvutil.TagMerchantName, Value: qrText(merchantMeta.Name, qrMerchantNameLength)},
+		emvutil.Field{Tag: emvutil.TagMerchantCity, Value: qrText(conf.QRMerchantCity, 15)},
+		emvutil.Template(emvutil.TagAdditionalData,
+			emvutil.Field{Tag: emvutil.SubTagReference, Value: reference},
+		),
+	)
+}
+
+// qrText keeps the printable ascii of val up to length, emv lengths count bytes
+func qrText(val string, length int) string {
+	sb := &strings.Builder{}
+	for _, v := range val {
+		if sb.Len() == length {
+			break
+		}
+
+		if v >= 0x20 && v <= 0x7e {
+			sb.WriteRune(v)
+		}
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+func isQRText(val string) bool {
+	return qrText(val, len(val)) == val
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	reconciliationBatch = 500
+
+	// progress is saved after every batch, a run silent for this long is considered abandoned
+	reconciliationStaleAfter = 2 * time.Hour
+)
+
+// HandleReconcileBalances compares every account's stored balance with the balance replayed from its transactions
+// and records each mismatch on a new run. Nothing is corrected, admins act on the discrepancy report
+func (s *service) HandleReconcileBalances(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	runModel := &model.ReconciliationRun{
+		ID:     snowflake.ID(),
+		Status: inconst.RECONCILIATION_STATUS_RUNNING,
+	}
+
+	if err = s.repository.CreateReconciliationRun(ctx, runModel, reconciliationStaleAfter); errors.Is(err, errs.ErrDuplicatedResources) {
+		logger.Info().Msg("another reconciliation run is in progress, skipping")
+		return nil
+	} else if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	err = s.reconcileBalances(ctx, runModel)
+
+	runModel.Status = inconst.RECONCILIATION_STATUS_COMPLETED
+	if err != nil {
+		runModel.Status = inconst.RECONCILIATION_STATUS_FAILED
+		runModel.LastError = err.Error()
+	}
+
+	finishedAt := time.Now()
+	runModel.FinishedAt = &finishedAt
+
+	if err := s.repository.UpdateReconciliationRun(ctx, runModel); err != nil {
+		logger.Error().Err(err).Uint64("run-id", runModel.ID).Msg("failed to finish reconciliation run")
+		return err
+	}
+
+	logger.Info().Uint64("run-id", runModel.ID).Int64("accounts-checked", runModel.AccountsChecked).
+		Int64("discrepancies", runModel.Discrepancies).Msg("reconciliation run finished")
+
+	return
+}
+
+func (s *service) reconcileBalances(ctx context.Context, run *model.ReconciliationRun) (err error) {
+	logger := log.Ctx(ctx)
+
+	params := &indto.AccountBalanceParams{Limit: reconciliationBatch}
+	for {
+		data, err := s.repository.FindAccountBalances(ctx, params)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		discrepancies := []*model.ReconciliationDiscrepancy{}
+		for _, v := range data {
+			if v.StoredBalance.Cmp(v.ExpectedBalance) == 0 {
+				continue
+			}
+
+			logger.Warn().Str("account-id", v.AccountID).Str("stored", v.StoredBalance.String()).
+				Str("expected", v.ExpectedBalance.String()).Msg("balance discrepancy")
+
+			discrepancies = append(discrepancies, &model.ReconciliationDiscrepancy{
+				RunID:           run.ID,
+				AccountID:       v.AccountID,
+				StoredBalance:   v.StoredBalance,
+				ExpectedBalance: v.ExpectedBalance,
+				Difference:      v.StoredBalance.Sub(v.ExpectedBalance),
+			})
+		}
+
+		if err = s.repository.CreateReconciliationDiscrepancies(ctx, discrepancies); err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		run.AccountsChecked += int64(len(data))
+		run.Discrepancies += int64(len(discrepancies))
+
+		if len(data) < reconciliationBatch {
+			return nil
+		}
+
+		params.AfterAccountID = data[len(data)-1].AccountID
+
+		// keeps the run from being taken as abandoned
+		if err = s.repository.UpdateReconciliationRun(ctx, run); err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+	}
+}
+
+func (s *service) GetAllReconciliationRun(ctx context.Context, params *dto.ReconciliationQueryParams) (res *dto.ListReconciliationRunResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.ReconciliationParams{
+		Limit: params.Limit,
+		Page:  params.Page,
+	}
+
+	res = &dto.ListReconciliationRunResponse{
+		Runs: []*dto.ReconciliationRunResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountReconciliationRuns(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindReconciliationRuns(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Runs = append(res.Runs, reconciliationRunResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetReconciliationDiscrepancies(ctx context.Context, params *dto.ReconciliationQueryParams) (res *dto.ListReconciliationDiscrepancyResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.ReconciliationParams{
+		RunID:     params.RunID,
+		AccountID: params.AccountID,
+		Limit:     params.Limit,
+		Page:      params.Page,
+	}
+
+	run, err := s.repository.FindReconciliationRun(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if run == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	res = &dto.ListReconciliationDiscrepancyResponse{
+		RunID:         run.ID,
+		Discrepancies: []*dto.ReconciliationDiscrepancyResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountReconciliationDiscrepancies(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindReconciliationDiscrepancies(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Discrepancies = append(res.Discrepancies, &dto.ReconciliationDiscrepancyResponse{
+			ID:              v.ID,
+			AccountID:       v.AccountID,
+			StoredBalance:   v.StoredBalance,
+			ExpectedBalance: v.ExpectedBalance,
+			Difference:      v.Difference,
+			CreatedAt:       timeutil.FormatVerboseTime(v.CreatedAt),
+		})
+	}
+
+	return
+}
+
+func reconciliationRunResponse(v *indto.ReconciliationRun) *dto.ReconciliationRunResponse {
+	res := &dto.ReconciliationRunResponse{
+		ID:              v.ID,
+		Status:          v.Status,
+		AccountsChecked: v.AccountsChecked,
+		Discrepancies:   v.Discrepancies,
+		LastError:       v.LastError,
+		StartedAt:       timeutil.FormatVerboseTime(v.StartedAt),
+	}
+
+	if v.FinishedAt != nil {
+		res.FinishedAt = timeutil.FormatVerboseTime(*v.FinishedAt)
+	}
+
+	return res
+}
@@ -0,0 +1,483 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+const (
+	scheduleTrxMaxAttempts = 3
+	// retries back off linearly, n-th retry waits n * scheduleTrxRetryDelay
+	scheduleTrxRetryDelay = 15 * time.Minute
+
+	scheduleTrxExecuteBatch = 50
+	// a claimed schedule is handed to another worker once the lease passes without an outcome recorded
+	scheduleTrxLease = 10 * time.Minute
+)
+
+func (s *service) GetAllScheduledTransaction(ctx context.Context, params *dto.ScheduledTransactionsQueryParams) (res *dto.ListScheduledTransactionResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.ScheduledTransactionParams{
+		Status: params.Status,
+		Limit:  params.Limit,
+		Page:   params.Page,
+	}
+
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER {
+		repoParams.UserID = usrmeta.UserID
+	}
+
+	res = &dto.ListScheduledTransactionResponse{
+		ScheduledTransactions: []*dto.ScheduledTransactionResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountScheduledTransactions(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindScheduledTransactions(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.ScheduledTransactions = append(res.ScheduledTransactions, scheduledTransactionResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetScheduledTransaction(ctx context.Context, params *dto.ScheduledTransactionsQueryParams) (res *dto.ScheduledTransactionResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	repoParams := &indto.ScheduledTransactionParams{ScheduleID: params.ScheduleID}
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER {
+		repoParams.UserID = usrmeta.UserID
+	}
+
+	data, err := s.repository.FindScheduledTransaction(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return scheduledTransactionResponse(data), nil
+}
+
+func (s *service) CreateScheduledTransaction(ctx context.Context, payload *dto.ScheduledTransactionPayload) (res *dto.ScheduledTransactionResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	scheduledAt := timeutil.ParseLocaltime(payload.ScheduledAt)
+	if scheduledAt.IsZero() {
+		logger.Error().Str("scheduled-at", payload.ScheduledAt).Msg("invalid schedule date")
+		return nil, errs.ErrBadRequest
+	}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	scheduleModel := &model.ScheduledTransaction{
+		ID:          snowflake.ID(),
+		UserID:      usrmeta.UserID,
+		AccountID:   payload.AccountID,
+		RecipientID: payload.RecipientID,
+		TrxType:     payload.TrxType,
+		Nominal:     payload.Nominal,
+		Description: payload.Description,
+		ScheduledAt: scheduledAt,
+	}
+
+	if err = s.validateScheduledTransaction(ctx, scheduleModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.verifyAccountPIN(ctx, payload.AccountID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.CreateScheduledTransaction(ctx, scheduleModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = scheduledTransactionResponse(&indto.ScheduledTransaction{
+		ID:          scheduleModel.ID,
+		AccountID:   scheduleModel.AccountID,
+		RecipientID: scheduleModel.RecipientID,
+		TrxType:     scheduleModel.TrxType,
+		Nominal:     scheduleModel.Nominal,
+		Description: scheduleModel.Description,
+		ScheduledAt: scheduleModel.ScheduledAt,
+		Status:      scheduleModel.Status,
+	})
+
+	return
+}
+
+func (s *service) CancelScheduledTransaction(ctx context.Context, params *dto.ScheduledTransactionsQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return errs.ErrNoAccess
+	}
+
+	repoParams := &indto.ScheduledTransactionParams{ScheduleID: params.ScheduleID}
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER {
+		repoParams.UserID = usrmeta.UserID
+	}
+
+	data, err := s.repository.FindScheduledTransaction(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return errs.ErrNotFound
+	}
+
+	if err = s.repository.CancelScheduledTransaction(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleCreateScheduledTransaction persists a schedule requested over the bus by a trusted service, the sender must
+// belong to the user the request is made for
+func (s *service) HandleCreateScheduledTransaction(ctx context.Context, envelope *indto.EventEnvelope) (err error) {
+	logger := log.Ctx(ctx)
+
+	payload := &indto.EventScheduledTransaction{}
+	if err = s.openEventEnvelope(ctx, inconst.TOPIC_CREATE_SCHEDULE_TRX, envelope, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if payload.UserID == "" || payload.AccountID == "" || payload.RecipientID == "" {
+		logger.Error().Str("service-id", envelope.ServiceID).Msg("schedule request must name the user, sender and recipient")
+		return errs.ErrBadRequest
+	}
+
+	scheduleModel := &model.ScheduledTransaction{
+		ID:          payload.ID,
+		UserID:      payload.UserID,
+		AccountID:   payload.AccountID,
+		RecipientID: payload.RecipientID,
+		TrxType:     payload.TrxType,
+		Nominal:     payload.Nominal,
+		Description: payload.Description,
+		ScheduledAt: payload.ScheduledAt,
+	}
+
+	if scheduleModel.ID == 0 {
+		scheduleModel.ID = snowflake.ID()
+	}
+
+	// checks the sender is owned by payload.UserID
+	if err = s.validateScheduledTransaction(ctx, scheduleModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.CreateScheduledTransaction(ctx, scheduleModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleDeleteScheduledTransaction cancels a schedule over the bus on behalf of its owner, a request that does not
+// name the owner is refused rather than cancelling the schedule of anyone
+func (s *service) HandleDeleteScheduledTransaction(ctx context.Context, envelope *indto.EventEnvelope) (err error) {
+	logger := log.Ctx(ctx)
+
+	payload := &indto.EventScheduledTransaction{}
+	if err = s.openEventEnvelope(ctx, inconst.TOPIC_DELETE_SCHEDULE_TRX, envelope, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if payload.UserID == "" || payload.ID == 0 {
+		logger.Error().Str("service-id", envelope.ServiceID).Msg("schedule cancellation must name the schedule and its owner")
+		return errs.ErrBadRequest
+	}
+
+	err = s.repository.CancelScheduledTransaction(ctx, &indto.ScheduledTransactionParams{ScheduleID: payload.ID, UserID: payload.UserID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleExecuteScheduledTransactions executes every due schedule, run periodically by the scheduler
+func (s *service) HandleExecuteScheduledTransactions(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	for {
+		data, err := s.repository.ClaimDueScheduledTransactions(ctx, scheduleTrxExecuteBatch, scheduleTrxLease)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		for _, v := range data {
+			if err = s.executeScheduledTransaction(ctx, v); err != nil {
+				logger.Error().Err(err).Uint64("schedule-id", v.ID).Msg("failed to update scheduled transaction")
+			}
+		}
+
+		if len(data) < scheduleTrxExecuteBatch {
+			return nil
+		}
+	}
+}
+
+// executeScheduledTransaction runs a claimed schedule through the regular transfer path. Insufficient balance and
+// server errors are retried until scheduleTrxMaxAttempts, the owner is notified once the schedule fails for good.
+// The transaction id is reserved before the transfer, a schedule reclaimed from a worker that died after paying is
+// closed with the transaction it already made instead of paying twice
+func (s *service) executeScheduledTransaction(ctx context.Context, data *indto.ScheduledTransaction) (err error) {
+	logger := log.Ctx(ctx)
+
+	description := data.Description
+	if description == "" {
+		description = fmt.Sprintf("scheduled transfer %d", data.ID)
+	}
+
+	scheduleModel := &model.ScheduledTransaction{
+		ID:            data.ID,
+		Status:        inconst.SCHEDULE_TRX_STATUS_SUCCESS,
+		NextAttemptAt: data.NextAttemptAt,
+		TransactionID: data.TransactionID,
+	}
+
+	if data.TransactionID != 0 {
+		trx, err := s.repository.FindTransaction(ctx, &indto.TransactionParams{TransactionID: data.TransactionID})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		if trx != nil {
+			logger.Warn().Uint64("schedule-id", data.ID).Uint64("transaction-id", trx.ID).Msg("scheduled transaction was already executed")
+			return s.repository.UpdateScheduledTransaction(ctx, scheduleModel)
+		}
+	} else {
+		scheduleModel.TransactionID = snowflake.ID()
+		scheduleModel.Status = inconst.SCHEDULE_TRX_STATUS_PROCESSING
+		scheduleModel.LastError = data.LastError
+
+		if err = s.repository.UpdateScheduledTransaction(ctx, scheduleModel); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+
+		scheduleModel.Status = inconst.SCHEDULE_TRX_STATUS_SUCCESS
+		scheduleModel.LastError = ""
+	}
+
+	_, trxErr := s.createTransfer(ctx, scheduleModel.TransactionID, data.TrxType, &dto.TransactionPayload{
+		AccountID:   data.AccountID,
+		RecipientID: data.RecipientID,
+		Nominal:     data.Nominal,
+		Description: description,
+	})
+
+	switch {
+	case trxErr == nil:
+		// scheduleModel already holds the reserved transaction id
+	case isRetryableTrxErr(trxErr) && data.Attempts < scheduleTrxMaxAttempts:
+		logger.Warn().Err(trxErr).Uint64("schedule-id", data.ID).Int64("attempts", data.Attempts).Msg("scheduled transaction will be retried")
+
+		scheduleModel.Status = inconst.SCHEDULE_TRX_STATUS_PENDING
+		scheduleModel.NextAttemptAt = time.Now().Add(time.Duration(data.Attempts) * scheduleTrxRetryDelay)
+		scheduleModel.LastError = trxErr.Error()
+	default:
+		logger.Error().Err(trxErr).Uint64("schedule-id", data.ID).Int64("attempts", data.Attempts).Msg("scheduled transaction failed")
+
+		scheduleModel.Status = inconst.SCHEDULE_TRX_STATUS_FAILED
+		scheduleModel.LastError = trxErr.Error()
+
+		event := &indto.EventFailedScheduledTransaction{
+			ID:        data.ID,
+			UserID:    data.UserID,
+			Attempts:  data.Attempts,
+			ErrorCode: errs.GetErrorResp(trxErr).Code,
+			Message:   trxErr.Error(),
+		}
+
+		if inerr := s.publishEvent(ctx, inconst.TOPIC_FAILED_SCHEDULE_TRX, event); inerr != nil {
+			logger.Error().Err(inerr).Send()
+		}
+	}
+
+	if err = s.repository.UpdateScheduledTransaction(ctx, scheduleModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// validateScheduledTransaction checks a schedule before it is persisted, recipient is validated again on execution
+func (s *service) validateScheduledTransaction(ctx context.Context, payload *model.ScheduledTransaction) (err error) {
+	logger := log.Ctx(ctx)
+
+	if !payload.ScheduledAt.After(time.Now()) {
+		logger.Error().Time("scheduled-at", payload.ScheduledAt).Msg("schedule date must be in the future")
+		return errs.ErrBadRequest
+	}
+
+	err = s.validateDeferredTransfer(ctx, &deferredTransferParams{
+		UserID:      payload.UserID,
+		AccountID:   payload.AccountID,
+		RecipientID: payload.RecipientID,
+		TrxType:     payload.TrxType,
+		Nominal:     payload.Nominal,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	payload.Status = inconst.SCHEDULE_TRX_STATUS_PENDING
+	payload.NextAttemptAt = payload.ScheduledAt
+
+	return
+}
+
+type deferredTransferParams struct {
+	UserID      string
+	AccountID   string
+	RecipientID string
+	TrxType     int64
+	Nominal     money.Money
+}
+
+// validateDeferredTransfer checks transfers that are executed later without the owner around, sender must belong to
+// params.UserID since no PIN is asked on execution
+func (s *service) validateDeferredTransfer(ctx context.Context, params *deferredTransferParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if params.TrxType != inconst.TRX_TYPE_P2P && params.TrxType != inconst.TRX_TYPE_P2B {
+		logger.Error().Int64("trx-type", params.TrxType).Msg("only p2p and p2b transfers can be deferred")
+		return errs.ErrBadRequest
+	}
+
+	if !params.Nominal.IsPositive() {
+		logger.Error().Str("nominal", params.Nominal.String()).Msg("nominal must be positive")
+		return errs.ErrBadRequest
+	}
+
+	senderMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: params.AccountID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if senderMeta == nil || senderMeta.OwnerID != params.UserID {
+		logger.Error().Err(errs.ErrNoAccess).Msgf("accountID: %s is not owned by userID: %s", params.AccountID, params.UserID)
+		return errs.ErrNoAccess
+	}
+
+	if recipientMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: params.RecipientID}); err != nil {
+		logger.Error().Err(err).Send()
+		return err
+	} else if recipientMeta == nil {
+		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s not found", params.RecipientID)
+		return errs.ErrBadRequest
+	}
+
+	return
+}
+
+// isRetryableTrxErr reports whether a failed transfer may succeed later without changing the request
+func isRetryableTrxErr(err error) bool {
+	return errors.Is(err, errs.ErrInsufficientBalance) || errs.GetErrorResp(err).Status >= http.StatusInternalServerError
+}
+
+func scheduledTransactionResponse(v *indto.ScheduledTransaction) *dto.ScheduledTransactionResponse {
+	res := &dto.ScheduledTransactionResponse{
+		ID:          v.ID,
+		AccountID:   v.AccountID,
+		RecipientID: v.RecipientID,
+		TrxType:     v.TrxType,
+		Nominal:     v.Nominal,
+		Description: v.Description,
+		ScheduledAt: timeutil.FormatVerboseTime(v.ScheduledAt),
+		Status:      v.Status,
+		Attempts:    v.Attempts,
+		LastError:   v.LastError,
+	}
+
+	// until the schedule succeeds the transaction id is only reserved
+	if v.Status == inconst.SCHEDULE_TRX_STATUS_SUCCESS {
+		res.TransactionID = v.TransactionID
+	}
+
+	return res
+}
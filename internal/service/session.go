@@ -2,15 +2,28 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/stellar-payment/sp-payment/internal/config"
 	"github.com/stellar-payment/sp-payment/internal/inconst"
 	"github.com/stellar-payment/sp-payment/internal/indto"
 	"github.com/stellar-payment/sp-payment/internal/util/apiutil"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
 	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	// a signed request is refused once its timestamp drifts further than this from ours, either way
+	apiClientClockSkew = 5 * time.Minute
+
+	apiClientMinNonce = 16
+	apiClientMaxNonce = 64
 )
 
 func (s *service) AuthorizedAccessCtx(ctx context.Context, token string) (res context.Context, err error) {
@@ -35,3 +48,90 @@ func (s *service) AuthorizedAccessCtx(ctx context.Context, token string) (res co
 	res = ctxutil.WrapCtx(res, inconst.TOKEN_CTX_KEY, token)
 	return
 }
+
+// AuthorizedAPIClientCtx verifies a request signed by a merchant api client and acts as that merchant from then on.
+// The timestamp bounds how long a signature is valid and the nonce, kept for as long, rejects it being sent twice
+func (s *service) AuthorizedAPIClientCtx(ctx context.Context, params *indto.APIClientSignature) (res context.Context, err error) {
+	logger := zerolog.Ctx(ctx)
+
+	ts, err := strconv.ParseInt(params.Timestamp, 10, 64)
+	if err != nil {
+		logger.Error().Err(err).Str("client-id", params.ClientID).Msg("invalid signature timestamp")
+		return nil, errs.ErrInvalidSignature
+	}
+
+	if drift := time.Since(time.Unix(ts, 0)); drift > apiClientClockSkew || drift < -apiClientClockSkew {
+		logger.Error().Str("client-id", params.ClientID).Dur("drift", drift).Msg("signature timestamp out of range")
+		return nil, errs.ErrInvalidSignature
+	}
+
+	if len(params.Nonce) < apiClientMinNonce || len(params.Nonce) > apiClientMaxNonce {
+		logger.Error().Str("client-id", params.ClientID).Msg("invalid signature nonce")
+		return nil, errs.ErrInvalidSignature
+	}
+
+	client, err := s.repository.FindAPIClient(ctx, &indto.APIClientParams{
+		ClientID: params.ClientID,
+		Status:   inconst.API_CLIENT_STATUS_ACTIVE,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if client == nil {
+		logger.Error().Str("client-id", params.ClientID).Msg("api client not found")
+		return nil, errs.ErrInvalidSignature
+	}
+
+	if !validAPIClientHash(client) {
+		logger.Error().Uint64("api-client-id", client.ID).Msg("api client row hash mismatch")
+		return nil, errs.New(errs.ErrDataIntegrity, "api client")
+	}
+
+	pk, err := cryptoutil.LoadPublicKey([]byte(client.PublicKey))
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params.Signature)
+	if err != nil {
+		logger.Error().Err(err).Str("client-id", params.ClientID).Msg("invalid signature encoding")
+		return nil, errs.ErrInvalidSignature
+	}
+
+	if err = cryptoutil.VerifySHA256WithRSA(apiClientStringToSign(params), pk, signature); err != nil {
+		logger.Error().Err(err).Str("client-id", params.ClientID).Send()
+		return nil, errs.ErrInvalidSignature
+	}
+
+	// the nonce is only taken by a valid signature, otherwise anyone could burn the nonces of a client
+	ok, err := s.repository.CreateAPIClientNonce(ctx, client.ClientID, params.Nonce, 2*apiClientClockSkew)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if !ok {
+		logger.Error().Str("client-id", params.ClientID).Str("nonce", params.Nonce).Msg("signature replayed")
+		return nil, errs.ErrSignatureReplayed
+	}
+
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{MerchantID: client.MerchantID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if merchantMeta == nil {
+		logger.Error().Str("client-id", params.ClientID).Msg("merchant of api client not found")
+		return nil, errs.ErrNoAccess
+	}
+
+	if err := s.repository.TouchAPIClient(ctx, &indto.APIClientParams{APIClientID: client.ID}); err != nil {
+		logger.Warn().Err(err).Uint64("api-client-id", client.ID).Msg("failed to update api client last use")
+	}
+
+	res = ctxutil.WrapCtx(ctx, inconst.AUTH_CTX_KEY, &indto.UserResponse{
+		UserID:   merchantMeta.UserID,
+		Username: merchantMeta.Name,
+		RoleID:   inconst.ROLE_MERCHANT,
+	})
+	res = ctxutil.WrapCtx(res, inconst.API_CLIENT_CTX_KEY, client.ClientID)
+	return
+}
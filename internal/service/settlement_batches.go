@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+func (s *service) GetAllSettlementBatch(ctx context.Context, params *dto.SettlementBatchQueryParams) (res *dto.ListSettlementBatchResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.SettlementBatchParams{
+		MerchantID: params.MerchantID,
+		Status:     params.Status,
+		Limit:      params.Limit,
+		Page:       params.Page,
+	}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to fetch merchant meta")
+			return nil, err
+		} else if merchantMeta == nil {
+			err = errs.New(errs.ErrNotFound)
+			logger.Error().Err(err).Str("user-id", usrmeta.UserID).Msg("failed to fetch merchant meta")
+			return nil, err
+		}
+
+		repoParams.MerchantID = merchantMeta.ID
+	}
+
+	res = &dto.ListSettlementBatchResponse{
+		Batches: []*dto.SettlementBatchResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountSettlementBatches(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindSettlementBatches(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Batches = append(res.Batches, settlementBatchResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetSettlementBatch(ctx context.Context, params *dto.SettlementBatchQueryParams) (res *dto.SettlementBatchResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	repoParams := &indto.SettlementBatchParams{BatchID: params.BatchID}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: usrmeta.UserID})
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to fetch merchant meta")
+			return nil, err
+		} else if merchantMeta == nil {
+			err = errs.New(errs.ErrNotFound)
+			logger.Error().Err(err).Str("user-id", usrmeta.UserID).Msg("failed to fetch merchant meta")
+			return nil, err
+		}
+
+		repoParams.MerchantID = merchantMeta.ID
+	}
+
+	data, err := s.repository.FindSettlementBatch(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return settlementBatchResponse(data), nil
+}
+
+// HandleBatchSettlements closes the day for every merchant, open settlements made before today's local midnight
+// are moved into one batch per merchant. A batch is failed upfront when the merchant account cannot take the payout,
+// its settlements stay withdrawable and the batch turns paid once they are withdrawn
+func (s *service) HandleBatchSettlements(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	cutoff := timeutil.Truncate(timeutil.ConvertLocalTime(time.Now()))
+
+	merchantIDs, err := s.repository.FindUnbatchedSettlementMerchants(ctx, cutoff)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, merchantID := range merchantIDs {
+		batchModel := &model.SettlementBatch{
+			ID:         snowflake.ID(),
+			MerchantID: merchantID,
+			Status:     inconst.SETTLEMENT_STATUS_BATCHED,
+			CutoffAt:   cutoff,
+		}
+
+		batchModel.FailureReason, err = s.settlementPayoutBlocker(ctx, merchantID)
+		if err != nil {
+			logger.Error().Err(err).Str("merchant-id", merchantID).Msg("failed to check merchant account")
+			continue
+		}
+
+		if batchModel.FailureReason != "" {
+			batchModel.Status = inconst.SETTLEMENT_STATUS_FAILED
+		}
+
+		if err = s.repository.CreateSettlementBatch(ctx, batchModel); err != nil {
+			logger.Error().Err(err).Str("merchant-id", merchantID).Msg("failed to create settlement batch")
+			continue
+		}
+
+		if batchModel.SettlementCount == 0 {
+			continue
+		}
+
+		s.emitWebhook(ctx, merchantID, inconst.WEBHOOK_EVENT_SETTLEMENT_BATCHED, &dto.WebhookSettlementData{
+			BatchID:         batchModel.ID,
+			Status:          batchModel.Status,
+			SettlementCount: batchModel.SettlementCount,
+			TotalAmount:     batchModel.TotalAmount,
+			CutoffAt:        timeutil.FormatVerboseTime(batchModel.CutoffAt),
+			FailureReason:   batchModel.FailureReason,
+		})
+
+		logger.Info().Uint64("batch-id", batchModel.ID).Str("merchant-id", merchantID).Int64("status", batchModel.Status).
+			Int64("settlements", batchModel.SettlementCount).Str("total", batchModel.TotalAmount.String()).Msg("settlement batch created")
+	}
+
+	return nil
+}
+
+// settlementPayoutBlocker returns why the merchant cannot be paid out, empty when nothing blocks it
+func (s *service) settlementPayoutBlocker(ctx context.Context, merchantID string) (reason string, err error) {
+	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{MerchantID: merchantID})
+	if err != nil {
+		return
+	} else if merchantMeta == nil {
+		return "merchant not found", nil
+	}
+
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: merchantMeta.UserID})
+	if err != nil {
+		return
+	} else if accountMeta == nil {
+		return "merchant account not found", nil
+	}
+
+	switch accountMeta.Status {
+	case inconst.ACCOUNT_STATUS_CREDIT_BLOCKED, inconst.ACCOUNT_STATUS_FROZEN, inconst.ACCOUNT_STATUS_CLOSED:
+		return "merchant account cannot receive payouts", nil
+	}
+
+	return
+}
+
+func settlementBatchResponse(v *indto.SettlementBatch) *dto.SettlementBatchResponse {
+	res := &dto.SettlementBatchResponse{
+		ID:              v.ID,
+		MerchantID:      v.MerchantID,
+		MerchantName:    v.MerchantName,
+		Status:          v.Status,
+		SettlementCount: v.SettlementCount,
+		TotalAmount:     v.TotalAmount,
+		FeeAmount:       v.FeeAmount,
+		FailureReason:   v.FailureReason,
+		CutoffAt:        timeutil.FormatVerboseTime(v.CutoffAt),
+		CreatedAt:       timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+
+	if v.PaidAt != nil {
+		res.PaidAt = timeutil.FormatVerboseTime(*v.PaidAt)
+	}
+
+	return res
+}
@@ -30,9 +30,12 @@ func (s *service) GetAllSettlement(ctx context.Context, params *dto.SettlementsQ
 	}
 
 	repoParams := &indto.SettlementParams{
-		Keyword: params.Keyword,
-		Limit:   params.Limit,
-		Page:    params.Page,
+		BatchID:       params.BatchID,
+		BeneficiaryID: params.BeneficiaryID,
+		Status:        params.Status,
+		Keyword:       params.Keyword,
+		Limit:         params.Limit,
+		Page:          params.Page,
 	}
 
 	usrmeta := ctxutil.GetUserCTX(ctx)
@@ -78,22 +81,17 @@ func (s *service) GetAllSettlement(ctx context.Context, params *dto.SettlementsQ
 	}
 
 	for _, v := range data {
-		temp := &dto.SettlementResponse{
-			ID:             v.ID,
-			TransactionID:  v.TransactionID,
-			MerchantID:     v.MerchantID,
-			MerchantName:   v.MerchantName,
-			BeneficiaryID:  v.BeneficiaryID,
-			Amount:         v.Amount,
-			Status:         v.Status,
-			SettlementDate: timeutil.FormatVerboseTime(v.SettlementDate),
-		}
-
-		if v.BeneficiaryID != 0 {
-			temp.Status = 1
-		}
-
-		res.Settlements = append(res.Settlements, temp)
+		res.Settlements = append(res.Settlements, &dto.SettlementResponse{
+			ID:              v.ID,
+			TransactionID:   v.TransactionID,
+			MerchantID:      v.MerchantID,
+			MerchantName:    v.MerchantName,
+			BatchID:         v.BatchID,
+			Amount:          v.Amount,
+			AllocatedAmount: v.AllocatedAmount,
+			Status:          v.Status,
+			SettlementDate:  timeutil.FormatVerboseTime(v.SettlementDate),
+		})
 	}
 
 	return
@@ -134,18 +132,15 @@ func (s *service) GetSettlement(ctx context.Context, params *dto.SettlementsQuer
 	}
 
 	res = &dto.SettlementResponse{
-		ID:             data.ID,
-		TransactionID:  data.TransactionID,
-		MerchantID:     data.MerchantID,
-		MerchantName:   data.MerchantName,
-		BeneficiaryID:  data.BeneficiaryID,
-		Amount:         data.Amount,
-		Status:         data.Status,
-		SettlementDate: timeutil.FormatVerboseTime(data.SettlementDate),
-	}
-
-	if data.BeneficiaryID != 0 {
-		res.Status = 1
+		ID:              data.ID,
+		TransactionID:   data.TransactionID,
+		MerchantID:      data.MerchantID,
+		MerchantName:    data.MerchantName,
+		BatchID:         data.BatchID,
+		Amount:          data.Amount,
+		AllocatedAmount: data.AllocatedAmount,
+		Status:          data.Status,
+		SettlementDate:  timeutil.FormatVerboseTime(data.SettlementDate),
 	}
 
 	return
@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// a participant is reminded of the same share at most once per splitBillRemindInterval
+const splitBillRemindInterval = time.Hour
+
+// GetAllSplitBill lists the splits the caller initiated or takes part in, admins see every split
+func (s *service) GetAllSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (res *dto.ListSplitBillResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams, err := s.splitBillParams(ctx, params)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+	repoParams.Limit = params.Limit
+	repoParams.Page = params.Page
+
+	res = &dto.ListSplitBillResponse{
+		SplitBills: []*dto.SplitBillResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountSplitBills(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindSplitBills(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.SplitBills = append(res.SplitBills, splitBillResponse(v))
+	}
+
+	return
+}
+
+// GetSplitBill is the status view of a split, listing every share along with the share of the caller
+func (s *service) GetSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (res *dto.SplitBillResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	repoParams, err := s.splitBillParams(ctx, params)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+	repoParams.SplitID = params.SplitID
+
+	if res, err = s.splitBillDetail(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// CreateSplitBill asks the participants to pay the initiator back their share of payload.Total,
+// a share of the initiator itself is settled from the start
+func (s *service) CreateSplitBill(ctx context.Context, payload *dto.SplitBillPayload) (res *dto.SplitBillResponse, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if len(payload.Participants) == 0 {
+		logger.Error().Msg("split bill requires at least one participant")
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, "Participants")
+	}
+
+	if !payload.Total.IsPositive() {
+		logger.Error().Str("total", payload.Total.String()).Msg("total must be positive")
+		return nil, errs.ErrBadRequest
+	}
+
+	if payload.SplitType != inconst.SPLIT_BILL_TYPE_EQUAL && payload.SplitType != inconst.SPLIT_BILL_TYPE_CUSTOM {
+		logger.Error().Int64("split-type", payload.SplitType).Msg("unsupported split type")
+		return nil, errs.ErrBadRequest
+	}
+
+	initiator, err := s.splitBillCaller(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	splitModel := &model.SplitBill{
+		ID:                 snowflake.ID(),
+		InitiatorAccountID: initiator.ID,
+		Total:              payload.Total,
+		Description:        payload.Description,
+		SplitType:          payload.SplitType,
+		Status:             inconst.SPLIT_BILL_STATUS_OPEN,
+	}
+
+	amounts := splitBillAmounts(payload)
+	sum := money.Zero()
+	owed := 0
+	for i, v := range payload.Participants {
+		if !amounts[i].IsPositive() {
+			logger.Error().Str("account-no", v.AccountNo).Str("amount", amounts[i].String()).Msg("share must be positive")
+			return nil, errs.ErrBadRequest
+		}
+
+		accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountNoHash: cryptoutil.HMACSHA512([]byte(v.AccountNo), conf.HashKey)})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return nil, err
+		} else if accountMeta == nil || accountMeta.AccountType != inconst.ACCOUNT_TYPE_CUST {
+			logger.Error().Msg("split bill participant account not found")
+			return nil, errs.ErrNotFound
+		}
+
+		share := &model.SplitBillShare{
+			AccountID: accountMeta.ID,
+			Amount:    amounts[i],
+			Status:    inconst.SPLIT_SHARE_STATUS_PENDING,
+		}
+
+		if accountMeta.ID == initiator.ID {
+			share.Status = inconst.SPLIT_SHARE_STATUS_PAID
+		} else {
+			owed++
+		}
+
+		splitModel.Shares = append(splitModel.Shares, share)
+		sum = sum.Add(share.Amount)
+	}
+
+	if sum.Cmp(payload.Total) != 0 {
+		logger.Error().Str("total", payload.Total.String()).Str("shares", sum.String()).Msg("shares do not add up to total")
+		return nil, errs.ErrBadRequest
+	}
+
+	if owed == 0 {
+		logger.Error().Msg("split bill has no participant besides the initiator")
+		return nil, errs.ErrBadRequest
+	}
+
+	if err = s.repository.CreateSplitBill(ctx, splitModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if res, err = s.splitBillDetail(ctx, &indto.SplitBillParams{SplitID: splitModel.ID}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// PaySplitBill pays the share of the caller to the initiator through the regular P2P transfer,
+// the split is settled once its last share is paid
+func (s *service) PaySplitBill(ctx context.Context, params *dto.SplitBillsQueryParams, payload *dto.SplitBillPayPayload) (res *dto.SplitBillResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	accountMeta, err := s.splitBillCaller(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.SplitBillParams{SplitID: params.SplitID, AccountID: accountMeta.ID}
+	data, err := s.repository.FindSplitBill(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	shares, err := s.repository.FindSplitBillShares(ctx, &indto.SplitBillShareParams{SplitID: data.ID, AccountID: accountMeta.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if len(shares) == 0 {
+		return nil, errs.ErrNotFound
+	}
+	share := shares[0]
+
+	if data.Status != inconst.SPLIT_BILL_STATUS_OPEN || share.Status != inconst.SPLIT_SHARE_STATUS_PENDING {
+		return nil, errs.ErrSplitBillClosed
+	}
+
+	if err = s.verifyAccountPIN(ctx, accountMeta.ID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trx, err := s.prepareTransfer(ctx, 0, inconst.TRX_TYPE_P2P, &dto.TransactionPayload{
+		AccountID:   accountMeta.ID,
+		RecipientID: data.InitiatorAccountID,
+		TrxType:     inconst.TRX_TYPE_P2P,
+		Nominal:     share.Amount,
+		Description: data.Description,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	// the share is closed in the database transaction that pays it
+	if err = s.repository.PaySplitBillShare(ctx, &model.SplitBillShare{SplitID: data.ID, AccountID: accountMeta.ID}, trx); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if res, err = s.splitBillDetail(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return res, nil
+}
+
+// RemindSplitBill notifies the participants whose share is still pending, participants reminded within
+// splitBillRemindInterval are skipped
+func (s *service) RemindSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (res *dto.SplitBillRemindResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	initiator, err := s.splitBillCaller(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	data, err := s.repository.FindSplitBill(ctx, &indto.SplitBillParams{SplitID: params.SplitID, InitiatorAccountID: initiator.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if data.Status != inconst.SPLIT_BILL_STATUS_OPEN {
+		return nil, errs.ErrSplitBillClosed
+	}
+
+	shares, err := s.repository.FindSplitBillShares(ctx, &indto.SplitBillShareParams{SplitID: data.ID, Statuses: []int64{inconst.SPLIT_SHARE_STATUS_PENDING}})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	reminded := []uint64{}
+	for _, v := range shares {
+		if v.RemindedAt.Valid && time.Since(v.RemindedAt.Time) < splitBillRemindInterval {
+			continue
+		}
+
+		accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: v.AccountID})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return nil, err
+		} else if accountMeta == nil {
+			logger.Warn().Str("account-id", v.AccountID).Msg("split bill participant account not found")
+			continue
+		}
+
+		event := &indto.EventSplitBillReminder{
+			SplitID:     data.ID,
+			ShareID:     v.ID,
+			UserID:      accountMeta.OwnerID,
+			Amount:      v.Amount,
+			Description: data.Description,
+		}
+
+		if err = s.publishEvent(ctx, inconst.TOPIC_SPLIT_BILL_REMINDER, event); err != nil {
+			logger.Error().Err(err).Uint64("share-id", v.ID).Msg("failed to publish split bill reminder")
+			continue
+		}
+
+		reminded = append(reminded, v.ID)
+	}
+
+	if err = s.repository.RemindSplitBillShares(ctx, &indto.SplitBillShareParams{ShareIDs: reminded}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return &dto.SplitBillRemindResponse{Reminded: int64(len(reminded))}, nil
+}
+
+// CancelSplitBill withdraws the pending shares of an open split of the caller, shares already paid stay paid
+func (s *service) CancelSplitBill(ctx context.Context, params *dto.SplitBillsQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return errs.ErrNoAccess
+	}
+
+	initiator, err := s.splitBillCaller(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.SplitBillParams{SplitID: params.SplitID, InitiatorAccountID: initiator.ID}
+	if exists, err := s.repository.FindSplitBill(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return err
+	} else if exists == nil {
+		return errs.ErrNotFound
+	}
+
+	if err = s.repository.CancelSplitBill(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// splitBillCaller finds the customer account of the caller
+func (s *service) splitBillCaller(ctx context.Context) (res *indto.Account, err error) {
+	usrmeta := ctxutil.GetUserCTX(ctx)
+
+	res, err = s.repository.FindAccount(ctx, &indto.AccountParams{UserID: usrmeta.UserID})
+	if err != nil {
+		return
+	} else if res == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return
+}
+
+func (s *service) splitBillParams(ctx context.Context, params *dto.SplitBillsQueryParams) (res *indto.SplitBillParams, err error) {
+	res = &indto.SplitBillParams{Status: params.Status}
+
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER {
+		accountMeta, err := s.splitBillCaller(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res.AccountID = accountMeta.ID
+	}
+
+	return
+}
+
+func (s *service) splitBillDetail(ctx context.Context, params *indto.SplitBillParams) (res *dto.SplitBillResponse, err error) {
+	data, err := s.repository.FindSplitBill(ctx, params)
+	if err != nil {
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	shares, err := s.repository.FindSplitBillShares(ctx, &indto.SplitBillShareParams{SplitID: data.ID})
+	if err != nil {
+		return
+	}
+
+	res = splitBillResponse(data)
+	res.Shares = []*dto.SplitBillShareResponse{}
+	for _, v := range shares {
+		share := splitBillShareResponse(v)
+		if params.AccountID != "" && v.AccountID == params.AccountID {
+			res.MyShare = share
+		}
+
+		res.Shares = append(res.Shares, share)
+	}
+
+	return
+}
+
+// splitBillAmounts is the share of each participant in order, an equal split hands the minor units left over
+// to the first participants
+func splitBillAmounts(payload *dto.SplitBillPayload) (res []money.Money) {
+	if payload.SplitType == inconst.SPLIT_BILL_TYPE_CUSTOM {
+		for _, v := range payload.Participants {
+			res = append(res, v.Amount)
+		}
+
+		return
+	}
+
+	count := int64(len(payload.Participants))
+	each, rem := payload.Total.Minor()/count, payload.Total.Minor()%count
+	for i := int64(0); i < count; i++ {
+		amount := each
+		if i < rem {
+			amount++
+		}
+
+		res = append(res, money.New(amount, payload.Total.Currency()))
+	}
+
+	return
+}
+
+func splitBillResponse(v *indto.SplitBill) *dto.SplitBillResponse {
+	res := &dto.SplitBillResponse{
+		ID:                 v.ID,
+		InitiatorAccountID: v.InitiatorAccountID,
+		Description:        v.Description,
+		Total:              v.Total,
+		Settled:            v.Settled,
+		Outstanding:        money.Zero(),
+		SplitType:          v.SplitType,
+		Status:             v.Status,
+		PaidShares:         v.PaidShares,
+		TotalShares:        v.TotalShares,
+		CreatedAt:          timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+
+	// a cancelled split is owed nothing anymore
+	if v.Status == inconst.SPLIT_BILL_STATUS_OPEN {
+		res.Outstanding = v.Total.Sub(v.Settled)
+	}
+
+	return res
+}
+
+func splitBillShareResponse(v *indto.SplitBillShare) *dto.SplitBillShareResponse {
+	res := &dto.SplitBillShareResponse{
+		ID:            v.ID,
+		AccountID:     v.AccountID,
+		Amount:        v.Amount,
+		Status:        v.Status,
+		TransactionID: v.TransactionID,
+		ReminderCount: v.ReminderCount,
+	}
+
+	if v.RemindedAt.Valid {
+		res.RemindedAt = timeutil.FormatVerboseTime(v.RemindedAt.Time)
+	}
+
+	if v.PaidAt.Valid {
+		res.PaidAt = timeutil.FormatVerboseTime(v.PaidAt.Time)
+	}
+
+	return res
+}
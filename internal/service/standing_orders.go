@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/rruleutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	standingOrderMaxAttempts = 3
+	// retries back off linearly, n-th retry waits n * standingOrderRetryDelay
+	standingOrderRetryDelay = time.Hour
+
+	standingOrderExecuteBatch = 50
+	// a claimed order is handed to another worker once the lease passes without a run recorded
+	standingOrderLease = 10 * time.Minute
+)
+
+func (s *service) GetAllStandingOrder(ctx context.Context, params *dto.StandingOrdersQueryParams) (res *dto.ListStandingOrderResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	repoParams := &indto.StandingOrderParams{
+		Status: params.Status,
+		Limit:  params.Limit,
+		Page:   params.Page,
+	}
+
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER {
+		repoParams.UserID = usrmeta.UserID
+	}
+
+	res = &dto.ListStandingOrderResponse{
+		StandingOrders: []*dto.StandingOrderResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountStandingOrders(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindStandingOrders(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.StandingOrders = append(res.StandingOrders, standingOrderResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetStandingOrder(ctx context.Context, params *dto.StandingOrdersQueryParams) (res *dto.StandingOrderResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.findOwnedStandingOrder(ctx, params.OrderID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return standingOrderResponse(data), nil
+}
+
+func (s *service) GetStandingOrderRuns(ctx context.Context, params *dto.StandingOrdersQueryParams) (res *dto.ListStandingOrderRunResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	if _, err = s.findOwnedStandingOrder(ctx, params.OrderID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.StandingOrderParams{
+		OrderID: params.OrderID,
+		Limit:   params.Limit,
+		Page:    params.Page,
+	}
+
+	res = &dto.ListStandingOrderRunResponse{
+		Runs: []*dto.StandingOrderRunResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountStandingOrderRuns(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindStandingOrderRuns(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		res.Runs = append(res.Runs, &dto.StandingOrderRunResponse{
+			ID:            v.ID,
+			OccurrenceAt:  timeutil.FormatVerboseTime(v.OccurrenceAt),
+			Attempt:       v.Attempt,
+			Status:        v.Status,
+			TransactionID: v.TransactionID,
+			ErrorMessage:  v.ErrorMessage,
+			CreatedAt:     timeutil.FormatVerboseTime(v.CreatedAt),
+		})
+	}
+
+	return
+}
+
+func (s *service) CreateStandingOrder(ctx context.Context, payload *dto.StandingOrderPayload) (res *dto.StandingOrderResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	rule, err := rruleutil.Parse(payload.Recurrence)
+	if err != nil {
+		logger.Error().Err(err).Str("recurrence", payload.Recurrence).Send()
+		return nil, errs.ErrBadRequest
+	}
+
+	startAt := timeutil.ParseLocaltime(payload.StartAt)
+	if startAt.IsZero() {
+		logger.Error().Str("start-at", payload.StartAt).Msg("invalid start date")
+		return nil, errs.ErrBadRequest
+	}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	orderModel := &model.StandingOrder{
+		ID:                        snowflake.ID(),
+		UserID:                    usrmeta.UserID,
+		AccountID:                 payload.AccountID,
+		RecipientID:               payload.RecipientID,
+		TrxType:                   payload.TrxType,
+		Nominal:                   payload.Nominal,
+		Description:               payload.Description,
+		Recurrence:                payload.Recurrence,
+		StartAt:                   startAt,
+		MaxOccurrences:            payload.MaxOccurrences,
+		InsufficientBalancePolicy: payload.InsufficientBalancePolicy,
+		Status:                    inconst.STANDING_ORDER_STATUS_ACTIVE,
+	}
+
+	if payload.EndAt != "" {
+		endAt := timeutil.ParseLocaltime(payload.EndAt)
+		if endAt.IsZero() || endAt.Before(startAt) {
+			logger.Error().Str("end-at", payload.EndAt).Msg("invalid end date")
+			return nil, errs.ErrBadRequest
+		}
+
+		orderModel.EndAt = &endAt
+	}
+
+	if orderModel.MaxOccurrences < 0 {
+		logger.Error().Int64("max-occurrences", orderModel.MaxOccurrences).Msg("max occurrences must not be negative")
+		return nil, errs.ErrBadRequest
+	}
+
+	if orderModel.InsufficientBalancePolicy == 0 {
+		orderModel.InsufficientBalancePolicy = inconst.STANDING_ORDER_POLICY_SKIP
+	} else if orderModel.InsufficientBalancePolicy != inconst.STANDING_ORDER_POLICY_SKIP && orderModel.InsufficientBalancePolicy != inconst.STANDING_ORDER_POLICY_RETRY {
+		logger.Error().Int64("policy", orderModel.InsufficientBalancePolicy).Msg("unknown insufficient balance policy")
+		return nil, errs.ErrBadRequest
+	}
+
+	// first occurrence is the earliest one not in the past
+	after := startAt.Add(-time.Second)
+	if now := time.Now(); now.After(after) {
+		after = now
+	}
+
+	orderModel.NextOccurrenceAt = rule.Next(timeutil.ConvertLocalTime(startAt), after)
+	if orderModel.NextOccurrenceAt.IsZero() || (orderModel.EndAt != nil && orderModel.NextOccurrenceAt.After(*orderModel.EndAt)) {
+		logger.Error().Str("recurrence", payload.Recurrence).Msg("standing order has no upcoming occurrence")
+		return nil, errs.ErrBadRequest
+	}
+
+	orderModel.NextRunAt = orderModel.NextOccurrenceAt
+
+	err = s.validateDeferredTransfer(ctx, &deferredTransferParams{
+		UserID:      orderModel.UserID,
+		AccountID:   orderModel.AccountID,
+		RecipientID: orderModel.RecipientID,
+		TrxType:     orderModel.TrxType,
+		Nominal:     orderModel.Nominal,
+	})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.verifyAccountPIN(ctx, payload.AccountID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.CreateStandingOrder(ctx, orderModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	res = standingOrderResponse(&indto.StandingOrder{
+		ID:                        orderModel.ID,
+		AccountID:                 orderModel.AccountID,
+		RecipientID:               orderModel.RecipientID,
+		TrxType:                   orderModel.TrxType,
+		Nominal:                   orderModel.Nominal,
+		Description:               orderModel.Description,
+		Recurrence:                orderModel.Recurrence,
+		StartAt:                   orderModel.StartAt,
+		EndAt:                     orderModel.EndAt,
+		MaxOccurrences:            orderModel.MaxOccurrences,
+		InsufficientBalancePolicy: orderModel.InsufficientBalancePolicy,
+		Status:                    orderModel.Status,
+		NextOccurrenceAt:          orderModel.NextOccurrenceAt,
+	})
+
+	return
+}
+
+func (s *service) CancelStandingOrder(ctx context.Context, params *dto.StandingOrdersQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.findOwnedStandingOrder(ctx, params.OrderID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.CancelStandingOrder(ctx, &indto.StandingOrderParams{OrderID: data.ID}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleExecuteStandingOrders materializes every due occurrence, run periodically by the scheduler
+func (s *service) HandleExecuteStandingOrders(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	for {
+		data, err := s.repository.ClaimDueStandingOrders(ctx, standingOrderExecuteBatch, standingOrderLease)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		for _, v := range data {
+			if err = s.executeStandingOrder(ctx, v); err != nil {
+				logger.Error().Err(err).Uint64("order-id", v.ID).Msg("failed to update standing order")
+			}
+		}
+
+		if len(data) < standingOrderExecuteBatch {
+			return nil
+		}
+	}
+}
+
+// executeStandingOrder runs the current occurrence of a claimed order through the regular transfer path.
+// Insufficient balance is skipped or retried per order policy, server errors are always retried, every attempt is
+// recorded in the run history. The transaction id is reserved before the transfer, an order reclaimed from a worker
+// that died after paying records the transaction it already made instead of paying twice
+func (s *service) executeStandingOrder(ctx context.Context, data *indto.StandingOrder) (err error) {
+	logger := log.Ctx(ctx)
+
+	description := data.Description
+	if description == "" {
+		description = fmt.Sprintf("standing order %d", data.ID)
+	}
+
+	orderModel := &model.StandingOrder{
+		ID:               data.ID,
+		Occurrences:      data.Occurrences,
+		Status:           inconst.STANDING_ORDER_STATUS_ACTIVE,
+		NextOccurrenceAt: data.NextOccurrenceAt,
+		NextRunAt:        data.NextRunAt,
+		Attempts:         data.Attempts,
+	}
+
+	runModel := &model.StandingOrderRun{
+		OccurrenceAt: data.NextOccurrenceAt,
+		Attempt:      data.Attempts,
+		Status:       inconst.STANDING_ORDER_RUN_SUCCESS,
+	}
+
+	if data.TransactionID != 0 {
+		trx, err := s.repository.FindTransaction(ctx, &indto.TransactionParams{TransactionID: data.TransactionID})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		if trx != nil {
+			logger.Warn().Uint64("order-id", data.ID).Uint64("transaction-id", trx.ID).Msg("standing order occurrence was already executed")
+
+			runModel.TransactionID = trx.ID
+			advanceStandingOrder(data, orderModel, time.Now())
+
+			return s.repository.UpdateStandingOrderRun(ctx, orderModel, runModel)
+		}
+	} else {
+		orderModel.TransactionID = snowflake.ID()
+
+		if err = s.repository.ReserveStandingOrderTransaction(ctx, orderModel); err != nil {
+			logger.Error().Err(err).Send()
+			return
+		}
+
+		data.TransactionID = orderModel.TransactionID
+	}
+
+	_, trxErr := s.createTransfer(ctx, data.TransactionID, data.TrxType, &dto.TransactionPayload{
+		AccountID:   data.AccountID,
+		RecipientID: data.RecipientID,
+		Nominal:     data.Nominal,
+		Description: description,
+	})
+
+	retry := false
+	switch {
+	case trxErr == nil:
+		runModel.TransactionID = data.TransactionID
+	case errors.Is(trxErr, errs.ErrInsufficientBalance):
+		runModel.Status = inconst.STANDING_ORDER_RUN_SKIPPED
+		retry = data.InsufficientBalancePolicy == inconst.STANDING_ORDER_POLICY_RETRY
+	default:
+		runModel.Status = inconst.STANDING_ORDER_RUN_FAILED
+		retry = isRetryableTrxErr(trxErr)
+	}
+
+	if trxErr != nil {
+		runModel.ErrorMessage = trxErr.Error()
+		logger.Warn().Err(trxErr).Uint64("order-id", data.ID).Int64("attempts", data.Attempts).Msg("standing order occurrence not executed")
+	}
+
+	if retry && data.Attempts < standingOrderMaxAttempts {
+		runModel.Status = inconst.STANDING_ORDER_RUN_RETRYING
+		orderModel.NextRunAt = time.Now().Add(time.Duration(data.Attempts) * standingOrderRetryDelay)
+	} else {
+		advanceStandingOrder(data, orderModel, time.Now())
+	}
+
+	if err = s.repository.UpdateStandingOrderRun(ctx, orderModel, runModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// advanceStandingOrder moves orderModel to its first occurrence after now, orders without an upcoming occurrence
+// inside their end date or max occurrences are completed. An order run late, e.g. after an outage or a long
+// retry, executes its current occurrence once and skips the ones it missed meanwhile instead of paying them
+// out in a burst, skipped occurrences do not count towards max occurrences
+func advanceStandingOrder(data *indto.StandingOrder, orderModel *model.StandingOrder, now time.Time) {
+	orderModel.Occurrences = data.Occurrences + 1
+	orderModel.Attempts = 0
+
+	after := data.NextOccurrenceAt
+	if now.After(after) {
+		after = now
+	}
+
+	var next time.Time
+	if rule, err := rruleutil.Parse(data.Recurrence); err == nil {
+		next = rule.Next(timeutil.ConvertLocalTime(data.StartAt), after)
+	}
+
+	switch {
+	case next.IsZero(),
+		data.EndAt != nil && next.After(*data.EndAt),
+		data.MaxOccurrences > 0 && orderModel.Occurrences >= data.MaxOccurrences:
+		orderModel.Status = inconst.STANDING_ORDER_STATUS_COMPLETED
+	default:
+		orderModel.NextOccurrenceAt = next
+		orderModel.NextRunAt = next
+	}
+}
+
+func (s *service) findOwnedStandingOrder(ctx context.Context, orderID uint64) (res *indto.StandingOrder, err error) {
+	logger := log.Ctx(ctx)
+
+	repoParams := &indto.StandingOrderParams{OrderID: orderID}
+	if usrmeta := ctxutil.GetUserCTX(ctx); usrmeta.RoleID == inconst.ROLE_CUSTOMER {
+		repoParams.UserID = usrmeta.UserID
+	}
+
+	res, err = s.repository.FindStandingOrder(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if res == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	return
+}
+
+func standingOrderResponse(v *indto.StandingOrder) *dto.StandingOrderResponse {
+	res := &dto.StandingOrderResponse{
+		ID:                        v.ID,
+		AccountID:                 v.AccountID,
+		RecipientID:               v.RecipientID,
+		TrxType:                   v.TrxType,
+		Nominal:                   v.Nominal,
+		Description:               v.Description,
+		Recurrence:                v.Recurrence,
+		StartAt:                   timeutil.FormatVerboseTime(v.StartAt),
+		MaxOccurrences:            v.MaxOccurrences,
+		Occurrences:               v.Occurrences,
+		InsufficientBalancePolicy: v.InsufficientBalancePolicy,
+		Status:                    v.Status,
+	}
+
+	if v.EndAt != nil {
+		res.EndAt = timeutil.FormatVerboseTime(*v.EndAt)
+	}
+
+	if v.Status == inconst.STANDING_ORDER_STATUS_ACTIVE || v.Status == inconst.STANDING_ORDER_STATUS_PROCESSING {
+		res.NextOccurrenceAt = timeutil.FormatVerboseTime(v.NextOccurrenceAt)
+	}
+
+	return res
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/ctxutil"
+	"github.com/stellar-payment/sp-payment/internal/util/exportutil"
+	"github.com/stellar-payment/sp-payment/internal/util/htmlutil"
+	"github.com/stellar-payment/sp-payment/internal/util/pdfutil"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+const (
+	statementFormatPDF = "pdf"
+	statementFormatCSV = "csv"
+
+	statementTemplate  = "statement.html"
+	statementMaxPeriod = 366 * 24 * time.Hour
+)
+
+type statementLine struct {
+	EntryDatetime string
+	TransactionID string
+	Description   string
+	Counterparty  string
+	Debit         string
+	Credit        string
+	Balance       string
+}
+
+type statementDocument struct {
+	HolderName     string
+	AccountNo      string
+	PeriodStart    string
+	PeriodEnd      string
+	GeneratedAt    string
+	OpeningBalance string
+	ClosingBalance string
+	TotalDebit     string
+	TotalCredit    string
+	Lines          []*statementLine
+}
+
+// GetAccountStatementMe renders the caller's account postings within the requested dates, inclusive, as pdf or csv.
+// Without dates the statement covers the previous calendar month
+func (s *service) GetAccountStatementMe(ctx context.Context, params *dto.AccountStatementQueryParams) (res *dto.AccountStatementFile, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Format == "" {
+		params.Format = statementFormatPDF
+	}
+
+	if params.Format != statementFormatPDF && params.Format != statementFormatCSV {
+		logger.Error().Str("format", params.Format).Msg("unsupported statement format")
+		return nil, errs.ErrBadRequest
+	}
+
+	// pdf goes through the renderer at PDF_RENDERER_ADDR, servers without one only serve csv
+	if params.Format == statementFormatPDF && conf.PDFRendererAddr == "" {
+		logger.Error().Msg("pdf statement requested without a pdf renderer")
+		return nil, errs.ErrPDFRendererMissing
+	}
+
+	dateStart, dateEnd, ok := statementPeriod(params)
+	if !ok {
+		logger.Error().Str("date-start", params.DateStart).Str("date-end", params.DateEnd).Msg("invalid statement period")
+		return nil, errs.ErrBadRequest
+	}
+
+	usrmeta := ctxutil.GetUserCTX(ctx)
+
+	account, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: usrmeta.UserID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if account == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	holderName, err := s.statementHolderName(ctx, usrmeta.RoleID, usrmeta.UserID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.StatementParams{
+		AccountID: account.ID,
+		DateStart: dateStart,
+		DateEnd:   dateEnd.AddDate(0, 0, 1),
+	}
+
+	opening, err := s.repository.FindStatementOpeningBalance(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	entries, err := s.repository.FindStatementEntries(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	doc := &statementDocument{
+		HolderName:     holderName,
+		AccountNo:      cryptoutil.DecryptField(account.AccountNo, conf.DBKey),
+		PeriodStart:    timeutil.FormatDate(dateStart),
+		PeriodEnd:      timeutil.FormatDate(dateEnd),
+		GeneratedAt:    timeutil.FormatLocaltime(time.Now()),
+		OpeningBalance: opening.String(),
+		Lines:          []*statementLine{},
+	}
+
+	balance, totalDebit, totalCredit := opening, money.Zero(), money.Zero()
+	for _, v := range entries {
+		balance = balance.Add(v.Amount)
+
+		line := &statementLine{
+			EntryDatetime: timeutil.FormatLocaltime(v.EntryDatetime),
+			Description:   v.Description,
+			Balance:       balance.String(),
+		}
+
+		if v.TransactionID != 0 {
+			line.TransactionID = strconv.FormatUint(v.TransactionID, 10)
+		}
+
+		if v.CustomerName != nil {
+			line.Counterparty = cryptoutil.DecryptField(v.CustomerName, conf.DBKey)
+		} else if v.MerchantName != nil {
+			line.Counterparty = *v.MerchantName
+		}
+
+		if v.Amount.IsNegative() {
+			totalDebit = totalDebit.Add(v.Amount.Abs())
+			line.Debit = v.Amount.Abs().String()
+		} else {
+			totalCredit = totalCredit.Add(v.Amount)
+			line.Credit = v.Amount.String()
+		}
+
+		doc.Lines = append(doc.Lines, line)
+	}
+
+	doc.ClosingBalance = balance.String()
+	doc.TotalDebit = totalDebit.String()
+	doc.TotalCredit = totalCredit.String()
+
+	res = &dto.AccountStatementFile{
+		Filename: fmt.Sprintf("statement-%s-%s.%s", doc.PeriodStart, doc.PeriodEnd, params.Format),
+	}
+
+	switch params.Format {
+	case statementFormatCSV:
+		res.ContentType = "text/csv"
+		res.Content, err = writeStatementCSV(doc)
+	case statementFormatPDF:
+		res.ContentType = "application/pdf"
+
+		html := ""
+		html, err = htmlutil.ParseTemplate(path.Join(conf.TemplatePath, statementTemplate), doc)
+		if err != nil {
+			logger.Error().Err(err).Msg("failed to render statement template")
+			return nil, err
+		}
+
+		res.Content, err = pdfutil.RenderHTML(ctx, html)
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Str("format", params.Format).Msg("failed to write statement")
+		return nil, err
+	}
+
+	return
+}
+
+func (s *service) statementHolderName(ctx context.Context, roleID int64, userID string) (res string, err error) {
+	conf := config.Get()
+
+	switch roleID {
+	case inconst.ROLE_CUSTOMER:
+		customer, err := s.repository.FindCustomer(ctx, &indto.CustomerParams{UserID: userID})
+		if err != nil || customer == nil {
+			return "", err
+		}
+
+		return cryptoutil.DecryptField(customer.LegalName, conf.DBKey), nil
+	case inconst.ROLE_MERCHANT:
+		merchant, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: userID})
+		if err != nil || merchant == nil {
+			return "", err
+		}
+
+		return merchant.Name, nil
+	}
+
+	return
+}
+
+// statementPeriod resolves the inclusive local dates of the statement, both dates must be given together
+func statementPeriod(params *dto.AccountStatementQueryParams) (dateStart time.Time, dateEnd time.Time, ok bool) {
+	if params.DateStart == "" && params.DateEnd == "" {
+		thisMonth, _ := timeutil.GetStartEndMonth(timeutil.ConvertLocalTime(time.Now()))
+		return thisMonth.AddDate(0, -1, 0), thisMonth.AddDate(0, 0, -1), true
+	}
+
+	dateStart, dateEnd = timeutil.ParseLocalDate(params.DateStart), timeutil.ParseLocalDate(params.DateEnd)
+	if dateStart.IsZero() || dateEnd.IsZero() || dateEnd.Before(dateStart) || dateEnd.Sub(dateStart) > statementMaxPeriod {
+		return
+	}
+
+	return dateStart, dateEnd, true
+}
+
+func writeStatementCSV(doc *statementDocument) (res *bytes.Buffer, err error) {
+	res = &bytes.Buffer{}
+	w := csv.NewWriter(res)
+
+	records := [][]string{
+		{"entry_datetime", "transaction_id", "description", "counterparty", "debit", "credit", "balance"},
+		{doc.PeriodStart, "", "opening balance", "", "", "", doc.OpeningBalance},
+	}
+
+	for _, v := range doc.Lines {
+		records = append(records, []string{v.EntryDatetime, v.TransactionID, v.Description, v.Counterparty, v.Debit, v.Credit, v.Balance})
+	}
+
+	records = append(records, []string{doc.PeriodEnd, "", "closing balance", "", doc.TotalDebit, doc.TotalCredit, doc.ClosingBalance})
+
+	for _, record := range records {
+		for i := range record {
+			record[i] = exportutil.SanitizeCell(record[i])
+		}
+	}
+
+	if err = w.WriteAll(records); err != nil {
+		return nil, err
+	}
+
+	return
+}
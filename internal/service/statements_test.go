@@ -0,0 +1,53 @@
+package service
+
+import (
+	"encoding/csv"
+	"testing"
+)
+
+func TestWriteStatementCSVFormulaCells(t *testing.T) {
+	doc := &statementDocument{
+		PeriodStart:    "2023-11-01",
+		PeriodEnd:      "2023-11-30",
+		OpeningBalance: "100.00",
+		ClosingBalance: "-5.00",
+		TotalDebit:     "105.00",
+		TotalCredit:    "0.00",
+		Lines: []*statementLine{
+			{EntryDatetime: "2023-11-02 10:00:00", TransactionID: "1", Description: `=HYPERLINK("http://evil.test","x")`, Counterparty: "+62 811", Debit: "5.00", Balance: "95.00"},
+			{EntryDatetime: "2023-11-03 10:00:00", TransactionID: "2", Description: "@SUM(A1)", Counterparty: "-cmd", Debit: "100.00", Balance: "-5.00"},
+			{EntryDatetime: "2023-11-04 10:00:00", TransactionID: "3", Description: "\tpad", Counterparty: "\rpad", Balance: "-5.00"},
+		},
+	}
+
+	buf, err := writeStatementCSV(doc)
+	if err != nil {
+		t.Fatalf("writeStatementCSV() unexpected err: %v", err)
+	}
+
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read statement csv: %v", err)
+	}
+
+	tests := []struct {
+		row, col int
+		want     string
+	}{
+		{row: 2, col: 2, want: `'=HYPERLINK("http://evil.test","x")`},
+		{row: 2, col: 3, want: "'+62 811"},
+		{row: 3, col: 2, want: "'@SUM(A1)"},
+		{row: 3, col: 3, want: "'-cmd"},
+		{row: 4, col: 2, want: "'\tpad"},
+		{row: 4, col: 3, want: "'\rpad"},
+		// amounts stay numbers so the sheet can still sum them
+		{row: 3, col: 6, want: "-5.00"},
+		{row: 5, col: 6, want: "-5.00"},
+	}
+
+	for _, tt := range tests {
+		if got := records[tt.row][tt.col]; got != tt.want {
+			t.Errorf("records[%d][%d] = %q, want %q", tt.row, tt.col, got, tt.want)
+		}
+	}
+}
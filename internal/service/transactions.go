@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"time"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
 	"github.com/stellar-payment/sp-payment/pkg/dto"
 	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -92,12 +94,22 @@ func (s *service) GetAllTransaction(ctx context.Context, params *dto.Transaction
 	for _, data := range data {
 		temp := &dto.TransactionResponse{
 			ID:          data.ID,
+			ParentID:    data.ParentID,
 			TrxType:     data.TrxType,
 			TrxDatetime: timeutil.FormatVerboseTime(data.TrxDatetime),
 			TrxStatus:   data.TrxStatus,
 			TrxFee:      data.TrxFee,
+			FeeRuleID:   data.FeeRuleID,
 			Nominal:     data.Nominal,
 			Description: data.Description,
+
+			RefundedAmount: data.RefundedAmount,
+
+			AuthorizedAmount: data.AuthorizedAmount,
+		}
+
+		if data.ExpiresAt != nil {
+			temp.ExpiresAt = timeutil.FormatVerboseTime(*data.ExpiresAt)
 		}
 
 		if data.AccountName != nil {
@@ -108,7 +120,7 @@ func (s *service) GetAllTransaction(ctx context.Context, params *dto.Transaction
 		if data.RecipientName != nil {
 			temp.RecipientID = data.RecipientID
 
-			if data.TrxType == 1 || data.TrxType == 9 {
+			if data.TrxType == 1 || data.TrxType == 4 || data.TrxType == 9 {
 				temp.RecipientName = cryptoutil.DecryptField(data.RecipientName, conf.DBKey)
 			} else {
 				temp.RecipientName = string(data.RecipientName)
@@ -141,12 +153,22 @@ func (s *service) GetTransaction(ctx context.Context, params *dto.TransactionsQu
 
 	res = &dto.TransactionResponse{
 		ID:          data.ID,
+		ParentID:    data.ParentID,
 		TrxType:     data.TrxType,
 		TrxDatetime: timeutil.FormatVerboseTime(data.TrxDatetime),
 		TrxStatus:   data.TrxStatus,
 		TrxFee:      data.TrxFee,
+		FeeRuleID:   data.FeeRuleID,
 		Nominal:     data.Nominal,
 		Description: data.Description,
+
+		RefundedAmount: data.RefundedAmount,
+
+		AuthorizedAmount: data.AuthorizedAmount,
+	}
+
+	if data.ExpiresAt != nil {
+		res.ExpiresAt = timeutil.FormatVerboseTime(*data.ExpiresAt)
 	}
 
 	if data.AccountName != nil {
@@ -157,7 +179,7 @@ func (s *service) GetTransaction(ctx context.Context, params *dto.TransactionsQu
 	if data.RecipientName != nil {
 		res.RecipientID = data.RecipientID
 
-		if data.TrxType == 1 || data.TrxType == 9 {
+		if data.TrxType == 1 || data.TrxType == 4 || data.TrxType == 9 {
 			res.RecipientName = cryptoutil.DecryptField(data.RecipientName, conf.DBKey)
 		} else {
 			res.RecipientName = string(data.RecipientName)
@@ -168,9 +190,40 @@ func (s *service) GetTransaction(ctx context.Context, params *dto.TransactionsQu
 }
 
 func (s *service) CreateTransactionP2P(ctx context.Context, payload *dto.TransactionPayload) (err error) {
+	_, err = s.createTransactionP2P(ctx, payload)
+	return
+}
+
+// createTransactionP2P is CreateTransactionP2P returning the recorded transaction, for flows that keep a link to it
+func (s *service) createTransactionP2P(ctx context.Context, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
 	logger := component.GetLogger()
 
 	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); err != nil {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if err = s.verifyAccountPIN(ctx, payload.AccountID, payload.PIN); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if res, err = s.createTransfer(ctx, 0, inconst.TRX_TYPE_P2P, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) CreateTransactionSystem(ctx context.Context, payload *dto.TransactionPayload) (err error) {
+	logger := component.GetLogger()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
 		return errs.ErrNoAccess
 	}
 
@@ -179,70 +232,226 @@ func (s *service) CreateTransactionP2P(ctx context.Context, payload *dto.Transac
 		return errs.New(errs.ErrMissingRequiredAttribute, val)
 	}
 
-	senderMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.AccountID})
-	if err != nil {
+	if _, err = s.createSystemTransfer(ctx, payload); err != nil {
 		logger.Error().Err(err).Send()
 		return
-	} else if senderMeta == nil {
-		logger.Error().Err(errs.ErrNotFound).Msgf("sender accountID: %s not found", payload.AccountID)
-		return errs.ErrBadRequest
 	}
 
-	if exists, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.RecipientID}); err != nil {
+	return
+}
+
+func (s *service) CreateTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (err error) {
+	_, err = s.createTransactionP2B(ctx, payload)
+	return
+}
+
+// createTransactionP2B is CreateTransactionP2B returning the recorded transaction, for flows that keep a link to it
+func (s *service) createTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
+	logger := component.GetLogger()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if val := structutil.CheckMandatoryField(payload); err != nil {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if err = s.verifyAccountPIN(ctx, payload.AccountID, payload.PIN); err != nil {
 		logger.Error().Err(err).Send()
-		return err
-	} else if exists == nil {
-		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s not found", payload.AccountID)
-		return errs.ErrBadRequest
-	} else if exists.AccountType != inconst.ACCOUNT_TYPE_CUST {
-		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s is not customer", payload.AccountID)
+		return
+	}
+
+	if res, err = s.createTransfer(ctx, 0, inconst.TRX_TYPE_P2B, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleCreateTransaction executes a transaction requested over the bus and publishes the outcome to
+// TOPIC_CREATE_TRX_RESULT under the request correlation id
+func (s *service) HandleCreateTransaction(ctx context.Context, envelope *indto.EventEnvelope) (err error) {
+	logger := log.Ctx(ctx)
+
+	// nothing is answered to an unauthenticated request, its correlation id cannot be trusted either
+	payload := &indto.EventTransaction{}
+	if err = s.openEventEnvelope(ctx, inconst.TOPIC_CREATE_TRX, envelope, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+	payload.ServiceID = envelope.ServiceID
+
+	if payload.CorrelationID == "" {
+		logger.Error().Str("service-id", payload.ServiceID).Msg("trx request without correlation id cannot be answered")
 		return errs.ErrBadRequest
 	}
 
-	if senderMeta.Balance < payload.Nominal*1.1 {
-		err = errs.ErrInsufficientBalance
-		logger.Error().Err(err).Msgf("accountID: %s does not have enough balance. (has=%.2f, need=%.2f)", senderMeta.Balance, payload.Nominal*1.1)
+	result := &indto.EventTransactionResult{
+		CorrelationID: payload.CorrelationID,
+		ServiceID:     payload.ServiceID,
+	}
+
+	trx, trxErr := s.createTransactionFromEvent(ctx, payload)
+	if trxErr != nil {
+		logger.Error().Err(trxErr).Str("correlation-id", payload.CorrelationID).Str("service-id", payload.ServiceID).Msg("trx request failed")
+
+		result.ErrorCode = errs.GetErrorResp(trxErr).Code
+		result.Message = trxErr.Error()
+	} else {
+		result.Success = true
+		result.TransactionID = trx.ID
+		result.TrxStatus = trx.TrxStatus
+	}
+
+	if err = s.publishEvent(ctx, inconst.TOPIC_CREATE_TRX_RESULT, result); err != nil {
+		logger.Error().Err(err).Send()
 		return
 	}
 
-	trxModel := &model.Transaction{
-		ID:          snowflake.ID(),
+	return
+}
+
+// createTransactionFromEvent acts for the user of an authenticated service request in place of the user scope and PIN,
+// then goes through the same validation as the API. The requesting service must be granted the trx type
+func (s *service) createTransactionFromEvent(ctx context.Context, payload *indto.EventTransaction) (res *model.Transaction, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if payload.UserID == "" || payload.AccountID == "" || payload.RecipientID == "" {
+		logger.Error().Str("account-id", payload.AccountID).Str("recipient-id", payload.RecipientID).Msg("trx request is missing a user or an account")
+		return nil, errs.ErrBadRequest
+	}
+
+	// the api takes an admin for system credits, over the bus a service has to be granted the trx type instead
+	if !conf.TrustedServiceTrxTypes[payload.ServiceID][payload.TrxType] {
+		logger.Error().Str("service-id", payload.ServiceID).Int64("trx-type", payload.TrxType).Msg("service is not allowed to request trx type")
+		return nil, errs.ErrNoAccess
+	}
+
+	if err = s.verifyEventAccountOwner(ctx, payload.UserID, payload.AccountID); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	trxPayload := &dto.TransactionPayload{
 		AccountID:   payload.AccountID,
 		RecipientID: payload.RecipientID,
-		TrxType:     inconst.TRX_TYPE_P2P,
-		TrxDatetime: time.Now(),
-		TrxStatus:   inconst.TRX_STATUS_SUCCESS, // always success
-		TrxFee:      payload.Nominal * 0.1,
 		Nominal:     payload.Nominal,
 		Description: payload.Description,
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(senderMeta.PIN), []byte(payload.PIN)); err != nil {
-		err = errs.ErrNoAccess
+	switch payload.TrxType {
+	case inconst.TRX_TYPE_P2P, inconst.TRX_TYPE_P2B:
+		res, err = s.createTransfer(ctx, 0, payload.TrxType, trxPayload)
+	case inconst.TRX_TYPE_CUST_SYSTEM:
+		res, err = s.createSystemTransfer(ctx, trxPayload)
+	default:
+		logger.Error().Int64("trx-type", payload.TrxType).Msg("unsupported trx type")
+		return nil, errs.ErrBadRequest
+	}
+
+	if err != nil {
 		logger.Error().Err(err).Send()
-		return
+		return nil, err
 	}
 
-	err = s.repository.CreateTransactionP2P(ctx, trxModel)
+	return
+}
+
+// verifyEventAccountOwner makes sure a service acting for userID only moves money out of an account of that user
+func (s *service) verifyEventAccountOwner(ctx context.Context, userID string, accountID string) (err error) {
+	logger := log.Ctx(ctx)
+
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: accountID})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
+	} else if accountMeta == nil || accountMeta.OwnerID != userID {
+		logger.Error().Err(errs.ErrNoAccess).Msgf("accountID: %s is not owned by userID: %s", accountID, userID)
+		return errs.ErrNoAccess
 	}
 
 	return
 }
 
-func (s *service) CreateTransactionSystem(ctx context.Context, payload *dto.TransactionPayload) (err error) {
+// createTransfer validates and records a P2P or P2B transfer out of payload.AccountID. It is shared by API, scheduled
+// and event driven transfers, callers are responsible for authorizing the sender. A non zero trxID is used as the
+// transaction id so a caller that reserved it beforehand can tell later whether the transfer went through
+func (s *service) createTransfer(ctx context.Context, trxID uint64, trxType int64, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
 	logger := component.GetLogger()
-	conf := config.Get()
 
-	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN); !ok {
-		return errs.ErrNoAccess
+	if res, err = s.prepareTransfer(ctx, trxID, trxType, payload); err != nil {
+		logger.Error().Err(err).Send()
+		return
 	}
 
-	if val := structutil.CheckMandatoryField(payload); err != nil {
-		logger.Error().Msgf("field %s is missing a value", val)
-		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	if trxType == inconst.TRX_TYPE_P2B {
+		err = s.repository.CreateTransactionP2B(ctx, res)
+	} else {
+		err = s.repository.CreateTransactionP2P(ctx, res)
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return nil, err
+	}
+
+	s.notifyTransfer(ctx, res)
+
+	return
+}
+
+// prepareTransfer validates a P2P or P2B transfer and builds it without recording it, for callers that record the
+// transfer along with what it pays for. Those call notifyTransfer once it is recorded
+func (s *service) prepareTransfer(ctx context.Context, trxID uint64, trxType int64, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
+	logger := component.GetLogger()
+
+	if !payload.Nominal.IsPositive() {
+		logger.Error().Str("nominal", payload.Nominal.String()).Msg("nominal must be positive")
+		return nil, errs.ErrBadRequest
+	}
+
+	switch trxType {
+	case inconst.TRX_TYPE_P2P:
+		res, err = s.prepareTransactionP2P(ctx, payload)
+	case inconst.TRX_TYPE_P2B:
+		res, err = s.prepareTransactionP2B(ctx, payload)
+	default:
+		logger.Error().Int64("trx-type", trxType).Msg("unsupported transfer type")
+		return nil, errs.ErrBadRequest
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return nil, err
+	}
+
+	if trxID != 0 {
+		res.ID = trxID
+	}
+
+	return
+}
+
+// notifyTransfer tells the merchant of a recorded P2B transfer that it was paid
+func (s *service) notifyTransfer(ctx context.Context, trx *model.Transaction) {
+	if trx.TrxType == inconst.TRX_TYPE_P2B {
+		s.emitWebhook(ctx, trx.MerchantID, inconst.WEBHOOK_EVENT_PAYMENT_RECEIVED, webhookPaymentData(trx))
+	}
+}
+
+// createSystemTransfer validates and records a credit from the system account to payload.RecipientID, callers are
+// responsible for authorizing the request
+func (s *service) createSystemTransfer(ctx context.Context, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
+	logger := component.GetLogger()
+	conf := config.Get()
+
+	if !payload.Nominal.IsPositive() {
+		logger.Error().Str("nominal", payload.Nominal.String()).Msg("nominal must be positive")
+		return nil, errs.ErrBadRequest
 	}
 
 	senderMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.AccountID})
@@ -251,97 +460,150 @@ func (s *service) CreateTransactionSystem(ctx context.Context, payload *dto.Tran
 		return
 	} else if senderMeta == nil {
 		logger.Error().Err(errs.ErrNotFound).Msgf("accountID: %s not found", payload.AccountID)
-		return errs.ErrBadRequest
+		return nil, errs.ErrBadRequest
 	}
 
 	if exists, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.RecipientID}); err != nil {
 		logger.Error().Err(err).Send()
-		return err
+		return nil, err
 	} else if exists == nil {
 		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s not found", payload.RecipientID)
-		return errs.ErrBadRequest
+		return nil, errs.ErrBadRequest
 	} else if exists.AccountType != inconst.ACCOUNT_TYPE_CUST {
 		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s is not customer", payload.RecipientID)
-		return errs.ErrBadRequest
+		return nil, errs.ErrBadRequest
 	}
 
-	trxModel := &model.Transaction{
+	res = &model.Transaction{
 		ID:          snowflake.ID(),
 		AccountID:   conf.SystemAccountUUID,
 		RecipientID: payload.RecipientID,
 		TrxType:     inconst.TRX_TYPE_CUST_SYSTEM,
 		TrxDatetime: time.Now(),
 		TrxStatus:   inconst.TRX_STATUS_SUCCESS, // always success
-		TrxFee:      0,
+		TrxFee:      money.Zero(),
 		Nominal:     payload.Nominal,
 		Description: payload.Description,
 	}
 
-	err = s.repository.CreateTransactionSystem(ctx, trxModel)
+	err = s.repository.CreateTransactionSystem(ctx, res)
 	if err != nil {
 		logger.Error().Err(err).Send()
-		return
+		return nil, err
 	}
 
 	return
 }
 
-func (s *service) CreateTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (err error) {
+func (s *service) prepareTransactionP2P(ctx context.Context, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
 	logger := component.GetLogger()
 
-	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_CUSTOMER, inconst.ROLE_MERCHANT); !ok {
-		return errs.ErrNoAccess
+	senderMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.AccountID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if senderMeta == nil {
+		logger.Error().Err(errs.ErrNotFound).Msgf("sender accountID: %s not found", payload.AccountID)
+		return nil, errs.ErrBadRequest
 	}
 
-	if val := structutil.CheckMandatoryField(payload); err != nil {
-		logger.Error().Msgf("field %s is missing a value", val)
-		return errs.New(errs.ErrMissingRequiredAttribute, val)
+	if exists, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.RecipientID}); err != nil {
+		logger.Error().Err(err).Send()
+		return nil, err
+	} else if exists == nil {
+		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s not found", payload.AccountID)
+		return nil, errs.ErrBadRequest
+	} else if exists.AccountType != inconst.ACCOUNT_TYPE_CUST {
+		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s is not customer", payload.AccountID)
+		return nil, errs.ErrBadRequest
 	}
 
+	limit, err := s.findTransactionLimit(ctx, senderMeta, inconst.TRX_TYPE_P2P)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to resolve trx limit")
+		return
+	}
+
+	trxFee, feeRuleID, err := s.calculateTrxFee(ctx, &trxFeeParams{
+		TrxType:     inconst.TRX_TYPE_P2P,
+		AccountTier: senderMeta.AccountTier,
+		Nominal:     payload.Nominal,
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to calculate trx fee")
+		return
+	}
+
+	res = &model.Transaction{
+		ID:          snowflake.ID(),
+		AccountID:   payload.AccountID,
+		RecipientID: payload.RecipientID,
+		TrxType:     inconst.TRX_TYPE_P2P,
+		TrxDatetime: time.Now(),
+		TrxStatus:   inconst.TRX_STATUS_SUCCESS, // always success
+		TrxFee:      trxFee,
+		FeeRuleID:   feeRuleID,
+		Nominal:     payload.Nominal,
+		Description: payload.Description,
+		Limit:       limit,
+	}
+
+	return
+}
+
+// prepareTransactionP2B resolves sender, merchant and fee of a P2B payload
+func (s *service) prepareTransactionP2B(ctx context.Context, payload *dto.TransactionPayload) (res *model.Transaction, err error) {
+	logger := component.GetLogger()
+
 	senderMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.AccountID})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	} else if senderMeta == nil {
 		logger.Error().Err(errs.ErrNotFound).Msgf("accountID: %s not found", payload.AccountID)
-		return errs.ErrBadRequest
+		return nil, errs.ErrBadRequest
 	}
 
 	recipientMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: payload.RecipientID})
 	if err != nil {
 		logger.Error().Err(err).Send()
-		return err
+		return nil, err
 	} else if recipientMeta == nil {
 		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s not found", payload.AccountID)
-		return errs.ErrBadRequest
+		return nil, errs.ErrBadRequest
 	} else if recipientMeta.AccountType != inconst.ACCOUNT_TYPE_MERCHANT {
 		logger.Error().Err(errs.ErrNotFound).Msgf("recepient accountID: %s is not merchant", payload.AccountID)
-		return errs.ErrBadRequest
+		return nil, errs.ErrBadRequest
 	}
 
 	merchantMeta, err := s.repository.FindMerchant(ctx, &indto.MerchantParams{UserID: recipientMeta.OwnerID})
 	if err != nil {
 		logger.Error().Err(err).Msg("failed to fetch merchant meta")
-		return err
+		return nil, err
 	} else if merchantMeta == nil {
 		err = errs.New(errs.ErrNotFound)
 		logger.Error().Err(err).Str("user-id", recipientMeta.OwnerID).Msg("failed to fetch merchant meta")
-		return err
+		return nil, err
 	}
 
-	if senderMeta.Balance < payload.Nominal*1.1 {
-		err = errs.ErrInsufficientBalance
-		logger.Error().Err(err).Msgf("accountID: %s does not have enough balance. (has=%.2f, need=%.2f)", senderMeta.Balance, payload.Nominal*1.1)
+	limit, err := s.findTransactionLimit(ctx, senderMeta, inconst.TRX_TYPE_P2B)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to resolve trx limit")
 		return
 	}
 
-	if err = bcrypt.CompareHashAndPassword([]byte(senderMeta.PIN), []byte(payload.PIN)); err != nil {
-		err = errs.ErrNoAccess
-		logger.Error().Err(err).Send()
+	trxFee, feeRuleID, err := s.calculateTrxFee(ctx, &trxFeeParams{
+		TrxType:     inconst.TRX_TYPE_P2B,
+		MerchantID:  merchantMeta.ID,
+		AccountTier: senderMeta.AccountTier,
+		Nominal:     payload.Nominal,
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to calculate trx fee")
 		return
 	}
 
-	trxModel := &model.Transaction{
+	res = &model.Transaction{
 		ID:          snowflake.ID(),
 		AccountID:   payload.AccountID,
 		RecipientID: payload.RecipientID,
@@ -349,17 +611,91 @@ func (s *service) CreateTransactionP2B(ctx context.Context, payload *dto.Transac
 		TrxType:     inconst.TRX_TYPE_P2B,
 		TrxDatetime: time.Now(),
 		TrxStatus:   inconst.TRX_STATUS_SUCCESS,
-		TrxFee:      payload.Nominal * 0.1,
+		TrxFee:      trxFee,
+		FeeRuleID:   feeRuleID,
 		Nominal:     payload.Nominal,
 		Description: payload.Description,
+		Limit:       limit,
+	}
+
+	return
+}
+
+func (s *service) RefundTransaction(ctx context.Context, params *dto.TransactionsQueryParams, payload *dto.TransactionRefundPayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_ADMIN, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
 	}
 
-	err = s.repository.CreateTransactionP2B(ctx, trxModel)
+	if payload.Nominal.IsNegative() {
+		logger.Error().Str("nominal", payload.Nominal.String()).Msg("refund nominal must not be negative")
+		return errs.ErrBadRequest
+	}
+
+	data, err := s.repository.FindTransaction(ctx, &indto.TransactionParams{TransactionID: params.TransactionID})
 	if err != nil {
 		logger.Error().Err(err).Send()
 		return
 	}
 
+	if data == nil {
+		return errs.ErrNotFound
+	}
+
+	// merchants may only refund payments they received
+	usrmeta := ctxutil.GetUserCTX(ctx)
+	if usrmeta.RoleID == inconst.ROLE_MERCHANT {
+		accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{UserID: usrmeta.UserID})
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		if accountMeta == nil || data.TrxType != inconst.TRX_TYPE_P2B || data.RecipientID != accountMeta.ID {
+			return errs.ErrNoAccess
+		}
+	}
+
+	trxModel := &model.Transaction{
+		ID:          snowflake.ID(),
+		ParentID:    data.ID,
+		TrxDatetime: time.Now(),
+		TrxStatus:   inconst.TRX_STATUS_SUCCESS,
+		Nominal:     payload.Nominal,
+		Description: payload.Description,
+	}
+
+	if trxModel.Description == "" {
+		trxModel.Description = fmt.Sprintf("refund of transaction %d", data.ID)
+	}
+
+	if err = s.repository.CreateTransactionRefund(ctx, trxModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) verifyAccountPIN(ctx context.Context, accountID string, pin string) (err error) {
+	logger := component.GetLogger()
+
+	accountMeta, err := s.repository.FindAccount(ctx, &indto.AccountParams{AccountID: accountID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if accountMeta == nil {
+		logger.Error().Err(errs.ErrNotFound).Msgf("accountID: %s not found", accountID)
+		return errs.ErrBadRequest
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(accountMeta.PIN), []byte(pin)); err != nil {
+		err = errs.ErrNoAccess
+		logger.Error().Err(err).Send()
+		return
+	}
+
 	return
 }
 
@@ -398,3 +734,14 @@ func (s *service) DeleteTransaction(ctx context.Context, params *dto.Transaction
 
 	return
 }
+
+func webhookPaymentData(v *model.Transaction) *dto.WebhookPaymentData {
+	return &dto.WebhookPaymentData{
+		TransactionID: v.ID,
+		AccountID:     v.AccountID,
+		Nominal:       v.Nominal,
+		Fee:           v.TrxFee,
+		Description:   v.Description,
+		TrxDatetime:   timeutil.FormatVerboseTime(v.TrxDatetime),
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/repository"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+// ownerCheckRepository answers the sender owner check with an account of nobody and records it was asked,
+// any other repository call panics
+type ownerCheckRepository struct {
+	repository.Repository
+	asked bool
+}
+
+func (r *ownerCheckRepository) FindAccount(ctx context.Context, params *indto.AccountParams) (res *indto.Account, err error) {
+	r.asked = true
+	return &indto.Account{ID: params.AccountID, OwnerID: uuid.NewString()}, nil
+}
+
+func TestCreateTransactionFromEventTrxTypeGrant(t *testing.T) {
+	config.Set(&config.Config{
+		TrustedServiceTrxTypes: map[string]map[int64]bool{
+			"sp-wallet": {inconst.TRX_TYPE_P2P: true, inconst.TRX_TYPE_P2B: true},
+			"sp-topup":  {inconst.TRX_TYPE_CUST_SYSTEM: true},
+		},
+	})
+
+	tests := []struct {
+		name      string
+		serviceID string
+		trxType   int64
+		wantAsked bool
+	}{
+		{name: "system credit from a service not granted it", serviceID: "sp-wallet", trxType: inconst.TRX_TYPE_CUST_SYSTEM},
+		{name: "transfer from a service granted system credits only", serviceID: "sp-topup", trxType: inconst.TRX_TYPE_P2P},
+		{name: "unknown service", serviceID: "sp-unknown", trxType: inconst.TRX_TYPE_P2P},
+		// granted requests go on to the sender owner check, which refuses the account of somebody else
+		{name: "system credit from a service granted it", serviceID: "sp-topup", trxType: inconst.TRX_TYPE_CUST_SYSTEM, wantAsked: true},
+		{name: "transfer from a service granted it", serviceID: "sp-wallet", trxType: inconst.TRX_TYPE_P2B, wantAsked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &ownerCheckRepository{}
+			s := &service{repository: repo}
+
+			_, err := s.createTransactionFromEvent(context.Background(), &indto.EventTransaction{
+				ServiceID:   tt.serviceID,
+				UserID:      uuid.NewString(),
+				AccountID:   uuid.NewString(),
+				RecipientID: uuid.NewString(),
+				TrxType:     tt.trxType,
+				Nominal:     money.MustParse("10"),
+			})
+			if !errors.Is(err, errs.ErrNoAccess) {
+				t.Fatalf("createTransactionFromEvent() err = %v, want %v", err, errs.ErrNoAccess)
+			}
+
+			if repo.asked != tt.wantAsked {
+				t.Errorf("sender owner checked = %v, want %v", repo.asked, tt.wantAsked)
+			}
+		})
+	}
+}
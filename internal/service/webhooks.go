@@ -0,0 +1,632 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/godruoyi/go-snowflake"
+	"github.com/rs/zerolog/log"
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/indto"
+	"github.com/stellar-payment/sp-payment/internal/model"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+	"github.com/stellar-payment/sp-payment/internal/util/namegen"
+	"github.com/stellar-payment/sp-payment/internal/util/scopeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/structutil"
+	"github.com/stellar-payment/sp-payment/internal/util/timeutil"
+	"github.com/stellar-payment/sp-payment/internal/util/webhookutil"
+	"github.com/stellar-payment/sp-payment/pkg/dto"
+	"github.com/stellar-payment/sp-payment/pkg/errs"
+)
+
+const (
+	webhookSecretSize   = 32
+	webhookSecretPrefix = "whsec_"
+
+	// a delivery the receiver keeps refusing fails after this many attempts, it can still be redelivered by hand
+	webhookMaxAttempts = 8
+
+	// retries back off exponentially, n-th retry waits webhookRetryDelay * 2^(n-1)
+	webhookRetryDelay = 30 * time.Second
+
+	// a claimed delivery is not picked up again before the lease ends, even if its worker died.
+	// it has to outlast a whole batch of receivers timing out
+	webhookLease = 5 * time.Minute
+
+	webhookDispatchBatch = 20
+)
+
+// webhookEventTypes are the events merchants can subscribe to
+var webhookEventTypes = map[string]bool{
+	inconst.WEBHOOK_EVENT_PAYMENT_RECEIVED:   true,
+	inconst.WEBHOOK_EVENT_SETTLEMENT_BATCHED: true,
+	inconst.WEBHOOK_EVENT_WITHDRAWAL_UPDATED: true,
+}
+
+func (s *service) GetAllWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (res *dto.ListWebhookResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.WebhookParams{
+		MerchantID: merchantMeta.ID,
+		Status:     params.Status,
+		Limit:      params.Limit,
+		Page:       params.Page,
+	}
+
+	res = &dto.ListWebhookResponse{
+		Webhooks: []*dto.WebhookResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountWebhooks(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	data, err := s.repository.FindWebhooks(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range data {
+		if !validWebhookHash(v) {
+			logger.Warn().Uint64("webhook-id", v.ID).Msg("webhook row hash mismatch")
+			continue
+		}
+
+		res.Webhooks = append(res.Webhooks, webhookResponse(v))
+	}
+
+	return
+}
+
+func (s *service) GetWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (res *dto.WebhookResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	data, err := s.merchantWebhook(ctx, params.WebhookID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return webhookResponse(data), nil
+}
+
+// CreateWebhook subscribes payload.URL to events of the calling merchant, deliveries are signed with a secret
+// generated here. The secret is returned by this response only, a merchant that loses it subscribes again
+func (s *service) CreateWebhook(ctx context.Context, payload *dto.WebhookPayload) (res *dto.WebhookResponse, err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	webhookModel, err := s.webhookModel(ctx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	secret, err := namegen.GenerateRandomToken(webhookSecretSize)
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to generate webhook secret")
+		return
+	}
+
+	webhookModel.ID = snowflake.ID()
+	webhookModel.MerchantID = merchantMeta.ID
+	webhookModel.Secret = cryptoutil.EncryptField([]byte(webhookSecretPrefix+secret), conf.DBKey, nil)
+	webhookModel.RowHash = webhookRowHash(webhookModel.Secret, webhookModel.URL)
+
+	if err = s.repository.CreateWebhook(ctx, webhookModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	data, err := s.repository.FindWebhook(ctx, &indto.WebhookParams{WebhookID: webhookModel.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	} else if data == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	res = webhookResponse(data)
+	res.Secret = webhookSecretPrefix + secret
+
+	return res, nil
+}
+
+// UpdateWebhook changes where and which events are delivered, a disabled webhook receives nothing new
+func (s *service) UpdateWebhook(ctx context.Context, params *dto.WebhooksQueryParams, payload *dto.WebhookPayload) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.merchantWebhook(ctx, params.WebhookID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	webhookModel, err := s.webhookModel(ctx, payload)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	webhookModel.ID = data.ID
+	webhookModel.MerchantID = data.MerchantID
+	webhookModel.RowHash = webhookRowHash(data.Secret, webhookModel.URL)
+
+	if err = s.repository.UpdateWebhook(ctx, webhookModel); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+func (s *service) DeleteWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.merchantWebhook(ctx, params.WebhookID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.DeleteWebhook(ctx, &indto.WebhookParams{WebhookID: data.ID, MerchantID: data.MerchantID}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// PingWebhook queues a webhook.ping delivery regardless of the subscribed events, to check a receiver end to end
+func (s *service) PingWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.merchantWebhook(ctx, params.WebhookID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if data.Status != inconst.WEBHOOK_STATUS_ACTIVE {
+		logger.Error().Uint64("webhook-id", data.ID).Msg("webhook is disabled")
+		return errs.ErrBadRequest
+	}
+
+	deliveryModel, err := webhookDelivery(data.ID, inconst.WEBHOOK_EVENT_PING, map[string]interface{}{"webhook_id": data.ID})
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if err = s.repository.CreateWebhookDeliveries(ctx, []*model.WebhookDelivery{deliveryModel}); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// GetAllWebhookDelivery is the delivery log of a webhook, newest first
+func (s *service) GetAllWebhookDelivery(ctx context.Context, params *dto.WebhooksQueryParams) (res *dto.ListWebhookDeliveryResponse, err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return nil, errs.ErrNoAccess
+	}
+
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	if params.Limit <= 0 || params.Limit >= 100 {
+		params.Limit = 100
+	}
+
+	data, err := s.merchantWebhook(ctx, params.WebhookID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.WebhookDeliveryParams{
+		WebhookID: data.ID,
+		Status:    params.Status,
+		Limit:     params.Limit,
+		Page:      params.Page,
+	}
+
+	res = &dto.ListWebhookDeliveryResponse{
+		Deliveries: []*dto.WebhookDeliveryResponse{},
+		Meta: dto.ListPaginations{
+			Limit: params.Limit,
+			Page:  params.Page,
+		},
+	}
+
+	count, err := s.repository.CountWebhookDeliveries(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	res.Meta.TotalItem = uint64(count)
+	res.Meta.TotalPage = uint64(math.Ceil(float64(count) / float64(params.Limit)))
+
+	deliveries, err := s.repository.FindWebhookDeliveries(ctx, repoParams)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	for _, v := range deliveries {
+		res.Deliveries = append(res.Deliveries, webhookDeliveryResponse(v))
+	}
+
+	return
+}
+
+// RedeliverWebhook sends a delivered or failed delivery again with the same body, it is signed anew when sent
+func (s *service) RedeliverWebhook(ctx context.Context, params *dto.WebhooksQueryParams) (err error) {
+	logger := log.Ctx(ctx)
+
+	if ok := scopeutil.ValidateScope(ctx, inconst.ROLE_MERCHANT); !ok {
+		return errs.ErrNoAccess
+	}
+
+	data, err := s.merchantWebhook(ctx, params.WebhookID)
+	if err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	repoParams := &indto.WebhookDeliveryParams{DeliveryID: params.DeliveryID, WebhookID: data.ID}
+	if exists, err := s.repository.FindWebhookDelivery(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return err
+	} else if exists == nil {
+		return errs.ErrNotFound
+	}
+
+	if err = s.repository.RedeliverWebhookDelivery(ctx, repoParams); err != nil {
+		logger.Error().Err(err).Send()
+		return
+	}
+
+	return
+}
+
+// HandleDispatchWebhooks sends the deliveries that are due, a refused delivery is retried with exponential backoff
+// until webhookMaxAttempts
+func (s *service) HandleDispatchWebhooks(ctx context.Context) (err error) {
+	logger := log.Ctx(ctx)
+
+	for {
+		data, err := s.repository.ClaimDueWebhookDeliveries(ctx, webhookDispatchBatch, webhookLease)
+		if err != nil {
+			logger.Error().Err(err).Send()
+			return err
+		}
+
+		for _, v := range data {
+			if err = s.dispatchWebhook(ctx, v); err != nil {
+				logger.Error().Err(err).Uint64("delivery-id", v.ID).Msg("failed to dispatch webhook")
+			}
+		}
+
+		if len(data) < webhookDispatchBatch {
+			return nil
+		}
+	}
+}
+
+// emitWebhook queues event for every active webhook of merchantID subscribed to it. Delivery is best effort,
+// failing to queue never fails the operation that emitted the event
+func (s *service) emitWebhook(ctx context.Context, merchantID string, event string, data interface{}) {
+	logger := log.Ctx(ctx)
+
+	if merchantID == "" {
+		return
+	}
+
+	webhooks, err := s.repository.FindWebhooks(ctx, &indto.WebhookParams{
+		MerchantID: merchantID,
+		EventType:  event,
+		Status:     inconst.WEBHOOK_STATUS_ACTIVE,
+	})
+	if err != nil {
+		logger.Error().Err(err).Str("merchant-id", merchantID).Str("event", event).Msg("failed to find webhooks")
+		return
+	}
+
+	deliveries := []*model.WebhookDelivery{}
+	for _, v := range webhooks {
+		deliveryModel, err := webhookDelivery(v.ID, event, data)
+		if err != nil {
+			logger.Error().Err(err).Str("event", event).Msg("failed to encode webhook event")
+			return
+		}
+
+		deliveries = append(deliveries, deliveryModel)
+	}
+
+	if err = s.repository.CreateWebhookDeliveries(ctx, deliveries); err != nil {
+		logger.Error().Err(err).Str("merchant-id", merchantID).Str("event", event).Msg("failed to queue webhook deliveries")
+	}
+}
+
+// dispatchWebhook makes one attempt of a claimed delivery, deliveries of removed or disabled webhooks fail right away
+func (s *service) dispatchWebhook(ctx context.Context, data *indto.WebhookDelivery) (err error) {
+	logger := log.Ctx(ctx)
+	conf := config.Get()
+
+	deliveryModel := &model.WebhookDelivery{
+		ID:       data.ID,
+		Status:   inconst.WEBHOOK_DELIVERY_STATUS_FAILED,
+		Attempts: data.Attempts,
+	}
+
+	webhookMeta, err := s.repository.FindWebhook(ctx, &indto.WebhookParams{WebhookID: data.SubscriptionID})
+	if err != nil {
+		return
+	}
+
+	switch {
+	case webhookMeta == nil:
+		deliveryModel.LastError = "webhook was deleted"
+		return s.repository.UpdateWebhookDelivery(ctx, deliveryModel)
+	case webhookMeta.Status != inconst.WEBHOOK_STATUS_ACTIVE:
+		deliveryModel.LastError = "webhook is disabled"
+		return s.repository.UpdateWebhookDelivery(ctx, deliveryModel)
+	case !validWebhookHash(webhookMeta):
+		deliveryModel.LastError = "webhook failed integrity check"
+		return s.repository.UpdateWebhookDelivery(ctx, deliveryModel)
+	}
+
+	deliveryModel.Attempts++
+	res, sendErr := webhookutil.Send(ctx, &webhookutil.Delivery{
+		ID:     data.ID,
+		Event:  data.EventType,
+		URL:    webhookMeta.URL,
+		Secret: []byte(cryptoutil.DecryptField(webhookMeta.Secret, conf.DBKey)),
+		Body:   data.Payload,
+	})
+	if res != nil {
+		deliveryModel.ResponseStatus = int64(res.StatusCode)
+		deliveryModel.ResponseBody = res.Body
+	}
+
+	switch {
+	case sendErr == nil:
+		deliveryModel.Status = inconst.WEBHOOK_DELIVERY_STATUS_DELIVERED
+	case deliveryModel.Attempts >= webhookMaxAttempts:
+		logger.Warn().Err(sendErr).Uint64("delivery-id", data.ID).Int64("attempts", deliveryModel.Attempts).Msg("webhook delivery failed")
+
+		deliveryModel.LastError = sendErr.Error()
+	default:
+		logger.Warn().Err(sendErr).Uint64("delivery-id", data.ID).Int64("attempt", deliveryModel.Attempts).Msg("webhook delivery will be retried")
+
+		next := time.Now().Add(webhookRetryDelay << (deliveryModel.Attempts - 1))
+		deliveryModel.Status = inconst.WEBHOOK_DELIVERY_STATUS_PENDING
+		deliveryModel.NextRunAt = &next
+		deliveryModel.LastError = sendErr.Error()
+	}
+
+	return s.repository.UpdateWebhookDelivery(ctx, deliveryModel)
+}
+
+// merchantWebhook finds webhookID among the webhooks of the calling merchant
+func (s *service) merchantWebhook(ctx context.Context, webhookID uint64) (res *indto.WebhookSubscription, err error) {
+	merchantMeta, err := s.callerMerchant(ctx)
+	if err != nil {
+		return
+	}
+
+	res, err = s.repository.FindWebhook(ctx, &indto.WebhookParams{WebhookID: webhookID, MerchantID: merchantMeta.ID})
+	if err != nil {
+		return
+	} else if res == nil {
+		return nil, errs.ErrNotFound
+	}
+
+	if !validWebhookHash(res) {
+		return nil, errs.New(errs.ErrDataIntegrity, "webhook")
+	}
+
+	return
+}
+
+// webhookModel validates payload, a webhook without status is created active
+func (s *service) webhookModel(ctx context.Context, payload *dto.WebhookPayload) (res *model.WebhookSubscription, err error) {
+	logger := log.Ctx(ctx)
+
+	if val := structutil.CheckMandatoryField(payload); val != "" {
+		logger.Error().Msgf("field %s is missing a value", val)
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, val)
+	}
+
+	if err = webhookutil.ValidateURL(payload.URL); err != nil {
+		logger.Error().Err(err).Str("url", payload.URL).Send()
+		return nil, errs.ErrInvalidWebhookURL
+	}
+
+	if len(payload.EventTypes) == 0 {
+		logger.Error().Msg("webhook requires at least one event type")
+		return nil, errs.New(errs.ErrMissingRequiredAttribute, "EventTypes")
+	}
+
+	seen := map[string]bool{}
+	eventTypes := []string{}
+	for _, v := range payload.EventTypes {
+		if !webhookEventTypes[v] {
+			logger.Error().Str("event-type", v).Msg("unsupported webhook event type")
+			return nil, errs.ErrBadRequest
+		}
+
+		if !seen[v] {
+			seen[v] = true
+			eventTypes = append(eventTypes, v)
+		}
+	}
+
+	status := payload.Status
+	if status == 0 {
+		status = inconst.WEBHOOK_STATUS_ACTIVE
+	} else if status != inconst.WEBHOOK_STATUS_ACTIVE && status != inconst.WEBHOOK_STATUS_DISABLED {
+		logger.Error().Int64("status", status).Msg("invalid webhook status")
+		return nil, errs.ErrBadRequest
+	}
+
+	res = &model.WebhookSubscription{
+		URL:    payload.URL,
+		Status: status,
+	}
+
+	if res.EventTypes, err = json.Marshal(eventTypes); err != nil {
+		return
+	}
+
+	return
+}
+
+// webhookDelivery wraps data into the event body of a new delivery, the body is stored as sent so a redelivery
+// carries exactly the same event
+func webhookDelivery(webhookID uint64, event string, data interface{}) (res *model.WebhookDelivery, err error) {
+	res = &model.WebhookDelivery{
+		ID:             snowflake.ID(),
+		SubscriptionID: webhookID,
+		EventType:      event,
+	}
+
+	res.Payload, err = json.Marshal(&indto.WebhookEvent{
+		ID:        res.ID,
+		Event:     event,
+		CreatedAt: timeutil.FormatVerboseTime(time.Now()),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// webhookRowHash covers the url as well, so deliveries cannot be redirected by editing the row
+func webhookRowHash(secret []byte, url string) []byte {
+	conf := config.Get()
+
+	msg := append(append([]byte{}, secret...), url...)
+	return cryptoutil.HMACSHA512(msg, conf.HashKey)
+}
+
+func validWebhookHash(v *indto.WebhookSubscription) bool {
+	conf := config.Get()
+
+	msg := append(append([]byte{}, v.Secret...), v.URL...)
+	return cryptoutil.VerifyHMACSHA512(msg, conf.HashKey, v.RowHash)
+}
+
+// webhookResponse leaves the signing secret out, it is only shown once by CreateWebhook
+func webhookResponse(v *indto.WebhookSubscription) *dto.WebhookResponse {
+	res := &dto.WebhookResponse{
+		ID:         v.ID,
+		MerchantID: v.MerchantID,
+		URL:        v.URL,
+		EventTypes: []string{},
+		Status:     v.Status,
+		CreatedAt:  timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+
+	_ = json.Unmarshal(v.EventTypes, &res.EventTypes)
+
+	return res
+}
+
+func webhookDeliveryResponse(v *indto.WebhookDelivery) *dto.WebhookDeliveryResponse {
+	res := &dto.WebhookDeliveryResponse{
+		ID:             v.ID,
+		WebhookID:      v.SubscriptionID,
+		EventType:      v.EventType,
+		Payload:        v.Payload,
+		Status:         v.Status,
+		Attempts:       v.Attempts,
+		ResponseStatus: v.ResponseStatus,
+		ResponseBody:   v.ResponseBody,
+		LastError:      v.LastError,
+		CreatedAt:      timeutil.FormatVerboseTime(v.CreatedAt),
+	}
+
+	if v.Status == inconst.WEBHOOK_DELIVERY_STATUS_PENDING && v.NextRunAt.Valid {
+		res.NextRunAt = timeutil.FormatVerboseTime(v.NextRunAt.Time)
+	}
+
+	if v.DeliveredAt.Valid {
+		res.DeliveredAt = timeutil.FormatVerboseTime(v.DeliveredAt.Time)
+	}
+
+	return res
+}
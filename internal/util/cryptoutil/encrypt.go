@@ -100,7 +100,10 @@ func LoadPublicKey(key []byte) (pk *rsa.PublicKey, err error) {
 		return nil, fmt.Errorf("failed to parse public key err: %+v", err)
 	}
 
-	pk = keyInterface.(*rsa.PublicKey)
+	pk, ok := keyInterface.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse public key err: not an rsa public key")
+	}
 
 	return
 }
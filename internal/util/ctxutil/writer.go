@@ -33,3 +33,9 @@ func GetTokenCtx(ctx context.Context) (res string) {
 
 	return
 }
+
+func GetAPIClientCtx(ctx context.Context) (res string) {
+	res, _ = GetCtx[string](ctx, inconst.API_CLIENT_CTX_KEY)
+
+	return
+}
@@ -3,6 +3,7 @@ package echttputil
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -33,3 +34,13 @@ func WriteFileBufferAttachment(ec echo.Context, file *bytes.Buffer, contentType
 	ec.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=\"%s\"", filename))
 	return ec.Blob(http.StatusOK, contentType, file.Bytes())
 }
+
+// WriteStreamAttachment commits the response headers then lets write stream the body, an error from write can
+// only cut the attachment short
+func WriteStreamAttachment(ec echo.Context, contentType string, filename string, write func(w io.Writer) error) error {
+	ec.Response().Header().Set(echo.HeaderContentType, contentType)
+	ec.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment;filename=\"%s\"", filename))
+	ec.Response().WriteHeader(http.StatusOK)
+
+	return write(ec.Response())
+}
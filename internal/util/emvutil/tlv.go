@@ -0,0 +1,161 @@
+package emvutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Tags of the EMVCo merchant-presented QR payload used by this service
+const (
+	TagPayloadFormat    = "00"
+	TagInitiationMethod = "01"
+	TagMerchantAccount  = "26"
+	TagMerchantCategory = "52"
+	TagCurrency         = "53"
+	TagAmount           = "54"
+	TagCountry          = "58"
+	TagMerchantName     = "59"
+	TagMerchantCity     = "60"
+	TagAdditionalData   = "62"
+	TagCRC              = "63"
+
+	// merchant account information template
+	SubTagGUID      = "00"
+	SubTagAccountNo = "01"
+
+	// additional data field template
+	SubTagReference = "05"
+
+	PayloadFormat     = "01"
+	InitiationStatic  = "11"
+	InitiationDynamic = "12"
+)
+
+var (
+	ErrMalformed = errors.New("malformed emv payload")
+	ErrChecksum  = errors.New("emv payload checksum mismatch")
+)
+
+type Field struct {
+	Tag   string
+	Value string
+}
+
+// Fields keeps the order tags were encoded or read in
+type Fields []Field
+
+func (f Fields) Get(tag string) (string, bool) {
+	for _, v := range f {
+		if v.Tag == tag {
+			return v.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Template nests fields as the value of tag, e.g. merchant account information
+func Template(tag string, fields ...Field) Field {
+	sb := &strings.Builder{}
+	writeFields(sb, fields)
+
+	return Field{Tag: tag, Value: sb.String()}
+}
+
+// Encode serializes fields and appends the CRC field, fields with an empty value are left out
+func Encode(fields ...Field) (res string, err error) {
+	for _, v := range fields {
+		if len(v.Tag) != 2 || len(v.Value) > 99 {
+			return "", fmt.Errorf("%w: field %s does not fit", ErrMalformed, v.Tag)
+		}
+	}
+
+	sb := &strings.Builder{}
+	writeFields(sb, fields)
+
+	// checksum covers the crc tag and length as well
+	sb.WriteString(TagCRC + "04")
+	sb.WriteString(CRC16(sb.String()))
+
+	return sb.String(), nil
+}
+
+// Decode verifies the checksum of payload and reads its top level fields, templates are read with DecodeTemplate
+func Decode(payload string) (res Fields, err error) {
+	if len(payload) < 8 {
+		return nil, ErrMalformed
+	}
+
+	body, crc := payload[:len(payload)-4], payload[len(payload)-4:]
+	if !strings.HasSuffix(body, TagCRC+"04") {
+		return nil, ErrMalformed
+	}
+
+	if !strings.EqualFold(CRC16(body), crc) {
+		return nil, ErrChecksum
+	}
+
+	if res, err = DecodeTemplate(body[:len(body)-4]); err != nil {
+		return
+	}
+
+	if val, _ := res.Get(TagPayloadFormat); len(res) == 0 || res[0].Tag != TagPayloadFormat || val != PayloadFormat {
+		return nil, fmt.Errorf("%w: unsupported payload format", ErrMalformed)
+	}
+
+	return
+}
+
+// DecodeTemplate reads the fields nested in a template value
+func DecodeTemplate(value string) (res Fields, err error) {
+	res = Fields{}
+
+	for i := 0; i < len(value); {
+		if i+4 > len(value) {
+			return nil, ErrMalformed
+		}
+
+		length, err := strconv.Atoi(value[i+2 : i+4])
+		if err != nil || length < 0 || i+4+length > len(value) {
+			return nil, ErrMalformed
+		}
+
+		res = append(res, Field{Tag: value[i : i+2], Value: value[i+4 : i+4+length]})
+		i += 4 + length
+	}
+
+	return
+}
+
+// CRC16 is the CRC-16/CCITT-FALSE checksum EMVCo mandates, as 4 uppercase hex digits
+func CRC16(data string) string {
+	crc := uint16(0xFFFF)
+
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%04X", crc)
+}
+
+func writeFields(sb *strings.Builder, fields []Field) {
+	for _, v := range fields {
+		if v.Value == "" {
+			continue
+		}
+
+		sb.WriteString(v.Tag)
+		sb.WriteString(fmt.Sprintf("%02d", len(v.Value)))
+		sb.WriteString(v.Value)
+	}
+}
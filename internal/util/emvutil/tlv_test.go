@@ -0,0 +1,170 @@
+package emvutil
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// qrisPayload is a dynamic merchant-presented payload, its checksum was computed independently of CRC16
+const qrisPayload = "00020101021226410017ID.CO.STELLAR.WWW011693600008123456785204581253033605405150005802ID" +
+	"5914STELLAR COFFEE6008MAKASSAR62120508INV-100163042453"
+
+func TestCRC16(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// standard check value of CRC-16/CCITT-FALSE
+		{name: "check value", input: "123456789", want: "29B1"},
+		{name: "empty", input: "", want: "FFFF"},
+		// sample payload of the EMVCo merchant-presented QR specification, up to and including the crc tag and length
+		{name: "emvco sample", input: "00020101021229300012D156000000000510A93FO3230Q31280012D15600000001030812345678520441115802CN" +
+			"5914BEST TRANSPORT6007BEIJING64200002ZH0104最佳运输0202北京540523.7253031565502016233030412340603***0708A6008667" +
+			"0902ME91320016A0112233449988770708123456786304", want: "A13A"},
+		{name: "qris payload", input: qrisPayload[:len(qrisPayload)-4], want: "2453"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CRC16(tt.input); got != tt.want {
+				t.Errorf("CRC16(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	got, err := Encode(
+		Field{Tag: TagPayloadFormat, Value: PayloadFormat},
+		Field{Tag: TagInitiationMethod, Value: InitiationDynamic},
+		Template(TagMerchantAccount,
+			Field{Tag: SubTagGUID, Value: "ID.CO.STELLAR.WWW"},
+			Field{Tag: SubTagAccountNo, Value: "9360000812345678"},
+		),
+		Field{Tag: TagMerchantCategory, Value: "5812"},
+		Field{Tag: TagCurrency, Value: "360"},
+		Field{Tag: TagAmount, Value: "15000"},
+		Field{Tag: TagCountry, Value: "ID"},
+		Field{Tag: TagMerchantName, Value: "STELLAR COFFEE"},
+		Field{Tag: TagMerchantCity, Value: "MAKASSAR"},
+		// empty fields are left out
+		Field{Tag: "55", Value: ""},
+		Template(TagAdditionalData, Field{Tag: SubTagReference, Value: "INV-1001"}),
+	)
+	if err != nil {
+		t.Fatalf("Encode() unexpected err: %v", err)
+	}
+
+	if got != qrisPayload {
+		t.Errorf("Encode() = %s, want %s", got, qrisPayload)
+	}
+}
+
+func TestEncodeFieldDoesNotFit(t *testing.T) {
+	tests := []struct {
+		name  string
+		field Field
+	}{
+		{name: "short tag", field: Field{Tag: "1", Value: "x"}},
+		{name: "long tag", field: Field{Tag: "100", Value: "x"}},
+		{name: "value over 99 characters", field: Field{Tag: TagMerchantName, Value: string(make([]byte, 100))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Encode(Field{Tag: TagPayloadFormat, Value: PayloadFormat}, tt.field); !errors.Is(err, ErrMalformed) {
+				t.Errorf("Encode() err = %v, want %v", err, ErrMalformed)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	got, err := Decode(qrisPayload)
+	if err != nil {
+		t.Fatalf("Decode() unexpected err: %v", err)
+	}
+
+	want := Fields{
+		{Tag: TagPayloadFormat, Value: PayloadFormat},
+		{Tag: TagInitiationMethod, Value: InitiationDynamic},
+		{Tag: TagMerchantAccount, Value: "0017ID.CO.STELLAR.WWW01169360000812345678"},
+		{Tag: TagMerchantCategory, Value: "5812"},
+		{Tag: TagCurrency, Value: "360"},
+		{Tag: TagAmount, Value: "15000"},
+		{Tag: TagCountry, Value: "ID"},
+		{Tag: TagMerchantName, Value: "STELLAR COFFEE"},
+		{Tag: TagMerchantCity, Value: "MAKASSAR"},
+		{Tag: TagAdditionalData, Value: "0508INV-1001"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Decode() = %+v, want %+v", got, want)
+	}
+
+	account, _ := got.Get(TagMerchantAccount)
+	fields, err := DecodeTemplate(account)
+	if err != nil {
+		t.Fatalf("DecodeTemplate(%s) unexpected err: %v", account, err)
+	}
+
+	if accountNo, ok := fields.Get(SubTagAccountNo); !ok || accountNo != "9360000812345678" {
+		t.Errorf("account no = %q, %v, want %q", accountNo, ok, "9360000812345678")
+	}
+
+	// the decoded fields encode back to the same payload
+	encoded, err := Encode(got...)
+	if err != nil {
+		t.Fatalf("Encode() unexpected err: %v", err)
+	}
+
+	if encoded != qrisPayload {
+		t.Errorf("Encode(Decode()) = %s, want %s", encoded, qrisPayload)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	body := qrisPayload[:len(qrisPayload)-4]
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "too short", input: "6304", wantErr: ErrMalformed},
+		{name: "missing crc field", input: body[:len(body)-4] + "2453", wantErr: ErrMalformed},
+		{name: "wrong checksum", input: body + "0000", wantErr: ErrChecksum},
+		{name: "lower case checksum", input: encodeRaw("000201")[:10] + strings.ToLower(CRC16("0002016304")), wantErr: nil},
+		{name: "tampered amount", input: "00020101021226410017ID.CO.STELLAR.WWW011693600008123456785204581253033605405990005802ID" +
+			"5914STELLAR COFFEE6008MAKASSAR62120508INV-100163042453", wantErr: ErrChecksum},
+		{name: "length beyond payload", input: encodeRaw("000201" + "5920STELLAR"), wantErr: ErrMalformed},
+		{name: "truncated field header", input: encodeRaw("000201" + "59"), wantErr: ErrMalformed},
+		{name: "payload format not first", input: encodeRaw("010212" + "000201"), wantErr: ErrMalformed},
+		{name: "unsupported payload format", input: encodeRaw("000202"), wantErr: ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.input)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Decode(%s) unexpected err: %v", tt.input, err)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decode(%s) err = %v, want %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// encodeRaw appends a valid checksum to body without validating its fields
+func encodeRaw(body string) string {
+	body += TagCRC + "04"
+	return body + CRC16(body)
+}
@@ -0,0 +1,45 @@
+package eventutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+)
+
+var (
+	ErrInvalidSignature = errors.New("event signature mismatch")
+	ErrStaleTimestamp   = errors.New("event timestamp outside tolerance")
+)
+
+// Sign signs payload as published by serviceID on topic at timestamp. Binding the topic keeps a request signed for
+// one topic from being replayed on another, binding the timestamp keeps it from being replayed later
+func Sign(key []byte, topic string, serviceID string, timestamp int64, payload []byte) string {
+	return hex.EncodeToString(cryptoutil.HMACSHA512(signedMessage(topic, serviceID, timestamp, payload), key))
+}
+
+// Verify authenticates an event signed with Sign, events signed more than tolerance away from now are rejected
+// even when the signature matches
+func Verify(key []byte, topic string, serviceID string, timestamp int64, payload []byte, signature string, tolerance time.Duration) (err error) {
+	if math.Abs(float64(time.Now().Unix()-timestamp)) > tolerance.Seconds() {
+		return ErrStaleTimestamp
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !cryptoutil.VerifyHMACSHA512(signedMessage(topic, serviceID, timestamp, payload), key, sig) {
+		return ErrInvalidSignature
+	}
+
+	return
+}
+
+func signedMessage(topic string, serviceID string, timestamp int64, payload []byte) []byte {
+	return append([]byte(fmt.Sprintf("%s.%s.%d.", topic, serviceID, timestamp)), payload...)
+}
@@ -0,0 +1,45 @@
+package eventutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	key := []byte("shared-key")
+	payload := []byte(`{"correlation_id":"c-1","user_id":"u-1"}`)
+	now := time.Now().Unix()
+
+	signature := Sign(key, "create-trx", "sp-worker", now, payload)
+
+	tests := []struct {
+		name      string
+		key       []byte
+		topic     string
+		serviceID string
+		timestamp int64
+		payload   []byte
+		signature string
+		wantErr   error
+	}{
+		{name: "valid", key: key, topic: "create-trx", serviceID: "sp-worker", timestamp: now, payload: payload, signature: signature},
+		{name: "other key", key: []byte("other-key"), topic: "create-trx", serviceID: "sp-worker", timestamp: now, payload: payload, signature: signature, wantErr: ErrInvalidSignature},
+		{name: "other topic", key: key, topic: "create-schedule-trx", serviceID: "sp-worker", timestamp: now, payload: payload, signature: signature, wantErr: ErrInvalidSignature},
+		{name: "other service", key: key, topic: "create-trx", serviceID: "sp-account", timestamp: now, payload: payload, signature: signature, wantErr: ErrInvalidSignature},
+		{name: "tampered payload", key: key, topic: "create-trx", serviceID: "sp-worker", timestamp: now, payload: []byte(`{"correlation_id":"c-1","user_id":"u-2"}`), signature: signature, wantErr: ErrInvalidSignature},
+		{name: "other timestamp", key: key, topic: "create-trx", serviceID: "sp-worker", timestamp: now - 1, payload: payload, signature: signature, wantErr: ErrInvalidSignature},
+		{name: "not hex", key: key, topic: "create-trx", serviceID: "sp-worker", timestamp: now, payload: payload, signature: "zz", wantErr: ErrInvalidSignature},
+		{name: "stale", key: key, topic: "create-trx", serviceID: "sp-worker", timestamp: now - 600, payload: payload, signature: Sign(key, "create-trx", "sp-worker", now-600, payload), wantErr: ErrStaleTimestamp},
+		{name: "future", key: key, topic: "create-trx", serviceID: "sp-worker", timestamp: now + 600, payload: payload, signature: Sign(key, "create-trx", "sp-worker", now+600, payload), wantErr: ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.key, tt.topic, tt.serviceID, tt.timestamp, tt.payload, tt.signature, 5*time.Minute)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Verify() err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package exportutil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// plainNumber matches amounts the way the ledger renders them, those are safe to open even with a leading minus
+var plainNumber = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// SanitizeCell quotes a value a spreadsheet would otherwise evaluate as a formula, cells are user controlled
+// through descriptions and names so anything starting with a formula trigger gets a leading apostrophe
+func SanitizeCell(v string) string {
+	if v == "" || plainNumber.MatchString(v) || !strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return v
+	}
+
+	return "'" + v
+}
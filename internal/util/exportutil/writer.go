@@ -0,0 +1,170 @@
+package exportutil
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// RowWriter writes tabular rows one at a time so exports never hold the whole result in memory
+type RowWriter interface {
+	WriteRow(row []string) error
+	Close() error
+}
+
+func IsValidFormat(format string) bool {
+	return format == FormatCSV || format == FormatXLSX
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "application/octet-stream"
+}
+
+func NewRowWriter(format string, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+
+	return nil, fmt.Errorf("unsupported export format %s", format)
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(row []string) error {
+	cells := make([]string, len(row))
+	for i, v := range row {
+		cells[i] = SanitizeCell(v)
+	}
+
+	return c.w.Write(cells)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// xlsxWriter streams a single sheet workbook, every cell is written as an inline string and never as a formula.
+// Cells are still sanitized so the sheet stays safe once it is saved back as csv
+type xlsxWriter struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+}
+
+func newXLSXWriter(w io.Writer) (res *xlsxWriter, err error) {
+	zw := zip.NewWriter(w)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+
+	for _, v := range parts {
+		f, err := zw.Create(v.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = io.WriteString(f, v.content); err != nil {
+			return nil, err
+		}
+	}
+
+	// sheet has to be the last entry, it stays open until Close
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return
+	}
+
+	res = &xlsxWriter{zw: zw, sheet: bufio.NewWriter(f)}
+	if _, err = res.sheet.WriteString(xlsxSheetHeader); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (x *xlsxWriter) WriteRow(row []string) (err error) {
+	b := &strings.Builder{}
+
+	b.WriteString("<row>")
+	for _, v := range row {
+		b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err = xml.EscapeText(b, []byte(SanitizeCell(v))); err != nil {
+			return
+		}
+		b.WriteString("</t></is></c>")
+	}
+	b.WriteString("</row>")
+
+	_, err = x.sheet.WriteString(b.String())
+	return
+}
+
+func (x *xlsxWriter) Close() (err error) {
+	if _, err = x.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return
+	}
+
+	if err = x.sheet.Flush(); err != nil {
+		return
+	}
+
+	return x.zw.Close()
+}
@@ -0,0 +1,89 @@
+package exportutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+)
+
+var formulaRow = []string{"=1+1", "+62 811", "-cmd", "@SUM(A1)", "\tpad", "\rpad", "-5.00", "plain"}
+
+func TestCSVWriterSanitizesCells(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	rw, err := NewRowWriter(FormatCSV, buf)
+	if err != nil {
+		t.Fatalf("NewRowWriter() unexpected err: %v", err)
+	}
+
+	if err = rw.WriteRow(formulaRow); err != nil {
+		t.Fatalf("WriteRow() unexpected err: %v", err)
+	}
+
+	if err = rw.Close(); err != nil {
+		t.Fatalf("Close() unexpected err: %v", err)
+	}
+
+	got, err := csv.NewReader(buf).Read()
+	if err != nil {
+		t.Fatalf("failed to read csv: %v", err)
+	}
+
+	want := []string{"'=1+1", "'+62 811", "'-cmd", "'@SUM(A1)", "'\tpad", "'\rpad", "-5.00", "plain"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("cell %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if formulaRow[0] != "=1+1" {
+		t.Errorf("WriteRow() modified caller's row: %q", formulaRow[0])
+	}
+}
+
+func TestXLSXWriterSanitizesCells(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	rw, err := NewRowWriter(FormatXLSX, buf)
+	if err != nil {
+		t.Fatalf("NewRowWriter() unexpected err: %v", err)
+	}
+
+	if err = rw.WriteRow(formulaRow); err != nil {
+		t.Fatalf("WriteRow() unexpected err: %v", err)
+	}
+
+	if err = rw.Close(); err != nil {
+		t.Fatalf("Close() unexpected err: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open xlsx: %v", err)
+	}
+
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("failed to open sheet: %v", err)
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("failed to read sheet: %v", err)
+	}
+	sheet := string(content)
+
+	if strings.Contains(sheet, "<f>") {
+		t.Errorf("sheet has formula cells: %s", sheet)
+	}
+
+	for _, v := range []string{"<t xml:space=\"preserve\">&#39;=1+1</t>", "<t xml:space=\"preserve\">&#39;@SUM(A1)</t>", "<t xml:space=\"preserve\">-5.00</t>"} {
+		if !strings.Contains(sheet, v) {
+			t.Errorf("sheet is missing %s", v)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package payoututil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/stellar-payment/sp-payment/internal/config"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+const (
+	ConnectorFile = "file"
+)
+
+var ErrTransferNotFound = errors.New("payout transfer not found")
+
+// Connector moves withdrawals out to a bank. Transfers are identified by the reference chosen by the caller,
+// so initiating the same reference again must not send the money twice
+type Connector interface {
+	Name() string
+
+	// Initiate submits req to the bank, the returned status is usually not final yet.
+	// An error means the bank could not be reached and the transfer may be retried
+	Initiate(ctx context.Context, req *Transfer) (res *TransferStatus, err error)
+
+	// Status queries the bank for the current state of reference. ErrTransferNotFound means the bank has no transfer
+	// under reference and will not act on it anymore, any other error leaves the state unknown
+	Status(ctx context.Context, reference string) (res *TransferStatus, err error)
+
+	// HandleCallback authenticates and parses a status notification pushed by the bank
+	HandleCallback(ctx context.Context, header http.Header, body []byte) (res *TransferStatus, err error)
+}
+
+type Transfer struct {
+	Reference   string      `json:"reference"`
+	BankCode    string      `json:"bank_code"`
+	AccountNo   string      `json:"account_no"`
+	AccountName string      `json:"account_name"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+}
+
+// TransferStatus carries one of the PAYOUT_STATUS_* of a transfer
+type TransferStatus struct {
+	Reference     string
+	ProviderRef   string
+	Status        int64
+	FailureReason string
+}
+
+var (
+	connector     Connector
+	connectorErr  error
+	connectorOnce sync.Once
+)
+
+// GetConnector returns the connector configured by PAYOUT_CONNECTOR
+func GetConnector() (Connector, error) {
+	connectorOnce.Do(func() {
+		conf := config.Get()
+
+		switch conf.PayoutConnector {
+		case ConnectorFile:
+			connector, connectorErr = newFileConnector(filepath.Join(conf.FilePath, "payouts"), conf.PayoutCallbackKey)
+		default:
+			connectorErr = fmt.Errorf("unsupported payout connector %s", conf.PayoutConnector)
+		}
+	})
+
+	return connector, connectorErr
+}
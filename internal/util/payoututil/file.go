@@ -0,0 +1,197 @@
+package payoututil
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+)
+
+const (
+	fileStatusSent    = "sent"
+	fileStatusSettled = "settled"
+	fileStatusFailed  = "failed"
+
+	// hex encoded HMAC-SHA512 of the callback body
+	CallbackSignatureHeader = "X-Payout-Signature"
+)
+
+var ErrInvalidSignature = errors.New("payout callback signature mismatch")
+
+// fileConnector stands in for a bank on local and dev environments. Each transfer is written to <dir>/<reference>.json
+// with status "sent", editing it to "settled" or "failed" completes the transfer on the next poll. While <dir>/offline
+// exists the bank is unreachable, which lets the retry path be exercised
+type fileConnector struct {
+	dir         string
+	callbackKey []byte
+	mu          sync.Mutex
+}
+
+type fileTransfer struct {
+	Transfer
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type fileCallback struct {
+	Reference     string `json:"reference"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason"`
+}
+
+func newFileConnector(dir string, callbackKey []byte) (*fileConnector, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &fileConnector{dir: dir, callbackKey: callbackKey}, nil
+}
+
+func (f *fileConnector) Name() string {
+	return ConnectorFile
+}
+
+func (f *fileConnector) Initiate(ctx context.Context, req *Transfer) (res *TransferStatus, err error) {
+	if err = f.checkOnline(); err != nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// a retried reference keeps the transfer already on file
+	if data, err := f.read(req.Reference); err == nil {
+		return data.status(), nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	data := &fileTransfer{Transfer: *req, Status: fileStatusSent, CreatedAt: time.Now()}
+
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return
+	}
+
+	if err = os.WriteFile(f.path(req.Reference), raw, 0o644); err != nil {
+		return
+	}
+
+	return data.status(), nil
+}
+
+func (f *fileConnector) Status(ctx context.Context, reference string) (res *TransferStatus, err error) {
+	if err = f.checkOnline(); err != nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := f.read(reference)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrTransferNotFound
+	} else if err != nil {
+		return
+	}
+
+	return data.status(), nil
+}
+
+func (f *fileConnector) HandleCallback(ctx context.Context, header http.Header, body []byte) (res *TransferStatus, err error) {
+	if len(f.callbackKey) == 0 {
+		return nil, fmt.Errorf("payout callback key is not configured")
+	}
+
+	signature, err := hex.DecodeString(header.Get(CallbackSignatureHeader))
+	if err != nil || !cryptoutil.VerifyHMACSHA512(body, f.callbackKey, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	data := &fileCallback{}
+	if err = json.Unmarshal(body, data); err != nil {
+		return
+	}
+
+	res = &TransferStatus{
+		Reference:     data.Reference,
+		ProviderRef:   fileProviderRef(data.Reference),
+		Status:        fileStatus(data.Status),
+		FailureReason: data.FailureReason,
+	}
+
+	if res.Status == 0 {
+		return nil, fmt.Errorf("unknown payout status %s", data.Status)
+	}
+
+	return
+}
+
+func (f *fileConnector) checkOnline() error {
+	if _, err := os.Stat(filepath.Join(f.dir, "offline")); err == nil {
+		return fmt.Errorf("file payout connector is offline")
+	}
+
+	return nil
+}
+
+func (f *fileConnector) read(reference string) (res *fileTransfer, err error) {
+	raw, err := os.ReadFile(f.path(reference))
+	if err != nil {
+		return
+	}
+
+	res = &fileTransfer{}
+	if err = json.Unmarshal(raw, res); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (f *fileConnector) path(reference string) string {
+	return filepath.Join(f.dir, filepath.Base(reference)+".json")
+}
+
+func (t *fileTransfer) status() *TransferStatus {
+	res := &TransferStatus{
+		Reference:     t.Reference,
+		ProviderRef:   fileProviderRef(t.Reference),
+		Status:        fileStatus(t.Status),
+		FailureReason: t.FailureReason,
+	}
+
+	// anything the tester wrote that is not a known status leaves the transfer in flight
+	if res.Status == 0 {
+		res.Status = inconst.PAYOUT_STATUS_SENT
+	}
+
+	return res
+}
+
+func fileProviderRef(reference string) string {
+	return "file-" + reference
+}
+
+func fileStatus(status string) int64 {
+	switch status {
+	case fileStatusSent:
+		return inconst.PAYOUT_STATUS_SENT
+	case fileStatusSettled:
+		return inconst.PAYOUT_STATUS_SETTLED
+	case fileStatusFailed:
+		return inconst.PAYOUT_STATUS_FAILED
+	}
+
+	return 0
+}
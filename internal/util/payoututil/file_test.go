@@ -0,0 +1,46 @@
+package payoututil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar-payment/sp-payment/internal/inconst"
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+func TestFileConnectorStatus(t *testing.T) {
+	dir := t.TempDir()
+
+	connector, err := newFileConnector(dir, []byte("callback-key"))
+	if err != nil {
+		t.Fatalf("newFileConnector() unexpected err: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err = connector.Status(ctx, "1001"); !errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("Status() of an unknown reference err = %v, want %v", err, ErrTransferNotFound)
+	}
+
+	transfer := &Transfer{Reference: "1001", BankCode: "014", AccountNo: "123", AccountName: "merchant", Amount: money.MustParse("10")}
+	if _, err = connector.Initiate(ctx, transfer); err != nil {
+		t.Fatalf("Initiate() unexpected err: %v", err)
+	}
+
+	res, err := connector.Status(ctx, "1001")
+	if err != nil || res.Status != inconst.PAYOUT_STATUS_SENT {
+		t.Fatalf("Status() = %+v, %v, want status %d", res, err, inconst.PAYOUT_STATUS_SENT)
+	}
+
+	// an unreachable bank leaves the state unknown, which must not read as a missing transfer
+	if err = os.WriteFile(filepath.Join(dir, "offline"), nil, 0o644); err != nil {
+		t.Fatalf("failed to take connector offline: %v", err)
+	}
+
+	if _, err = connector.Status(ctx, "1002"); err == nil || errors.Is(err, ErrTransferNotFound) {
+		t.Fatalf("Status() while offline err = %v, want an unknown state", err)
+	}
+}
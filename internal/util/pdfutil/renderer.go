@@ -0,0 +1,71 @@
+package pdfutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/internal/config"
+)
+
+var client = http.Client{Timeout: 30 * time.Second}
+
+var ErrNotConfigured = errors.New("pdf renderer address is not configured")
+
+// RenderHTML converts an html document to pdf through the gotenberg instance at PDF_RENDERER_ADDR
+func RenderHTML(ctx context.Context, html string) (res *bytes.Buffer, err error) {
+	conf := config.Get()
+
+	if conf.PDFRendererAddr == "" {
+		return nil, ErrNotConfigured
+	}
+
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+
+	// chromium route requires the document to be named index.html
+	part, err := form.CreateFormFile("files", "index.html")
+	if err != nil {
+		return
+	}
+
+	if _, err = io.WriteString(part, html); err != nil {
+		return
+	}
+
+	if err = form.Close(); err != nil {
+		return
+	}
+
+	endpoint := strings.TrimSuffix(conf.PDFRendererAddr, "/") + "/forms/chromium/convert/html"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return
+	}
+
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	data, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer data.Body.Close()
+
+	if data.StatusCode < 200 || data.StatusCode > 299 {
+		msg, _ := io.ReadAll(data.Body)
+		return nil, fmt.Errorf("pdf renderer returned %s: %s", data.Status, msg)
+	}
+
+	res = &bytes.Buffer{}
+	if _, err = io.Copy(res, data.Body); err != nil {
+		return nil, err
+	}
+
+	return
+}
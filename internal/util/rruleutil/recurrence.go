@@ -0,0 +1,224 @@
+package rruleutil
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+
+	// bounds the monthly periods searched for the next occurrence, a rule without match inside them is treated
+	// as exhausted. Month days repeat every 12 periods except february 29, 100 periods cover its leap cycle
+	maxMonthlyPeriods = 100
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Rule is the supported subset of RFC 5545 RRULE: FREQ, INTERVAL, BYDAY (weekly) and BYMONTHDAY (monthly).
+// Occurrences keep the time of day of the series start, end date and count are tracked by the caller
+type Rule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+// Parse reads rules such as "FREQ=MONTHLY;BYMONTHDAY=1" or "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR"
+func Parse(rule string) (res *Rule, err error) {
+	res = &Rule{Interval: 1}
+
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+
+		key, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		switch key {
+		case "FREQ":
+			if val != FreqDaily && val != FreqWeekly && val != FreqMonthly {
+				return nil, fmt.Errorf("%w: unsupported frequency %s", ErrInvalidRule, val)
+			}
+
+			res.Freq = val
+		case "INTERVAL":
+			if res.Interval, err = strconv.Atoi(val); err != nil || res.Interval < 1 || res.Interval > 366 {
+				return nil, fmt.Errorf("%w: invalid interval %s", ErrInvalidRule, val)
+			}
+		case "BYDAY":
+			for _, v := range strings.Split(val, ",") {
+				day, ok := weekdays[v]
+				if !ok {
+					return nil, fmt.Errorf("%w: invalid weekday %s", ErrInvalidRule, v)
+				}
+
+				res.ByDay = append(res.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, v := range strings.Split(val, ",") {
+				day, err := strconv.Atoi(v)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("%w: invalid month day %s", ErrInvalidRule, v)
+				}
+
+				res.ByMonthDay = append(res.ByMonthDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, key)
+		}
+	}
+
+	if res.Freq == "" {
+		return nil, fmt.Errorf("%w: missing frequency", ErrInvalidRule)
+	}
+
+	if len(res.ByDay) != 0 && res.Freq != FreqWeekly {
+		return nil, fmt.Errorf("%w: BYDAY is only supported on weekly rules", ErrInvalidRule)
+	}
+
+	if len(res.ByMonthDay) != 0 && res.Freq != FreqMonthly {
+		return nil, fmt.Errorf("%w: BYMONTHDAY is only supported on monthly rules", ErrInvalidRule)
+	}
+
+	return
+}
+
+// Next returns the first occurrence of a series starting at start that is strictly after after,
+// zero time is returned when no occurrence is found.
+// The search jumps straight to the first period of the series not before after, so it costs the same
+// whatever the interval or the distance between start and after
+func (r *Rule) Next(start, after time.Time) time.Time {
+	after = after.In(start.Location())
+
+	from := after
+	if start.After(from) {
+		from = start
+	}
+
+	valid := func(cand time.Time) bool {
+		return cand.After(after) && !cand.Before(start)
+	}
+
+	switch r.Freq {
+	case FreqDaily:
+		n := alignUp(daysBetween(start, from), r.Interval)
+
+		// the aligned day may be earlier in the day than after, the next one is not
+		for i := 0; i < 2; i++ {
+			if cand := dayAt(start, start, n); valid(cand) {
+				return cand
+			}
+
+			n += r.Interval
+		}
+	case FreqWeekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{start.Weekday()}
+		}
+
+		// weeks start on monday, same as RRULE default WKST
+		week := weekStart(start)
+		n := alignUp(daysBetween(week, weekStart(from))/7, r.Interval)
+
+		for i := 0; i < 2; i++ {
+			for d := 0; d < 7; d++ {
+				if cand := dayAt(start, week, n*7+d); containsWeekday(days, cand.Weekday()) && valid(cand) {
+					return cand
+				}
+			}
+
+			n += r.Interval
+		}
+	case FreqMonthly:
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{start.Day()}
+		}
+
+		n := alignUp((from.Year()-start.Year())*12+int(from.Month())-int(start.Month()), r.Interval)
+
+		for i := 0; i < maxMonthlyPeriods; i++ {
+			month := time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, start.Location())
+			last := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+			for d := 0; d < last; d++ {
+				if cand := dayAt(start, month, d); containsMonthDay(days, cand) && valid(cand) {
+					return cand
+				}
+			}
+
+			n += r.Interval
+		}
+	}
+
+	return time.Time{}
+}
+
+// dayAt is the date days after the date of day, at the time of day of start
+func dayAt(start, day time.Time, days int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+days, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+}
+
+// alignUp rounds n up to a multiple of interval
+func alignUp(n, interval int) int {
+	return (n + interval - 1) / interval * interval
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func weekStart(t time.Time) time.Time {
+	return dateOf(t).AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+}
+
+// daysBetween counts calendar days, computed on UTC dates so DST shifts do not skew it
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, v := range days {
+		if v == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsMonthDay matches positive days as-is and negative days from the end of month, -1 being the last day.
+// Days that do not exist in a month are skipped, e.g. 31 never matches april
+func containsMonthDay(days []int, t time.Time) bool {
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	for _, v := range days {
+		if v == t.Day() || (v < 0 && last+v+1 == t.Day()) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package rruleutil
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Rule
+		wantErr bool
+	}{
+		{name: "daily", input: "FREQ=DAILY", want: &Rule{Freq: FreqDaily, Interval: 1}},
+		{name: "prefix and lower case", input: " rrule:freq=daily;interval=3 ", want: &Rule{Freq: FreqDaily, Interval: 3}},
+		{name: "weekly by day", input: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", want: &Rule{Freq: FreqWeekly, Interval: 2, ByDay: []time.Weekday{time.Monday, time.Friday}}},
+		{name: "monthly by month day", input: "FREQ=MONTHLY;BYMONTHDAY=1,-1", want: &Rule{Freq: FreqMonthly, Interval: 1, ByMonthDay: []int{1, -1}}},
+		{name: "trailing separator", input: "FREQ=MONTHLY;", want: &Rule{Freq: FreqMonthly, Interval: 1}},
+		{name: "missing frequency", input: "INTERVAL=2", wantErr: true},
+		{name: "unsupported frequency", input: "FREQ=YEARLY", wantErr: true},
+		{name: "zero interval", input: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "interval too large", input: "FREQ=DAILY;INTERVAL=367", wantErr: true},
+		{name: "interval not a number", input: "FREQ=DAILY;INTERVAL=two", wantErr: true},
+		{name: "invalid weekday", input: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "zero month day", input: "FREQ=MONTHLY;BYMONTHDAY=0", wantErr: true},
+		{name: "month day out of range", input: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{name: "by day on monthly", input: "FREQ=MONTHLY;BYDAY=MO", wantErr: true},
+		{name: "by month day on weekly", input: "FREQ=WEEKLY;BYMONTHDAY=1", wantErr: true},
+		{name: "malformed part", input: "FREQ=DAILY;COUNT", wantErr: true},
+		{name: "unsupported part", input: "FREQ=DAILY;COUNT=3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Fatalf("Parse(%q) err = %v, want %v", tt.input, err, ErrInvalidRule)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected err: %v", tt.input, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	loc := time.FixedZone("WITA", 8*60*60)
+	at := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		start time.Time
+		after time.Time
+		want  time.Time
+	}{
+		{name: "daily first occurrence is start", rule: "FREQ=DAILY", start: at(2024, 1, 10, 9), after: at(2024, 1, 10, 8), want: at(2024, 1, 10, 9)},
+		{name: "daily after start", rule: "FREQ=DAILY", start: at(2024, 1, 10, 9), after: at(2024, 1, 10, 9), want: at(2024, 1, 11, 9)},
+		{name: "daily later in the day", rule: "FREQ=DAILY;INTERVAL=3", start: at(2024, 1, 10, 9), after: at(2024, 1, 13, 10), want: at(2024, 1, 16, 9)},
+		{name: "daily between intervals", rule: "FREQ=DAILY;INTERVAL=3", start: at(2024, 1, 10, 9), after: at(2024, 1, 11, 0), want: at(2024, 1, 13, 9)},
+		{name: "weekly on start weekday", rule: "FREQ=WEEKLY", start: at(2024, 1, 10, 9), after: at(2024, 1, 10, 9), want: at(2024, 1, 17, 9)},
+		{name: "weekly by day within week", rule: "FREQ=WEEKLY;BYDAY=MO,FR", start: at(2024, 1, 10, 9), after: at(2024, 1, 10, 9), want: at(2024, 1, 12, 9)},
+		{name: "weekly by day skips days before start", rule: "FREQ=WEEKLY;BYDAY=MO", start: at(2024, 1, 10, 9), after: at(2024, 1, 1, 0), want: at(2024, 1, 15, 9)},
+		{name: "weekly interval skips off weeks", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", start: at(2024, 1, 8, 9), after: at(2024, 1, 12, 9), want: at(2024, 1, 22, 9)},
+		{name: "weekly large interval", rule: "FREQ=WEEKLY;INTERVAL=300", start: at(2024, 1, 10, 9), after: at(2024, 1, 10, 9), want: at(2024, 1, 10, 9).AddDate(0, 0, 300*7)},
+		{name: "monthly on start day", rule: "FREQ=MONTHLY", start: at(2024, 1, 15, 9), after: at(2024, 1, 15, 9), want: at(2024, 2, 15, 9)},
+		{name: "monthly last day", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", start: at(2024, 1, 31, 9), after: at(2024, 1, 31, 9), want: at(2024, 2, 29, 9)},
+		{name: "monthly day missing in month is skipped", rule: "FREQ=MONTHLY;BYMONTHDAY=31", start: at(2024, 3, 31, 9), after: at(2024, 3, 31, 9), want: at(2024, 5, 31, 9)},
+		{name: "monthly interval", rule: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", start: at(2024, 1, 1, 9), after: at(2024, 2, 1, 9), want: at(2024, 4, 1, 9)},
+		{name: "monthly interval beyond four years", rule: "FREQ=MONTHLY;INTERVAL=49", start: at(2024, 1, 15, 9), after: at(2024, 1, 15, 9), want: at(2028, 2, 15, 9)},
+		{name: "monthly far past start", rule: "FREQ=MONTHLY;INTERVAL=2", start: at(2024, 1, 15, 9), after: at(2030, 6, 20, 9), want: at(2030, 7, 15, 9)},
+		{name: "yearly february 29", rule: "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=29", start: at(2024, 2, 29, 9), after: at(2024, 2, 29, 9), want: at(2028, 2, 29, 9)},
+		{name: "never matching month day", rule: "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=31", start: at(2024, 4, 1, 9), after: at(2024, 4, 1, 9), want: time.Time{}},
+		{name: "after in another zone", rule: "FREQ=DAILY", start: at(2024, 1, 10, 9), after: time.Date(2024, 1, 10, 2, 0, 0, 0, time.UTC), want: at(2024, 1, 11, 9)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected err: %v", tt.rule, err)
+			}
+
+			if got := rule.Next(tt.start, tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s, %s) of %q = %s, want %s", tt.start, tt.after, tt.rule, got, tt.want)
+			}
+		})
+	}
+}
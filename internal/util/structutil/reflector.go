@@ -75,6 +75,15 @@ func CheckMandatoryField(val any) (field string) {
 			return fieldName
 		}
 
+		// value types with own notion of emptiness, e.g. money.Money
+		if z, ok := f.Interface().(interface{ IsZero() bool }); ok {
+			if z.IsZero() {
+				return fieldName
+			}
+
+			continue
+		}
+
 		switch f.Type().Kind() {
 		case reflect.String:
 			val := f.Interface().(string)
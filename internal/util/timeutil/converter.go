@@ -16,6 +16,12 @@ func ParseLocaltimeOnly(t string) (local time.Time) {
 	return
 }
 
+func ParseLocalDate(t string) (local time.Time) {
+	loc, _ := time.LoadLocation("Asia/Makassar")
+	local, _ = time.ParseInLocation("2006-01-02", t, loc)
+	return
+}
+
 func ParseDate(t string) (local time.Time) {
 	local, _ = time.Parse("2006-01-02", t)
 	return
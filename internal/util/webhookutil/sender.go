@@ -0,0 +1,182 @@
+package webhookutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/internal/config"
+)
+
+// only the start of a receiver response is kept for the delivery log
+const responseBodyLimit = 1024
+
+var ErrInvalidURL = errors.New("invalid webhook url")
+
+// reserved ranges net.IP has no predicate for, a receiver may not resolve into them either
+var reservedNetworks = mustParseCIDRs("0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "64:ff9b::/96")
+
+// redirects are not followed, a receiver must answer on the subscribed url itself. Connections go straight to the
+// receiver, never through a proxy, so the address checked by controlDial is the one actually reached
+var client = http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   controlDial,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+type Delivery struct {
+	ID     uint64
+	Event  string
+	URL    string
+	Secret []byte
+	Body   []byte
+}
+
+type Result struct {
+	StatusCode int
+	Body       string
+}
+
+// Send posts a signed delivery, any response outside 2xx is returned along with an error
+func Send(ctx context.Context, req *Delivery) (res *Result, err error) {
+	conf := config.Get()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return
+	}
+
+	timestamp := time.Now().Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", fmt.Sprintf("%s-%s %s", conf.ServiceID, conf.Environment, conf.BuildVer))
+	httpReq.Header.Set(DeliveryHeader, strconv.FormatUint(req.ID, 10))
+	httpReq.Header.Set(EventHeader, req.Event)
+	httpReq.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(SignatureHeader, Sign(req.Secret, timestamp, req.Body))
+
+	httpRes, err := client.Do(httpReq)
+	if err != nil {
+		return
+	}
+	defer httpRes.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(httpRes.Body, responseBodyLimit))
+	res = &Result{
+		StatusCode: httpRes.StatusCode,
+		Body:       string(body),
+	}
+
+	if httpRes.StatusCode < 200 || httpRes.StatusCode > 299 {
+		return res, fmt.Errorf("receiver responded with status %d", httpRes.StatusCode)
+	}
+
+	return
+}
+
+// ValidateURL accepts absolute https urls, plain http and local receivers are only allowed outside production
+// so integrations can be tested against a receiver on the developer machine
+func ValidateURL(raw string) (err error) {
+	conf := config.Get()
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	if conf.Environment != config.EnvironmentProd {
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return ErrInvalidURL
+		}
+
+		return nil
+	}
+
+	if u.Scheme != "https" {
+		return ErrInvalidURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrInvalidURL
+	}
+
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return ErrInvalidURL
+	}
+
+	return
+}
+
+// controlDial refuses connections to internal addresses on production. It runs on the resolved address right
+// before connecting, so a public host name that resolves or is rebound to an internal address is caught as well
+func controlDial(network string, address string, c syscall.RawConn) error {
+	conf := config.Get()
+
+	if conf.Environment != config.EnvironmentProd {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return fmt.Errorf("%w: %s resolves to an internal address", ErrInvalidURL, host)
+	}
+
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, v := range reservedNetworks {
+		if v.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	res := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, v := range cidrs {
+		_, network, err := net.ParseCIDR(v)
+		if err != nil {
+			panic(err)
+		}
+
+		res = append(res, network)
+	}
+
+	return res
+}
@@ -0,0 +1,47 @@
+package webhookutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar-payment/sp-payment/internal/config"
+)
+
+func TestSendInternalReceiver(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	tests := []struct {
+		name    string
+		env     config.Environment
+		wantErr error
+	}{
+		{name: "allowed outside production", env: config.EnvironmentDev},
+		{name: "refused on production", env: config.EnvironmentProd, wantErr: ErrInvalidURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Set(&config.Config{Environment: tt.env})
+			// a kept alive connection would skip the dial check
+			client.CloseIdleConnections()
+
+			_, err := Send(context.Background(), &Delivery{ID: 1, Event: "test", URL: receiver.URL, Secret: []byte("secret"), Body: []byte("{}")})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Send(%s) err = %v, want %v", receiver.URL, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Send(%s) unexpected err: %v", receiver.URL, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package webhookutil
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stellar-payment/sp-payment/internal/util/cryptoutil"
+)
+
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+
+	// hex encoded HMAC-SHA512 of "<timestamp>.<body>" keyed by the subscription secret
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature mismatch")
+	ErrStaleTimestamp   = errors.New("webhook timestamp outside tolerance")
+)
+
+// Sign signs body as sent at timestamp, binding the timestamp keeps a captured delivery from being replayed later
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	msg := append([]byte(fmt.Sprintf("%d.", timestamp)), body...)
+	return hex.EncodeToString(cryptoutil.HMACSHA512(msg, secret))
+}
+
+// Verify authenticates a delivery the way a receiver should, deliveries signed more than tolerance away from now
+// are rejected even when the signature matches
+func Verify(secret []byte, header http.Header, body []byte, tolerance time.Duration) (err error) {
+	timestamp, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if math.Abs(float64(time.Now().Unix()-timestamp)) > tolerance.Seconds() {
+		return ErrStaleTimestamp
+	}
+
+	signature, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	msg := append([]byte(fmt.Sprintf("%d.", timestamp)), body...)
+	if !cryptoutil.VerifyHMACSHA512(msg, secret, signature) {
+		return ErrInvalidSignature
+	}
+
+	return
+}
@@ -1,6 +1,11 @@
 package main
 
 import (
+	"os"
+	"strconv"
+
+	"github.com/stellar-payment/sp-payment/cmd/reconciler"
+	"github.com/stellar-payment/sp-payment/cmd/webhookreceiver"
 	"github.com/stellar-payment/sp-payment/cmd/webservice"
 	"github.com/stellar-payment/sp-payment/internal/component"
 	"github.com/stellar-payment/sp-payment/internal/config"
@@ -23,5 +28,21 @@ func main() {
 		PrettyPrint: true,
 	})
 
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		reconciler.Start(conf, logger)
+		return
+	}
+
+	// webhook-receiver <addr> <secret> [fail-status]
+	if len(os.Args) > 3 && os.Args[1] == "webhook-receiver" {
+		params := &webhookreceiver.StartParams{Addr: os.Args[2], Secret: os.Args[3]}
+		if len(os.Args) > 4 {
+			params.FailStatus, _ = strconv.Atoi(os.Args[4])
+		}
+
+		webhookreceiver.Start(params, logger)
+		return
+	}
+
 	webservice.Start(conf, logger)
 }
@@ -0,0 +1,4 @@
+drop trigger journal_postings_balanced on journal_postings;
+drop function check_journal_balanced;
+drop table journal_postings;
+drop table journal_entries;
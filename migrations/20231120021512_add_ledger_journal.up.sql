@@ -0,0 +1,41 @@
+create table journal_entries (
+    id bigint primary key,
+    transaction_id bigint not null default 0,
+    entry_type int not null,
+    description text not null,
+    entry_datetime timestamp with time zone not null,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create index journal_entries_transaction_id_idx on journal_entries (transaction_id);
+
+create table journal_postings (
+    id bigserial primary key,
+    journal_id bigint not null references journal_entries (id),
+    account_id uuid not null,
+    amount decimal(18, 2) not null,
+    created_at timestamp with time zone not null default now()
+);
+
+create index journal_postings_account_id_idx on journal_postings (account_id);
+
+-- postings of a single journal must always net to zero, checked on commit
+create function check_journal_balanced() returns trigger as $$
+begin
+    if (select coalesce(sum(amount), 0) from journal_postings where journal_id = new.journal_id) <> 0 then
+        raise exception 'journal % is not balanced', new.journal_id;
+    end if;
+
+    return null;
+end;
+$$ language plpgsql;
+
+create constraint trigger journal_postings_balanced
+    after insert or update on journal_postings
+    deferrable initially deferred
+    for each row execute procedure check_journal_balanced();
+
+-- opening balances of accounts existing before the journal are posted by the service on start,
+-- against the equity account it is configured with
@@ -0,0 +1,5 @@
+alter table transactions drop column fee_rule_id;
+alter table accounts drop column account_tier;
+
+drop table fee_rules;
+drop table fee_schedules;
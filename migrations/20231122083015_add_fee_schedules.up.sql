@@ -0,0 +1,43 @@
+create table fee_schedules (
+    id bigint primary key,
+    code varchar(50) not null,
+    name varchar(255) not null,
+    trx_type int not null,
+    merchant_id uuid,
+    account_tier smallint not null default 0,
+    version int not null default 1,
+    effective_from timestamp with time zone not null,
+    effective_until timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create unique index fee_schedules_code_version_idx on fee_schedules (code, version);
+
+create table fee_rules (
+    id bigint primary key,
+    schedule_id bigint not null references fee_schedules (id),
+    min_nominal decimal(18, 2) not null default 0,
+    fee_type smallint not null,
+    flat_fee decimal(18, 2) not null default 0,
+    rate_bps int not null default 0,
+    min_fee decimal(18, 2) not null default 0,
+    max_fee decimal(18, 2) not null default 0,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index fee_rules_schedule_id_idx on fee_rules (schedule_id);
+
+alter table accounts add column account_tier smallint not null default 1;
+alter table transactions add column fee_rule_id bigint not null default 0;
+
+-- keep previous hard-coded 10% fee for p2p and p2b as default schedules
+insert into fee_schedules (id, code, name, trx_type, effective_from) values
+    (1, 'default-p2p', 'Default P2P Fee', 1, '2020-08-01'),
+    (2, 'default-p2b', 'Default P2B Fee', 2, '2020-08-01');
+
+insert into fee_rules (id, schedule_id, fee_type, rate_bps) values
+    (1, 1, 2, 1000),
+    (2, 2, 2, 1000);
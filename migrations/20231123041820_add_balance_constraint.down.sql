@@ -0,0 +1,7 @@
+alter table accounts drop constraint accounts_balance_non_negative;
+
+update accounts a set balance = w.prev_balance
+from account_balance_writeoffs w
+where w.account_id = a.id;
+
+drop table account_balance_writeoffs;
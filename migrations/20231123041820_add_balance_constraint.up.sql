@@ -0,0 +1,19 @@
+-- accounts already overdrawn are flagged for review before the constraint is validated,
+-- prev_balance keeps what was written off so it can be settled with the owner.
+-- The write-off is journaled by the service on start against the equity account it is configured with,
+-- journal_id is set once it is
+create table account_balance_writeoffs (
+    id bigserial primary key,
+    account_id uuid not null,
+    prev_balance decimal(18, 2) not null,
+    journal_id bigint references journal_entries (id),
+    created_at timestamp with time zone not null default now()
+);
+
+insert into account_balance_writeoffs (account_id, prev_balance)
+select id, balance from accounts where balance < 0;
+
+update accounts set balance = 0, updated_at = now() where balance < 0;
+
+-- existing rows are checked too, none is negative anymore
+alter table accounts add constraint accounts_balance_non_negative check (balance >= 0);
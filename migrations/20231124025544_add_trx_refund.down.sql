@@ -0,0 +1,5 @@
+drop index transactions_parent_id_idx;
+
+alter table transactions drop column refunded_fee;
+alter table transactions drop column refunded_amount;
+alter table transactions drop column parent_id;
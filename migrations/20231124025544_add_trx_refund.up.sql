@@ -0,0 +1,5 @@
+alter table transactions add column parent_id bigint not null default 0;
+alter table transactions add column refunded_amount decimal(18, 2) not null default 0;
+alter table transactions add column refunded_fee decimal(18, 2) not null default 0;
+
+create index transactions_parent_id_idx on transactions (parent_id);
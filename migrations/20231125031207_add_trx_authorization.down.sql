@@ -0,0 +1,8 @@
+drop index transactions_expires_at_idx;
+
+alter table transactions drop column expires_at;
+alter table transactions drop column hold_amount;
+alter table transactions drop column authorized_amount;
+
+alter table accounts drop constraint accounts_hold_balance_valid;
+alter table accounts drop column hold_balance;
@@ -0,0 +1,9 @@
+alter table accounts add column hold_balance decimal(18, 2) not null default 0;
+-- available balance is balance - hold_balance, held funds never exceed what the account owns
+alter table accounts add constraint accounts_hold_balance_valid check (hold_balance >= 0 and hold_balance <= balance) not valid;
+
+alter table transactions add column authorized_amount decimal(18, 2) not null default 0;
+alter table transactions add column hold_amount decimal(18, 2) not null default 0;
+alter table transactions add column expires_at timestamptz null;
+
+create index transactions_expires_at_idx on transactions (expires_at) where trx_status = 0 and hold_amount > 0;
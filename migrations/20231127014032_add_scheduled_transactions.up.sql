@@ -0,0 +1,21 @@
+create table scheduled_transactions (
+    id bigint primary key,
+    user_id uuid not null,
+    account_id uuid not null,
+    recipient_id uuid not null,
+    trx_type int not null,
+    nominal decimal(18, 2) not null,
+    description text not null default '',
+    scheduled_at timestamp with time zone not null,
+    status smallint not null default 1,
+    attempts int not null default 0,
+    next_attempt_at timestamp with time zone not null,
+    last_error text not null default '',
+    transaction_id bigint not null default 0,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create index scheduled_transactions_due_idx on scheduled_transactions (next_attempt_at) where status = 1 and deleted_at is null;
+create index scheduled_transactions_user_id_idx on scheduled_transactions (user_id);
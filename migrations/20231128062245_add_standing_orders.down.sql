@@ -0,0 +1,2 @@
+drop table standing_order_runs;
+drop table standing_orders;
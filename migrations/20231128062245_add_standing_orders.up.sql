@@ -0,0 +1,38 @@
+create table standing_orders (
+    id bigint primary key,
+    user_id uuid not null,
+    account_id uuid not null,
+    recipient_id uuid not null,
+    trx_type int not null,
+    nominal decimal(18, 2) not null,
+    description text not null default '',
+    recurrence varchar(255) not null,
+    start_at timestamp with time zone not null,
+    end_at timestamp with time zone,
+    max_occurrences int not null default 0,
+    occurrences int not null default 0,
+    insufficient_balance_policy smallint not null default 1,
+    status smallint not null default 1,
+    next_occurrence_at timestamp with time zone not null,
+    next_run_at timestamp with time zone not null,
+    attempts int not null default 0,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create index standing_orders_due_idx on standing_orders (next_run_at) where status = 1 and deleted_at is null;
+create index standing_orders_user_id_idx on standing_orders (user_id);
+
+create table standing_order_runs (
+    id bigint primary key,
+    order_id bigint not null references standing_orders (id),
+    occurrence_at timestamp with time zone not null,
+    attempt int not null,
+    status smallint not null,
+    transaction_id bigint not null default 0,
+    error_message text not null default '',
+    created_at timestamp with time zone not null default now()
+);
+
+create index standing_order_runs_order_id_idx on standing_order_runs (order_id, created_at);
@@ -0,0 +1,3 @@
+drop index transactions_account_id_trx_datetime_idx;
+
+drop table transaction_limits;
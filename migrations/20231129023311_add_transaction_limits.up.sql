@@ -0,0 +1,20 @@
+create table transaction_limits (
+    id bigint primary key,
+    name varchar(255) not null,
+    trx_type int not null default 0,
+    account_type smallint not null default 0,
+    account_tier smallint not null default 0,
+    max_per_trx decimal(18, 2) not null default 0,
+    daily_amount decimal(18, 2) not null default 0,
+    daily_count int not null default 0,
+    monthly_amount decimal(18, 2) not null default 0,
+    monthly_count int not null default 0,
+    counterparty_daily_amount decimal(18, 2) not null default 0,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create unique index transaction_limits_scope_idx on transaction_limits (trx_type, account_type, account_tier) where deleted_at is null;
+
+create index transactions_account_id_trx_datetime_idx on transactions (account_id, trx_datetime);
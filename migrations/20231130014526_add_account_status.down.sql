@@ -0,0 +1,4 @@
+drop table account_status_histories;
+
+alter table accounts drop column status_reason;
+alter table accounts drop column status;
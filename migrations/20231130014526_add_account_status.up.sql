@@ -0,0 +1,15 @@
+alter table accounts add column status smallint not null default 1;
+alter table accounts add column status_reason smallint not null default 0;
+
+create table account_status_histories (
+    id bigint primary key,
+    account_id uuid not null,
+    prev_status smallint not null,
+    status smallint not null,
+    reason_code smallint not null default 0,
+    note text not null default '',
+    changed_by varchar(255) not null default '',
+    created_at timestamp with time zone not null default now()
+);
+
+create index account_status_histories_account_id_idx on account_status_histories (account_id, created_at);
@@ -0,0 +1 @@
+drop table export_jobs;
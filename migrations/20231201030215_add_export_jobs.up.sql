@@ -0,0 +1,18 @@
+create table export_jobs (
+    id bigint primary key,
+    user_id uuid not null,
+    export_type smallint not null,
+    format varchar(8) not null,
+    params jsonb not null default '{}',
+    status smallint not null default 1,
+    row_count bigint not null default 0,
+    file_name text not null default '',
+    last_error text not null default '',
+    expires_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create index export_jobs_pending_idx on export_jobs (created_at) where status in (1, 2) and deleted_at is null;
+create index export_jobs_user_id_idx on export_jobs (user_id);
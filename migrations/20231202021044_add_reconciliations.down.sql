@@ -0,0 +1,4 @@
+drop index transactions_recipient_id_idx;
+
+drop table reconciliation_discrepancies;
+drop table reconciliation_runs;
@@ -0,0 +1,28 @@
+create table reconciliation_runs (
+    id bigint primary key,
+    status smallint not null default 1,
+    accounts_checked bigint not null default 0,
+    discrepancies bigint not null default 0,
+    last_error text not null default '',
+    started_at timestamp with time zone not null default now(),
+    finished_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+-- only one run may be in progress at a time
+create unique index reconciliation_runs_running_idx on reconciliation_runs (status) where status = 1;
+
+create table reconciliation_discrepancies (
+    id bigserial primary key,
+    run_id bigint not null references reconciliation_runs (id),
+    account_id uuid not null,
+    stored_balance decimal(18, 2) not null,
+    expected_balance decimal(18, 2) not null,
+    difference decimal(18, 2) not null,
+    created_at timestamp with time zone not null default now()
+);
+
+create index reconciliation_discrepancies_run_id_idx on reconciliation_discrepancies (run_id);
+create index reconciliation_discrepancies_account_id_idx on reconciliation_discrepancies (account_id);
+create index transactions_recipient_id_idx on transactions (recipient_id);
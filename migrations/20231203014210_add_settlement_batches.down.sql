@@ -0,0 +1,6 @@
+drop index settlements_batch_id_idx;
+
+alter table settlements drop column status;
+alter table settlements drop column batch_id;
+
+drop table settlement_batches;
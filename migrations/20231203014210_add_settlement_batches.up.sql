@@ -0,0 +1,23 @@
+create table settlement_batches (
+    id bigint primary key,
+    merchant_id uuid not null,
+    status smallint not null,
+    settlement_count int not null default 0,
+    total_amount decimal(18, 2) not null default 0,
+    fee_amount decimal(18, 2) not null default 0,
+    failure_reason text not null default '',
+    cutoff_at timestamp with time zone not null,
+    paid_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index settlement_batches_merchant_id_idx on settlement_batches (merchant_id, cutoff_at);
+
+alter table settlements add column batch_id bigint not null default 0;
+alter table settlements add column status smallint not null default 1;
+
+-- settlements already withdrawn by a beneficiary are paid
+update settlements set status = 3 where beneficiary_id != 0;
+
+create index settlements_batch_id_idx on settlements (batch_id);
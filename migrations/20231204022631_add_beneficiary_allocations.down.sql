@@ -0,0 +1,10 @@
+alter table settlements add column beneficiary_id bigint not null default 0;
+
+-- a partially allocated settlement cannot be represented anymore, it is linked to its latest beneficiary
+update settlements s set beneficiary_id = a.beneficiary_id from (
+    select distinct on (settlement_id) settlement_id, beneficiary_id from beneficiary_allocations order by settlement_id, id desc
+) a where a.settlement_id = s.id;
+
+alter table settlements drop column allocated_amount;
+
+drop table beneficiary_allocations;
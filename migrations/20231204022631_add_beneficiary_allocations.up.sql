@@ -0,0 +1,20 @@
+create table beneficiary_allocations (
+    id bigserial primary key,
+    beneficiary_id bigint not null,
+    settlement_id bigint not null,
+    amount decimal(18, 2) not null,
+    created_at timestamp with time zone not null default now()
+);
+
+create index beneficiary_allocations_beneficiary_id_idx on beneficiary_allocations (beneficiary_id);
+create index beneficiary_allocations_settlement_id_idx on beneficiary_allocations (settlement_id);
+
+alter table settlements add column allocated_amount decimal(18, 2) not null default 0;
+
+-- settlements linked to a beneficiary were always withdrawn in full
+insert into beneficiary_allocations (beneficiary_id, settlement_id, amount, created_at)
+    select beneficiary_id, id, amount, updated_at from settlements where beneficiary_id != 0;
+
+update settlements set allocated_amount = amount where beneficiary_id != 0;
+
+alter table settlements drop column beneficiary_id;
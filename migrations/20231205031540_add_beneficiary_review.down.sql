@@ -0,0 +1,3 @@
+alter table beneficiaries drop column reviewed_at;
+alter table beneficiaries drop column reviewed_by;
+alter table beneficiaries drop column reject_reason;
@@ -0,0 +1,3 @@
+alter table beneficiaries add column reject_reason text not null default '';
+alter table beneficiaries add column reviewed_by varchar(255) not null default '';
+alter table beneficiaries add column reviewed_at timestamp with time zone;
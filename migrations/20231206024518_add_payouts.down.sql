@@ -0,0 +1,5 @@
+drop table payouts;
+
+alter table beneficiaries drop column payout_method;
+
+drop table merchant_bank_accounts;
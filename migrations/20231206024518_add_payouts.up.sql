@@ -0,0 +1,34 @@
+create table merchant_bank_accounts (
+    merchant_id uuid primary key,
+    bank_code varchar(16) not null,
+    account_no bytea not null,
+    account_name bytea not null,
+    row_hash bytea not null,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+alter table beneficiaries add column payout_method smallint not null default 1;
+
+create table payouts (
+    id bigint primary key,
+    beneficiary_id bigint not null,
+    merchant_id uuid not null,
+    connector varchar(32) not null,
+    bank_code varchar(16) not null,
+    account_no bytea not null,
+    account_name bytea not null,
+    amount decimal(18, 2) not null,
+    status smallint not null,
+    provider_ref varchar(255) not null default '',
+    failure_reason text not null default '',
+    attempts int not null default 0,
+    next_run_at timestamp with time zone,
+    sent_at timestamp with time zone,
+    settled_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create unique index payouts_beneficiary_id_idx on payouts (beneficiary_id);
+create index payouts_status_idx on payouts (status, next_run_at);
@@ -0,0 +1 @@
+drop table merchant_qr_codes;
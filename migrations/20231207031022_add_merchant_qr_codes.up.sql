@@ -0,0 +1,15 @@
+create table merchant_qr_codes (
+    id bigint primary key,
+    merchant_id uuid not null,
+    account_id uuid not null,
+    reference varchar(25) not null,
+    amount decimal(18, 2) not null,
+    status smallint not null,
+    transaction_id bigint not null default 0,
+    expires_at timestamp with time zone not null,
+    paid_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create unique index merchant_qr_codes_reference_idx on merchant_qr_codes (merchant_id, reference);
@@ -0,0 +1,2 @@
+drop table invoice_items;
+drop table invoices;
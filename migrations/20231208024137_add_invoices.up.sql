@@ -0,0 +1,37 @@
+create table invoices (
+    id bigint primary key,
+    merchant_id uuid not null,
+    customer_account_id uuid not null,
+    customer_reference varchar(100) not null default '',
+    description varchar(255) not null default '',
+    subtotal decimal(18, 2) not null,
+    tax_rate_bps int not null default 0,
+    tax_amount decimal(18, 2) not null default 0,
+    total decimal(18, 2) not null,
+    due_date date not null,
+    status smallint not null,
+    transaction_id bigint not null default 0,
+    claimed_at timestamp with time zone,
+    issued_at timestamp with time zone,
+    paid_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create index invoices_merchant_id_idx on invoices (merchant_id);
+create index invoices_customer_account_id_idx on invoices (customer_account_id);
+create index invoices_status_due_date_idx on invoices (status, due_date);
+
+create table invoice_items (
+    id bigint primary key,
+    invoice_id bigint not null references invoices (id),
+    description varchar(255) not null,
+    quantity int not null,
+    unit_price decimal(18, 2) not null,
+    amount decimal(18, 2) not null,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index invoice_items_invoice_id_idx on invoice_items (invoice_id);
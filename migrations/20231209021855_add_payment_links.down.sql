@@ -0,0 +1,2 @@
+drop table payment_link_payments;
+drop table payment_links;
@@ -0,0 +1,29 @@
+create table payment_links (
+    id bigint primary key,
+    merchant_id uuid not null,
+    token bytea not null,
+    token_hash bytea not null,
+    amount decimal(18, 2) not null default 0,
+    description varchar(255) not null,
+    max_uses int not null default 0,
+    use_count int not null default 0,
+    status smallint not null,
+    expires_at timestamp with time zone not null,
+    row_hash bytea,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create unique index payment_links_token_hash_idx on payment_links (token_hash);
+create index payment_links_merchant_id_idx on payment_links (merchant_id);
+
+create table payment_link_payments (
+    id bigint primary key,
+    link_id bigint not null references payment_links (id),
+    transaction_id bigint not null,
+    account_id uuid not null,
+    amount decimal(18, 2) not null,
+    created_at timestamp with time zone not null default now()
+);
+
+create index payment_link_payments_link_id_idx on payment_link_payments (link_id);
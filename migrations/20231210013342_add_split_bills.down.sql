@@ -0,0 +1,2 @@
+drop table split_bill_shares;
+drop table split_bills;
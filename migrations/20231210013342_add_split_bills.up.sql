@@ -0,0 +1,29 @@
+create table split_bills (
+    id bigint primary key,
+    initiator_account_id uuid not null,
+    total decimal(18, 2) not null,
+    description varchar(255) not null,
+    split_type smallint not null,
+    status smallint not null,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index split_bills_initiator_account_id_idx on split_bills (initiator_account_id);
+
+create table split_bill_shares (
+    id bigint primary key,
+    split_id bigint not null references split_bills (id),
+    account_id uuid not null,
+    amount decimal(18, 2) not null,
+    status smallint not null,
+    transaction_id bigint not null default 0,
+    reminder_count int not null default 0,
+    reminded_at timestamp with time zone,
+    paid_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create unique index split_bill_shares_split_id_account_id_idx on split_bill_shares (split_id, account_id);
+create index split_bill_shares_account_id_idx on split_bill_shares (account_id);
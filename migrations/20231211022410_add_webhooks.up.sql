@@ -0,0 +1,33 @@
+create table webhook_subscriptions (
+    id bigint primary key,
+    merchant_id uuid not null,
+    url varchar(2048) not null,
+    secret bytea not null,
+    event_types jsonb not null default '[]',
+    status smallint not null,
+    row_hash bytea,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    deleted_at timestamp with time zone
+);
+
+create index webhook_subscriptions_merchant_id_idx on webhook_subscriptions (merchant_id);
+
+create table webhook_deliveries (
+    id bigint primary key,
+    subscription_id bigint not null references webhook_subscriptions (id),
+    event_type varchar(64) not null,
+    payload jsonb not null,
+    status smallint not null,
+    attempts int not null default 0,
+    next_run_at timestamp with time zone,
+    response_status int not null default 0,
+    response_body text not null default '',
+    last_error text not null default '',
+    delivered_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now()
+);
+
+create index webhook_deliveries_subscription_id_idx on webhook_deliveries (subscription_id, created_at);
+create index webhook_deliveries_due_idx on webhook_deliveries (next_run_at) where status = 1;
@@ -0,0 +1 @@
+drop table merchant_api_clients;
@@ -0,0 +1,16 @@
+create table merchant_api_clients (
+    id bigint primary key,
+    merchant_id uuid not null,
+    client_id varchar(64) not null,
+    name varchar(255) not null default '',
+    public_key text not null,
+    status smallint not null,
+    row_hash bytea,
+    last_used_at timestamp with time zone,
+    created_at timestamp with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+    revoked_at timestamp with time zone
+);
+
+create unique index merchant_api_clients_client_id_idx on merchant_api_clients (client_id);
+create index merchant_api_clients_merchant_id_idx on merchant_api_clients (merchant_id);
@@ -0,0 +1,3 @@
+drop index if exists scheduled_transactions_lease_idx;
+
+alter table scheduled_transactions drop column if exists locked_until;
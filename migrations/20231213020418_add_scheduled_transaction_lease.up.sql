@@ -0,0 +1,6 @@
+alter table scheduled_transactions add column locked_until timestamp with time zone;
+
+-- schedules stuck in processing before leases existed are picked up again right away
+update scheduled_transactions set locked_until = now() where status = 2;
+
+create index scheduled_transactions_lease_idx on scheduled_transactions (locked_until) where status = 2 and deleted_at is null;
@@ -0,0 +1 @@
+alter table invoices add column if not exists claimed_at timestamp with time zone;
@@ -0,0 +1,2 @@
+-- invoices are paid and closed in one database transaction, a payment attempt no longer claims the invoice
+alter table invoices drop column if exists claimed_at;
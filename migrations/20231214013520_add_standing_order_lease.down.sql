@@ -0,0 +1,4 @@
+drop index if exists standing_orders_lease_idx;
+
+alter table standing_orders drop column if exists transaction_id;
+alter table standing_orders drop column if exists locked_until;
@@ -0,0 +1,8 @@
+alter table standing_orders add column locked_until timestamp with time zone;
+-- reserved for the transfer of the occurrence being processed, 0 once its outcome is recorded
+alter table standing_orders add column transaction_id bigint not null default 0;
+
+-- orders stuck in processing before leases existed are picked up again right away
+update standing_orders set locked_until = now() where status = 2;
+
+create index standing_orders_lease_idx on standing_orders (locked_until) where status = 2 and deleted_at is null;
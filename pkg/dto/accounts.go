@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type AccountsQueryParams struct {
 	AccountID   string `param:"accountID"`
 	AccountNo   string `param:"accountNo"`
@@ -10,23 +12,50 @@ type AccountsQueryParams struct {
 }
 
 type AccountPayload struct {
-	OwnerID     string  `json:"owner_id"`
-	AccountType int64   `json:"account_type"`
-	Balance     float64 `json:"balance"`
-	AccountNo   string  `json:"account_no"`
-	PIN         string  `json:"pin"`
+	OwnerID     string      `json:"owner_id"`
+	AccountType int64       `json:"account_type"`
+	AccountTier int64       `json:"account_tier"`
+	Balance     money.Money `json:"balance"`
+	AccountNo   string      `json:"account_no"`
+	PIN         string      `json:"pin"`
 }
 
 type AccountResponse struct {
-	ID          string  `json:"id"`
-	OwnerID     string  `json:"owner_id"`
-	OwnerName   string  `json:"owner_name"`
-	AccountType int64   `json:"account_type"`
-	Balance     float64 `json:"balance,omitempty"`
-	AccountNo   string  `json:"account_no"`
+	ID           string       `json:"id"`
+	OwnerID      string       `json:"owner_id"`
+	OwnerName    string       `json:"owner_name"`
+	AccountType  int64        `json:"account_type"`
+	AccountTier  int64        `json:"account_tier"`
+	Balance      *money.Money `json:"balance,omitempty"`
+	HoldBalance  *money.Money `json:"hold_balance,omitempty"`
+	AccountNo    string       `json:"account_no"`
+	Status       int64        `json:"status,omitempty"`
+	StatusReason int64        `json:"status_reason,omitempty"`
 }
 
 type ListAccountResponse struct {
 	Accounts []*AccountResponse `json:"accounts"`
 	Meta     ListPaginations    `json:"meta"`
 }
+
+type AccountStatusPayload struct {
+	Status     int64  `json:"status" validate:"required"`
+	ReasonCode int64  `json:"reason_code"`
+	Note       string `json:"note"`
+}
+
+type AccountStatusHistoryResponse struct {
+	ID         uint64 `json:"id"`
+	PrevStatus int64  `json:"prev_status"`
+	Status     int64  `json:"status"`
+	ReasonCode int64  `json:"reason_code"`
+	Note       string `json:"note"`
+	ChangedBy  string `json:"changed_by"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type ListAccountStatusHistoryResponse struct {
+	AccountID string                          `json:"account_id"`
+	Histories []*AccountStatusHistoryResponse `json:"histories"`
+	Meta      ListPaginations                 `json:"meta"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+type APIClientsQueryParams struct {
+	APIClientID uint64 `param:"apiClientID"`
+	Status      int64  `query:"status"`
+	Limit       uint64 `query:"limit"`
+	Page        uint64 `query:"page"`
+}
+
+// APIClientPayload.PublicKey is a pem encoded PKIX rsa public key, the merchant keeps the private key
+type APIClientPayload struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key" validate:"required"`
+}
+
+type APIClientResponse struct {
+	ID         uint64 `json:"id"`
+	MerchantID string `json:"merchant_id"`
+	ClientID   string `json:"client_id"`
+	Name       string `json:"name"`
+	PublicKey  string `json:"public_key"`
+	Status     int64  `json:"status"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	RevokedAt  string `json:"revoked_at,omitempty"`
+}
+
+type ListAPIClientResponse struct {
+	APIClients []*APIClientResponse `json:"api_clients"`
+	Meta       ListPaginations      `json:"meta"`
+}
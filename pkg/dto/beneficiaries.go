@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type BeneficiariesQueryParams struct {
 	BeneficiaryID int64  `param:"beneficiaryID"`
 	MerchantID    string `query:"merchantID"`
@@ -9,19 +11,49 @@ type BeneficiariesQueryParams struct {
 }
 
 type BeneficiaryPayload struct {
-	MerchantID     string `json:"merchant_id"`
-	Amount         string `json:"amount"`
-	WithdrawalDate string `json:"withdrawal_date"`
-	Status         int64  `json:"status"`
+	MerchantID     string      `json:"merchant_id"`
+	Amount         money.Money `json:"amount"`
+	WithdrawalDate string      `json:"withdrawal_date"`
+	Status         int64       `json:"status"`
+	PayoutMethod   int64       `json:"payout_method"`
+}
+
+type BeneficiaryRejectPayload struct {
+	Reason string `json:"reason" validate:"required"`
 }
 
 type BeneficiaryResponse struct {
-	ID             uint64 `json:"id"`
-	MerchantID     string `json:"merchant_id"`
-	MerchantName   string `json:"merchant_name"`
-	Amount         string `json:"amount"`
-	WithdrawalDate string `json:"withdrawal_date"`
-	Status         int64  `json:"status"`
+	ID             uint64      `json:"id"`
+	MerchantID     string      `json:"merchant_id"`
+	MerchantName   string      `json:"merchant_name"`
+	Amount         money.Money `json:"amount"`
+	WithdrawalDate string      `json:"withdrawal_date"`
+	Status         int64       `json:"status"`
+	PayoutMethod   int64       `json:"payout_method"`
+	RejectReason   string      `json:"reject_reason,omitempty"`
+	ReviewedBy     string      `json:"reviewed_by,omitempty"`
+	ReviewedAt     string      `json:"reviewed_at,omitempty"`
+
+	Allocations []*BeneficiaryAllocationResponse `json:"allocations,omitempty"`
+	Payout      *BeneficiaryPayoutResponse       `json:"payout,omitempty"`
+}
+
+type BeneficiaryPayoutResponse struct {
+	ID            uint64 `json:"id"`
+	Connector     string `json:"connector"`
+	BankCode      string `json:"bank_code"`
+	Status        int64  `json:"status"`
+	ProviderRef   string `json:"provider_ref,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	Attempts      int64  `json:"attempts"`
+	SentAt        string `json:"sent_at,omitempty"`
+	SettledAt     string `json:"settled_at,omitempty"`
+}
+
+type BeneficiaryAllocationResponse struct {
+	SettlementID  uint64      `json:"settlement_id"`
+	TransactionID uint64      `json:"transaction_id"`
+	Amount        money.Money `json:"amount"`
 }
 
 type ListBeneficiaryResponse struct {
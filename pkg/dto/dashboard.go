@@ -1,16 +1,18 @@
 package dto
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type GenericDashboardGraph struct {
-	Key   any     `json:"key"`
-	Value float64 `json:"value"`
+	Key   any         `json:"key"`
+	Value money.Money `json:"value"`
 }
 
 type TransactionMetaDashboard struct {
-	SenderName    string  `json:"sender_name"`
-	RecipientName string  `json:"recipient_name"`
-	Nominal       float64 `json:"nominal"`
-	TrxDate       string  `json:"trx_date"`
-	TrxType       int64   `json:"trx_type"`
+	SenderName    string      `json:"sender_name"`
+	RecipientName string      `json:"recipient_name"`
+	Nominal       money.Money `json:"nominal"`
+	TrxDate       string      `json:"trx_date"`
+	TrxType       int64       `json:"trx_type"`
 }
 
 type AdminDashboard struct {
@@ -24,22 +26,22 @@ type AdminDashboard struct {
 
 type MerchantDashboard struct {
 	AccountID          string                     `json:"account_id"`
-	AccountBalance     float64                    `json:"account_balance"`
+	AccountBalance     money.Money                `json:"account_balance"`
 	TrxCount           int64                      `json:"trx_count"`
-	TrxNominal         float64                    `json:"trx_nominal"`
-	SettlementNominal  float64                    `json:"settlement_nominal"`
-	BeneficiaryNominal float64                    `json:"beneficiary_nominal"`
+	TrxNominal         money.Money                `json:"trx_nominal"`
+	SettlementNominal  money.Money                `json:"settlement_nominal"`
+	BeneficiaryNominal money.Money                `json:"beneficiary_nominal"`
 	TrxTraffic         []GenericDashboardGraph    `json:"trx_traffic"`
 	LastTrx            []TransactionMetaDashboard `json:"last_trx"`
 }
 
 type CustomerDashboard struct {
 	AccountID          string                     `json:"account_id"`
-	AccountBalance     float64                    `json:"account_balance"`
+	AccountBalance     money.Money                `json:"account_balance"`
 	PeerTrxCount       int64                      `json:"peer_trx_count"`
-	PeerTrxNominal     float64                    `json:"peer_trx_nominal"`
+	PeerTrxNominal     money.Money                `json:"peer_trx_nominal"`
 	MerchantTrxCount   int64                      `json:"merchant_trx_count"`
-	MerchantTrxNominal float64                    `json:"merchant_trx_nominal"`
+	MerchantTrxNominal money.Money                `json:"merchant_trx_nominal"`
 	TrxTraffic         []GenericDashboardGraph    `json:"trx_traffic"`
 	LastTrx            []TransactionMetaDashboard `json:"last_trx"`
 }
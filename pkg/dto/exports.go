@@ -0,0 +1,41 @@
+package dto
+
+import "io"
+
+// TransactionExportParams accepts the filters of TransactionsQueryParams, as query string when streamed and
+// as json body when requested as a background export
+type TransactionExportParams struct {
+	TrxType   int64  `query:"trxType" json:"trx_type"`
+	AccountID string `query:"accountID" json:"account_id"`
+	DateStart string `query:"dateStart" json:"date_start"`
+	DateEnd   string `query:"dateEnd" json:"date_end"`
+	Format    string `query:"format" json:"format"`
+}
+
+type ExportQueryParams struct {
+	ExportID uint64 `param:"exportID"`
+}
+
+type ExportJobResponse struct {
+	ID          uint64 `json:"id"`
+	ExportType  int64  `json:"export_type"`
+	Format      string `json:"format"`
+	Status      int64  `json:"status"`
+	RowCount    int64  `json:"row_count"`
+	LastError   string `json:"last_error,omitempty"`
+	DownloadURL string `json:"download_url,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// ExportStream is written straight to the response once the request has been validated
+type ExportStream struct {
+	Filename    string
+	ContentType string
+	Write       func(w io.Writer) error
+}
+
+type ExportFile struct {
+	Path     string
+	Filename string
+}
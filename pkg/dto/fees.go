@@ -0,0 +1,60 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type FeeSchedulesQueryParams struct {
+	FeeScheduleID uint64 `param:"feeScheduleID"`
+	Code          string `query:"code"`
+	TrxType       int64  `query:"trxType"`
+	MerchantID    string `query:"merchantID"`
+	Limit         uint64 `query:"limit"`
+	Page          uint64 `query:"page"`
+}
+
+type FeeRulePayload struct {
+	MinNominal money.Money `json:"min_nominal"`
+	FeeType    int64       `json:"fee_type"`
+	FlatFee    money.Money `json:"flat_fee"`
+	RateBps    int64       `json:"rate_bps"`
+	MinFee     money.Money `json:"min_fee"`
+	MaxFee     money.Money `json:"max_fee"`
+}
+
+type FeeSchedulePayload struct {
+	Code           string            `json:"code" validate:"required"`
+	Name           string            `json:"name" validate:"required"`
+	TrxType        int64             `json:"trx_type" validate:"required"`
+	MerchantID     string            `json:"merchant_id"`
+	AccountTier    int64             `json:"account_tier"`
+	EffectiveFrom  string            `json:"effective_from"`
+	EffectiveUntil string            `json:"effective_until"`
+	Rules          []*FeeRulePayload `json:"rules"`
+}
+
+type FeeRuleResponse struct {
+	ID         uint64      `json:"id"`
+	MinNominal money.Money `json:"min_nominal"`
+	FeeType    int64       `json:"fee_type"`
+	FlatFee    money.Money `json:"flat_fee"`
+	RateBps    int64       `json:"rate_bps"`
+	MinFee     money.Money `json:"min_fee"`
+	MaxFee     money.Money `json:"max_fee"`
+}
+
+type FeeScheduleResponse struct {
+	ID             uint64             `json:"id"`
+	Code           string             `json:"code"`
+	Name           string             `json:"name"`
+	TrxType        int64              `json:"trx_type"`
+	MerchantID     string             `json:"merchant_id"`
+	AccountTier    int64              `json:"account_tier"`
+	Version        int64              `json:"version"`
+	EffectiveFrom  string             `json:"effective_from"`
+	EffectiveUntil string             `json:"effective_until"`
+	Rules          []*FeeRuleResponse `json:"rules,omitempty"`
+}
+
+type ListFeeScheduleResponse struct {
+	FeeSchedules []*FeeScheduleResponse `json:"fee_schedules"`
+	Meta         ListPaginations        `json:"meta"`
+}
@@ -0,0 +1,62 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type InvoicesQueryParams struct {
+	InvoiceID uint64 `param:"invoiceID"`
+	Status    int64  `query:"status"`
+	Limit     uint64 `query:"limit"`
+	Page      uint64 `query:"page"`
+}
+
+type InvoiceItemPayload struct {
+	Description string      `json:"description"`
+	Quantity    int64       `json:"quantity"`
+	UnitPrice   money.Money `json:"unit_price"`
+}
+
+type InvoicePayload struct {
+	CustomerAccountNo string                `json:"customer_account_no" validate:"required"`
+	CustomerReference string                `json:"customer_reference"`
+	Description       string                `json:"description"`
+	TaxRateBps        int64                 `json:"tax_rate_bps"`
+	DueDate           string                `json:"due_date" validate:"required"`
+	Items             []*InvoiceItemPayload `json:"items"`
+}
+
+type InvoicePayPayload struct {
+	PIN string `json:"pin" validate:"required"`
+}
+
+type InvoiceItemResponse struct {
+	ID          uint64      `json:"id"`
+	Description string      `json:"description"`
+	Quantity    int64       `json:"quantity"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Amount      money.Money `json:"amount"`
+}
+
+type InvoiceResponse struct {
+	ID                uint64                 `json:"id"`
+	MerchantID        string                 `json:"merchant_id"`
+	MerchantName      string                 `json:"merchant_name"`
+	CustomerAccountID string                 `json:"customer_account_id"`
+	CustomerReference string                 `json:"customer_reference"`
+	Description       string                 `json:"description"`
+	Subtotal          money.Money            `json:"subtotal"`
+	TaxRateBps        int64                  `json:"tax_rate_bps"`
+	TaxAmount         money.Money            `json:"tax_amount"`
+	Total             money.Money            `json:"total"`
+	DueDate           string                 `json:"due_date"`
+	Status            int64                  `json:"status"`
+	TransactionID     uint64                 `json:"transaction_id,omitempty"`
+	IssuedAt          string                 `json:"issued_at,omitempty"`
+	PaidAt            string                 `json:"paid_at,omitempty"`
+	CreatedAt         string                 `json:"created_at"`
+	Items             []*InvoiceItemResponse `json:"items,omitempty"`
+}
+
+type ListInvoiceResponse struct {
+	Invoices []*InvoiceResponse `json:"invoices"`
+	Meta     ListPaginations    `json:"meta"`
+}
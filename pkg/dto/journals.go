@@ -0,0 +1,27 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type LedgerQueryParams struct {
+	AccountID string `param:"accountID"`
+	Limit     uint64 `query:"limit"`
+	Page      uint64 `query:"page"`
+}
+
+type JournalPostingResponse struct {
+	ID            uint64      `json:"id"`
+	JournalID     uint64      `json:"journal_id"`
+	TransactionID uint64      `json:"transaction_id"`
+	EntryType     int64       `json:"entry_type"`
+	Description   string      `json:"description"`
+	EntryDatetime string      `json:"entry_datetime"`
+	Amount        money.Money `json:"amount"`
+}
+
+type AccountLedgerResponse struct {
+	AccountID     string                    `json:"account_id"`
+	LedgerBalance money.Money               `json:"ledger_balance"`
+	StoredBalance money.Money               `json:"stored_balance"`
+	Postings      []*JournalPostingResponse `json:"postings"`
+	Meta          ListPaginations           `json:"meta"`
+}
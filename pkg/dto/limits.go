@@ -0,0 +1,75 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type TransactionLimitsQueryParams struct {
+	LimitID     uint64 `param:"limitID"`
+	TrxType     int64  `query:"trxType"`
+	AccountType int64  `query:"accountType"`
+	AccountTier int64  `query:"accountTier"`
+	Limit       uint64 `query:"limit"`
+	Page        uint64 `query:"page"`
+}
+
+type TransactionLimitPayload struct {
+	Name                    string      `json:"name" validate:"required"`
+	TrxType                 int64       `json:"trx_type"`
+	AccountType             int64       `json:"account_type"`
+	AccountTier             int64       `json:"account_tier"`
+	MaxPerTrx               money.Money `json:"max_per_trx"`
+	DailyAmount             money.Money `json:"daily_amount"`
+	DailyCount              int64       `json:"daily_count"`
+	MonthlyAmount           money.Money `json:"monthly_amount"`
+	MonthlyCount            int64       `json:"monthly_count"`
+	CounterpartyDailyAmount money.Money `json:"counterparty_daily_amount"`
+}
+
+type TransactionLimitResponse struct {
+	ID                      uint64      `json:"id"`
+	Name                    string      `json:"name"`
+	TrxType                 int64       `json:"trx_type"`
+	AccountType             int64       `json:"account_type"`
+	AccountTier             int64       `json:"account_tier"`
+	MaxPerTrx               money.Money `json:"max_per_trx"`
+	DailyAmount             money.Money `json:"daily_amount"`
+	DailyCount              int64       `json:"daily_count"`
+	MonthlyAmount           money.Money `json:"monthly_amount"`
+	MonthlyCount            int64       `json:"monthly_count"`
+	CounterpartyDailyAmount money.Money `json:"counterparty_daily_amount"`
+}
+
+type ListTransactionLimitResponse struct {
+	TransactionLimits []*TransactionLimitResponse `json:"transaction_limits"`
+	Meta              ListPaginations             `json:"meta"`
+}
+
+type AccountLimitQueryParams struct {
+	AccountID   string `param:"accountID"`
+	TrxType     int64  `query:"trxType"`
+	RecipientID string `query:"recipientID"`
+}
+
+type LimitAmountHeadroom struct {
+	Limit     money.Money `json:"limit"`
+	Used      money.Money `json:"used"`
+	Remaining money.Money `json:"remaining"`
+}
+
+type LimitCountHeadroom struct {
+	Limit     int64 `json:"limit"`
+	Used      int64 `json:"used"`
+	Remaining int64 `json:"remaining"`
+}
+
+// AccountLimitResponse shows what is left of each cap, caps that are not set are omitted
+type AccountLimitResponse struct {
+	AccountID               string               `json:"account_id"`
+	LimitID                 uint64               `json:"limit_id,omitempty"`
+	TrxType                 int64                `json:"trx_type"`
+	MaxPerTrx               *money.Money         `json:"max_per_trx,omitempty"`
+	DailyAmount             *LimitAmountHeadroom `json:"daily_amount,omitempty"`
+	DailyCount              *LimitCountHeadroom  `json:"daily_count,omitempty"`
+	MonthlyAmount           *LimitAmountHeadroom `json:"monthly_amount,omitempty"`
+	MonthlyCount            *LimitCountHeadroom  `json:"monthly_count,omitempty"`
+	CounterpartyDailyAmount *LimitAmountHeadroom `json:"counterparty_daily_amount,omitempty"`
+}
@@ -31,6 +31,21 @@ type MerchantResponse struct {
 	PhotoProfile string `json:"photo_profile"`
 }
 
+// MerchantBankAccountPayload registers the bank account withdrawals paid out by bank transfer are sent to
+type MerchantBankAccountPayload struct {
+	BankCode    string `json:"bank_code" validate:"required"`
+	AccountNo   string `json:"account_no" validate:"required"`
+	AccountName string `json:"account_name" validate:"required"`
+}
+
+type MerchantBankAccountResponse struct {
+	MerchantID  string `json:"merchant_id"`
+	BankCode    string `json:"bank_code"`
+	AccountNo   string `json:"account_no"`
+	AccountName string `json:"account_name"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
 type ListMerchantResponse struct {
 	Merchants []*MerchantResponse `json:"merchants"`
 	Meta      ListPaginations     `json:"meta"`
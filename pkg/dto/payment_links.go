@@ -0,0 +1,68 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type PaymentLinksQueryParams struct {
+	LinkID uint64 `param:"linkID"`
+	Status int64  `query:"status"`
+	Limit  uint64 `query:"limit"`
+	Page   uint64 `query:"page"`
+}
+
+type PaymentLinkPayload struct {
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description" validate:"required"`
+	ExpiresAt   string      `json:"expires_at" validate:"required"`
+	MaxUses     int64       `json:"max_uses"`
+}
+
+type PaymentLinkResponse struct {
+	ID          uint64      `json:"id"`
+	MerchantID  string      `json:"merchant_id"`
+	Token       string      `json:"token"`
+	Amount      money.Money `json:"amount"`
+	Description string      `json:"description"`
+	MaxUses     int64       `json:"max_uses"`
+	UseCount    int64       `json:"use_count"`
+	Status      int64       `json:"status"`
+	ExpiresAt   string      `json:"expires_at"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+type ListPaymentLinkResponse struct {
+	PaymentLinks []*PaymentLinkResponse `json:"payment_links"`
+	Meta         ListPaginations        `json:"meta"`
+}
+
+type PaymentLinkStatsResponse struct {
+	LinkID        uint64      `json:"link_id"`
+	Payments      int64       `json:"payments"`
+	Payers        int64       `json:"payers"`
+	Collected     money.Money `json:"collected"`
+	RemainingUses *int64      `json:"remaining_uses,omitempty"`
+	LastPaidAt    string      `json:"last_paid_at,omitempty"`
+}
+
+type PaymentLinkTokenParams struct {
+	Token string `param:"token"`
+}
+
+type PaymentLinkPreviewResponse struct {
+	MerchantID   string      `json:"merchant_id"`
+	MerchantName string      `json:"merchant_name"`
+	Amount       money.Money `json:"amount"`
+	Description  string      `json:"description"`
+	ExpiresAt    string      `json:"expires_at"`
+}
+
+type PaymentLinkPayPayload struct {
+	AccountID string      `json:"account_id" validate:"required"`
+	Nominal   money.Money `json:"nominal"`
+	PIN       string      `json:"pin" validate:"required"`
+}
+
+type PaymentLinkPaymentResponse struct {
+	TransactionID uint64      `json:"transaction_id"`
+	MerchantID    string      `json:"merchant_id"`
+	Nominal       money.Money `json:"nominal"`
+}
@@ -0,0 +1,53 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type QRQueryParams struct {
+	MerchantID string `query:"merchantID"`
+}
+
+type DynamicQRPayload struct {
+	Amount    money.Money `json:"amount" validate:"required"`
+	Reference string      `json:"reference" validate:"required"`
+	ExpiresIn int64       `json:"expires_in"` // seconds
+}
+
+type QRResponse struct {
+	ID         uint64      `json:"id,omitempty"`
+	MerchantID string      `json:"merchant_id"`
+	Type       int64       `json:"type"`
+	Payload    string      `json:"payload"`
+	Amount     money.Money `json:"amount"`
+	Reference  string      `json:"reference,omitempty"`
+	ExpiresAt  string      `json:"expires_at,omitempty"`
+}
+
+type QRParsePayload struct {
+	Payload string `json:"payload" validate:"required"`
+}
+
+type QRPreviewResponse struct {
+	Type         int64       `json:"type"`
+	MerchantID   string      `json:"merchant_id"`
+	MerchantName string      `json:"merchant_name"`
+	RecipientID  string      `json:"recipient_id"`
+	AccountNo    string      `json:"account_no"`
+	Amount       money.Money `json:"amount"`
+	Reference    string      `json:"reference,omitempty"`
+	ExpiresAt    string      `json:"expires_at,omitempty"`
+}
+
+type QRPayPayload struct {
+	Payload     string      `json:"payload" validate:"required"`
+	AccountID   string      `json:"account_id" validate:"required"`
+	Nominal     money.Money `json:"nominal"`
+	Description string      `json:"description"`
+	PIN         string      `json:"pin" validate:"required"`
+}
+
+type QRPaymentResponse struct {
+	TransactionID uint64      `json:"transaction_id"`
+	MerchantID    string      `json:"merchant_id"`
+	Nominal       money.Money `json:"nominal"`
+	Reference     string      `json:"reference,omitempty"`
+}
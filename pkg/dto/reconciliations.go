@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type ReconciliationQueryParams struct {
+	RunID     uint64 `param:"runID"`
+	AccountID string `query:"accountID"`
+	Limit     uint64 `query:"limit"`
+	Page      uint64 `query:"page"`
+}
+
+type ReconciliationRunResponse struct {
+	ID              uint64 `json:"id"`
+	Status          int64  `json:"status"`
+	AccountsChecked int64  `json:"accounts_checked"`
+	Discrepancies   int64  `json:"discrepancies"`
+	LastError       string `json:"last_error,omitempty"`
+	StartedAt       string `json:"started_at"`
+	FinishedAt      string `json:"finished_at,omitempty"`
+}
+
+type ListReconciliationRunResponse struct {
+	Runs []*ReconciliationRunResponse `json:"runs"`
+	Meta ListPaginations              `json:"meta"`
+}
+
+type ReconciliationDiscrepancyResponse struct {
+	ID              uint64      `json:"id"`
+	AccountID       string      `json:"account_id"`
+	StoredBalance   money.Money `json:"stored_balance"`
+	ExpectedBalance money.Money `json:"expected_balance"`
+	Difference      money.Money `json:"difference"`
+	CreatedAt       string      `json:"created_at"`
+}
+
+type ListReconciliationDiscrepancyResponse struct {
+	RunID         uint64                               `json:"run_id"`
+	Discrepancies []*ReconciliationDiscrepancyResponse `json:"discrepancies"`
+	Meta          ListPaginations                      `json:"meta"`
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type ScheduledTransactionsQueryParams struct {
+	ScheduleID uint64 `param:"scheduleID"`
+	Status     int64  `query:"status"`
+	Limit      uint64 `query:"limit"`
+	Page       uint64 `query:"page"`
+}
+
+type ScheduledTransactionPayload struct {
+	AccountID   string      `json:"account_id" validate:"required"`
+	RecipientID string      `json:"recipient_id" validate:"required"`
+	TrxType     int64       `json:"trx_type" validate:"required"`
+	Nominal     money.Money `json:"nominal" validate:"required"`
+	Description string      `json:"description"`
+	ScheduledAt string      `json:"scheduled_at" validate:"required"`
+	PIN         string      `json:"pin"`
+}
+
+type ScheduledTransactionResponse struct {
+	ID            uint64      `json:"id"`
+	AccountID     string      `json:"account_id"`
+	RecipientID   string      `json:"recipient_id"`
+	TrxType       int64       `json:"trx_type"`
+	Nominal       money.Money `json:"nominal"`
+	Description   string      `json:"description"`
+	ScheduledAt   string      `json:"scheduled_at"`
+	Status        int64       `json:"status"`
+	Attempts      int64       `json:"attempts"`
+	LastError     string      `json:"last_error,omitempty"`
+	TransactionID uint64      `json:"transaction_id,omitempty"`
+}
+
+type ListScheduledTransactionResponse struct {
+	ScheduledTransactions []*ScheduledTransactionResponse `json:"scheduled_transactions"`
+	Meta                  ListPaginations                 `json:"meta"`
+}
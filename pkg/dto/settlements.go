@@ -1,34 +1,67 @@
 package dto
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type SettlementsQueryParams struct {
-	SettlementID uint64 `param:"settlementID"`
-	MerchantID   string `param:"merchantID"`
-	Keyword      string `query:"keyword"`
-	Limit        uint64 `query:"limit"`
-	Page         uint64 `query:"page"`
+	SettlementID  uint64 `param:"settlementID"`
+	MerchantID    string `param:"merchantID"`
+	BatchID       uint64 `query:"batchID"`
+	BeneficiaryID uint64 `query:"beneficiaryID"`
+	Status        int64  `query:"status"`
+	Keyword       string `query:"keyword"`
+	Limit         uint64 `query:"limit"`
+	Page          uint64 `query:"page"`
 }
 
 type SettlementPayload struct {
-	TransactionID  uint64  `json:"transaction_id"`
-	MerchantID     string  `json:"merchant_id"`
-	BeneficiaryID  uint64  `json:"beneficiary_id"`
-	Amount         float64 `json:"amount"`
-	Status         int64   `json:"status"`
-	SettlementDate string  `json:"settlement_date"`
+	TransactionID   uint64      `json:"transaction_id"`
+	MerchantID      string      `json:"merchant_id"`
+	Amount          money.Money `json:"amount"`
+	AllocatedAmount money.Money `json:"allocated_amount"`
+	Status          int64       `json:"status"`
+	SettlementDate  string      `json:"settlement_date"`
 }
 
 type SettlementResponse struct {
-	ID             uint64  `json:"id"`
-	TransactionID  uint64  `json:"transaction_id"`
-	MerchantID     string  `json:"merchant_id"`
-	MerchantName   string  `json:"merchant_name"`
-	BeneficiaryID  uint64  `json:"beneficiary_id"`
-	Amount         float64 `json:"amount"`
-	Status         int64   `json:"status"`
-	SettlementDate string  `json:"settlement_date"`
+	ID              uint64      `json:"id"`
+	TransactionID   uint64      `json:"transaction_id"`
+	MerchantID      string      `json:"merchant_id"`
+	MerchantName    string      `json:"merchant_name"`
+	BatchID         uint64      `json:"batch_id"`
+	Amount          money.Money `json:"amount"`
+	AllocatedAmount money.Money `json:"allocated_amount"`
+	Status          int64       `json:"status"`
+	SettlementDate  string      `json:"settlement_date"`
 }
 
 type ListSettlementResponse struct {
 	Settlements []*SettlementResponse `json:"settlements"`
 	Meta        ListPaginations       `json:"meta"`
 }
+
+type SettlementBatchQueryParams struct {
+	BatchID    uint64 `param:"batchID"`
+	MerchantID string `query:"merchantID"`
+	Status     int64  `query:"status"`
+	Limit      uint64 `query:"limit"`
+	Page       uint64 `query:"page"`
+}
+
+type SettlementBatchResponse struct {
+	ID              uint64      `json:"id"`
+	MerchantID      string      `json:"merchant_id"`
+	MerchantName    string      `json:"merchant_name"`
+	Status          int64       `json:"status"`
+	SettlementCount int64       `json:"settlement_count"`
+	TotalAmount     money.Money `json:"total_amount"`
+	FeeAmount       money.Money `json:"fee_amount"`
+	FailureReason   string      `json:"failure_reason,omitempty"`
+	CutoffAt        string      `json:"cutoff_at"`
+	PaidAt          string      `json:"paid_at,omitempty"`
+	CreatedAt       string      `json:"created_at"`
+}
+
+type ListSettlementBatchResponse struct {
+	Batches []*SettlementBatchResponse `json:"batches"`
+	Meta    ListPaginations            `json:"meta"`
+}
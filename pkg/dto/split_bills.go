@@ -0,0 +1,63 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type SplitBillsQueryParams struct {
+	SplitID uint64 `param:"splitID"`
+	Status  int64  `query:"status"`
+	Limit   uint64 `query:"limit"`
+	Page    uint64 `query:"page"`
+}
+
+// SplitBillParticipantPayload.Amount is only read for custom splits
+type SplitBillParticipantPayload struct {
+	AccountNo string      `json:"account_no"`
+	Amount    money.Money `json:"amount"`
+}
+
+type SplitBillPayload struct {
+	Total        money.Money                    `json:"total"`
+	Description  string                         `json:"description" validate:"required"`
+	SplitType    int64                          `json:"split_type" validate:"required"`
+	Participants []*SplitBillParticipantPayload `json:"participants"`
+}
+
+type SplitBillPayPayload struct {
+	PIN string `json:"pin" validate:"required"`
+}
+
+type SplitBillShareResponse struct {
+	ID            uint64      `json:"id"`
+	AccountID     string      `json:"account_id"`
+	Amount        money.Money `json:"amount"`
+	Status        int64       `json:"status"`
+	TransactionID uint64      `json:"transaction_id,omitempty"`
+	ReminderCount int64       `json:"reminder_count"`
+	RemindedAt    string      `json:"reminded_at,omitempty"`
+	PaidAt        string      `json:"paid_at,omitempty"`
+}
+
+type SplitBillResponse struct {
+	ID                 uint64                    `json:"id"`
+	InitiatorAccountID string                    `json:"initiator_account_id"`
+	Description        string                    `json:"description"`
+	Total              money.Money               `json:"total"`
+	Settled            money.Money               `json:"settled"`
+	Outstanding        money.Money               `json:"outstanding"`
+	SplitType          int64                     `json:"split_type"`
+	Status             int64                     `json:"status"`
+	PaidShares         int64                     `json:"paid_shares"`
+	TotalShares        int64                     `json:"total_shares"`
+	CreatedAt          string                    `json:"created_at"`
+	MyShare            *SplitBillShareResponse   `json:"my_share,omitempty"`
+	Shares             []*SplitBillShareResponse `json:"shares,omitempty"`
+}
+
+type ListSplitBillResponse struct {
+	SplitBills []*SplitBillResponse `json:"split_bills"`
+	Meta       ListPaginations      `json:"meta"`
+}
+
+type SplitBillRemindResponse struct {
+	Reminded int64 `json:"reminded"`
+}
@@ -0,0 +1,61 @@
+package dto
+
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
+type StandingOrdersQueryParams struct {
+	OrderID uint64 `param:"orderID"`
+	Status  int64  `query:"status"`
+	Limit   uint64 `query:"limit"`
+	Page    uint64 `query:"page"`
+}
+
+type StandingOrderPayload struct {
+	AccountID                 string      `json:"account_id" validate:"required"`
+	RecipientID               string      `json:"recipient_id" validate:"required"`
+	TrxType                   int64       `json:"trx_type" validate:"required"`
+	Nominal                   money.Money `json:"nominal" validate:"required"`
+	Description               string      `json:"description"`
+	Recurrence                string      `json:"recurrence" validate:"required"`
+	StartAt                   string      `json:"start_at" validate:"required"`
+	EndAt                     string      `json:"end_at"`
+	MaxOccurrences            int64       `json:"max_occurrences"`
+	InsufficientBalancePolicy int64       `json:"insufficient_balance_policy"`
+	PIN                       string      `json:"pin"`
+}
+
+type StandingOrderResponse struct {
+	ID                        uint64      `json:"id"`
+	AccountID                 string      `json:"account_id"`
+	RecipientID               string      `json:"recipient_id"`
+	TrxType                   int64       `json:"trx_type"`
+	Nominal                   money.Money `json:"nominal"`
+	Description               string      `json:"description"`
+	Recurrence                string      `json:"recurrence"`
+	StartAt                   string      `json:"start_at"`
+	EndAt                     string      `json:"end_at,omitempty"`
+	MaxOccurrences            int64       `json:"max_occurrences"`
+	Occurrences               int64       `json:"occurrences"`
+	InsufficientBalancePolicy int64       `json:"insufficient_balance_policy"`
+	Status                    int64       `json:"status"`
+	NextOccurrenceAt          string      `json:"next_occurrence_at,omitempty"`
+}
+
+type ListStandingOrderResponse struct {
+	StandingOrders []*StandingOrderResponse `json:"standing_orders"`
+	Meta           ListPaginations          `json:"meta"`
+}
+
+type StandingOrderRunResponse struct {
+	ID            uint64 `json:"id"`
+	OccurrenceAt  string `json:"occurrence_at"`
+	Attempt       int64  `json:"attempt"`
+	Status        int64  `json:"status"`
+	TransactionID uint64 `json:"transaction_id,omitempty"`
+	ErrorMessage  string `json:"error_message,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+type ListStandingOrderRunResponse struct {
+	Runs []*StandingOrderRunResponse `json:"runs"`
+	Meta ListPaginations             `json:"meta"`
+}
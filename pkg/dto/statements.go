@@ -0,0 +1,15 @@
+package dto
+
+import "bytes"
+
+type AccountStatementQueryParams struct {
+	DateStart string `query:"dateStart"`
+	DateEnd   string `query:"dateEnd"`
+	Format    string `query:"format"`
+}
+
+type AccountStatementFile struct {
+	Filename    string
+	ContentType string
+	Content     *bytes.Buffer
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "github.com/stellar-payment/sp-payment/pkg/money"
+
 type TransactionsQueryParams struct {
 	TransactionID uint64 `param:"trxID"`
 	TrxType       int64  `query:"trxType"`
@@ -12,28 +14,50 @@ type TransactionsQueryParams struct {
 }
 
 type TransactionPayload struct {
-	AccountID   string  `json:"account_id" validate:"required"`
-	RecipientID string  `json:"recipient_id" validate:"required"`
-	TrxType     int64   `json:"trx_type" validate:"required"`
-	TrxDatetime string  `json:"trx_datetime" validate:"required"`
-	TrxStatus   int64   `json:"trx_status" validate:"required"`
-	Nominal     float64 `json:"nominal" validate:"required"`
-	Description string  `json:"description" validate:"required"`
-	PIN         string  `json:"pin"`
+	AccountID   string      `json:"account_id" validate:"required"`
+	RecipientID string      `json:"recipient_id" validate:"required"`
+	TrxType     int64       `json:"trx_type" validate:"required"`
+	TrxDatetime string      `json:"trx_datetime" validate:"required"`
+	TrxStatus   int64       `json:"trx_status" validate:"required"`
+	Nominal     money.Money `json:"nominal" validate:"required"`
+	Description string      `json:"description" validate:"required"`
+	PIN         string      `json:"pin"`
+}
+
+type TransactionRefundPayload struct {
+	Nominal     money.Money `json:"nominal"`
+	Description string      `json:"description"`
+}
+
+type TransactionCapturePayload struct {
+	Nominal money.Money `json:"nominal"`
+}
+
+type TransactionAuthorizationResponse struct {
+	ID         uint64      `json:"id"`
+	HoldAmount money.Money `json:"hold_amount"`
+	ExpiresAt  string      `json:"expires_at"`
 }
 
 type TransactionResponse struct {
-	ID            uint64  `json:"id"`
-	AccountID     string  `json:"account_id"`
-	AccountName   string  `json:"account_name"`
-	RecipientID   string  `json:"recipient_id"`
-	RecipientName string  `json:"recipient_name"`
-	TrxType       int64   `json:"trx_type"`
-	TrxDatetime   string  `json:"trx_datetime"`
-	TrxStatus     int64   `json:"trx_status"`
-	TrxFee        float64 `json:"trx_fee"`
-	Nominal       float64 `json:"nominal"`
-	Description   string  `json:"description"`
+	ID            uint64      `json:"id"`
+	ParentID      uint64      `json:"parent_id"`
+	AccountID     string      `json:"account_id"`
+	AccountName   string      `json:"account_name"`
+	RecipientID   string      `json:"recipient_id"`
+	RecipientName string      `json:"recipient_name"`
+	TrxType       int64       `json:"trx_type"`
+	TrxDatetime   string      `json:"trx_datetime"`
+	TrxStatus     int64       `json:"trx_status"`
+	TrxFee        money.Money `json:"trx_fee"`
+	FeeRuleID     uint64      `json:"fee_rule_id"`
+	Nominal       money.Money `json:"nominal"`
+	Description   string      `json:"description"`
+
+	RefundedAmount money.Money `json:"refunded_amount"`
+
+	AuthorizedAmount money.Money `json:"authorized_amount"`
+	ExpiresAt        string      `json:"expires_at,omitempty"`
 }
 
 type ListTransactionResponse struct {
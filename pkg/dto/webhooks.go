@@ -0,0 +1,84 @@
+package dto
+
+import (
+	"encoding/json"
+
+	"github.com/stellar-payment/sp-payment/pkg/money"
+)
+
+type WebhooksQueryParams struct {
+	WebhookID  uint64 `param:"webhookID"`
+	DeliveryID uint64 `param:"deliveryID"`
+	Status     int64  `query:"status"`
+	Limit      uint64 `query:"limit"`
+	Page       uint64 `query:"page"`
+}
+
+type WebhookPayload struct {
+	URL        string   `json:"url" validate:"required"`
+	EventTypes []string `json:"event_types"`
+	Status     int64    `json:"status"`
+}
+
+type WebhookResponse struct {
+	ID         uint64   `json:"id"`
+	MerchantID string   `json:"merchant_id"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	EventTypes []string `json:"event_types"`
+	Status     int64    `json:"status"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type ListWebhookResponse struct {
+	Webhooks []*WebhookResponse `json:"webhooks"`
+	Meta     ListPaginations    `json:"meta"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             uint64          `json:"id"`
+	WebhookID      uint64          `json:"webhook_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         int64           `json:"status"`
+	Attempts       int64           `json:"attempts"`
+	NextRunAt      string          `json:"next_run_at,omitempty"`
+	ResponseStatus int64           `json:"response_status"`
+	ResponseBody   string          `json:"response_body"`
+	LastError      string          `json:"last_error"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+type ListWebhookDeliveryResponse struct {
+	Deliveries []*WebhookDeliveryResponse `json:"deliveries"`
+	Meta       ListPaginations            `json:"meta"`
+}
+
+// WebhookPaymentData is sent with payment.received once a P2B payment to the merchant completes
+type WebhookPaymentData struct {
+	TransactionID uint64      `json:"transaction_id"`
+	AccountID     string      `json:"account_id"`
+	Nominal       money.Money `json:"nominal"`
+	Fee           money.Money `json:"fee"`
+	Description   string      `json:"description"`
+	TrxDatetime   string      `json:"trx_datetime"`
+}
+
+// WebhookSettlementData is sent with settlement.batched once the day of the merchant is closed
+type WebhookSettlementData struct {
+	BatchID         uint64      `json:"batch_id"`
+	Status          int64       `json:"status"`
+	SettlementCount int64       `json:"settlement_count"`
+	TotalAmount     money.Money `json:"total_amount"`
+	CutoffAt        string      `json:"cutoff_at"`
+	FailureReason   string      `json:"failure_reason,omitempty"`
+}
+
+// WebhookWithdrawalData is sent with withdrawal.updated whenever a withdrawal changes status
+type WebhookWithdrawalData struct {
+	BeneficiaryID uint64      `json:"beneficiary_id"`
+	Amount        money.Money `json:"amount"`
+	Status        int64       `json:"status"`
+	Reason        string      `json:"reason,omitempty"`
+}
@@ -24,6 +24,29 @@ var (
 	ErrDataIntegrity            = errors.New("%s data integrity is compromised")
 	ErrInsufficientBalance      = errors.New("user does not have enough credit")
 	ErrUserSessionExpired       = errors.New("session expired")
+	ErrIdempotencyConflict      = errors.New("idempotency key was already used for a different request")
+	ErrRequestInProgress        = errors.New("request with the same idempotency key is still in progress")
+	ErrRefundExceeded           = errors.New("refund exceeds refundable amount")
+	ErrAuthorizationClosed      = errors.New("authorization is no longer pending")
+	ErrScheduleClosed           = errors.New("scheduled transaction is no longer pending")
+	ErrStandingOrderClosed      = errors.New("standing order is no longer active")
+	ErrLimitExceeded            = errors.New("transaction exceeds %s limit")
+	ErrAccountRestricted        = errors.New("account status does not allow this transaction")
+	ErrExportTooLarge           = errors.New("export exceeds %d rows, request a background export instead")
+	ErrWithdrawalExceeded       = errors.New("withdrawal exceeds the %s available above settlement reserve")
+	ErrBeneficiaryClosed        = errors.New("beneficiary is no longer pending")
+	ErrBankAccountMissing       = errors.New("merchant has no registered bank account")
+	ErrInvalidQR                = errors.New("qr payload is invalid")
+	ErrQRClosed                 = errors.New("qr code is no longer payable")
+	ErrInvoiceClosed            = errors.New("invoice status does not allow this action")
+	ErrPaymentLinkClosed        = errors.New("payment link is no longer payable")
+	ErrSplitBillClosed          = errors.New("split bill share is no longer payable")
+	ErrInvalidWebhookURL        = errors.New("webhook url is invalid")
+	ErrWebhookDeliveryBusy      = errors.New("webhook delivery is still being attempted")
+	ErrInvalidPublicKey         = errors.New("public key must be a pem encoded rsa key of at least 2048 bits")
+	ErrInvalidSignature         = errors.New("request signature is invalid")
+	ErrSignatureReplayed        = errors.New("request signature was already used")
+	ErrPDFRendererMissing       = errors.New("pdf rendering is not configured on this server")
 )
 
 type CustomError struct {
@@ -70,6 +93,29 @@ const (
 	ErrCodeUserExisted              constant.ErrCode = 400022
 	ErrCodeUserDeactivated          constant.ErrCode = 403023
 	ErrCodeInsufficientBalance      constant.ErrCode = 400024
+	ErrCodeIdempotencyConflict      constant.ErrCode = 409025
+	ErrCodeRequestInProgress        constant.ErrCode = 409026
+	ErrCodeRefundExceeded           constant.ErrCode = 400027
+	ErrCodeAuthorizationClosed      constant.ErrCode = 409028
+	ErrCodeScheduleClosed           constant.ErrCode = 409029
+	ErrCodeStandingOrderClosed      constant.ErrCode = 409030
+	ErrCodeLimitExceeded            constant.ErrCode = 400031
+	ErrCodeAccountRestricted        constant.ErrCode = 403032
+	ErrCodeExportTooLarge           constant.ErrCode = 400033
+	ErrCodeWithdrawalExceeded       constant.ErrCode = 400034
+	ErrCodeBeneficiaryClosed        constant.ErrCode = 409035
+	ErrCodeBankAccountMissing       constant.ErrCode = 400036
+	ErrCodeInvalidQR                constant.ErrCode = 400037
+	ErrCodeQRClosed                 constant.ErrCode = 409038
+	ErrCodeInvoiceClosed            constant.ErrCode = 409039
+	ErrCodePaymentLinkClosed        constant.ErrCode = 409040
+	ErrCodeSplitBillClosed          constant.ErrCode = 409041
+	ErrCodeInvalidWebhookURL        constant.ErrCode = 400042
+	ErrCodeWebhookDeliveryBusy      constant.ErrCode = 409043
+	ErrCodeInvalidPublicKey         constant.ErrCode = 400044
+	ErrCodeInvalidSignature         constant.ErrCode = 401045
+	ErrCodeSignatureReplayed        constant.ErrCode = 401046
+	ErrCodePDFRendererMissing       constant.ErrCode = 501047
 	ErrCodeDataIntegrity            constant.ErrCode = 500999
 )
 
@@ -80,6 +126,8 @@ const (
 	ErrStatusNoAccess    = http.StatusForbidden
 	ErrStatusReqBody     = http.StatusUnprocessableEntity
 	ErrStatusNotFound    = http.StatusNotFound
+	ErrStatusConflict    = http.StatusConflict
+	ErrStatusNotImpl     = http.StatusNotImplemented
 )
 
 var errorMap = map[error]dto.ErrorResponse{
@@ -97,6 +145,29 @@ var errorMap = map[error]dto.ErrorResponse{
 	ErrDataIntegrity:            ErrorResponse(ErrStatusUnknown, ErrCodeDataIntegrity, ErrDataIntegrity),
 	ErrInsufficientBalance:      ErrorResponse(ErrStatusClient, ErrCodeInsufficientBalance, ErrInsufficientBalance),
 	ErrUserSessionExpired:       ErrorResponse(ErrStatusNoAccess, ErrCodeUserSessionExpired, ErrUserSessionExpired),
+	ErrIdempotencyConflict:      ErrorResponse(ErrStatusConflict, ErrCodeIdempotencyConflict, ErrIdempotencyConflict),
+	ErrRequestInProgress:        ErrorResponse(ErrStatusConflict, ErrCodeRequestInProgress, ErrRequestInProgress),
+	ErrRefundExceeded:           ErrorResponse(ErrStatusClient, ErrCodeRefundExceeded, ErrRefundExceeded),
+	ErrAuthorizationClosed:      ErrorResponse(ErrStatusConflict, ErrCodeAuthorizationClosed, ErrAuthorizationClosed),
+	ErrScheduleClosed:           ErrorResponse(ErrStatusConflict, ErrCodeScheduleClosed, ErrScheduleClosed),
+	ErrStandingOrderClosed:      ErrorResponse(ErrStatusConflict, ErrCodeStandingOrderClosed, ErrStandingOrderClosed),
+	ErrLimitExceeded:            ErrorResponse(ErrStatusClient, ErrCodeLimitExceeded, ErrLimitExceeded),
+	ErrAccountRestricted:        ErrorResponse(ErrStatusNoAccess, ErrCodeAccountRestricted, ErrAccountRestricted),
+	ErrExportTooLarge:           ErrorResponse(ErrStatusClient, ErrCodeExportTooLarge, ErrExportTooLarge),
+	ErrWithdrawalExceeded:       ErrorResponse(ErrStatusClient, ErrCodeWithdrawalExceeded, ErrWithdrawalExceeded),
+	ErrBeneficiaryClosed:        ErrorResponse(ErrStatusConflict, ErrCodeBeneficiaryClosed, ErrBeneficiaryClosed),
+	ErrBankAccountMissing:       ErrorResponse(ErrStatusClient, ErrCodeBankAccountMissing, ErrBankAccountMissing),
+	ErrInvalidQR:                ErrorResponse(ErrStatusClient, ErrCodeInvalidQR, ErrInvalidQR),
+	ErrQRClosed:                 ErrorResponse(ErrStatusConflict, ErrCodeQRClosed, ErrQRClosed),
+	ErrInvoiceClosed:            ErrorResponse(ErrStatusConflict, ErrCodeInvoiceClosed, ErrInvoiceClosed),
+	ErrPaymentLinkClosed:        ErrorResponse(ErrStatusConflict, ErrCodePaymentLinkClosed, ErrPaymentLinkClosed),
+	ErrSplitBillClosed:          ErrorResponse(ErrStatusConflict, ErrCodeSplitBillClosed, ErrSplitBillClosed),
+	ErrInvalidWebhookURL:        ErrorResponse(ErrStatusClient, ErrCodeInvalidWebhookURL, ErrInvalidWebhookURL),
+	ErrWebhookDeliveryBusy:      ErrorResponse(ErrStatusConflict, ErrCodeWebhookDeliveryBusy, ErrWebhookDeliveryBusy),
+	ErrInvalidPublicKey:         ErrorResponse(ErrStatusClient, ErrCodeInvalidPublicKey, ErrInvalidPublicKey),
+	ErrInvalidSignature:         ErrorResponse(ErrStatusNotLoggedIn, ErrCodeInvalidSignature, ErrInvalidSignature),
+	ErrSignatureReplayed:        ErrorResponse(ErrStatusNotLoggedIn, ErrCodeSignatureReplayed, ErrSignatureReplayed),
+	ErrPDFRendererMissing:       ErrorResponse(ErrStatusNotImpl, ErrCodePDFRendererMissing, ErrPDFRendererMissing),
 }
 
 func ErrorResponse(status int, code constant.ErrCode, err error) dto.ErrorResponse {
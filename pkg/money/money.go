@@ -0,0 +1,315 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount     = errors.New("invalid money amount")
+	ErrPrecisionExceeded = errors.New("money amount exceeds currency precision")
+	ErrCurrencyMismatch  = errors.New("money currency mismatch")
+)
+
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+var (
+	IDR = Currency{Code: "IDR", Exponent: 2}
+
+	// DefaultCurrency is assumed whenever amount is read without explicit currency,
+	// it must match the scale of decimal columns (decimal(18, 2))
+	DefaultCurrency = IDR
+)
+
+// Money is a fixed-point amount stored as minor units (e.g. cents) of its currency.
+// Zero value is a valid zero amount in DefaultCurrency.
+type Money struct {
+	minor    int64
+	currency Currency
+}
+
+func New(minor int64, cur Currency) Money {
+	return Money{minor: minor, currency: cur}
+}
+
+func FromMinor(minor int64) Money {
+	return New(minor, DefaultCurrency)
+}
+
+func Zero() Money {
+	return FromMinor(0)
+}
+
+// Parse reads a decimal string (e.g. "1500", "-12.50") in DefaultCurrency
+func Parse(s string) (Money, error) {
+	return ParseIn(s, DefaultCurrency)
+}
+
+// ParseIn reads a decimal string, trailing zeros beyond currency exponent are tolerated
+// while any other extra fractional digit is rejected
+func ParseIn(s string, cur Currency) (res Money, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return res, ErrInvalidAmount
+	}
+
+	neg := false
+	if s[0] == '-' || s[0] == '+' {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if whole == "" || (hasFrac && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return res, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > cur.Exponent {
+		return res, fmt.Errorf("%w: %s allows %d decimal places", ErrPrecisionExceeded, cur.Code, cur.Exponent)
+	}
+
+	frac += strings.Repeat("0", cur.Exponent-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return res, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	if neg {
+		minor = -minor
+	}
+
+	return New(minor, cur), nil
+}
+
+// MustParse is Parse for trusted literals, panics on invalid input
+func MustParse(s string) Money {
+	res, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return res
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+func (m Money) Currency() Currency {
+	if m.currency.Code == "" {
+		return DefaultCurrency
+	}
+
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+// arithmetic across currencies is a programming error, hence panic
+func (m Money) mustMatch(o Money) Currency {
+	if m.Currency() != o.Currency() {
+		panic(fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency().Code, o.Currency().Code))
+	}
+
+	return m.Currency()
+}
+
+func (m Money) Add(o Money) Money {
+	return New(m.minor+o.minor, m.mustMatch(o))
+}
+
+func (m Money) Sub(o Money) Money {
+	return New(m.minor-o.minor, m.mustMatch(o))
+}
+
+func (m Money) Neg() Money {
+	return New(-m.minor, m.Currency())
+}
+
+func (m Money) Abs() Money {
+	if m.minor < 0 {
+		return m.Neg()
+	}
+
+	return m
+}
+
+// Cmp returns -1, 0, 1 when m is less, equal, greater than o
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (m Money) LessThan(o Money) bool {
+	return m.Cmp(o) < 0
+}
+
+func (m Money) GreaterThan(o Money) bool {
+	return m.Cmp(o) > 0
+}
+
+func (m Money) Min(o Money) Money {
+	if m.Cmp(o) <= 0 {
+		return m
+	}
+
+	return o
+}
+
+func (m Money) Max(o Money) Money {
+	if m.Cmp(o) >= 0 {
+		return m
+	}
+
+	return o
+}
+
+// MulRatio returns m * num / den rounded half away from zero,
+// e.g. MulRatio(250, 10000) applies a 2.5% rate
+func (m Money) MulRatio(num, den int64) Money {
+	if den == 0 {
+		panic(errors.New("money: zero denominator"))
+	}
+
+	// big arithmetic keeps m * num from overflowing on large ratios
+	prod := new(big.Int).Mul(big.NewInt(m.minor), big.NewInt(num))
+	q, r := new(big.Int).QuoRem(prod, big.NewInt(den), new(big.Int))
+
+	twiceRem := new(big.Int).Abs(r)
+	twiceRem.Lsh(twiceRem, 1)
+
+	if r.Sign() != 0 && twiceRem.Cmp(new(big.Int).Abs(big.NewInt(den))) >= 0 {
+		if prod.Sign()*sign(den) < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	return New(q.Int64(), m.Currency())
+}
+
+func sign(v int64) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (m Money) String() string {
+	cur := m.Currency()
+
+	minor := m.minor
+	prefix := ""
+	if minor < 0 {
+		prefix = "-"
+		minor = -minor
+	}
+
+	if cur.Exponent == 0 {
+		return prefix + strconv.FormatInt(minor, 10)
+	}
+
+	digits := fmt.Sprintf("%0*d", cur.Exponent+1, minor)
+	cut := len(digits) - cur.Exponent
+
+	return prefix + digits[:cut] + "." + digits[cut:]
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON accepts both quoted ("12.50") and bare (12.50) decimals,
+// bare numbers are read from their literal text so no float rounding occurs
+func (m *Money) UnmarshalJSON(data []byte) (err error) {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+
+	res, err := ParseIn(raw, m.Currency())
+	if err != nil {
+		return err
+	}
+
+	*m = res
+	return nil
+}
+
+// Scan implements sql.Scanner, decimal columns arrive as text from pgx
+func (m *Money) Scan(src any) (err error) {
+	var res Money
+
+	switch v := src.(type) {
+	case nil:
+		res = New(0, m.Currency())
+	case string:
+		res, err = ParseIn(v, m.Currency())
+	case []byte:
+		res, err = ParseIn(string(v), m.Currency())
+	case int64:
+		res, err = ParseIn(strconv.FormatInt(v, 10), m.Currency())
+	case float64:
+		res, err = ParseIn(strconv.FormatFloat(v, 'f', m.Currency().Exponent, 64), m.Currency())
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	*m = res
+	return nil
+}
+
+// Value implements driver.Valuer, amount is sent as exact decimal text
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -0,0 +1,254 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr error
+	}{
+		{name: "whole", input: "1500", want: 150000},
+		{name: "fraction", input: "12.50", want: 1250},
+		{name: "single fraction digit", input: "1.5", want: 150},
+		{name: "negative", input: "-12.50", want: -1250},
+		{name: "explicit positive", input: "+3", want: 300},
+		{name: "surrounding space", input: " 7 ", want: 700},
+		{name: "trailing zeros beyond precision", input: "1.2300", want: 123},
+		{name: "zero", input: "0.00", want: 0},
+		{name: "extra fractional digit", input: "1.234", wantErr: ErrPrecisionExceeded},
+		{name: "extra fractional digit negative", input: "-0.001", wantErr: ErrPrecisionExceeded},
+		{name: "empty", input: "", wantErr: ErrInvalidAmount},
+		{name: "sign only", input: "-", wantErr: ErrInvalidAmount},
+		{name: "letters", input: "abc", wantErr: ErrInvalidAmount},
+		{name: "dangling point", input: "1.", wantErr: ErrInvalidAmount},
+		{name: "missing whole", input: ".5", wantErr: ErrInvalidAmount},
+		{name: "exponent", input: "1e5", wantErr: ErrInvalidAmount},
+		{name: "thousand separator", input: "1,000", wantErr: ErrInvalidAmount},
+		{name: "overflow", input: "999999999999999999999", wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse(%q) err = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected err: %v", tt.input, err)
+			}
+
+			if got.Minor() != tt.want {
+				t.Errorf("Parse(%q) = %d, want %d", tt.input, got.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInZeroExponent(t *testing.T) {
+	jpy := Currency{Code: "JPY", Exponent: 0}
+
+	got, err := ParseIn("120.0", jpy)
+	if err != nil || got.Minor() != 120 {
+		t.Fatalf("ParseIn(120.0) = %d, %v, want 120", got.Minor(), err)
+	}
+
+	if _, err := ParseIn("120.5", jpy); !errors.Is(err, ErrPrecisionExceeded) {
+		t.Fatalf("ParseIn(120.5) err = %v, want %v", err, ErrPrecisionExceeded)
+	}
+
+	if got.String() != "120" {
+		t.Errorf("String() = %q, want %q", got.String(), "120")
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		minor int64
+		want  string
+	}{
+		{minor: 0, want: "0.00"},
+		{minor: 5, want: "0.05"},
+		{minor: 150, want: "1.50"},
+		{minor: -5, want: "-0.05"},
+		{minor: -123456, want: "-1234.56"},
+	}
+
+	for _, tt := range tests {
+		if got := FromMinor(tt.minor).String(); got != tt.want {
+			t.Errorf("FromMinor(%d).String() = %q, want %q", tt.minor, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type payload struct {
+		Nominal Money `json:"nominal"`
+	}
+
+	data, err := json.Marshal(payload{Nominal: MustParse("12.5")})
+	if err != nil {
+		t.Fatalf("Marshal unexpected err: %v", err)
+	}
+
+	if string(data) != `{"nominal":"12.50"}` {
+		t.Fatalf("Marshal = %s, want %s", data, `{"nominal":"12.50"}`)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr error
+	}{
+		{name: "round trip", input: string(data), want: 1250},
+		{name: "bare number", input: `{"nominal":12.50}`, want: 1250},
+		{name: "bare number read literally", input: `{"nominal":0.10}`, want: 10},
+		{name: "negative", input: `{"nominal":"-3"}`, want: -300},
+		{name: "null", input: `{"nominal":null}`, want: 0},
+		{name: "missing", input: `{}`, want: 0},
+		{name: "extra fractional digit", input: `{"nominal":"1.001"}`, wantErr: ErrPrecisionExceeded},
+		{name: "bare extra fractional digit", input: `{"nominal":1.001}`, wantErr: ErrPrecisionExceeded},
+		{name: "not a number", input: `{"nominal":"ten"}`, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := payload{}
+
+			err := json.Unmarshal([]byte(tt.input), &got)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Unmarshal(%s) err = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) unexpected err: %v", tt.input, err)
+			}
+
+			if got.Nominal.Minor() != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.input, got.Nominal.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     any
+		want    int64
+		wantErr error
+	}{
+		{name: "nil", src: nil, want: 0},
+		{name: "int64", src: int64(15), want: 1500},
+		{name: "negative int64", src: int64(-2), want: -200},
+		{name: "float64", src: float64(12.5), want: 1250},
+		{name: "negative float64", src: float64(-0.25), want: -25},
+		{name: "string", src: "12.50", want: 1250},
+		{name: "bytes", src: []byte("-3.10"), want: -310},
+		{name: "string extra fractional digit", src: "1.005", wantErr: ErrPrecisionExceeded},
+		{name: "bytes not a number", src: []byte("n/a"), wantErr: ErrInvalidAmount},
+		{name: "unsupported type", src: true, wantErr: ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MustParse("99")
+
+			err := got.Scan(tt.src)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Scan(%v) err = %v, want %v", tt.src, err, tt.wantErr)
+				}
+
+				if got.Minor() != 9900 {
+					t.Errorf("Scan(%v) changed the value on error to %d", tt.src, got.Minor())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Scan(%v) unexpected err: %v", tt.src, err)
+			}
+
+			if got.Minor() != tt.want {
+				t.Errorf("Scan(%v) = %d, want %d", tt.src, got.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	val, err := MustParse("-7.5").Value()
+	if err != nil || val != "-7.50" {
+		t.Fatalf("Value() = %v, %v, want -7.50", val, err)
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		minor int64
+		num   int64
+		den   int64
+		want  int64
+	}{
+		{name: "exact", minor: 1000, num: 250, den: 10000, want: 25},
+		{name: "round down", minor: 10, num: 1, den: 3, want: 3},
+		{name: "round up", minor: 7, num: 1, den: 4, want: 2},
+		{name: "half rounds up", minor: 5, num: 1, den: 2, want: 3},
+		{name: "half rounds up even quotient", minor: 6, num: 1, den: 4, want: 2},
+		{name: "negative round down", minor: -10, num: 1, den: 3, want: -3},
+		{name: "negative round up", minor: -7, num: 1, den: 4, want: -2},
+		{name: "negative half away from zero", minor: -5, num: 1, den: 2, want: -3},
+		{name: "negative num half away from zero", minor: 5, num: -1, den: 2, want: -3},
+		{name: "negative den half away from zero", minor: 5, num: 1, den: -2, want: -3},
+		{name: "both negative half away from zero", minor: -5, num: 1, den: -2, want: 3},
+		{name: "just below half", minor: 49, num: 1, den: 100, want: 0},
+		{name: "just below half negative", minor: -49, num: 1, den: 100, want: 0},
+		{name: "zero", minor: 0, num: 3, den: 7, want: 0},
+		{name: "product beyond int64", minor: math.MaxInt64, num: 3, den: 3, want: math.MaxInt64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromMinor(tt.minor).MulRatio(tt.num, tt.den); got.Minor() != tt.want {
+				t.Errorf("FromMinor(%d).MulRatio(%d, %d) = %d, want %d", tt.minor, tt.num, tt.den, got.Minor(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMulRatioZeroDenominator(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MulRatio with zero denominator did not panic")
+		}
+	}()
+
+	FromMinor(100).MulRatio(1, 0)
+}
+
+func TestCurrencyMismatch(t *testing.T) {
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrCurrencyMismatch) {
+			t.Fatalf("Add across currencies recovered %v, want %v", err, ErrCurrencyMismatch)
+		}
+	}()
+
+	FromMinor(100).Add(New(100, Currency{Code: "USD", Exponent: 2}))
+}